@@ -9,9 +9,11 @@ FTPとSSHによるリモートアクセスもサポートしており、リモ
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
+	"os"
 	"runtime/debug"
 	"time"
 
@@ -19,19 +21,32 @@ import (
 	"github.com/223n/image-converter/internal/local"
 	"github.com/223n/image-converter/internal/remote"
 	"github.com/223n/image-converter/internal/utils"
+	"github.com/223n/image-converter/pkg/imageutils"
 )
 
 var (
-	configPath string
-	dryRun     bool
-	remoteMode bool
-	startTime  time.Time
+	configPath    string
+	dryRun        bool
+	remoteMode    bool
+	quarantineDir string
+	summaryOnly   bool
+	retryFailures string
+	configCheck   bool
+	cpuProfile    string
+	memProfile    string
+	startTime     time.Time
 )
 
 func init() {
 	flag.StringVar(&configPath, "config", "configs/config.yml", "設定ファイルのパス")
 	flag.BoolVar(&dryRun, "dry-run", false, "ドライランモード（実際の変換は行わない）")
 	flag.BoolVar(&remoteMode, "remote", false, "リモートモード（SSHで接続して変換）")
+	flag.StringVar(&retryFailures, "retry-failures", "", "前回失敗したファイルの一覧（reporting.failures_file）を指定し、そのファイルだけを再変換する")
+	flag.BoolVar(&summaryOnly, "summary-only", false, "ファイルごとの情報ログを出力せず、警告・エラーと集計結果のみを出力する")
+	flag.StringVar(&quarantineDir, "quarantine-dir", "", "デコードできない破損画像の移動先ディレクトリ")
+	flag.BoolVar(&configCheck, "config-check", false, "設定ファイルを検証し、適用される設定を表示して終了する")
+	flag.StringVar(&cpuProfile, "cpuprofile", "", "CPUプロファイルの出力先ファイル")
+	flag.StringVar(&memProfile, "memprofile", "", "メモリ（ヒープ）プロファイルの出力先ファイル")
 
 	// メモリ関連の設定
 	debug.SetGCPercent(20)                   // GCの頻度を上げる（デフォルトは100）
@@ -43,31 +58,91 @@ func init() {
 
 // main はプログラムのエントリーポイントです
 func main() {
+	// コマンドライン引数の解析
+	flag.Parse()
+
+	// サブコマンドの処理
+	if args := flag.Args(); len(args) >= 2 && args[0] == "config" && args[1] == "validate" {
+		// サブコマンドの後に指定されたオプションも受け付ける
+		if err := flag.CommandLine.Parse(args[2:]); err != nil {
+			os.Exit(2)
+		}
+		os.Exit(runConfigValidate())
+	}
+
+	// 画像情報の表示
+	if args := flag.Args(); len(args) >= 1 && args[0] == "info" {
+		os.Exit(runInfo(args[1:]))
+	}
+
+	// 設定チェックモードの場合は検証結果を表示して終了
+	if configCheck {
+		os.Exit(runConfigCheck())
+	}
+
+	os.Exit(run())
+}
+
+// run は変換処理を実行し、終了コードを返します
+// エラーで終了する場合もプロファイルを確実に書き込むため、os.Exit を呼ばずに defer を実行してから戻ります
+func run() int {
+	// プロファイルの記録
+	stopProfiling, err := startProfiling(cpuProfile, memProfile)
+	if err != nil {
+		log.Printf("プロファイルの開始に失敗しました: %v", err)
+		return 1
+	}
+	defer stopProfiling()
+
 	// 初期化と設定の読み込み
 	if err := initializeApplication(); err != nil {
-		log.Fatalf("初期化に失敗しました: %v", err)
+		log.Printf("初期化に失敗しました: %v", err)
+		return 1
 	}
 
 	// リモートモードの処理
 	if config.GetConfig().Remote.Enabled {
+		if retryFailures != "" {
+			log.Printf("-retry-failures はローカルモードでのみ使用できます")
+			return 1
+		}
 		if err := executeRemoteMode(); err != nil {
-			log.Fatalf("リモート変換に失敗しました: %v", err)
+			log.Printf("リモート変換に失敗しました: %v", err)
+			return 1
 		}
-		return
+		return 0
 	}
 
 	// ローカルモードの処理
 	if err := executeLocalMode(); err != nil {
-		log.Fatalf("ローカル変換に失敗しました: %v", err)
+		log.Printf("ローカル変換に失敗しました: %v", err)
+		return 1
 	}
+
+	return 0
 }
 
 // initializeApplication はアプリケーションの初期化と設定を行います
 func initializeApplication() error {
-	// コマンドライン引数の解析
-	flag.Parse()
-
 	// 設定ファイルを読み込む
+	if err := loadConfiguration(); err != nil {
+		return err
+	}
+
+	// ログファイル名に開始日時を含める
+	logFileName := utils.GetLogFileName(startTime)
+
+	// ログ設定を適用
+	utils.SetupLogger(logFileName)
+
+	// 開始ログを出力
+	utils.LogStartupInfo(configPath)
+
+	return nil
+}
+
+// loadConfiguration は設定ファイルを読み込み、コマンドラインオプションによる上書きを適用します
+func loadConfiguration() error {
 	if err := config.LoadConfig(configPath); err != nil {
 		return err
 	}
@@ -81,18 +156,85 @@ func initializeApplication() error {
 		config.SetRemoteMode(true)
 	}
 
-	// ログファイル名に開始日時を含める
-	logFileName := utils.GetLogFileName(startTime)
-
-	// ログ設定を適用
-	utils.SetupLogger(logFileName)
+	if quarantineDir != "" {
+		config.SetQuarantineDir(quarantineDir)
+	}
 
-	// 開始ログを出力
-	utils.LogStartupInfo(configPath)
+	if summaryOnly {
+		config.SetPerFileLogging(false)
+	}
 
 	return nil
 }
 
+// runConfigCheck は設定ファイルを検証し、デフォルト値と調整を反映した実際の設定をYAMLで出力します
+// 調整内容は読み込み時に警告として出力されます。成功時は0、失敗時は1を返します
+func runConfigCheck() int {
+	if err := loadConfiguration(); err != nil {
+		fmt.Fprintf(os.Stderr, "設定ファイルの検証に失敗しました: %v\n", err)
+		return 1
+	}
+
+	data, err := config.EffectiveConfigYAML()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "設定の出力に失敗しました: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("# 適用される設定: %s\n", configPath)
+	fmt.Print(string(data))
+	return 0
+}
+
+// runConfigValidate は設定ファイルを調整せずに厳密に検証し、範囲外の項目をすべて表示します
+// エラーがない場合は0、ある場合や読み込みに失敗した場合は1を返します
+func runConfigValidate() int {
+	// 読み込み時の自動調整を反映しない元の値を検証する
+	cfg, err := config.LoadRawConfig(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "設定ファイルの検証に失敗しました: %v\n", err)
+		return 1
+	}
+
+	if verr := config.ValidateStrict(cfg); verr != nil {
+		for _, fieldErr := range verr.Errors {
+			fmt.Fprintln(os.Stderr, fieldErr.Error())
+		}
+		fmt.Fprintf(os.Stderr, "%d 件のエラーが見つかりました: %s\n", len(verr.Errors), configPath)
+		return 1
+	}
+
+	fmt.Printf("設定ファイルに問題はありません: %s\n", configPath)
+	return 0
+}
+
+// runInfo は指定された画像ファイルの情報（寸法・形式・EXIFなど）をJSONで出力します
+// 無効な画像が含まれる場合は1、引数がない場合は2を返します
+func runInfo(paths []string) int {
+	if len(paths) == 0 {
+		fmt.Fprintln(os.Stderr, "使用方法: image-converter info <画像ファイル>...")
+		return 2
+	}
+
+	exitCode := 0
+	infos := make([]*imageutils.ImageInfo, 0, len(paths))
+	for _, path := range paths {
+		info, err := imageutils.GetImageInfo(path)
+		if err != nil {
+			exitCode = 1
+		}
+		infos = append(infos, info)
+	}
+
+	data, err := json.MarshalIndent(infos, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "画像情報の出力に失敗しました: %v\n", err)
+		return 1
+	}
+	fmt.Println(string(data))
+	return exitCode
+}
+
 // executeRemoteMode はリモートモード処理を実行します
 func executeRemoteMode() error {
 	log.Printf("リモートモードで実行中 - ホスト: %s", config.GetConfig().Remote.Host)
@@ -122,6 +264,13 @@ func executeLocalMode() error {
 
 	// ローカル変換サービスを作成して実行
 	localService := local.NewService(configPtr, logManager)
+	if retryFailures != "" {
+		files, err := local.ReadFailuresFile(retryFailures)
+		if err != nil {
+			return err
+		}
+		localService.SetRetryFiles(files)
+	}
 	if err := localService.Execute(); err != nil {
 		return fmt.Errorf("ローカル変換に失敗しました: %v", err)
 	}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+	"runtime/pprof"
+)
+
+// startProfiling はCPUプロファイルの記録を開始し、終了時に呼び出す関数を返します
+// 返された関数はCPUプロファイルを停止し、メモリプロファイルを書き込みます（パスが空の場合はそれぞれ何もしません）
+func startProfiling(cpuProfilePath, memProfilePath string) (func(), error) {
+	var cpuFile *os.File
+	if cpuProfilePath != "" {
+		f, err := os.Create(cpuProfilePath)
+		if err != nil {
+			return nil, fmt.Errorf("CPUプロファイルの作成に失敗しました: %v", err)
+		}
+		if err := pprof.StartCPUProfile(f); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("CPUプロファイルの開始に失敗しました: %v", err)
+		}
+		cpuFile = f
+	}
+
+	return func() {
+		if cpuFile != nil {
+			pprof.StopCPUProfile()
+			if err := cpuFile.Close(); err != nil {
+				log.Printf("警告: CPUプロファイルの書き込みに失敗しました: %v", err)
+			} else {
+				log.Printf("CPUプロファイルを書き込みました: %s", cpuProfilePath)
+			}
+		}
+
+		if memProfilePath != "" {
+			if err := writeHeapProfile(memProfilePath); err != nil {
+				log.Printf("警告: %v", err)
+			} else {
+				log.Printf("メモリプロファイルを書き込みました: %s", memProfilePath)
+			}
+		}
+	}, nil
+}
+
+// writeHeapProfile は現在のヒーププロファイルをファイルに書き込みます
+func writeHeapProfile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("メモリプロファイルの作成に失敗しました: %v", err)
+	}
+	defer f.Close()
+
+	// 最新の割り当て状況を反映させる
+	runtime.GC()
+	if err := pprof.WriteHeapProfile(f); err != nil {
+		return fmt.Errorf("メモリプロファイルの書き込みに失敗しました: %v", err)
+	}
+	return nil
+}
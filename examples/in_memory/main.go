@@ -0,0 +1,58 @@
+/*
+Package main は pkg/converter の Converter を使ってメモリ上の画像データを変換する例です。
+
+使用方法:
+
+	go run ./examples/in_memory <画像ファイル>
+
+画像ファイルを読み込み、一時ファイルを作らずにWebPに変換して、
+変換結果を元のファイル名に .webp を付けたファイルに書き込みます。
+*/
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/223n/image-converter/pkg/converter"
+)
+
+func main() {
+	if len(os.Args) != 2 {
+		fmt.Fprintln(os.Stderr, "使用方法: in_memory <画像ファイル>")
+		os.Exit(2)
+	}
+	path := os.Args[1]
+
+	// 設定ファイルを使わずにデフォルト設定から変換器を作成する
+	opts := converter.DefaultOptions()
+	opts.WebP.Enabled = true
+	opts.WebP.Quality = 75
+	opts.AVIF.Enabled = false
+	conv := converter.NewConverter(opts)
+
+	input, err := os.Open(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "画像ファイルの読み込みに失敗しました: %v\n", err)
+		os.Exit(1)
+	}
+	defer input.Close()
+
+	var webp bytes.Buffer
+	if err := conv.ConvertStream(input, filepath.Ext(path), &webp, nil); err != nil {
+		fmt.Fprintf(os.Stderr, "変換に失敗しました: %v\n", err)
+		os.Exit(1)
+	}
+
+	output := path + ".webp"
+	if err := os.WriteFile(output, webp.Bytes(), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "変換結果の書き込みに失敗しました: %v\n", err)
+		os.Exit(1)
+	}
+
+	if fi, err := input.Stat(); err == nil {
+		fmt.Printf("%s: %d バイト -> %s: %d バイト\n", path, fi.Size(), output, webp.Len())
+	}
+}
@@ -7,13 +7,22 @@ toolchain go1.24.0
 require (
 	github.com/Kagami/go-avif v0.1.0
 	github.com/chai2010/webp v1.1.1
+	github.com/gliderlabs/ssh v0.3.5
 	github.com/jdeng/goheif v0.0.0-20241115163857-e2bbb197c985
 	github.com/pkg/sftp v1.13.5
+	github.com/rwcarlsen/goexif v0.0.0-20190401172101-9e8deecbddbd
+	github.com/srwiley/oksvg v0.0.0-20221011165216-be6e8873101c
+	github.com/srwiley/rasterx v0.0.0-20220730225603-2ab79fcdd4ef
 	golang.org/x/crypto v0.12.0
+	golang.org/x/image v0.0.0-20211028202545-6944b10bf410
+	golang.org/x/time v0.8.0
 	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
+	github.com/anmitsu/go-shlex v0.0.0-20200514113438-38f4b401e2be // indirect
 	github.com/kr/fs v0.1.0 // indirect
+	golang.org/x/net v0.10.0 // indirect
 	golang.org/x/sys v0.11.0 // indirect
+	golang.org/x/text v0.12.0 // indirect
 )
@@ -4,112 +4,516 @@ Package config は設定ファイルの読み込みと設定値の管理を行
 package config
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"log"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/223n/image-converter/pkg/imageutils"
 	"gopkg.in/yaml.v3"
 )
 
 // Config はYAML設定ファイルの構造を表します
 type Config struct {
 	Remote struct {
-		Enabled     bool   `yaml:"enabled"`
-		Host        string `yaml:"host"`
-		Port        int    `yaml:"port"`
-		User        string `yaml:"user"`
-		KeyPath     string `yaml:"key_path"`
-		KnownHosts  string `yaml:"known_hosts"`
-		RemotePath  string `yaml:"remote_path"`
-		UseSSHAgent bool   `yaml:"use_ssh_agent"`
-		Timeout     int    `yaml:"timeout"`
-	} `yaml:"remote"`
+		Enabled                  bool            `yaml:"enabled" json:"enabled"`
+		Host                     string          `yaml:"host" json:"host"`
+		Port                     int             `yaml:"port" json:"port"`
+		User                     string          `yaml:"user" json:"user"`
+		KeyPath                  string          `yaml:"key_path" json:"key_path"`
+		KnownHosts               string          `yaml:"known_hosts" json:"known_hosts"`
+		RemotePath               string          `yaml:"remote_path" json:"remote_path"`
+		UseSSHAgent              bool            `yaml:"use_ssh_agent" json:"use_ssh_agent"`
+		Timeout                  int             `yaml:"timeout" json:"timeout"`
+		ConcurrentTransfers      int             `yaml:"concurrent_transfers" json:"concurrent_transfers"`
+		ListMethod               string          `yaml:"list_method" json:"list_method"`
+		KeepAliveIntervalSeconds int             `yaml:"keepalive_interval" json:"keepalive_interval"`
+		SFTPMaxPacketBytes       int             `yaml:"sftp_max_packet" json:"sftp_max_packet"`
+		SFTPConcurrentRequests   int             `yaml:"sftp_concurrent_requests" json:"sftp_concurrent_requests"`
+		VerifyUploads            bool            `yaml:"verify_uploads" json:"verify_uploads"`
+		MaxBandwidthKBps         int             `yaml:"max_bandwidth_kbps" json:"max_bandwidth_kbps"`
+		ResumeDownloads          bool            `yaml:"resume_downloads" json:"resume_downloads"`
+		PartialDir               string          `yaml:"partial_dir" json:"partial_dir"` // 再開用に途中までのダウンロードを保存するディレクトリ（空の場合はユーザーのキャッシュディレクトリ）
+		CertPath                 string          `yaml:"cert_path" json:"cert_path"`
+		CertPrincipal            string          `yaml:"cert_principal" json:"cert_principal"`
+		DeltaSync                DeltaSyncConfig `yaml:"delta_sync" json:"delta_sync"`
+		VerifyChecksums          bool            `yaml:"verify_checksums" json:"verify_checksums"`
+		BatchSize                int             `yaml:"batch_size" json:"batch_size"`
+		BatchPauseSeconds        int             `yaml:"batch_pause_seconds" json:"batch_pause_seconds"`
+	} `yaml:"remote" json:"remote"`
 
 	Mode struct {
-		DryRun bool `yaml:"dry_run"`
-	} `yaml:"mode"`
+		DryRun            bool   `yaml:"dry_run" json:"dry_run"`
+		HashIndex         string `yaml:"hash_index" json:"hash_index"`                   // 変換元のハッシュを記録するJSONファイル（空の場合は使用しない）
+		MaxRuntimeSeconds int    `yaml:"max_runtime_seconds" json:"max_runtime_seconds"` // 実行時間の上限（秒、0は無制限）
+		DryRunOutput      string `yaml:"dry_run_output" json:"dry_run_output"`           // ドライランの変換予定を書き込むファイル（.jsonの場合はJSON）
+	} `yaml:"mode" json:"mode"`
 
 	Input struct {
-		Directory           string   `yaml:"directory"`
-		SupportedExtensions []string `yaml:"supported_extensions"`
-	} `yaml:"input"`
-
-	Conversion struct {
-		Workers int `yaml:"workers"`
-		WebP    struct {
-			Enabled          bool `yaml:"enabled"`
-			Quality          int  `yaml:"quality"`
-			CompressionLevel int  `yaml:"compression_level"`
-		} `yaml:"webp"`
-		AVIF struct {
-			Enabled  bool `yaml:"enabled"`
-			Quality  int  `yaml:"quality"`
-			Speed    int  `yaml:"speed"`
-			Lossless bool `yaml:"lossless"`
-		} `yaml:"avif"`
-	} `yaml:"conversion"`
+		Directory           string   `yaml:"directory" json:"directory"`
+		SupportedExtensions []string `yaml:"supported_extensions" json:"supported_extensions"`
+		MinWidth            int      `yaml:"min_width" json:"min_width"`
+		MinHeight           int      `yaml:"min_height" json:"min_height"`
+		MaxDepth            int      `yaml:"max_depth" json:"max_depth"`
+		FollowSymlinks      bool     `yaml:"follow_symlinks" json:"follow_symlinks"`
+		OnUnsupported       string   `yaml:"on_unsupported" json:"on_unsupported"` // skip または error
+		URLs                []string `yaml:"urls" json:"urls"`                     // 入力として取得する画像のURL
+		URLTimeout          int      `yaml:"url_timeout" json:"url_timeout"`       // URLの取得タイムアウト（秒）
+		SVG                 struct {
+			Width  int `yaml:"width" json:"width"`
+			Height int `yaml:"height" json:"height"`
+		} `yaml:"svg" json:"svg"`
+	} `yaml:"input" json:"input"`
+
+	Conversion ConversionConfig `yaml:"conversion" json:"conversion"`
+
+	Notifications struct {
+		WebhookURL string `yaml:"webhook_url" json:"webhook_url"`
+		Timeout    int    `yaml:"timeout" json:"timeout"`
+		MaxRetries int    `yaml:"max_retries" json:"max_retries"`
+		Format     string `yaml:"format" json:"format"`
+	} `yaml:"notifications" json:"notifications"`
+
+	Hooks struct {
+		PostConvert string `yaml:"post_convert" json:"post_convert"`   // 変換後に出力ファイルごとに実行するコマンド
+		Timeout     int    `yaml:"timeout" json:"timeout"`             // 1回の実行のタイムアウト（秒）
+		FailOnError bool   `yaml:"fail_on_error" json:"fail_on_error"` // 失敗した場合にファイルの処理を失敗とするかどうか
+	} `yaml:"hooks" json:"hooks"`
+
+	Output struct {
+		WriteChecksums   bool   `yaml:"write_checksums" json:"write_checksums"`
+		FilenameTemplate string `yaml:"filename_template" json:"filename_template"`
+		Zip              string `yaml:"zip" json:"zip"` // 出力ファイルをまとめるZIPアーカイブのパス（空の場合はファイルのまま出力）
+	} `yaml:"output" json:"output"`
+
+	Reporting struct {
+		TopSlowCount   int    `yaml:"top_slow_count" json:"top_slow_count"`
+		FailuresFile   string `yaml:"failures_file" json:"failures_file"`     // 変換に失敗したファイルの一覧の出力先（空の場合は出力しない）
+		ProgressStream string `yaml:"progress_stream" json:"progress_stream"` // JSON Lines形式の進捗の出力先（ファイルのパスまたは fd:N。空の場合は出力しない）
+	} `yaml:"reporting" json:"reporting"`
 
 	FTP struct {
-		Enabled bool `yaml:"enabled"`
-		Port    int  `yaml:"port"`
+		Enabled bool `yaml:"enabled" json:"enabled"`
+		Port    int  `yaml:"port" json:"port"`
 		User    struct {
-			Name     string `yaml:"name"`
-			Password string `yaml:"password"`
-		} `yaml:"user"`
+			Name     string `yaml:"name" json:"name"`
+			Password string `yaml:"password" json:"password"`
+		} `yaml:"user" json:"user"`
 		Passive struct {
-			Enabled   bool   `yaml:"enabled"`
-			PortRange string `yaml:"port_range"`
-		} `yaml:"passive"`
-	} `yaml:"ftp"`
+			Enabled   bool   `yaml:"enabled" json:"enabled"`
+			PortRange string `yaml:"port_range" json:"port_range"`
+		} `yaml:"passive" json:"passive"`
+	} `yaml:"ftp" json:"ftp"`
 
 	SSH struct {
-		Enabled bool `yaml:"enabled"`
-		Port    int  `yaml:"port"`
+		Enabled bool `yaml:"enabled" json:"enabled"`
+		Port    int  `yaml:"port" json:"port"`
 		Auth    struct {
-			PasswordAuth bool   `yaml:"password_auth"`
-			PubkeyAuth   bool   `yaml:"pubkey_auth"`
-			AuthKeysFile string `yaml:"auth_keys_file"`
-		} `yaml:"auth"`
-	} `yaml:"ssh"`
+			PasswordAuth bool   `yaml:"password_auth" json:"password_auth"`
+			PubkeyAuth   bool   `yaml:"pubkey_auth" json:"pubkey_auth"`
+			AuthKeysFile string `yaml:"auth_keys_file" json:"auth_keys_file"`
+		} `yaml:"auth" json:"auth"`
+	} `yaml:"ssh" json:"ssh"`
 
 	Logging struct {
-		Level      string `yaml:"level"`
-		File       string `yaml:"file"`
-		Directory  string `yaml:"directory"`
-		MaxSize    int    `yaml:"max_size"`
-		MaxBackups int    `yaml:"max_backups"`
-		MaxAge     int    `yaml:"max_age"`
-		Compress   bool   `yaml:"compress"`
-	} `yaml:"logging"`
+		Level        string            `yaml:"level" json:"level"`
+		Format       string            `yaml:"format" json:"format"`               // text または json
+		ModuleLevels map[string]string `yaml:"module_levels" json:"module_levels"` // モジュール（パッケージ名）ごとのログレベル
+		PerFile      bool              `yaml:"per_file" json:"per_file"`           // ファイルごとの変換成功などの情報ログを出力するかどうか
+		File         string            `yaml:"file" json:"file"`
+		Directory    string            `yaml:"directory" json:"directory"`
+		MaxSize      int               `yaml:"max_size" json:"max_size"`
+		MaxBackups   int               `yaml:"max_backups" json:"max_backups"`
+		MaxAge       int               `yaml:"max_age" json:"max_age"`
+		Compress     bool              `yaml:"compress" json:"compress"`
+		Syslog       struct {
+			Enabled bool   `yaml:"enabled" json:"enabled"`
+			Network string `yaml:"network" json:"network"`
+			Address string `yaml:"address" json:"address"`
+			Tag     string `yaml:"tag" json:"tag"`
+		} `yaml:"syslog" json:"syslog"`
+	} `yaml:"logging" json:"logging"`
+}
+
+// ConversionConfig は画像変換の設定（設定ファイルの conversion）です
+// ライブラリとして使用する場合は pkg/converter の Options として変換器ごとに指定できます
+type ConversionConfig struct {
+	Workers              int `yaml:"workers" json:"workers"`
+	MaxDecodeConcurrency int `yaml:"max_decode_concurrency" json:"max_decode_concurrency"` // 0はワーカー数と同じ
+	ExternalThreads      int `yaml:"external_threads" json:"external_threads"`
+	WebP                 struct {
+		Enabled          bool `yaml:"enabled" json:"enabled"`
+		Quality          int  `yaml:"quality" json:"quality"`
+		CompressionLevel int  `yaml:"compression_level" json:"compression_level"`
+		// AutoLossless はスクリーンショットや線画など可逆圧縮に向く画像を画像ごとに判定して可逆WebPにするかどうかです
+		AutoLossless bool `yaml:"auto_lossless" json:"auto_lossless"`
+	} `yaml:"webp" json:"webp"`
+	AVIF struct {
+		Enabled  bool `yaml:"enabled" json:"enabled"`
+		Quality  int  `yaml:"quality" json:"quality"`
+		Speed    int  `yaml:"speed" json:"speed"`
+		Lossless bool `yaml:"lossless" json:"lossless"`
+		// QualityScale は quality の尺度です（native: 1〜63、0-100: WebPと同じ0〜100）
+		QualityScale string `yaml:"quality_scale" json:"quality_scale"`
+		// ChromaSubsampling は色差のサブサンプリングです（420, 422, 444）
+		ChromaSubsampling string `yaml:"chroma_subsampling" json:"chroma_subsampling"`
+		// BitDepth は出力のビット深度です（8, 10, 12）
+		BitDepth int `yaml:"bit_depth" json:"bit_depth"`
+	} `yaml:"avif" json:"avif"`
+	JXL struct {
+		Enabled bool `yaml:"enabled" json:"enabled"`
+		Quality int  `yaml:"quality" json:"quality"`
+		Effort  int  `yaml:"effort" json:"effort"`
+	} `yaml:"jxl" json:"jxl"`
+	Optimize struct {
+		Enabled             bool   `yaml:"enabled" json:"enabled"`
+		JPEGQuality         int    `yaml:"jpeg_quality" json:"jpeg_quality"`
+		PNGCompressionLevel string `yaml:"png_compression_level" json:"png_compression_level"`
+		Overwrite           bool   `yaml:"overwrite" json:"overwrite"`
+	} `yaml:"optimize" json:"optimize"`
+	AnimatedGIF struct {
+		Enabled       bool `yaml:"enabled" json:"enabled"`
+		PreserveDelay bool `yaml:"preserve_delay" json:"preserve_delay"`
+	} `yaml:"animated_gif" json:"animated_gif"`
+	Watermark struct {
+		Enabled   bool    `yaml:"enabled" json:"enabled"`
+		ImagePath string  `yaml:"image_path" json:"image_path"`
+		Position  string  `yaml:"position" json:"position"`
+		Opacity   float64 `yaml:"opacity" json:"opacity"`
+	} `yaml:"watermark" json:"watermark"`
+	Resize struct {
+		MaxWidth  int `yaml:"max_width" json:"max_width"`   // 出力の最大幅（超える場合は縦横比を保って縮小する。0の場合は制限しない）
+		MaxHeight int `yaml:"max_height" json:"max_height"` // 出力の最大高さ（0の場合は制限しない）
+	} `yaml:"resize" json:"resize"`
+	AutoOrient           bool     `yaml:"auto_orient" json:"auto_orient"` // EXIFの Orientation に従って画素を回転・反転し、正しい向きで出力する
+	DeduplicateByHash    bool     `yaml:"deduplicate_by_hash" json:"deduplicate_by_hash"`
+	QuarantineDir        string   `yaml:"quarantine_dir" json:"quarantine_dir"`
+	PreserveEXIF         bool     `yaml:"preserve_exif" json:"preserve_exif"`
+	PreserveXMP          bool     `yaml:"preserve_xmp" json:"preserve_xmp"`
+	PreserveColorProfile bool     `yaml:"preserve_color_profile" json:"preserve_color_profile"` // 元画像のICCプロファイルを引き継ぐ
+	StripMetadata        bool     `yaml:"strip_metadata" json:"strip_metadata"`                 // ピクセルのみを複製し、EXIF・XMPを引き継がない（preserve_exif/preserve_xmp より優先）
+	AdaptiveQuality      bool     `yaml:"adaptive_quality" json:"adaptive_quality"`
+	VerifySSIM           bool     `yaml:"verify_ssim" json:"verify_ssim"`
+	MinSSIM              float64  `yaml:"min_ssim" json:"min_ssim"`
+	MaxOutputRatio       float64  `yaml:"max_output_ratio" json:"max_output_ratio"`   // 元画像に対する出力サイズの上限の比率（0の場合は制限しない）
+	MaxDecodePixels      int64    `yaml:"max_decode_pixels" json:"max_decode_pixels"` // デコードする入力画像の最大画素数（超える画像はスキップ、0の場合は制限しない）
+	StripEXIFTags        []string `yaml:"strip_exif_tags" json:"strip_exif_tags"`
 }
 
+// DeltaSyncConfig はリモートの差分同期（前回から更新されていないファイルのスキップ）の設定
+type DeltaSyncConfig struct {
+	Enabled   bool   `yaml:"enabled" json:"enabled"`
+	StateFile string `yaml:"state_file" json:"state_file"`
+}
+
+// DefaultDeltaStateFile は差分同期の状態ファイルのデフォルトパスです
+const DefaultDeltaStateFile = "remote-delta-state.json"
+
 // RemoteConfig はリモートサーバーの接続設定
 type RemoteConfig struct {
-	Enabled     bool   `yaml:"enabled"`
-	Host        string `yaml:"host"`
-	Port        int    `yaml:"port"`
-	User        string `yaml:"user"`
-	KeyPath     string `yaml:"key_path"`
-	KnownHosts  string `yaml:"known_hosts"`
-	RemotePath  string `yaml:"remote_path"`
-	UseSSHAgent bool   `yaml:"use_ssh_agent"`
-	Timeout     int    `yaml:"timeout"`
+	Enabled                  bool            `yaml:"enabled" json:"enabled"`
+	Host                     string          `yaml:"host" json:"host"`
+	Port                     int             `yaml:"port" json:"port"`
+	User                     string          `yaml:"user" json:"user"`
+	KeyPath                  string          `yaml:"key_path" json:"key_path"`
+	KnownHosts               string          `yaml:"known_hosts" json:"known_hosts"`
+	RemotePath               string          `yaml:"remote_path" json:"remote_path"`
+	UseSSHAgent              bool            `yaml:"use_ssh_agent" json:"use_ssh_agent"`
+	Timeout                  int             `yaml:"timeout" json:"timeout"`
+	ConcurrentTransfers      int             `yaml:"concurrent_transfers" json:"concurrent_transfers"`
+	ListMethod               string          `yaml:"list_method" json:"list_method"`
+	KeepAliveIntervalSeconds int             `yaml:"keepalive_interval" json:"keepalive_interval"`
+	SFTPMaxPacketBytes       int             `yaml:"sftp_max_packet" json:"sftp_max_packet"`
+	SFTPConcurrentRequests   int             `yaml:"sftp_concurrent_requests" json:"sftp_concurrent_requests"`
+	VerifyUploads            bool            `yaml:"verify_uploads" json:"verify_uploads"`
+	MaxBandwidthKBps         int             `yaml:"max_bandwidth_kbps" json:"max_bandwidth_kbps"`
+	ResumeDownloads          bool            `yaml:"resume_downloads" json:"resume_downloads"`
+	PartialDir               string          `yaml:"partial_dir" json:"partial_dir"`
+	CertPath                 string          `yaml:"cert_path" json:"cert_path"`
+	CertPrincipal            string          `yaml:"cert_principal" json:"cert_principal"`
+	DeltaSync                DeltaSyncConfig `yaml:"delta_sync" json:"delta_sync"`
+	VerifyChecksums          bool            `yaml:"verify_checksums" json:"verify_checksums"`
+	BatchSize                int             `yaml:"batch_size" json:"batch_size"`
+	BatchPauseSeconds        int             `yaml:"batch_pause_seconds" json:"batch_pause_seconds"`
 }
 
 // ConversionStats は変換統計情報を保持する構造体
+// カウンタは複数のゴルーチンから同時に更新できるよう atomic.Int64 で保持し、値は Count 系のメソッドで取得します
 type ConversionStats struct {
-	TotalProcessed int
-	DownloadFailed int
-	ConvertFailed  int
-	WebPSuccess    int
-	WebPFailed     int
-	AVIFSuccess    int
-	AVIFFailed     int
-	UploadedFiles  int
-	SkippedUploads int
-	StartTime      time.Time
+	TotalProcessed     atomic.Int64
+	DownloadFailed     atomic.Int64
+	ConvertFailed      atomic.Int64
+	WebPSuccess        atomic.Int64
+	WebPFailed         atomic.Int64
+	AVIFSuccess        atomic.Int64
+	AVIFFailed         atomic.Int64
+	JXLSuccess         atomic.Int64
+	JXLFailed          atomic.Int64
+	OptimizeSuccess    atomic.Int64
+	OptimizeFailed     atomic.Int64
+	Quarantined        atomic.Int64
+	SkippedTooSmall    atomic.Int64
+	SkippedUnsupported atomic.Int64
+	SkippedUnchanged   atomic.Int64
+	SkippedMissing     atomic.Int64 // 検索後、処理までの間に削除されたファイル
+	SkippedTooLarge    atomic.Int64 // 画素数が conversion.max_decode_pixels を超えるファイル
+	UploadedFiles      atomic.Int64
+	SkippedUploads     atomic.Int64
+	InputBytes         atomic.Int64 // 変換に成功した元ファイルの合計サイズ
+	OutputBytes        atomic.Int64 // 各ファイルで最も小さい変換結果の合計サイズ
+	StartTime          time.Time
+}
+
+// TotalProcessedCount は TotalProcessed の現在の値を返します
+func (s *ConversionStats) TotalProcessedCount() int {
+	return int(s.TotalProcessed.Load())
+}
+
+// DownloadFailedCount は DownloadFailed の現在の値を返します
+func (s *ConversionStats) DownloadFailedCount() int {
+	return int(s.DownloadFailed.Load())
+}
+
+// ConvertFailedCount は ConvertFailed の現在の値を返します
+func (s *ConversionStats) ConvertFailedCount() int {
+	return int(s.ConvertFailed.Load())
+}
+
+// WebPSuccessCount は WebPSuccess の現在の値を返します
+func (s *ConversionStats) WebPSuccessCount() int {
+	return int(s.WebPSuccess.Load())
+}
+
+// WebPFailedCount は WebPFailed の現在の値を返します
+func (s *ConversionStats) WebPFailedCount() int {
+	return int(s.WebPFailed.Load())
+}
+
+// AVIFSuccessCount は AVIFSuccess の現在の値を返します
+func (s *ConversionStats) AVIFSuccessCount() int {
+	return int(s.AVIFSuccess.Load())
+}
+
+// AVIFFailedCount は AVIFFailed の現在の値を返します
+func (s *ConversionStats) AVIFFailedCount() int {
+	return int(s.AVIFFailed.Load())
+}
+
+// JXLSuccessCount は JXLSuccess の現在の値を返します
+func (s *ConversionStats) JXLSuccessCount() int {
+	return int(s.JXLSuccess.Load())
+}
+
+// JXLFailedCount は JXLFailed の現在の値を返します
+func (s *ConversionStats) JXLFailedCount() int {
+	return int(s.JXLFailed.Load())
+}
+
+// OptimizeSuccessCount は OptimizeSuccess の現在の値を返します
+func (s *ConversionStats) OptimizeSuccessCount() int {
+	return int(s.OptimizeSuccess.Load())
+}
+
+// OptimizeFailedCount は OptimizeFailed の現在の値を返します
+func (s *ConversionStats) OptimizeFailedCount() int {
+	return int(s.OptimizeFailed.Load())
+}
+
+// QuarantinedCount は Quarantined の現在の値を返します
+func (s *ConversionStats) QuarantinedCount() int {
+	return int(s.Quarantined.Load())
+}
+
+// SkippedTooSmallCount は SkippedTooSmall の現在の値を返します
+func (s *ConversionStats) SkippedTooSmallCount() int {
+	return int(s.SkippedTooSmall.Load())
+}
+
+// SkippedUnsupportedCount は SkippedUnsupported の現在の値を返します
+func (s *ConversionStats) SkippedUnsupportedCount() int {
+	return int(s.SkippedUnsupported.Load())
+}
+
+// SkippedUnchangedCount は SkippedUnchanged の現在の値を返します
+func (s *ConversionStats) SkippedUnchangedCount() int {
+	return int(s.SkippedUnchanged.Load())
+}
+
+// SkippedMissingCount は SkippedMissing の現在の値を返します
+func (s *ConversionStats) SkippedMissingCount() int {
+	return int(s.SkippedMissing.Load())
+}
+
+// SkippedTooLargeCount は SkippedTooLarge の現在の値を返します
+func (s *ConversionStats) SkippedTooLargeCount() int {
+	return int(s.SkippedTooLarge.Load())
+}
+
+// UploadedFilesCount は UploadedFiles の現在の値を返します
+func (s *ConversionStats) UploadedFilesCount() int {
+	return int(s.UploadedFiles.Load())
+}
+
+// SkippedUploadsCount は SkippedUploads の現在の値を返します
+func (s *ConversionStats) SkippedUploadsCount() int {
+	return int(s.SkippedUploads.Load())
+}
+
+// InputByteCount は変換に成功した元ファイルの合計サイズを返します
+func (s *ConversionStats) InputByteCount() int64 {
+	return s.InputBytes.Load()
+}
+
+// OutputByteCount は各ファイルで最も小さい変換結果の合計サイズを返します
+func (s *ConversionStats) OutputByteCount() int64 {
+	return s.OutputBytes.Load()
+}
+
+// conversionStatsJSON はJSONに出力する統計情報の値です
+type conversionStatsJSON struct {
+	TotalProcessed     int       `json:"total_processed"`
+	DownloadFailed     int       `json:"download_failed"`
+	ConvertFailed      int       `json:"convert_failed"`
+	WebPSuccess        int       `json:"webp_success"`
+	WebPFailed         int       `json:"webp_failed"`
+	AVIFSuccess        int       `json:"avif_success"`
+	AVIFFailed         int       `json:"avif_failed"`
+	JXLSuccess         int       `json:"jxl_success"`
+	JXLFailed          int       `json:"jxl_failed"`
+	OptimizeSuccess    int       `json:"optimize_success"`
+	OptimizeFailed     int       `json:"optimize_failed"`
+	Quarantined        int       `json:"quarantined"`
+	SkippedTooSmall    int       `json:"skipped_too_small"`
+	SkippedUnsupported int       `json:"skipped_unsupported"`
+	SkippedUnchanged   int       `json:"skipped_unchanged"`
+	SkippedMissing     int       `json:"skipped_missing"`
+	SkippedTooLarge    int       `json:"skipped_too_large"`
+	UploadedFiles      int       `json:"uploaded_files"`
+	SkippedUploads     int       `json:"skipped_uploads"`
+	InputBytes         int64     `json:"input_bytes"`
+	OutputBytes        int64     `json:"output_bytes"`
+	StartTime          time.Time `json:"start_time"`
+}
+
+// MarshalJSON は各カウンタの現在の値をJSONに出力します
+func (s *ConversionStats) MarshalJSON() ([]byte, error) {
+	return json.Marshal(conversionStatsJSON{
+		TotalProcessed:     s.TotalProcessedCount(),
+		DownloadFailed:     s.DownloadFailedCount(),
+		ConvertFailed:      s.ConvertFailedCount(),
+		WebPSuccess:        s.WebPSuccessCount(),
+		WebPFailed:         s.WebPFailedCount(),
+		AVIFSuccess:        s.AVIFSuccessCount(),
+		AVIFFailed:         s.AVIFFailedCount(),
+		JXLSuccess:         s.JXLSuccessCount(),
+		JXLFailed:          s.JXLFailedCount(),
+		OptimizeSuccess:    s.OptimizeSuccessCount(),
+		OptimizeFailed:     s.OptimizeFailedCount(),
+		Quarantined:        s.QuarantinedCount(),
+		SkippedTooSmall:    s.SkippedTooSmallCount(),
+		SkippedUnsupported: s.SkippedUnsupportedCount(),
+		SkippedUnchanged:   s.SkippedUnchangedCount(),
+		SkippedMissing:     s.SkippedMissingCount(),
+		SkippedTooLarge:    s.SkippedTooLargeCount(),
+		UploadedFiles:      s.UploadedFilesCount(),
+		SkippedUploads:     s.SkippedUploadsCount(),
+		InputBytes:         s.InputByteCount(),
+		OutputBytes:        s.OutputByteCount(),
+		StartTime:          s.StartTime,
+	})
+}
+
+// Merge は other のカウンタとバイト数を加算します
+// 並列処理でワーカーごとに集計した統計情報をまとめる場合に使用します。StartTime は変更しません
+func (s *ConversionStats) Merge(other *ConversionStats) {
+	if other == nil || other == s {
+		return
+	}
+
+	s.TotalProcessed.Add(other.TotalProcessed.Load())
+	s.DownloadFailed.Add(other.DownloadFailed.Load())
+	s.ConvertFailed.Add(other.ConvertFailed.Load())
+	s.WebPSuccess.Add(other.WebPSuccess.Load())
+	s.WebPFailed.Add(other.WebPFailed.Load())
+	s.AVIFSuccess.Add(other.AVIFSuccess.Load())
+	s.AVIFFailed.Add(other.AVIFFailed.Load())
+	s.JXLSuccess.Add(other.JXLSuccess.Load())
+	s.JXLFailed.Add(other.JXLFailed.Load())
+	s.OptimizeSuccess.Add(other.OptimizeSuccess.Load())
+	s.OptimizeFailed.Add(other.OptimizeFailed.Load())
+	s.Quarantined.Add(other.Quarantined.Load())
+	s.SkippedTooSmall.Add(other.SkippedTooSmall.Load())
+	s.SkippedUnsupported.Add(other.SkippedUnsupported.Load())
+	s.SkippedUnchanged.Add(other.SkippedUnchanged.Load())
+	s.SkippedMissing.Add(other.SkippedMissing.Load())
+	s.SkippedTooLarge.Add(other.SkippedTooLarge.Load())
+	s.UploadedFiles.Add(other.UploadedFiles.Load())
+	s.SkippedUploads.Add(other.SkippedUploads.Load())
+	s.InputBytes.Add(other.InputBytes.Load())
+	s.OutputBytes.Add(other.OutputBytes.Load())
+}
+
+// RecordBytes は変換に成功したファイルの元サイズと変換後のサイズを加算します
+func (s *ConversionStats) RecordBytes(inputSize, outputSize int64) {
+	s.InputBytes.Add(inputSize)
+	s.OutputBytes.Add(outputSize)
+}
+
+// BytesSaved は変換によって削減されたバイト数を返します
+func (s *ConversionStats) BytesSaved() int64 {
+	return s.InputByteCount() - s.OutputByteCount()
+}
+
+// ElapsedTime は処理開始からの経過時間を返します
+func (s *ConversionStats) ElapsedTime() time.Duration {
+	return time.Since(s.StartTime)
+}
+
+// SuccessRate は処理したファイルのうち変換に失敗しなかった割合（0〜1）を返します
+// 処理したファイルがない場合は0を返します
+func (s *ConversionStats) SuccessRate() float64 {
+	total := s.TotalProcessedCount()
+	if total == 0 {
+		return 0
+	}
+	return float64(total-s.ConvertFailedCount()) / float64(total)
+}
+
+// WebPSuccessRate はWebP変換の成功率（0〜1）を返します
+// WebP変換を試行していない場合は0を返します
+func (s *ConversionStats) WebPSuccessRate() float64 {
+	return successRate(s.WebPSuccessCount(), s.WebPFailedCount())
+}
+
+// AVIFSuccessRate はAVIF変換の成功率（0〜1）を返します
+// AVIF変換を試行していない場合は0を返します
+func (s *ConversionStats) AVIFSuccessRate() float64 {
+	return successRate(s.AVIFSuccessCount(), s.AVIFFailedCount())
+}
+
+// Throughput は1秒あたりの処理ファイル数を返します
+// 経過時間が0の場合は0を返します
+func (s *ConversionStats) Throughput() float64 {
+	elapsed := s.ElapsedTime().Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(s.TotalProcessedCount()) / elapsed
+}
+
+// successRate は成功数と失敗数から成功率を計算します
+func successRate(success, failed int) float64 {
+	if success+failed == 0 {
+		return 0
+	}
+	return float64(success) / float64(success+failed)
 }
 
 // NewConversionStats は新しい統計情報構造体を作成します
@@ -119,10 +523,62 @@ func NewConversionStats() *ConversionStats {
 	}
 }
 
+// AVIF品質の尺度
+const (
+	AVIFQualityScaleNative  = "native" // go-avif の1〜63
+	AVIFQualityScalePercent = "0-100"  // WebPと同じ0〜100
+)
+
+// AVIFMaxSpeed は conversion.avif.speed の上限です（go-avif が対応する0〜8に合わせる）
+const AVIFMaxSpeed = 8
+
+// AVIFの色差サブサンプリング
+const (
+	AVIFChroma420 = "420" // go-avif でエンコード
+	AVIFChroma422 = "422" // avifenc でエンコード
+	AVIFChroma444 = "444" // avifenc でエンコード（文字のエッジがにじまない）
+)
+
+// デコードできない形式の入力ファイルの扱い
+const (
+	OnUnsupportedSkip  = "skip"  // スキップしてスキップ件数に数える
+	OnUnsupportedError = "error" // 変換を開始せずにエラーで終了する
+)
+
+// ログの出力形式
+const (
+	LogFormatText = "text" // [INFO] メッセージ key=value 形式
+	LogFormatJSON = "json" // 1行に1つのJSONオブジェクト
+)
+
+// 完了通知の形式
+const (
+	NotificationFormatJSON  = "json"  // 統計情報をそのままJSONで送信
+	NotificationFormatSlack = "slack" // SlackのIncoming Webhook向けの {"text": "..."} 形式
+)
+
+// リモートファイルの一覧取得方法
+const (
+	RemoteListMethodFind = "find" // リモートで find コマンドを実行する
+	RemoteListMethodSFTP = "sftp" // SFTPプロトコルでディレクトリを走査する（シェル不要）
+)
+
 // グローバル変数
 var (
 	config              Config
 	supportedExtensions map[string]bool
+
+	// configFilePath は最後に読み込んだ設定ファイルのパスです
+	configFilePath string
+
+	// コマンドラインで指定された上書き設定（再読み込み時にも維持する）
+	dryRunOverride        *bool
+	remoteModeOverride    *bool
+	quarantineDirOverride *string
+	perFileLogOverride    *bool
+
+	// configMu は設定の読み書きを保護します（シグナルによる再読み込みに対応するため）
+	configMu sync.RWMutex
 )
 
 // LoadConfig は設定ファイルを読み込みます
@@ -142,158 +598,664 @@ func LoadConfig(configPath string) error {
 		return fmt.Errorf("設定ファイルが存在しません: %s", configPath)
 	}
 
-	// 設定ファイルを読み込む
-	configData, err := os.ReadFile(configPath)
+	// 設定ファイルを開いて読み込む
+	file, err := os.Open(configPath)
 	if err != nil {
 		return fmt.Errorf("設定ファイルの読み込みに失敗しました: %v", err)
 	}
+	defer file.Close()
 
-	// デフォルト設定を適用
-	config = DefaultConfig()
+	return loadConfigReader(file, configPath)
+}
 
-	// YAMLデータを構造体にアンマーシャル
-	err = yaml.Unmarshal(configData, &config)
+// LoadConfigFromBytes はYAML形式のデータから設定を読み込みます
+// ファイルを経由しないため、埋め込みデータやテスト用の設定を直接渡せます
+func LoadConfigFromBytes(data []byte) error {
+	return applyConfigData(data, "")
+}
+
+// LoadConfigReader はReaderからYAML形式の設定を読み込みます
+// デフォルト値を適用した上でYAMLを反映し、検証・調整を行います。空のReaderの場合はデフォルト設定になります
+func LoadConfigReader(r io.Reader) error {
+	return loadConfigReader(r, "")
+}
+
+// loadConfigReader はReaderの内容を読み込み、設定として適用します
+// configPath は再読み込み用に記録するパスです（ファイル以外から読み込んだ場合は空文字列）
+func loadConfigReader(r io.Reader, configPath string) error {
+	data, err := io.ReadAll(r)
 	if err != nil {
-		return fmt.Errorf("設定ファイルの解析に失敗しました: %v", err)
+		return fmt.Errorf("設定データの読み込みに失敗しました: %v", err)
+	}
+
+	return applyConfigData(data, configPath)
+}
+
+// applyConfigData はYAMLデータを解析し、デフォルト値・上書き設定・検証を適用して現在の設定と入れ替えます
+// configPath は再読み込み用に記録するパスです（ファイル以外から読み込んだ場合は空文字列）
+func applyConfigData(configData []byte, configPath string) error {
+	// デフォルト設定を適用
+	newConfig := DefaultConfig()
+
+	// YAMLデータを構造体にアンマーシャル（extends で指定された継承元を先に反映）
+	if err := unmarshalWithExtends(configData, configPath, &newConfig); err != nil {
+		return err
+	}
+
+	// 環境変数による上書き（YAMLより優先）
+	applyEnvOverrides(&newConfig)
+
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	// コマンドラインでの上書き設定を再適用
+	if dryRunOverride != nil {
+		newConfig.Mode.DryRun = *dryRunOverride
+	}
+	if remoteModeOverride != nil {
+		newConfig.Remote.Enabled = *remoteModeOverride
+	}
+	if quarantineDirOverride != nil {
+		newConfig.Conversion.QuarantineDir = *quarantineDirOverride
+	}
+	if perFileLogOverride != nil {
+		newConfig.Logging.PerFile = *perFileLogOverride
 	}
 
 	// 設定値の検証と調整
-	validateConfig()
+	for _, adjustment := range validateConfig(&newConfig) {
+		log.Printf("警告: 設定値を調整しました: %s", adjustment)
+	}
 
 	// サポートされている拡張子をマップに変換
-	supportedExtensions = make(map[string]bool)
-	for _, ext := range config.Input.SupportedExtensions {
-		supportedExtensions[strings.ToLower(ext)] = true
+	newExtensions := make(map[string]bool)
+	for _, ext := range newConfig.Input.SupportedExtensions {
+		newExtensions[strings.ToLower(ext)] = true
 	}
 
+	// 検証済みの設定に入れ替える
+	config = newConfig
+	supportedExtensions = newExtensions
+	configFilePath = configPath
+
 	return nil
 }
 
+// ReloadConfig は最後に読み込んだ設定ファイルを再度読み込みます
+// 読み込みに失敗した場合は現在の設定が維持されます
+func ReloadConfig() error {
+	configMu.RLock()
+	path := configFilePath
+	configMu.RUnlock()
+
+	if path == "" {
+		return fmt.Errorf("再読み込みできる設定ファイルがありません（未読み込み、またはファイル以外から読み込まれています）")
+	}
+
+	return LoadConfig(path)
+}
+
+// NormalizeConfig は設定値を検証し、範囲外の値を読み込み時と同じく調整します
+// 設定ファイルを介さずに組み立てた設定に使用します。調整を行った項目の説明を返します
+func NormalizeConfig(cfg *Config) []string {
+	return validateConfig(cfg)
+}
+
 // validateConfig は設定値を検証し、必要に応じて調整します
-func validateConfig() {
-	// ワーカー数の検証（少なくとも1以上）
-	if config.Conversion.Workers < 1 {
-		config.Conversion.Workers = 1
+// 調整を行った項目の説明を返します
+func validateConfig(cfg *Config) []string {
+	var adjustments []string
+
+	// ワーカー数の検証（未指定または0の場合はCPU数、負の値は1とする）
+	if cfg.Conversion.Workers == 0 {
+		cfg.Conversion.Workers = runtime.NumCPU()
+		log.Printf("ワーカー数をCPU数に合わせて設定しました: %d", cfg.Conversion.Workers)
+	} else if cfg.Conversion.Workers < 0 {
+		adjustments = append(adjustments, fmt.Sprintf("conversion.workers: %d -> 1", cfg.Conversion.Workers))
+		cfg.Conversion.Workers = 1
+	}
+	if cfg.Conversion.MaxDecodeConcurrency < 0 {
+		adjustments = append(adjustments, fmt.Sprintf("conversion.max_decode_concurrency: %d -> 0", cfg.Conversion.MaxDecodeConcurrency))
+		cfg.Conversion.MaxDecodeConcurrency = 0
 	}
 
-	// WebP品質の検証（0〜100の範囲）
-	if config.Conversion.WebP.Quality < 0 {
-		config.Conversion.WebP.Quality = 0
-	} else if config.Conversion.WebP.Quality > 100 {
-		config.Conversion.WebP.Quality = 100
+	// 外部エンコーダーのスレッド数の検証（0は自動）
+	if cfg.Conversion.ExternalThreads < 0 {
+		adjustments = append(adjustments, fmt.Sprintf("conversion.external_threads: %d -> 0", cfg.Conversion.ExternalThreads))
+		cfg.Conversion.ExternalThreads = 0
+	}
+
+	// 最小寸法の検証（0は無効、負の値は0とする）
+	if cfg.Input.MinWidth < 0 {
+		adjustments = append(adjustments, fmt.Sprintf("input.min_width: %d -> 0", cfg.Input.MinWidth))
+		cfg.Input.MinWidth = 0
+	}
+	if cfg.Input.MinHeight < 0 {
+		adjustments = append(adjustments, fmt.Sprintf("input.min_height: %d -> 0", cfg.Input.MinHeight))
+		cfg.Input.MinHeight = 0
+	}
+
+	// 実行時間の上限の検証（0は無制限、負の値は0とする）
+	if cfg.Mode.MaxRuntimeSeconds < 0 {
+		adjustments = append(adjustments, fmt.Sprintf("mode.max_runtime_seconds: %d -> 0", cfg.Mode.MaxRuntimeSeconds))
+		cfg.Mode.MaxRuntimeSeconds = 0
+	}
+
+	// 走査する深さの検証（0は無制限、負の値は0とする）
+	if cfg.Input.MaxDepth < 0 {
+		adjustments = append(adjustments, fmt.Sprintf("input.max_depth: %d -> 0", cfg.Input.MaxDepth))
+		cfg.Input.MaxDepth = 0
 	}
 
-	// AVIF品質の検証（1〜63の範囲）
-	if config.Conversion.AVIF.Quality < 1 {
-		config.Conversion.AVIF.Quality = 1
-	} else if config.Conversion.AVIF.Quality > 63 {
-		config.Conversion.AVIF.Quality = 63
+	// URL取得タイムアウトの検証（1秒以上）
+	if cfg.Input.URLTimeout < 1 {
+		adjustments = append(adjustments, fmt.Sprintf("input.url_timeout: %d -> 30", cfg.Input.URLTimeout))
+		cfg.Input.URLTimeout = 30
+	}
+
+	// SVGキャンバスサイズの検証（1〜16384の範囲）
+	clampInt(&cfg.Input.SVG.Width, 1, 16384, "input.svg.width", &adjustments)
+	clampInt(&cfg.Input.SVG.Height, 1, 16384, "input.svg.height", &adjustments)
+
+	// WebP品質の検証（0〜100の範囲）
+	clampInt(&cfg.Conversion.WebP.Quality, 0, 100, "conversion.webp.quality", &adjustments)
+
+	// WebP圧縮レベルの検証（0〜6の範囲）
+	clampInt(&cfg.Conversion.WebP.CompressionLevel, 0, 6, "conversion.webp.compression_level", &adjustments)
+
+	// AVIF品質の検証（尺度に応じて1〜63または0〜100の範囲）
+	switch cfg.Conversion.AVIF.QualityScale {
+	case AVIFQualityScaleNative, AVIFQualityScalePercent:
+	default:
+		adjustments = append(adjustments, fmt.Sprintf("conversion.avif.quality_scale: %q -> %q", cfg.Conversion.AVIF.QualityScale, AVIFQualityScaleNative))
+		cfg.Conversion.AVIF.QualityScale = AVIFQualityScaleNative
+	}
+	switch cfg.Conversion.AVIF.ChromaSubsampling {
+	case AVIFChroma420, AVIFChroma422, AVIFChroma444:
+	default:
+		adjustments = append(adjustments, fmt.Sprintf("conversion.avif.chroma_subsampling: %q -> %q", cfg.Conversion.AVIF.ChromaSubsampling, AVIFChroma420))
+		cfg.Conversion.AVIF.ChromaSubsampling = AVIFChroma420
+	}
+	switch cfg.Conversion.AVIF.BitDepth {
+	case 8, 10, 12:
+	default:
+		adjustments = append(adjustments, fmt.Sprintf("conversion.avif.bit_depth: %d -> 8", cfg.Conversion.AVIF.BitDepth))
+		cfg.Conversion.AVIF.BitDepth = 8
+	}
+	if cfg.Conversion.AVIF.QualityScale == AVIFQualityScalePercent {
+		clampInt(&cfg.Conversion.AVIF.Quality, 0, 100, "conversion.avif.quality", &adjustments)
+	} else {
+		// WebPと同じ感覚で0〜100の値を指定している可能性が高いため、尺度の違いを明示する
+		if q := cfg.Conversion.AVIF.Quality; q > 63 && q <= 100 {
+			log.Printf("警告: AVIFの品質 %d は1〜63の尺度を超えています。WebPと同じ0〜100で指定する場合は conversion.avif.quality_scale に \"0-100\" を設定してください", q)
+		}
+		clampInt(&cfg.Conversion.AVIF.Quality, 1, 63, "conversion.avif.quality", &adjustments)
 	}
 
 	// AVIF速度の検証（0〜10の範囲）
-	if config.Conversion.AVIF.Speed < 0 {
-		config.Conversion.AVIF.Speed = 0
-	} else if config.Conversion.AVIF.Speed > 10 {
-		config.Conversion.AVIF.Speed = 10
+	clampInt(&cfg.Conversion.AVIF.Speed, 0, AVIFMaxSpeed, "conversion.avif.speed", &adjustments)
+
+	// JPEG XL品質の検証（1〜100の範囲）
+	clampInt(&cfg.Conversion.JXL.Quality, 1, 100, "conversion.jxl.quality", &adjustments)
+
+	// JPEG XLエフォートの検証（1〜9の範囲）
+	clampInt(&cfg.Conversion.JXL.Effort, 1, 9, "conversion.jxl.effort", &adjustments)
+
+	// 透かし設定の検証
+	if !imageutils.IsValidWatermarkPosition(cfg.Conversion.Watermark.Position) {
+		adjustments = append(adjustments, fmt.Sprintf("conversion.watermark.position: %q -> %q", cfg.Conversion.Watermark.Position, imageutils.WatermarkBottomRight))
+		cfg.Conversion.Watermark.Position = imageutils.WatermarkBottomRight
+	}
+	if cfg.Conversion.Watermark.Opacity < 0 || cfg.Conversion.Watermark.Opacity > 1 {
+		adjustments = append(adjustments, fmt.Sprintf("conversion.watermark.opacity: %g -> 0.5", cfg.Conversion.Watermark.Opacity))
+		cfg.Conversion.Watermark.Opacity = 0.5
+	}
+	if cfg.Conversion.Watermark.Enabled && cfg.Conversion.Watermark.ImagePath == "" {
+		adjustments = append(adjustments, "conversion.watermark.enabled: 透かし画像が未指定のため無効化")
+		cfg.Conversion.Watermark.Enabled = false
+	}
+
+	// SSIM基準値の検証（0〜1の範囲）
+	if cfg.Conversion.MinSSIM < 0 || cfg.Conversion.MinSSIM > 1 {
+		adjustments = append(adjustments, fmt.Sprintf("conversion.min_ssim: %g -> 0.95", cfg.Conversion.MinSSIM))
+		cfg.Conversion.MinSSIM = 0.95
+	}
+
+	// 出力サイズの比率の上限の検証（0は制限なし）
+	if cfg.Conversion.MaxOutputRatio < 0 {
+		adjustments = append(adjustments, fmt.Sprintf("conversion.max_output_ratio: %g -> 0 (制限なし)", cfg.Conversion.MaxOutputRatio))
+		cfg.Conversion.MaxOutputRatio = 0
+	}
+
+	// デコードする最大画素数の検証（0は制限なし）
+	if cfg.Conversion.MaxDecodePixels < 0 {
+		adjustments = append(adjustments, fmt.Sprintf("conversion.max_decode_pixels: %d -> 0 (制限なし)", cfg.Conversion.MaxDecodePixels))
+		cfg.Conversion.MaxDecodePixels = 0
+	}
+
+	// リサイズの最大幅・最大高さの検証（0は制限なし）
+	if cfg.Conversion.Resize.MaxWidth < 0 {
+		adjustments = append(adjustments, fmt.Sprintf("conversion.resize.max_width: %d -> 0 (制限なし)", cfg.Conversion.Resize.MaxWidth))
+		cfg.Conversion.Resize.MaxWidth = 0
+	}
+	if cfg.Conversion.Resize.MaxHeight < 0 {
+		adjustments = append(adjustments, fmt.Sprintf("conversion.resize.max_height: %d -> 0 (制限なし)", cfg.Conversion.Resize.MaxHeight))
+		cfg.Conversion.Resize.MaxHeight = 0
+	}
+
+	// JPEG最適化品質の検証（1〜100の範囲）
+	clampInt(&cfg.Conversion.Optimize.JPEGQuality, 1, 100, "conversion.optimize.jpeg_quality", &adjustments)
+
+	// PNG圧縮レベルの検証（不明な値は best とする）
+	pngLevel := strings.ToLower(cfg.Conversion.Optimize.PNGCompressionLevel)
+	switch pngLevel {
+	case "default", "none", "speed", "best":
+		cfg.Conversion.Optimize.PNGCompressionLevel = pngLevel
+	default:
+		adjustments = append(adjustments, fmt.Sprintf("conversion.optimize.png_compression_level: %q -> \"best\"", cfg.Conversion.Optimize.PNGCompressionLevel))
+		cfg.Conversion.Optimize.PNGCompressionLevel = "best"
+	}
+
+	// 削除対象EXIFタグ名の検証（未対応の名前は除外する）
+	var stripTags []string
+	for _, tag := range cfg.Conversion.StripEXIFTags {
+		if !imageutils.IsKnownEXIFTag(tag) {
+			adjustments = append(adjustments, fmt.Sprintf("conversion.strip_exif_tags: 未対応のタグ名 %q を除外", tag))
+			continue
+		}
+		stripTags = append(stripTags, tag)
+	}
+	cfg.Conversion.StripEXIFTags = stripTags
+
+	// デコードできない形式の扱いの検証
+	switch cfg.Input.OnUnsupported {
+	case OnUnsupportedSkip, OnUnsupportedError:
+	default:
+		adjustments = append(adjustments, fmt.Sprintf("input.on_unsupported: %q -> %q", cfg.Input.OnUnsupported, OnUnsupportedSkip))
+		cfg.Input.OnUnsupported = OnUnsupportedSkip
+	}
+
+	// ログの出力形式の検証
+	switch cfg.Logging.Format {
+	case LogFormatText, LogFormatJSON:
+	default:
+		adjustments = append(adjustments, fmt.Sprintf("logging.format: %q -> %q", cfg.Logging.Format, LogFormatText))
+		cfg.Logging.Format = LogFormatText
+	}
+
+	// 通知設定の検証
+	// フックのタイムアウトの検証（1秒以上）
+	if cfg.Hooks.Timeout <= 0 {
+		adjustments = append(adjustments, fmt.Sprintf("hooks.timeout: %d -> 30", cfg.Hooks.Timeout))
+		cfg.Hooks.Timeout = 30
+	}
+
+	if cfg.Notifications.Timeout <= 0 {
+		adjustments = append(adjustments, fmt.Sprintf("notifications.timeout: %d -> 10", cfg.Notifications.Timeout))
+		cfg.Notifications.Timeout = 10
+	}
+	switch cfg.Notifications.Format {
+	case NotificationFormatJSON, NotificationFormatSlack:
+	default:
+		adjustments = append(adjustments, fmt.Sprintf("notifications.format: %q -> %q", cfg.Notifications.Format, NotificationFormatJSON))
+		cfg.Notifications.Format = NotificationFormatJSON
+	}
+	if cfg.Notifications.MaxRetries < 0 {
+		adjustments = append(adjustments, fmt.Sprintf("notifications.max_retries: %d -> 0", cfg.Notifications.MaxRetries))
+		cfg.Notifications.MaxRetries = 0
+	}
+
+	// 出力ファイル名テンプレートの検証（不正な場合はデフォルトに戻す）
+	if err := ValidateFilenameTemplate(cfg.Output.FilenameTemplate); err != nil {
+		adjustments = append(adjustments, fmt.Sprintf("output.filename_template: %q -> %q (%v)", cfg.Output.FilenameTemplate, DefaultFilenameTemplate, err))
+		cfg.Output.FilenameTemplate = DefaultFilenameTemplate
+	}
+
+	// レポート設定の検証（0は表示しない）
+	if cfg.Reporting.TopSlowCount < 0 {
+		adjustments = append(adjustments, fmt.Sprintf("reporting.top_slow_count: %d -> 0", cfg.Reporting.TopSlowCount))
+		cfg.Reporting.TopSlowCount = 0
+	}
+
+	// 帯域制限の検証（0は無制限）
+	if cfg.Remote.MaxBandwidthKBps < 0 {
+		adjustments = append(adjustments, fmt.Sprintf("remote.max_bandwidth_kbps: %d -> 0", cfg.Remote.MaxBandwidthKBps))
+		cfg.Remote.MaxBandwidthKBps = 0
+	}
+
+	// 差分同期の状態ファイルの検証（空の場合はデフォルト）
+	if cfg.Remote.DeltaSync.Enabled && cfg.Remote.DeltaSync.StateFile == "" {
+		adjustments = append(adjustments, fmt.Sprintf("remote.delta_sync.state_file: \"\" -> %q", DefaultDeltaStateFile))
+		cfg.Remote.DeltaSync.StateFile = DefaultDeltaStateFile
+	}
+
+	// バッチ処理の検証（バッチサイズは1以上、休止時間は0以上）
+	if cfg.Remote.BatchSize < 1 {
+		adjustments = append(adjustments, fmt.Sprintf("remote.batch_size: %d -> 1", cfg.Remote.BatchSize))
+		cfg.Remote.BatchSize = 1
+	}
+	if cfg.Remote.BatchPauseSeconds < 0 {
+		adjustments = append(adjustments, fmt.Sprintf("remote.batch_pause_seconds: %d -> 0", cfg.Remote.BatchPauseSeconds))
+		cfg.Remote.BatchPauseSeconds = 0
+	}
+
+	// リモートの同時転送数の検証（1以上）
+	if cfg.Remote.ConcurrentTransfers < 1 {
+		adjustments = append(adjustments, fmt.Sprintf("remote.concurrent_transfers: %d -> 1", cfg.Remote.ConcurrentTransfers))
+		cfg.Remote.ConcurrentTransfers = 1
+	}
+
+	// SFTPの転送設定の検証
+	clampInt(&cfg.Remote.SFTPMaxPacketBytes, 1024, 262144, "remote.sftp_max_packet", &adjustments)
+	clampInt(&cfg.Remote.SFTPConcurrentRequests, 1, 1024, "remote.sftp_concurrent_requests", &adjustments)
+
+	// キープアライブ間隔の検証（0は無効）
+	if cfg.Remote.KeepAliveIntervalSeconds < 0 {
+		adjustments = append(adjustments, fmt.Sprintf("remote.keepalive_interval: %d -> 0", cfg.Remote.KeepAliveIntervalSeconds))
+		cfg.Remote.KeepAliveIntervalSeconds = 0
+	}
+
+	// リモートファイルの一覧取得方法の検証
+	switch cfg.Remote.ListMethod {
+	case RemoteListMethodFind, RemoteListMethodSFTP:
+	default:
+		adjustments = append(adjustments, fmt.Sprintf("remote.list_method: %q -> %q", cfg.Remote.ListMethod, RemoteListMethodFind))
+		cfg.Remote.ListMethod = RemoteListMethodFind
 	}
 
 	// リモートタイムアウトが短すぎる場合は調整
-	if config.Remote.Enabled && config.Remote.Timeout < 60 {
-		config.Remote.Timeout = 60
+	if cfg.Remote.Enabled && cfg.Remote.Timeout < 60 {
+		adjustments = append(adjustments, fmt.Sprintf("remote.timeout: %d -> 60", cfg.Remote.Timeout))
+		cfg.Remote.Timeout = 60
+	}
+
+	return adjustments
+}
+
+// clampInt は値を最小値と最大値の範囲に収め、調整した場合はその内容を記録します
+func clampInt(value *int, minValue, maxValue int, field string, adjustments *[]string) {
+	original := *value
+	if *value < minValue {
+		*value = minValue
+	} else if *value > maxValue {
+		*value = maxValue
+	}
+
+	if *value != original {
+		*adjustments = append(*adjustments, fmt.Sprintf("%s: %d -> %d", field, original, *value))
 	}
 }
 
 // GetConfig は現在の設定を返します
 func GetConfig() Config {
+	configMu.RLock()
+	defer configMu.RUnlock()
 	return config
 }
 
+// MarshalConfigJSON は現在の設定をJSON形式で返します
+func MarshalConfigJSON() ([]byte, error) {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return json.MarshalIndent(&config, "", "  ")
+}
+
+// MarshalConfigYAML は現在の設定をYAML形式で返します
+func MarshalConfigYAML() ([]byte, error) {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return yaml.Marshal(&config)
+}
+
+// EffectiveConfigYAML はデフォルト値・上書き設定・検証による調整を反映した現在の設定をYAMLで返します
+func EffectiveConfigYAML() ([]byte, error) {
+	return MarshalConfigYAML()
+}
+
 // GetRemoteConfig はリモート設定を作成します
 func GetRemoteConfig() *RemoteConfig {
+	configMu.RLock()
+	defer configMu.RUnlock()
 	return &RemoteConfig{
-		Enabled:     config.Remote.Enabled,
-		Host:        config.Remote.Host,
-		Port:        config.Remote.Port,
-		User:        config.Remote.User,
-		KeyPath:     config.Remote.KeyPath,
-		KnownHosts:  config.Remote.KnownHosts,
-		RemotePath:  config.Remote.RemotePath,
-		UseSSHAgent: config.Remote.UseSSHAgent,
-		Timeout:     config.Remote.Timeout,
+		Enabled:                  config.Remote.Enabled,
+		Host:                     config.Remote.Host,
+		Port:                     config.Remote.Port,
+		User:                     config.Remote.User,
+		KeyPath:                  config.Remote.KeyPath,
+		KnownHosts:               config.Remote.KnownHosts,
+		RemotePath:               config.Remote.RemotePath,
+		UseSSHAgent:              config.Remote.UseSSHAgent,
+		Timeout:                  config.Remote.Timeout,
+		ConcurrentTransfers:      config.Remote.ConcurrentTransfers,
+		ListMethod:               config.Remote.ListMethod,
+		KeepAliveIntervalSeconds: config.Remote.KeepAliveIntervalSeconds,
+		SFTPMaxPacketBytes:       config.Remote.SFTPMaxPacketBytes,
+		SFTPConcurrentRequests:   config.Remote.SFTPConcurrentRequests,
+		VerifyUploads:            config.Remote.VerifyUploads,
+		MaxBandwidthKBps:         config.Remote.MaxBandwidthKBps,
+		ResumeDownloads:          config.Remote.ResumeDownloads,
+		PartialDir:               config.Remote.PartialDir,
+		CertPath:                 config.Remote.CertPath,
+		CertPrincipal:            config.Remote.CertPrincipal,
+		DeltaSync:                config.Remote.DeltaSync,
+		VerifyChecksums:          config.Remote.VerifyChecksums,
+		BatchSize:                config.Remote.BatchSize,
+		BatchPauseSeconds:        config.Remote.BatchPauseSeconds,
 	}
 }
 
 // SetDryRun はドライランモードを設定します
 func SetDryRun(enabled bool) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	dryRunOverride = &enabled
 	config.Mode.DryRun = enabled
 }
 
 // SetRemoteMode はリモートモードを設定します
 func SetRemoteMode(enabled bool) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	remoteModeOverride = &enabled
 	config.Remote.Enabled = enabled
 }
 
+// SetQuarantineDir は破損画像の隔離先ディレクトリを設定します
+func SetQuarantineDir(dir string) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	quarantineDirOverride = &dir
+	config.Conversion.QuarantineDir = dir
+}
+
+// SetPerFileLogging はファイルごとの情報ログを出力するかどうかを設定します
+func SetPerFileLogging(enabled bool) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	perFileLogOverride = &enabled
+	config.Logging.PerFile = enabled
+}
+
 // IsDryRun はドライランモードかどうかを返します
 func IsDryRun() bool {
+	configMu.RLock()
+	defer configMu.RUnlock()
 	return config.Mode.DryRun
 }
 
 // IsRemoteMode はリモートモードかどうかを返します
 func IsRemoteMode() bool {
+	configMu.RLock()
+	defer configMu.RUnlock()
 	return config.Remote.Enabled
 }
 
 // IsSupportedExtension は指定された拡張子がサポートされているかどうかを返します
 func IsSupportedExtension(ext string) bool {
+	configMu.RLock()
+	defer configMu.RUnlock()
 	return supportedExtensions[strings.ToLower(ext)]
 }
 
 // GetSupportedExtensions はサポートされている拡張子のリストを返します
 func GetSupportedExtensions() []string {
+	configMu.RLock()
+	defer configMu.RUnlock()
 	return config.Input.SupportedExtensions
 }
 
 // GetInputDirectory は入力ディレクトリのパスを返します
 func GetInputDirectory() string {
+	configMu.RLock()
+	defer configMu.RUnlock()
 	return config.Input.Directory
 }
 
+// GetSVGCanvasSize はSVGをラスタライズする際のキャンバスサイズ（幅, 高さ）を返します
+func GetSVGCanvasSize() (int, int) {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return config.Input.SVG.Width, config.Input.SVG.Height
+}
+
 // GetWorkerCount はワーカー数を返します
 func GetWorkerCount() int {
+	configMu.RLock()
+	defer configMu.RUnlock()
 	return config.Conversion.Workers
 }
 
+// GetExternalThreads はエンコーダー1回あたりに使用するスレッド数を返します
+// 0（自動）の場合は、CPU数をワーカー数で割った値（最低1）を返します
+func GetExternalThreads() int {
+	configMu.RLock()
+	defer configMu.RUnlock()
+
+	if config.Conversion.ExternalThreads > 0 {
+		return config.Conversion.ExternalThreads
+	}
+
+	workers := config.Conversion.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	return max(1, runtime.NumCPU()/workers)
+}
+
+// IsChecksumEnabled はチェックサムファイル（.sha256）を書き込むかどうかを返します
+func IsChecksumEnabled() bool {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return config.ChecksumsEnabled()
+}
+
+// ChecksumsEnabled はこの設定でチェックサムファイル（output.write_checksums）を書き込むかどうかを返します
+func (c *Config) ChecksumsEnabled() bool {
+	return c.Output.WriteChecksums
+}
+
 // IsWebPEnabled はWebP変換が有効かどうかを返します
 func IsWebPEnabled() bool {
+	configMu.RLock()
+	defer configMu.RUnlock()
 	return config.Conversion.WebP.Enabled
 }
 
 // GetWebPQuality はWebP品質設定を返します
 func GetWebPQuality() int {
+	configMu.RLock()
+	defer configMu.RUnlock()
 	return config.Conversion.WebP.Quality
 }
 
+// GetWebPCompressionLevel はWebP圧縮レベル設定（0〜6）を返します
+func GetWebPCompressionLevel() int {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return config.Conversion.WebP.CompressionLevel
+}
+
 // IsAVIFEnabled はAVIF変換が有効かどうかを返します
 func IsAVIFEnabled() bool {
+	configMu.RLock()
+	defer configMu.RUnlock()
 	return config.Conversion.AVIF.Enabled
 }
 
-// GetAVIFQuality はAVIF品質設定を返します
+// GetAVIFQuality はAVIF品質設定をエンコーダーの尺度（1〜63）で返します
 func GetAVIFQuality() int {
-	return config.Conversion.AVIF.Quality
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return config.AVIFNativeQuality()
+}
+
+// AVIFNativeQuality はこの設定のAVIF品質をエンコーダーの尺度（1〜63）で返します
+// quality_scale が "0-100" の場合は1〜63に換算します
+func (c *Config) AVIFNativeQuality() int {
+	if c.Conversion.AVIF.QualityScale == AVIFQualityScalePercent {
+		return ScaleAVIFQuality(c.Conversion.AVIF.Quality)
+	}
+	return c.Conversion.AVIF.Quality
+}
+
+// ScaleAVIFQuality は0〜100の品質値をAVIFエンコーダーの尺度（1〜63）に換算します
+func ScaleAVIFQuality(percent int) int {
+	percent = max(0, min(100, percent))
+	return 1 + (percent*62+50)/100
 }
 
 // GetAVIFSpeed はAVIF速度設定を返します
 func GetAVIFSpeed() int {
+	configMu.RLock()
+	defer configMu.RUnlock()
 	return config.Conversion.AVIF.Speed
 }
 
+// GetAVIFChromaSubsampling はAVIFの色差サブサンプリング設定を返します
+func GetAVIFChromaSubsampling() string {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return config.Conversion.AVIF.ChromaSubsampling
+}
+
+// GetAVIFBitDepth はAVIFの出力ビット深度を返します
+func GetAVIFBitDepth() int {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return config.Conversion.AVIF.BitDepth
+}
+
+// IsJXLEnabled はJPEG XL変換が有効かどうかを返します
+func IsJXLEnabled() bool {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return config.Conversion.JXL.Enabled
+}
+
+// GetJXLQuality はJPEG XL品質設定を返します
+func GetJXLQuality() int {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return config.Conversion.JXL.Quality
+}
+
+// GetJXLEffort はJPEG XLエフォート設定を返します
+func GetJXLEffort() int {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return config.Conversion.JXL.Effort
+}
+
 // IsFTPEnabled はFTPサーバーが有効かどうかを返します
 func IsFTPEnabled() bool {
+	configMu.RLock()
+	defer configMu.RUnlock()
 	return config.FTP.Enabled
 }
 
 // IsSSHEnabled はSSHサーバーが有効かどうかを返します
 func IsSSHEnabled() bool {
+	configMu.RLock()
+	defer configMu.RUnlock()
 	return config.SSH.Enabled
 }
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfigReaderMalformed(t *testing.T) {
+	t.Cleanup(func() { LoadConfigFromBytes(nil) })
+	if err := LoadConfigReader(strings.NewReader("conversion:\n  webp:\n    quality: 70\n")); err != nil {
+		t.Fatalf("設定の読み込みに失敗しました: %v", err)
+	}
+
+	for _, data := range []string{
+		"conversion: [unterminated",
+		"conversion:\n  workers: many\n",
+		"\tremote: {}\n",
+	} {
+		if err := LoadConfigReader(strings.NewReader(data)); err == nil {
+			t.Errorf("不正なYAML %q でエラーになりませんでした", data)
+		}
+	}
+
+	// 読み込みに失敗した場合は直前の設定を維持する
+	if got := GetConfig().Conversion.WebP.Quality; got != 70 {
+		t.Errorf("失敗後の WebP の画質 = %d, want 70", got)
+	}
+}
+
+func TestLoadConfigReaderEmpty(t *testing.T) {
+	t.Cleanup(func() { LoadConfigFromBytes(nil) })
+	if err := LoadConfigReader(strings.NewReader("")); err != nil {
+		t.Fatalf("空の設定の読み込みに失敗しました: %v", err)
+	}
+
+	want := DefaultConfig()
+	validateConfig(&want)
+	if got := GetConfig(); !reflect.DeepEqual(got, want) {
+		t.Errorf("空の設定がデフォルト設定と一致しません:\ngot  %+v\nwant %+v", got, want)
+	}
+}
+
+func TestLoadConfigReaderValid(t *testing.T) {
+	t.Cleanup(func() { LoadConfigFromBytes(nil) })
+	data := `
+remote:
+  enabled: true
+  host: example.com
+  port: 2222
+  user: deploy
+input:
+  directory: /srv/images
+  supported_extensions: [".png", ".gif"]
+conversion:
+  workers: 3
+  webp:
+    enabled: true
+    quality: 65
+    compression_level: 5
+  avif:
+    enabled: false
+logging:
+  level: debug
+`
+	if err := LoadConfigReader(strings.NewReader(data)); err != nil {
+		t.Fatalf("設定の読み込みに失敗しました: %v", err)
+	}
+
+	got := GetConfig()
+	checks := []struct {
+		name      string
+		got, want interface{}
+	}{
+		{"remote.enabled", got.Remote.Enabled, true},
+		{"remote.host", got.Remote.Host, "example.com"},
+		{"remote.port", got.Remote.Port, 2222},
+		{"remote.user", got.Remote.User, "deploy"},
+		{"input.directory", got.Input.Directory, "/srv/images"},
+		{"input.supported_extensions", got.Input.SupportedExtensions, []string{".png", ".gif"}},
+		{"conversion.workers", got.Conversion.Workers, 3},
+		{"conversion.webp.quality", got.Conversion.WebP.Quality, 65},
+		{"conversion.webp.compression_level", got.Conversion.WebP.CompressionLevel, 5},
+		{"conversion.avif.enabled", got.Conversion.AVIF.Enabled, false},
+		{"logging.level", got.Logging.Level, "debug"},
+		// 指定していない値はデフォルト値になる
+		{"remote.timeout", got.Remote.Timeout, DefaultConfig().Remote.Timeout},
+	}
+	for _, c := range checks {
+		if !reflect.DeepEqual(c.got, c.want) {
+			t.Errorf("%s = %v, want %v", c.name, c.got, c.want)
+		}
+	}
+	if !IsSupportedExtension(".gif") || IsSupportedExtension(".jpg") {
+		t.Errorf("サポートされている拡張子が設定を反映していません")
+	}
+}
+
+// TestAVIFSpeedRange は conversion.avif.speed をエンコーダーが対応する0〜8の範囲に揃えることを確認します
+func TestAVIFSpeedRange(t *testing.T) {
+	t.Cleanup(func() { LoadConfigFromBytes(nil) })
+
+	for _, tt := range []struct {
+		speed, want int
+	}{
+		{speed: 8, want: 8},
+		{speed: 10, want: AVIFMaxSpeed},
+		{speed: -1, want: 0},
+	} {
+		data := "conversion:\n  avif:\n    speed: " + strconv.Itoa(tt.speed) + "\n"
+		if err := LoadConfigFromBytes([]byte(data)); err != nil {
+			t.Fatalf("設定の読み込みに失敗しました: %v", err)
+		}
+		if got := GetConfig().Conversion.AVIF.Speed; got != tt.want {
+			t.Errorf("速度 %d の読み込み結果 = %d, want %d", tt.speed, got, tt.want)
+		}
+
+		cfg := DefaultConfig()
+		cfg.Conversion.AVIF.Speed = tt.speed
+		if rejected := ValidateStrict(cfg) != nil; rejected != (tt.speed != tt.want) {
+			t.Errorf("速度 %d の厳密な検証でのエラー = %v, want %v", tt.speed, rejected, tt.speed != tt.want)
+		}
+	}
+}
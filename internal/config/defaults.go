@@ -14,25 +14,106 @@ func DefaultConfig() Config {
 	config.Remote.RemotePath = "/var/www/html/images"
 	config.Remote.UseSSHAgent = true
 	config.Remote.Timeout = 60
+	config.Remote.ConcurrentTransfers = 1
+	config.Remote.ListMethod = RemoteListMethodFind
+	config.Remote.KeepAliveIntervalSeconds = 0
+	config.Remote.SFTPMaxPacketBytes = 32768
+	config.Remote.SFTPConcurrentRequests = 64
+	config.Remote.VerifyUploads = true
+	config.Remote.MaxBandwidthKBps = 0
+	config.Remote.ResumeDownloads = true
+	config.Remote.PartialDir = ""
+	config.Remote.CertPath = ""
+	config.Remote.CertPrincipal = ""
+	config.Remote.DeltaSync = DeltaSyncConfig{StateFile: DefaultDeltaStateFile}
+	config.Remote.VerifyChecksums = false
+	config.Remote.BatchSize = 10
+	config.Remote.BatchPauseSeconds = 5
 
 	// モード設定のデフォルト値
 	config.Mode.DryRun = false
+	config.Mode.HashIndex = ""        // 空の場合はすべてのファイルを変換
+	config.Mode.MaxRuntimeSeconds = 0 // 0は無制限
+	config.Mode.DryRunOutput = ""     // 空の場合はログにのみ出力
 
 	// 入力設定のデフォルト値
 	config.Input.Directory = "./images"
 	config.Input.SupportedExtensions = []string{
-		".jpg", ".jpeg", ".png", ".heic", ".heif",
+		".jpg", ".jpeg", ".png", ".heic", ".heif", ".svg",
 	}
+	config.Input.MinWidth = 0
+	config.Input.MinHeight = 0
+	config.Input.MaxDepth = 0
+	config.Input.FollowSymlinks = false
+	config.Input.OnUnsupported = OnUnsupportedSkip
+	config.Input.URLs = []string{}
+	config.Input.URLTimeout = 30
+	config.Input.SVG.Width = 1024
+	config.Input.SVG.Height = 1024
 
 	// 変換設定のデフォルト値
-	config.Conversion.Workers = 4
+	config.Conversion.Workers = 0              // 0はCPU数に合わせる
+	config.Conversion.MaxDecodeConcurrency = 0 // 0はワーカー数と同じ
+	config.Conversion.ExternalThreads = 0
+	config.Conversion.DeduplicateByHash = false
+	config.Conversion.QuarantineDir = ""
+	config.Conversion.PreserveEXIF = false
+	config.Conversion.PreserveXMP = false
+	config.Conversion.PreserveColorProfile = false
+	config.Conversion.StripMetadata = false
+	config.Conversion.AdaptiveQuality = false
+	config.Conversion.VerifySSIM = false
+	config.Conversion.MinSSIM = 0.95
+	config.Conversion.MaxOutputRatio = 0  // 制限しない
+	config.Conversion.MaxDecodePixels = 0 // 制限しない
+	config.Conversion.StripEXIFTags = []string{}
+	config.Conversion.AutoOrient = true
+	config.Conversion.Resize.MaxWidth = 0  // 制限しない
+	config.Conversion.Resize.MaxHeight = 0 // 制限しない
 	config.Conversion.WebP.Enabled = true
 	config.Conversion.WebP.Quality = 80
 	config.Conversion.WebP.CompressionLevel = 4
+	config.Conversion.WebP.AutoLossless = false
 	config.Conversion.AVIF.Enabled = true
 	config.Conversion.AVIF.Quality = 40
 	config.Conversion.AVIF.Speed = 6
 	config.Conversion.AVIF.Lossless = false
+	config.Conversion.AVIF.QualityScale = AVIFQualityScaleNative
+	config.Conversion.AVIF.ChromaSubsampling = AVIFChroma420
+	config.Conversion.AVIF.BitDepth = 8
+	config.Conversion.JXL.Enabled = false
+	config.Conversion.JXL.Quality = 90
+	config.Conversion.JXL.Effort = 7
+	config.Conversion.Optimize.Enabled = false
+	config.Conversion.Optimize.JPEGQuality = 85
+	config.Conversion.Optimize.PNGCompressionLevel = "best"
+	config.Conversion.Optimize.Overwrite = false
+	config.Conversion.AnimatedGIF.Enabled = false
+	config.Conversion.AnimatedGIF.PreserveDelay = true
+	config.Conversion.Watermark.Enabled = false
+	config.Conversion.Watermark.ImagePath = ""
+	config.Conversion.Watermark.Position = "bottom-right"
+	config.Conversion.Watermark.Opacity = 0.5
+
+	// 通知設定のデフォルト値
+	config.Notifications.WebhookURL = ""
+	config.Notifications.Timeout = 10
+	config.Notifications.MaxRetries = 3
+	config.Notifications.Format = NotificationFormatJSON
+
+	// フック設定のデフォルト値
+	config.Hooks.PostConvert = ""
+	config.Hooks.Timeout = 30
+	config.Hooks.FailOnError = false
+
+	// 出力設定のデフォルト値
+	config.Output.WriteChecksums = false
+	config.Output.FilenameTemplate = DefaultFilenameTemplate
+	config.Output.Zip = "" // 空の場合はファイルのまま出力
+
+	// レポート設定のデフォルト値
+	config.Reporting.TopSlowCount = 10
+	config.Reporting.FailuresFile = ""
 
 	// FTPサーバー設定のデフォルト値
 	config.FTP.Enabled = false
@@ -51,12 +132,19 @@ func DefaultConfig() Config {
 
 	// ログ設定のデフォルト値
 	config.Logging.Level = "info"
+	config.Logging.Format = LogFormatText
+	config.Logging.ModuleLevels = map[string]string{} // 空の場合はすべてのモジュールで level を使用
+	config.Logging.PerFile = true
 	config.Logging.File = ""
 	config.Logging.Directory = "logs" // デフォルトディレクトリを設定
 	config.Logging.MaxSize = 10
 	config.Logging.MaxBackups = 3
 	config.Logging.MaxAge = 28
 	config.Logging.Compress = true
+	config.Logging.Syslog.Enabled = false
+	config.Logging.Syslog.Network = "" // 空の場合はローカルのUNIXソケット
+	config.Logging.Syslog.Address = ""
+	config.Logging.Syslog.Tag = "image-converter"
 
 	return config
 }
@@ -106,15 +194,30 @@ func DefaultConversionConfig() struct {
 // DefaultRemoteConfig はリモート設定のデフォルト値を返します
 func DefaultRemoteConfig() RemoteConfig {
 	return RemoteConfig{
-		Enabled:     false,
-		Host:        "localhost",
-		Port:        22,
-		User:        "user",
-		KeyPath:     "",
-		KnownHosts:  "~/.ssh/known_hosts",
-		RemotePath:  "/var/www/html/images",
-		UseSSHAgent: true,
-		Timeout:     60,
+		Enabled:                  false,
+		Host:                     "localhost",
+		Port:                     22,
+		User:                     "user",
+		KeyPath:                  "",
+		KnownHosts:               "~/.ssh/known_hosts",
+		RemotePath:               "/var/www/html/images",
+		UseSSHAgent:              true,
+		Timeout:                  60,
+		ConcurrentTransfers:      1,
+		ListMethod:               RemoteListMethodFind,
+		KeepAliveIntervalSeconds: 0,
+		SFTPMaxPacketBytes:       32768,
+		SFTPConcurrentRequests:   64,
+		VerifyUploads:            true,
+		MaxBandwidthKBps:         0,
+		ResumeDownloads:          true,
+		PartialDir:               "",
+		CertPath:                 "",
+		CertPrincipal:            "",
+		DeltaSync:                DeltaSyncConfig{StateFile: DefaultDeltaStateFile},
+		VerifyChecksums:          false,
+		BatchSize:                10,
+		BatchPauseSeconds:        5,
 	}
 }
 
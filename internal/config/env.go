@@ -0,0 +1,71 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+)
+
+// 環境変数で上書きできるリモート設定
+// 設定ファイルに接続情報や鍵のパスを記述せずに済むよう、YAMLの読み込み後に適用します（環境変数が優先）
+const (
+	EnvRemoteHost          = "IMGCONV_REMOTE_HOST"           // remote.host
+	EnvRemotePort          = "IMGCONV_REMOTE_PORT"           // remote.port
+	EnvRemoteUser          = "IMGCONV_REMOTE_USER"           // remote.user
+	EnvRemoteKeyPath       = "IMGCONV_REMOTE_KEY_PATH"       // remote.key_path
+	EnvRemoteCertPath      = "IMGCONV_REMOTE_CERT_PATH"      // remote.cert_path
+	EnvRemoteCertPrincipal = "IMGCONV_REMOTE_CERT_PRINCIPAL" // remote.cert_principal
+	EnvRemoteKnownHosts    = "IMGCONV_REMOTE_KNOWN_HOSTS"    // remote.known_hosts
+	EnvRemotePath          = "IMGCONV_REMOTE_PATH"           // remote.remote_path
+	EnvRemoteUseSSHAgent   = "IMGCONV_REMOTE_USE_SSH_AGENT"  // remote.use_ssh_agent
+)
+
+// envOverride は環境変数と、その値を設定に反映する関数の組です
+type envOverride struct {
+	name  string
+	apply func(cfg *Config, value string) error
+}
+
+// remoteEnvOverrides は環境変数で上書きできるリモート設定の一覧です
+var remoteEnvOverrides = []envOverride{
+	{EnvRemoteHost, func(cfg *Config, v string) error { cfg.Remote.Host = v; return nil }},
+	{EnvRemotePort, func(cfg *Config, v string) error {
+		port, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("ポート番号ではありません: %q", v)
+		}
+		cfg.Remote.Port = port
+		return nil
+	}},
+	{EnvRemoteUser, func(cfg *Config, v string) error { cfg.Remote.User = v; return nil }},
+	{EnvRemoteKeyPath, func(cfg *Config, v string) error { cfg.Remote.KeyPath = v; return nil }},
+	{EnvRemoteCertPath, func(cfg *Config, v string) error { cfg.Remote.CertPath = v; return nil }},
+	{EnvRemoteCertPrincipal, func(cfg *Config, v string) error { cfg.Remote.CertPrincipal = v; return nil }},
+	{EnvRemoteKnownHosts, func(cfg *Config, v string) error { cfg.Remote.KnownHosts = v; return nil }},
+	{EnvRemotePath, func(cfg *Config, v string) error { cfg.Remote.RemotePath = v; return nil }},
+	{EnvRemoteUseSSHAgent, func(cfg *Config, v string) error {
+		useAgent, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("真偽値ではありません: %q", v)
+		}
+		cfg.Remote.UseSSHAgent = useAgent
+		return nil
+	}},
+}
+
+// applyEnvOverrides は設定されている環境変数でリモート設定を上書きします
+// 値が不正な環境変数は警告を出力して無視します。値には秘密情報が含まれ得るため、ログには変数名のみを出力します
+func applyEnvOverrides(cfg *Config) {
+	for _, override := range remoteEnvOverrides {
+		value, ok := os.LookupEnv(override.name)
+		if !ok {
+			continue
+		}
+		if err := override.apply(cfg, value); err != nil {
+			log.Printf("警告: 環境変数 %s を無視します: %v", override.name, err)
+			continue
+		}
+		log.Printf("環境変数 %s で設定を上書きしました", override.name)
+	}
+}
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// extendsHeader は設定ファイルの継承元を指定する extends キーだけを読み込むための構造体です
+type extendsHeader struct {
+	Extends string `yaml:"extends"`
+}
+
+// unmarshalWithExtends はYAMLデータを cfg に反映します
+// extends キーで継承元の設定ファイルが指定されている場合は、継承元を先に反映してからこのデータの値で上書きします
+// 継承元の相対パスは、参照元のファイルがあるディレクトリを基準に解決します（ファイル以外から読み込んだ場合は作業ディレクトリ）
+func unmarshalWithExtends(data []byte, configPath string, cfg *Config) error {
+	visited := make(map[string]bool)
+	if configPath != "" {
+		if abs, err := filepath.Abs(configPath); err == nil {
+			visited[abs] = true
+		}
+	}
+	return unmarshalExtends(data, configPath, cfg, visited)
+}
+
+// unmarshalExtends は継承元を再帰的にたどって反映します
+// visited は循環参照を検出するために、読み込み済みのファイルの絶対パスを記録します
+func unmarshalExtends(data []byte, configPath string, cfg *Config, visited map[string]bool) error {
+	var header extendsHeader
+	if err := yaml.Unmarshal(data, &header); err != nil {
+		return fmt.Errorf("設定ファイルの解析に失敗しました: %v", err)
+	}
+
+	if header.Extends != "" {
+		basePath := header.Extends
+		if !filepath.IsAbs(basePath) && configPath != "" {
+			basePath = filepath.Join(filepath.Dir(configPath), basePath)
+		}
+		basePath, err := filepath.Abs(basePath)
+		if err != nil {
+			return fmt.Errorf("継承元の設定ファイルのパスを解決できません: %v", err)
+		}
+
+		if visited[basePath] {
+			return fmt.Errorf("設定ファイルの継承が循環しています: %s", basePath)
+		}
+		visited[basePath] = true
+
+		baseData, err := os.ReadFile(basePath)
+		if err != nil {
+			return fmt.Errorf("継承元の設定ファイルの読み込みに失敗しました: %v", err)
+		}
+		if err := unmarshalExtends(baseData, basePath, cfg, visited); err != nil {
+			return fmt.Errorf("%s: %w", basePath, err)
+		}
+	}
+
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return fmt.Errorf("設定ファイルの解析に失敗しました: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// fillValue は v のすべてのフィールドにゼロ値以外の値を設定します
+// フィールドごとに異なる値になるよう、seed を増やしながら設定します
+func fillValue(t *testing.T, v reflect.Value, seed *int) {
+	t.Helper()
+
+	*seed++
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				fillValue(t, v.Field(i), seed)
+			}
+		}
+	case reflect.Pointer:
+		v.Set(reflect.New(v.Type().Elem()))
+		fillValue(t, v.Elem(), seed)
+	case reflect.String:
+		v.SetString(fmt.Sprintf("value-%d", *seed))
+	case reflect.Bool:
+		v.SetBool(true)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(int64(*seed))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(uint64(*seed))
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(float64(*seed) + 0.5)
+	case reflect.Slice:
+		v.Set(reflect.MakeSlice(v.Type(), 1, 1))
+		fillValue(t, v.Index(0), seed)
+	case reflect.Map:
+		key := reflect.New(v.Type().Key()).Elem()
+		elem := reflect.New(v.Type().Elem()).Elem()
+		fillValue(t, key, seed)
+		fillValue(t, elem, seed)
+		v.Set(reflect.MakeMap(v.Type()))
+		v.SetMapIndex(key, elem)
+	default:
+		t.Fatalf("値を設定できない型です: %s", v.Type())
+	}
+}
+
+// filledConfig はすべてのフィールドにゼロ値以外の値を設定した設定を返します
+func filledConfig(t *testing.T) Config {
+	t.Helper()
+
+	var cfg Config
+	seed := 0
+	fillValue(t, reflect.ValueOf(&cfg).Elem(), &seed)
+	return cfg
+}
+
+// setConfigForTest はテストの間だけグローバルな設定を cfg に置き換えます
+func setConfigForTest(t *testing.T, cfg Config) {
+	t.Helper()
+
+	configMu.Lock()
+	config = cfg
+	configMu.Unlock()
+	t.Cleanup(func() { LoadConfigFromBytes(nil) })
+}
+
+func TestMarshalConfigRoundTrip(t *testing.T) {
+	want := filledConfig(t)
+	setConfigForTest(t, want)
+
+	tests := []struct {
+		name      string
+		marshal   func() ([]byte, error)
+		unmarshal func([]byte, interface{}) error
+	}{
+		{name: "JSON", marshal: MarshalConfigJSON, unmarshal: json.Unmarshal},
+		{name: "YAML", marshal: MarshalConfigYAML, unmarshal: yaml.Unmarshal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := tt.marshal()
+			if err != nil {
+				t.Fatalf("設定の出力に失敗しました: %v", err)
+			}
+
+			var got Config
+			if err := tt.unmarshal(data, &got); err != nil {
+				t.Fatalf("出力した設定を読み込めません: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("読み込み直した設定が一致しません:\n%s", data)
+			}
+		})
+	}
+}
+
+// TestJSONTagsMatchYAMLTags は設定のすべてのフィールドに、YAMLと同じ名前のJSONタグがあることを確認します
+func TestJSONTagsMatchYAMLTags(t *testing.T) {
+	var check func(typ reflect.Type, path string)
+	check = func(typ reflect.Type, path string) {
+		for typ.Kind() == reflect.Pointer || typ.Kind() == reflect.Slice || typ.Kind() == reflect.Map {
+			typ = typ.Elem()
+		}
+		if typ.Kind() != reflect.Struct {
+			return
+		}
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name := path + "." + field.Name
+			if jsonTag, yamlTag := field.Tag.Get("json"), field.Tag.Get("yaml"); jsonTag == "" || jsonTag != yamlTag {
+				t.Errorf("%s: json タグ %q が yaml タグ %q と一致しません", name, jsonTag, yamlTag)
+			}
+			check(field.Type, name)
+		}
+	}
+
+	check(reflect.TypeOf(Config{}), "Config")
+	check(reflect.TypeOf(RemoteConfig{}), "RemoteConfig")
+}
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DirectoryOverrideFile はディレクトリごとの設定上書きファイルの名前です
+const DirectoryOverrideFile = ".image-converter.yml"
+
+// MergeConfigs は base に override のゼロ値でない項目だけを上書きした設定を返します
+// 構造体は項目ごとに再帰的にマージし、スライスは空でない場合に丸ごと置き換えます
+// ゼロ値（false, 0, 空文字列）は「未指定」として扱うため、上書きで false や 0 に戻すことはできません
+func MergeConfigs(base, override Config) Config {
+	merged := base
+	mergeValue(reflect.ValueOf(&merged).Elem(), reflect.ValueOf(override))
+	return merged
+}
+
+// mergeValue は src のゼロ値でない項目を dst にコピーします
+func mergeValue(dst, src reflect.Value) {
+	switch src.Kind() {
+	case reflect.Struct:
+		for i := 0; i < src.NumField(); i++ {
+			if !dst.Field(i).CanSet() {
+				continue
+			}
+			mergeValue(dst.Field(i), src.Field(i))
+		}
+	case reflect.Slice, reflect.Map:
+		if src.Len() > 0 {
+			dst.Set(src)
+		}
+	default:
+		if !src.IsZero() {
+			dst.Set(src)
+		}
+	}
+}
+
+// LoadDirectoryOverride はディレクトリごとの上書きファイルを読み込みます
+// デフォルト値は適用しないため、ファイルに記述された項目だけがゼロ値以外になります
+func LoadDirectoryOverride(path string) (Config, error) {
+	var override Config
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return override, fmt.Errorf("上書き設定ファイルの読み込みに失敗しました: %v", err)
+	}
+
+	if err := yaml.Unmarshal(data, &override); err != nil {
+		return override, fmt.Errorf("上書き設定ファイルの解析に失敗しました: %v", err)
+	}
+
+	return override, nil
+}
+
+// ApplyDirectoryOverride は base に上書き設定をマージし、検証・調整した設定を返します
+// 調整内容は警告としてログに出力します
+func ApplyDirectoryOverride(base, override Config) Config {
+	merged := MergeConfigs(base, override)
+	for _, adjustment := range validateConfig(&merged) {
+		log.Printf("警告: 上書き設定の値を調整しました: %s", adjustment)
+	}
+	return merged
+}
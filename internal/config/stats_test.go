@@ -0,0 +1,288 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestConversionStatsConcurrentUpdates は50個のゴルーチンから同時に更新しても、すべてのカウンタが正確に集計されることを確認します
+func TestConversionStatsConcurrentUpdates(t *testing.T) {
+	const (
+		goroutines = 50
+		iterations = 200
+	)
+
+	stats := &ConversionStats{StartTime: time.Now()}
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < iterations; j++ {
+				stats.TotalProcessed.Add(1)
+				stats.DownloadFailed.Add(1)
+				stats.ConvertFailed.Add(1)
+				stats.WebPSuccess.Add(1)
+				stats.WebPFailed.Add(1)
+				stats.AVIFSuccess.Add(1)
+				stats.AVIFFailed.Add(1)
+				stats.JXLSuccess.Add(1)
+				stats.JXLFailed.Add(1)
+				stats.OptimizeSuccess.Add(1)
+				stats.OptimizeFailed.Add(1)
+				stats.Quarantined.Add(1)
+				stats.SkippedTooSmall.Add(1)
+				stats.SkippedUnsupported.Add(1)
+				stats.SkippedUnchanged.Add(1)
+				stats.SkippedMissing.Add(1)
+				stats.SkippedTooLarge.Add(1)
+				stats.UploadedFiles.Add(1)
+				stats.SkippedUploads.Add(1)
+				stats.RecordBytes(100, 40)
+
+				// 集計中の読み取りも競合しないこと
+				_ = stats.SuccessRate()
+				_ = stats.BytesSaved()
+			}
+		}()
+	}
+	wg.Wait()
+
+	want := goroutines * iterations
+	counts := map[string]int{
+		"TotalProcessed":     stats.TotalProcessedCount(),
+		"DownloadFailed":     stats.DownloadFailedCount(),
+		"ConvertFailed":      stats.ConvertFailedCount(),
+		"WebPSuccess":        stats.WebPSuccessCount(),
+		"WebPFailed":         stats.WebPFailedCount(),
+		"AVIFSuccess":        stats.AVIFSuccessCount(),
+		"AVIFFailed":         stats.AVIFFailedCount(),
+		"JXLSuccess":         stats.JXLSuccessCount(),
+		"JXLFailed":          stats.JXLFailedCount(),
+		"OptimizeSuccess":    stats.OptimizeSuccessCount(),
+		"OptimizeFailed":     stats.OptimizeFailedCount(),
+		"Quarantined":        stats.QuarantinedCount(),
+		"SkippedTooSmall":    stats.SkippedTooSmallCount(),
+		"SkippedUnsupported": stats.SkippedUnsupportedCount(),
+		"SkippedUnchanged":   stats.SkippedUnchangedCount(),
+		"SkippedMissing":     stats.SkippedMissingCount(),
+		"SkippedTooLarge":    stats.SkippedTooLargeCount(),
+		"UploadedFiles":      stats.UploadedFilesCount(),
+		"SkippedUploads":     stats.SkippedUploadsCount(),
+	}
+	for name, got := range counts {
+		if got != want {
+			t.Errorf("%s = %d, want %d", name, got, want)
+		}
+	}
+	if got := stats.InputByteCount(); got != int64(want)*100 {
+		t.Errorf("InputBytes = %d, want %d", got, int64(want)*100)
+	}
+	if got := stats.OutputByteCount(); got != int64(want)*40 {
+		t.Errorf("OutputBytes = %d, want %d", got, int64(want)*40)
+	}
+	if got := stats.BytesSaved(); got != int64(want)*60 {
+		t.Errorf("BytesSaved = %d, want %d", got, int64(want)*60)
+	}
+}
+
+// TestConversionStatsMerge はワーカーごとの統計情報を同時にまとめても合計が正確になることを確認します
+func TestConversionStatsMerge(t *testing.T) {
+	const goroutines = 50
+
+	total := &ConversionStats{}
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			worker := &ConversionStats{}
+			worker.TotalProcessed.Add(2)
+			worker.WebPSuccess.Add(1)
+			worker.ConvertFailed.Add(1)
+			worker.RecordBytes(10, 3)
+			total.Merge(worker)
+		}()
+	}
+	wg.Wait()
+
+	if got := total.TotalProcessedCount(); got != goroutines*2 {
+		t.Errorf("TotalProcessed = %d, want %d", got, goroutines*2)
+	}
+	if got := total.WebPSuccessCount(); got != goroutines {
+		t.Errorf("WebPSuccess = %d, want %d", got, goroutines)
+	}
+	if got := total.ConvertFailedCount(); got != goroutines {
+		t.Errorf("ConvertFailed = %d, want %d", got, goroutines)
+	}
+	if got := total.InputByteCount(); got != goroutines*10 {
+		t.Errorf("InputBytes = %d, want %d", got, goroutines*10)
+	}
+	if got := total.OutputByteCount(); got != goroutines*3 {
+		t.Errorf("OutputBytes = %d, want %d", got, goroutines*3)
+	}
+
+	// 自分自身や nil をまとめても値は変わらない
+	total.Merge(total)
+	total.Merge(nil)
+	if got := total.TotalProcessedCount(); got != goroutines*2 {
+		t.Errorf("自身を Merge した後の TotalProcessed = %d, want %d", got, goroutines*2)
+	}
+}
+
+// TestConversionStatsMarshalJSON はJSON出力のキーと値が atomic 化の前と変わらないことを確認します
+func TestConversionStatsMarshalJSON(t *testing.T) {
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	stats := &ConversionStats{StartTime: start}
+	stats.TotalProcessed.Add(3)
+	stats.WebPSuccess.Add(2)
+	stats.SkippedTooLarge.Add(1)
+	stats.RecordBytes(1000, 400)
+
+	data, err := json.Marshal(stats)
+	if err != nil {
+		t.Fatalf("json.Marshal に失敗しました: %v", err)
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("json.Unmarshal に失敗しました: %v", err)
+	}
+
+	want := map[string]float64{
+		"total_processed":   3,
+		"webp_success":      2,
+		"skipped_too_large": 1,
+		"input_bytes":       1000,
+		"output_bytes":      400,
+		"avif_failed":       0,
+		"uploaded_files":    0,
+	}
+	for key, value := range want {
+		if got[key] != value {
+			t.Errorf("%s = %v, want %v", key, got[key], value)
+		}
+	}
+	if got["start_time"] != start.Format(time.RFC3339) {
+		t.Errorf("start_time = %v, want %s", got["start_time"], start.Format(time.RFC3339))
+	}
+}
+
+// newStats は指定した件数を設定した統計情報を返します
+func newStats(total, convertFailed, webpSuccess, webpFailed, avifSuccess, avifFailed int64) *ConversionStats {
+	stats := &ConversionStats{StartTime: time.Now()}
+	stats.TotalProcessed.Store(total)
+	stats.ConvertFailed.Store(convertFailed)
+	stats.WebPSuccess.Store(webpSuccess)
+	stats.WebPFailed.Store(webpFailed)
+	stats.AVIFSuccess.Store(avifSuccess)
+	stats.AVIFFailed.Store(avifFailed)
+	return stats
+}
+
+func TestConversionStatsSuccessRates(t *testing.T) {
+	tests := []struct {
+		name     string
+		stats    *ConversionStats
+		wantAll  float64
+		wantWebP float64
+		wantAVIF float64
+	}{
+		{name: "処理なし", stats: newStats(0, 0, 0, 0, 0, 0), wantAll: 0, wantWebP: 0, wantAVIF: 0},
+		{name: "すべて成功", stats: newStats(4, 0, 4, 0, 4, 0), wantAll: 1, wantWebP: 1, wantAVIF: 1},
+		{name: "すべて失敗", stats: newStats(4, 4, 0, 4, 0, 4), wantAll: 0, wantWebP: 0, wantAVIF: 0},
+		{name: "一部失敗", stats: newStats(4, 1, 3, 1, 1, 3), wantAll: 0.75, wantWebP: 0.75, wantAVIF: 0.25},
+		{name: "AVIFは未試行", stats: newStats(2, 0, 2, 0, 0, 0), wantAll: 1, wantWebP: 1, wantAVIF: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.stats.SuccessRate(); got != tt.wantAll {
+				t.Errorf("SuccessRate = %v, want %v", got, tt.wantAll)
+			}
+			if got := tt.stats.WebPSuccessRate(); got != tt.wantWebP {
+				t.Errorf("WebPSuccessRate = %v, want %v", got, tt.wantWebP)
+			}
+			if got := tt.stats.AVIFSuccessRate(); got != tt.wantAVIF {
+				t.Errorf("AVIFSuccessRate = %v, want %v", got, tt.wantAVIF)
+			}
+		})
+	}
+}
+
+func TestConversionStatsThroughput(t *testing.T) {
+	stats := newStats(20, 0, 0, 0, 0, 0)
+	stats.StartTime = time.Now().Add(-10 * time.Second)
+	if got := stats.Throughput(); got < 1.9 || got > 2.0 {
+		t.Errorf("Throughput = %v, want 約2.0", got)
+	}
+
+	// 開始時刻が未来（経過時間が0以下）の場合は0
+	stats.StartTime = time.Now().Add(time.Hour)
+	if got := stats.Throughput(); got != 0 {
+		t.Errorf("経過時間が0以下の Throughput = %v, want 0", got)
+	}
+
+	if got := newStats(0, 0, 0, 0, 0, 0).Throughput(); got != 0 {
+		t.Errorf("処理なしの Throughput = %v, want 0", got)
+	}
+}
+
+// statsCounters は統計情報のすべてのカウンタ（atomic.Int64 のフィールド）をフィールド名とともに返します
+func statsCounters(s *ConversionStats) map[string]*atomic.Int64 {
+	counters := make(map[string]*atomic.Int64)
+	v := reflect.ValueOf(s).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		if counter, ok := v.Field(i).Addr().Interface().(*atomic.Int64); ok {
+			counters[v.Type().Field(i).Name] = counter
+		}
+	}
+	return counters
+}
+
+// TestConversionStatsMergeNonZero は値を持つ2つの統計情報をまとめると、すべてのカウンタが加算されることを確認します
+func TestConversionStatsMergeNonZero(t *testing.T) {
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	a := &ConversionStats{StartTime: start}
+	b := &ConversionStats{StartTime: start.Add(time.Hour)}
+
+	counters := statsCounters(a)
+	if len(counters) < 20 {
+		t.Fatalf("カウンタの数 = %d, 20以上を期待しました", len(counters))
+	}
+	bCounters := statsCounters(b)
+	i := int64(1)
+	for name := range counters {
+		counters[name].Store(i)
+		bCounters[name].Store(i * 10)
+		i++
+	}
+
+	want := make(map[string]int64, len(counters))
+	wantOther := make(map[string]int64, len(counters))
+	for name, counter := range counters {
+		want[name] = counter.Load() + bCounters[name].Load()
+		wantOther[name] = bCounters[name].Load()
+	}
+
+	a.Merge(b)
+
+	for name, counter := range counters {
+		if got := counter.Load(); got != want[name] {
+			t.Errorf("%s = %d, want %d", name, got, want[name])
+		}
+	}
+	if !a.StartTime.Equal(start) {
+		t.Errorf("StartTime = %v, want %v", a.StartTime, start)
+	}
+	// まとめる側の値は変わらない
+	for name, counter := range bCounters {
+		if got := counter.Load(); got != wantOther[name] {
+			t.Errorf("other の %s = %d, want %d", name, got, wantOther[name])
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DefaultFilenameTemplate は出力ファイル名テンプレートのデフォルト値です（元のファイル名 + 出力形式の拡張子）
+const DefaultFilenameTemplate = "{name}.{format}"
+
+// FilenamePlaceholders は出力ファイル名テンプレートで使用できるプレースホルダーです
+var FilenamePlaceholders = []string{"{name}", "{ext}", "{width}", "{height}", "{quality}", "{format}"}
+
+// placeholderPattern はテンプレート内のプレースホルダーを検出する正規表現です
+var placeholderPattern = regexp.MustCompile(`\{[^{}]*\}`)
+
+// ValidateFilenameTemplate は出力ファイル名テンプレートを検証します
+// 未対応のプレースホルダーを含む場合や、形式ごとに異なる名前にするための {format} を含まない場合はエラーを返します
+func ValidateFilenameTemplate(template string) error {
+	if template == "" {
+		return fmt.Errorf("テンプレートが空です")
+	}
+
+	for _, placeholder := range placeholderPattern.FindAllString(template, -1) {
+		if !isFilenamePlaceholder(placeholder) {
+			return fmt.Errorf("未対応のプレースホルダー %s が含まれています（使用可能: %s）",
+				placeholder, strings.Join(FilenamePlaceholders, ", "))
+		}
+	}
+
+	if !strings.Contains(template, "{format}") {
+		return fmt.Errorf("出力形式ごとに異なるファイル名にするため {format} を含める必要があります")
+	}
+
+	return nil
+}
+
+// isFilenamePlaceholder は対応しているプレースホルダーかどうかを判定します
+func isFilenamePlaceholder(placeholder string) bool {
+	for _, p := range FilenamePlaceholders {
+		if p == placeholder {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,310 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/223n/image-converter/pkg/imageutils"
+)
+
+// FieldError は設定項目ごとの検証エラーを表します
+type FieldError struct {
+	Field   string      // 項目のパス（例: conversion.avif.quality）
+	Value   interface{} // 設定されている値
+	Message string      // エラーの内容
+}
+
+// Error は項目のパスを含むエラーメッセージを返します
+func (e FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// ValidationError は厳密な検証で見つかったすべてのエラーを保持します
+type ValidationError struct {
+	Errors []FieldError
+}
+
+// Error はすべての項目エラーを改行区切りで返します
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, fieldErr := range e.Errors {
+		messages[i] = fieldErr.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+// add は項目エラーを追加します
+func (e *ValidationError) add(field string, value interface{}, format string, args ...interface{}) {
+	e.Errors = append(e.Errors, FieldError{
+		Field:   field,
+		Value:   value,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// checkRange は整数値が範囲内にあるかどうかを検証します
+func (e *ValidationError) checkRange(field string, value, minValue, maxValue int) {
+	if value < minValue {
+		e.add(field, value, "値 %d は最小値 %d を下回っています", value, minValue)
+	} else if value > maxValue {
+		e.add(field, value, "値 %d は最大値 %d を超えています", value, maxValue)
+	}
+}
+
+// checkFloatRange は小数値が範囲内にあるかどうかを検証します
+func (e *ValidationError) checkFloatRange(field string, value, minValue, maxValue float64) {
+	if value < minValue {
+		e.add(field, value, "値 %g は最小値 %g を下回っています", value, minValue)
+	} else if value > maxValue {
+		e.add(field, value, "値 %g は最大値 %g を超えています", value, maxValue)
+	}
+}
+
+// ValidateStrict は設定値を調整せずに検証し、範囲外の項目をすべて返します
+// validateConfig が自動調整する項目と同じ規則で検証します。エラーがない場合は nil を返します
+func ValidateStrict(cfg Config) *ValidationError {
+	verr := &ValidationError{}
+
+	// ワーカー数（0は自動）
+	if cfg.Conversion.Workers < 0 {
+		verr.add("conversion.workers", cfg.Conversion.Workers, "値 %d は最小値 0 を下回っています", cfg.Conversion.Workers)
+	}
+	if cfg.Conversion.MaxDecodeConcurrency < 0 {
+		verr.add("conversion.max_decode_concurrency", cfg.Conversion.MaxDecodeConcurrency, "値 %d は最小値 0 を下回っています", cfg.Conversion.MaxDecodeConcurrency)
+	}
+	if cfg.Conversion.ExternalThreads < 0 {
+		verr.add("conversion.external_threads", cfg.Conversion.ExternalThreads, "値 %d は最小値 0 を下回っています", cfg.Conversion.ExternalThreads)
+	}
+
+	// 実行時間の上限（0は無制限）
+	if cfg.Mode.MaxRuntimeSeconds < 0 {
+		verr.add("mode.max_runtime_seconds", cfg.Mode.MaxRuntimeSeconds, "値 %d は最小値 0 を下回っています", cfg.Mode.MaxRuntimeSeconds)
+	}
+
+	// 入力設定
+	if cfg.Input.MinWidth < 0 {
+		verr.add("input.min_width", cfg.Input.MinWidth, "値 %d は最小値 0 を下回っています", cfg.Input.MinWidth)
+	}
+	if cfg.Input.MinHeight < 0 {
+		verr.add("input.min_height", cfg.Input.MinHeight, "値 %d は最小値 0 を下回っています", cfg.Input.MinHeight)
+	}
+	if cfg.Input.MaxDepth < 0 {
+		verr.add("input.max_depth", cfg.Input.MaxDepth, "値 %d は最小値 0 を下回っています", cfg.Input.MaxDepth)
+	}
+	switch cfg.Input.OnUnsupported {
+	case OnUnsupportedSkip, OnUnsupportedError:
+	default:
+		verr.add("input.on_unsupported", cfg.Input.OnUnsupported, "値 %q は skip, error のいずれでもありません", cfg.Input.OnUnsupported)
+	}
+	if cfg.Input.URLTimeout < 1 {
+		verr.add("input.url_timeout", cfg.Input.URLTimeout, "値 %d は最小値 1 を下回っています", cfg.Input.URLTimeout)
+	}
+	verr.checkRange("input.svg.width", cfg.Input.SVG.Width, 1, 16384)
+	verr.checkRange("input.svg.height", cfg.Input.SVG.Height, 1, 16384)
+
+	// 形式ごとの画質・速度
+	verr.checkRange("conversion.webp.quality", cfg.Conversion.WebP.Quality, 0, 100)
+	verr.checkRange("conversion.webp.compression_level", cfg.Conversion.WebP.CompressionLevel, 0, 6)
+	switch cfg.Conversion.AVIF.QualityScale {
+	case AVIFQualityScalePercent:
+		verr.checkRange("conversion.avif.quality", cfg.Conversion.AVIF.Quality, 0, 100)
+	case AVIFQualityScaleNative:
+		if q := cfg.Conversion.AVIF.Quality; q > 63 && q <= 100 {
+			verr.add("conversion.avif.quality", q, "値 %d は最大値 63 を超えています（0〜100で指定する場合は quality_scale: \"0-100\" を設定してください）", q)
+		} else {
+			verr.checkRange("conversion.avif.quality", q, 1, 63)
+		}
+	default:
+		verr.add("conversion.avif.quality_scale", cfg.Conversion.AVIF.QualityScale,
+			"値 %q は native, 0-100 のいずれでもありません", cfg.Conversion.AVIF.QualityScale)
+	}
+	switch cfg.Conversion.AVIF.ChromaSubsampling {
+	case AVIFChroma420, AVIFChroma422, AVIFChroma444:
+	default:
+		verr.add("conversion.avif.chroma_subsampling", cfg.Conversion.AVIF.ChromaSubsampling,
+			"値 %q は 420, 422, 444 のいずれでもありません", cfg.Conversion.AVIF.ChromaSubsampling)
+	}
+	switch cfg.Conversion.AVIF.BitDepth {
+	case 8, 10, 12:
+	default:
+		verr.add("conversion.avif.bit_depth", cfg.Conversion.AVIF.BitDepth, "値 %d は 8, 10, 12 のいずれでもありません", cfg.Conversion.AVIF.BitDepth)
+	}
+	verr.checkRange("conversion.avif.speed", cfg.Conversion.AVIF.Speed, 0, AVIFMaxSpeed)
+	verr.checkRange("conversion.jxl.quality", cfg.Conversion.JXL.Quality, 1, 100)
+	verr.checkRange("conversion.jxl.effort", cfg.Conversion.JXL.Effort, 1, 9)
+	verr.checkRange("conversion.optimize.jpeg_quality", cfg.Conversion.Optimize.JPEGQuality, 1, 100)
+
+	switch strings.ToLower(cfg.Conversion.Optimize.PNGCompressionLevel) {
+	case "default", "none", "speed", "best":
+	default:
+		verr.add("conversion.optimize.png_compression_level", cfg.Conversion.Optimize.PNGCompressionLevel,
+			"値 %q は default, none, speed, best のいずれでもありません", cfg.Conversion.Optimize.PNGCompressionLevel)
+	}
+
+	// 透かし設定
+	if !imageutils.IsValidWatermarkPosition(cfg.Conversion.Watermark.Position) {
+		verr.add("conversion.watermark.position", cfg.Conversion.Watermark.Position,
+			"値 %q は有効な配置位置ではありません", cfg.Conversion.Watermark.Position)
+	}
+	verr.checkFloatRange("conversion.watermark.opacity", cfg.Conversion.Watermark.Opacity, 0, 1)
+	if cfg.Conversion.Watermark.Enabled && cfg.Conversion.Watermark.ImagePath == "" {
+		verr.add("conversion.watermark.image_path", cfg.Conversion.Watermark.ImagePath, "透かしが有効ですが画像のパスが指定されていません")
+	}
+
+	// SSIM基準値
+	verr.checkFloatRange("conversion.min_ssim", cfg.Conversion.MinSSIM, 0, 1)
+
+	// 出力サイズの比率の上限
+	if cfg.Conversion.MaxOutputRatio < 0 {
+		verr.add("conversion.max_output_ratio", cfg.Conversion.MaxOutputRatio, "0以上である必要があります（0は制限なし）")
+	}
+
+	// デコードする最大画素数
+	if cfg.Conversion.MaxDecodePixels < 0 {
+		verr.add("conversion.max_decode_pixels", cfg.Conversion.MaxDecodePixels, "0以上である必要があります（0は制限なし）")
+	}
+
+	// リサイズの最大幅・最大高さ
+	if cfg.Conversion.Resize.MaxWidth < 0 {
+		verr.add("conversion.resize.max_width", cfg.Conversion.Resize.MaxWidth, "0以上である必要があります（0は制限なし）")
+	}
+	if cfg.Conversion.Resize.MaxHeight < 0 {
+		verr.add("conversion.resize.max_height", cfg.Conversion.Resize.MaxHeight, "0以上である必要があります（0は制限なし）")
+	}
+
+	// 削除対象EXIFタグ名
+	for _, tag := range cfg.Conversion.StripEXIFTags {
+		if !imageutils.IsKnownEXIFTag(tag) {
+			verr.add("conversion.strip_exif_tags", tag, "未対応のタグ名 %q です", tag)
+		}
+	}
+
+	// ログの出力形式
+	switch cfg.Logging.Format {
+	case LogFormatText, LogFormatJSON:
+	default:
+		verr.add("logging.format", cfg.Logging.Format, "値 %q は text, json のいずれでもありません", cfg.Logging.Format)
+	}
+
+	// 通知設定
+	if cfg.Notifications.Timeout <= 0 {
+		verr.add("notifications.timeout", cfg.Notifications.Timeout, "値 %d は最小値 1 を下回っています", cfg.Notifications.Timeout)
+	}
+	switch cfg.Notifications.Format {
+	case NotificationFormatJSON, NotificationFormatSlack:
+	default:
+		verr.add("notifications.format", cfg.Notifications.Format, "値 %q は json, slack のいずれでもありません", cfg.Notifications.Format)
+	}
+	if cfg.Notifications.MaxRetries < 0 {
+		verr.add("notifications.max_retries", cfg.Notifications.MaxRetries, "値 %d は最小値 0 を下回っています", cfg.Notifications.MaxRetries)
+	}
+
+	// フック設定
+	if cfg.Hooks.Timeout <= 0 {
+		verr.add("hooks.timeout", cfg.Hooks.Timeout, "値 %d は最小値 1 を下回っています", cfg.Hooks.Timeout)
+	}
+
+	// 出力ファイル名テンプレート
+	if err := ValidateFilenameTemplate(cfg.Output.FilenameTemplate); err != nil {
+		verr.add("output.filename_template", cfg.Output.FilenameTemplate, "%v", err)
+	}
+
+	// レポート設定
+	if cfg.Reporting.TopSlowCount < 0 {
+		verr.add("reporting.top_slow_count", cfg.Reporting.TopSlowCount, "値 %d は最小値 0 を下回っています", cfg.Reporting.TopSlowCount)
+	}
+	if _, err := ParseProgressStream(cfg.Reporting.ProgressStream); err != nil {
+		verr.add("reporting.progress_stream", cfg.Reporting.ProgressStream, "%v", err)
+	}
+
+	// SSH証明書は秘密鍵と組み合わせて使用する
+	if cfg.Remote.CertPath != "" && cfg.Remote.KeyPath == "" {
+		verr.add("remote.cert_path", cfg.Remote.CertPath, "証明書を使用するには remote.key_path も指定してください")
+	}
+
+	// 差分同期
+	if cfg.Remote.DeltaSync.Enabled && cfg.Remote.DeltaSync.StateFile == "" {
+		verr.add("remote.delta_sync.state_file", cfg.Remote.DeltaSync.StateFile, "差分同期が有効ですが状態ファイルのパスが指定されていません")
+	}
+
+	// バッチ処理
+	if cfg.Remote.BatchSize < 1 {
+		verr.add("remote.batch_size", cfg.Remote.BatchSize, "値 %d は最小値 1 を下回っています", cfg.Remote.BatchSize)
+	}
+	if cfg.Remote.BatchPauseSeconds < 0 {
+		verr.add("remote.batch_pause_seconds", cfg.Remote.BatchPauseSeconds, "値 %d は最小値 0 を下回っています", cfg.Remote.BatchPauseSeconds)
+	}
+
+	// リモートの同時転送数
+	if cfg.Remote.ConcurrentTransfers < 1 {
+		verr.add("remote.concurrent_transfers", cfg.Remote.ConcurrentTransfers, "値 %d は最小値 1 を下回っています", cfg.Remote.ConcurrentTransfers)
+	}
+
+	// SFTPの転送設定
+	verr.checkRange("remote.sftp_max_packet", cfg.Remote.SFTPMaxPacketBytes, 1024, 262144)
+	verr.checkRange("remote.sftp_concurrent_requests", cfg.Remote.SFTPConcurrentRequests, 1, 1024)
+
+	// 帯域制限
+	if cfg.Remote.MaxBandwidthKBps < 0 {
+		verr.add("remote.max_bandwidth_kbps", cfg.Remote.MaxBandwidthKBps, "値 %d は最小値 0 を下回っています", cfg.Remote.MaxBandwidthKBps)
+	}
+
+	// キープアライブ間隔
+	if cfg.Remote.KeepAliveIntervalSeconds < 0 {
+		verr.add("remote.keepalive_interval", cfg.Remote.KeepAliveIntervalSeconds, "値 %d は最小値 0 を下回っています", cfg.Remote.KeepAliveIntervalSeconds)
+	}
+
+	// リモートファイルの一覧取得方法
+	switch cfg.Remote.ListMethod {
+	case RemoteListMethodFind, RemoteListMethodSFTP:
+	default:
+		verr.add("remote.list_method", cfg.Remote.ListMethod, "値 %q は find, sftp のいずれでもありません", cfg.Remote.ListMethod)
+	}
+
+	// リモートタイムアウト
+	if cfg.Remote.Enabled && cfg.Remote.Timeout < 60 {
+		verr.add("remote.timeout", cfg.Remote.Timeout, "値 %d は最小値 60 を下回っています", cfg.Remote.Timeout)
+	}
+
+	if len(verr.Errors) == 0 {
+		return nil
+	}
+	return verr
+}
+
+// LoadRawConfig は設定ファイルをデフォルト値の上に読み込み、調整を行わずに返します
+// 環境変数による上書きは適用します。グローバル設定は変更しません。ValidateStrict で元の値を検証する場合に使用します
+func LoadRawConfig(configPath string) (Config, error) {
+	cfg := DefaultConfig()
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return cfg, fmt.Errorf("設定ファイルの読み込みに失敗しました: %v", err)
+	}
+
+	if err := unmarshalWithExtends(data, configPath, &cfg); err != nil {
+		return cfg, err
+	}
+
+	applyEnvOverrides(&cfg)
+	return cfg, nil
+}
+
+// progressStreamFDPrefix は進捗の出力先をファイルディスクリプタ番号で指定する場合の接頭辞です（例: fd:3）
+const progressStreamFDPrefix = "fd:"
+
+// ParseProgressStream は reporting.progress_stream の指定を検証し、ファイルディスクリプタ番号での指定の場合はその番号を返します
+// ファイルのパスで指定した場合や空の場合は -1 を返します
+func ParseProgressStream(target string) (int, error) {
+	if !strings.HasPrefix(target, progressStreamFDPrefix) {
+		return -1, nil
+	}
+
+	fd, err := strconv.Atoi(strings.TrimPrefix(target, progressStreamFDPrefix))
+	if err != nil || fd < 0 {
+		return -1, fmt.Errorf("ファイルディスクリプタの指定が正しくありません: %s（例: fd:3）", target)
+	}
+	return fd, nil
+}
@@ -0,0 +1,166 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestValidateStrictDefaults はデフォルト設定が厳密な検証を通ることを確認します
+func TestValidateStrictDefaults(t *testing.T) {
+	if verr := ValidateStrict(DefaultConfig()); verr != nil {
+		t.Errorf("デフォルト設定の検証エラー:\n%v", verr)
+	}
+}
+
+// TestValidateStrictRanges は範囲が決まっているすべての項目について、境界の値を受け付け、範囲外の値を項目のパスと値つきで報告することを確認します
+func TestValidateStrictRanges(t *testing.T) {
+	tests := []struct {
+		field string
+		value interface{}
+		set   func(cfg *Config)
+	}{
+		// 0以上の項目
+		{field: "conversion.workers", value: -1, set: func(c *Config) { c.Conversion.Workers = -1 }},
+		{field: "conversion.max_decode_concurrency", value: -1, set: func(c *Config) { c.Conversion.MaxDecodeConcurrency = -1 }},
+		{field: "conversion.external_threads", value: -1, set: func(c *Config) { c.Conversion.ExternalThreads = -1 }},
+		{field: "mode.max_runtime_seconds", value: -1, set: func(c *Config) { c.Mode.MaxRuntimeSeconds = -1 }},
+		{field: "input.min_width", value: -1, set: func(c *Config) { c.Input.MinWidth = -1 }},
+		{field: "input.min_height", value: -1, set: func(c *Config) { c.Input.MinHeight = -1 }},
+		{field: "input.max_depth", value: -1, set: func(c *Config) { c.Input.MaxDepth = -1 }},
+		{field: "conversion.max_output_ratio", value: -0.5, set: func(c *Config) { c.Conversion.MaxOutputRatio = -0.5 }},
+		{field: "conversion.max_decode_pixels", value: int64(-1), set: func(c *Config) { c.Conversion.MaxDecodePixels = -1 }},
+		{field: "conversion.resize.max_width", value: -1, set: func(c *Config) { c.Conversion.Resize.MaxWidth = -1 }},
+		{field: "conversion.resize.max_height", value: -1, set: func(c *Config) { c.Conversion.Resize.MaxHeight = -1 }},
+		{field: "notifications.max_retries", value: -1, set: func(c *Config) { c.Notifications.MaxRetries = -1 }},
+		{field: "reporting.top_slow_count", value: -1, set: func(c *Config) { c.Reporting.TopSlowCount = -1 }},
+		{field: "remote.batch_pause_seconds", value: -1, set: func(c *Config) { c.Remote.BatchPauseSeconds = -1 }},
+		{field: "remote.max_bandwidth_kbps", value: -1, set: func(c *Config) { c.Remote.MaxBandwidthKBps = -1 }},
+		{field: "remote.keepalive_interval", value: -1, set: func(c *Config) { c.Remote.KeepAliveIntervalSeconds = -1 }},
+
+		// 1以上の項目
+		{field: "input.url_timeout", value: 0, set: func(c *Config) { c.Input.URLTimeout = 0 }},
+		{field: "notifications.timeout", value: 0, set: func(c *Config) { c.Notifications.Timeout = 0 }},
+		{field: "hooks.timeout", value: 0, set: func(c *Config) { c.Hooks.Timeout = 0 }},
+		{field: "remote.batch_size", value: 0, set: func(c *Config) { c.Remote.BatchSize = 0 }},
+		{field: "remote.concurrent_transfers", value: 0, set: func(c *Config) { c.Remote.ConcurrentTransfers = 0 }},
+		{field: "remote.timeout", value: 59, set: func(c *Config) { c.Remote.Enabled, c.Remote.Timeout = true, 59 }},
+
+		// 上限と下限がある項目
+		{field: "input.svg.width", value: 0, set: func(c *Config) { c.Input.SVG.Width = 0 }},
+		{field: "input.svg.width", value: 16385, set: func(c *Config) { c.Input.SVG.Width = 16385 }},
+		{field: "input.svg.height", value: 0, set: func(c *Config) { c.Input.SVG.Height = 0 }},
+		{field: "input.svg.height", value: 16385, set: func(c *Config) { c.Input.SVG.Height = 16385 }},
+		{field: "conversion.webp.quality", value: -1, set: func(c *Config) { c.Conversion.WebP.Quality = -1 }},
+		{field: "conversion.webp.quality", value: 101, set: func(c *Config) { c.Conversion.WebP.Quality = 101 }},
+		{field: "conversion.webp.compression_level", value: -1, set: func(c *Config) { c.Conversion.WebP.CompressionLevel = -1 }},
+		{field: "conversion.webp.compression_level", value: 7, set: func(c *Config) { c.Conversion.WebP.CompressionLevel = 7 }},
+		{field: "conversion.avif.quality", value: 0, set: func(c *Config) { c.Conversion.AVIF.QualityScale, c.Conversion.AVIF.Quality = AVIFQualityScaleNative, 0 }},
+		{field: "conversion.avif.quality", value: 64, set: func(c *Config) {
+			c.Conversion.AVIF.QualityScale, c.Conversion.AVIF.Quality = AVIFQualityScaleNative, 64
+		}},
+		{field: "conversion.avif.quality", value: 200, set: func(c *Config) {
+			c.Conversion.AVIF.QualityScale, c.Conversion.AVIF.Quality = AVIFQualityScaleNative, 200
+		}},
+		{field: "conversion.avif.quality", value: -1, set: func(c *Config) {
+			c.Conversion.AVIF.QualityScale, c.Conversion.AVIF.Quality = AVIFQualityScalePercent, -1
+		}},
+		{field: "conversion.avif.quality", value: 101, set: func(c *Config) {
+			c.Conversion.AVIF.QualityScale, c.Conversion.AVIF.Quality = AVIFQualityScalePercent, 101
+		}},
+		{field: "conversion.avif.speed", value: -1, set: func(c *Config) { c.Conversion.AVIF.Speed = -1 }},
+		{field: "conversion.avif.speed", value: AVIFMaxSpeed + 1, set: func(c *Config) { c.Conversion.AVIF.Speed = AVIFMaxSpeed + 1 }},
+		{field: "conversion.jxl.quality", value: 0, set: func(c *Config) { c.Conversion.JXL.Quality = 0 }},
+		{field: "conversion.jxl.quality", value: 101, set: func(c *Config) { c.Conversion.JXL.Quality = 101 }},
+		{field: "conversion.jxl.effort", value: 0, set: func(c *Config) { c.Conversion.JXL.Effort = 0 }},
+		{field: "conversion.jxl.effort", value: 10, set: func(c *Config) { c.Conversion.JXL.Effort = 10 }},
+		{field: "conversion.optimize.jpeg_quality", value: 0, set: func(c *Config) { c.Conversion.Optimize.JPEGQuality = 0 }},
+		{field: "conversion.optimize.jpeg_quality", value: 101, set: func(c *Config) { c.Conversion.Optimize.JPEGQuality = 101 }},
+		{field: "conversion.watermark.opacity", value: -0.1, set: func(c *Config) { c.Conversion.Watermark.Opacity = -0.1 }},
+		{field: "conversion.watermark.opacity", value: 1.5, set: func(c *Config) { c.Conversion.Watermark.Opacity = 1.5 }},
+		{field: "conversion.min_ssim", value: -0.1, set: func(c *Config) { c.Conversion.MinSSIM = -0.1 }},
+		{field: "conversion.min_ssim", value: 1.1, set: func(c *Config) { c.Conversion.MinSSIM = 1.1 }},
+		{field: "remote.sftp_max_packet", value: 1023, set: func(c *Config) { c.Remote.SFTPMaxPacketBytes = 1023 }},
+		{field: "remote.sftp_max_packet", value: 262145, set: func(c *Config) { c.Remote.SFTPMaxPacketBytes = 262145 }},
+		{field: "remote.sftp_concurrent_requests", value: 0, set: func(c *Config) { c.Remote.SFTPConcurrentRequests = 0 }},
+		{field: "remote.sftp_concurrent_requests", value: 1025, set: func(c *Config) { c.Remote.SFTPConcurrentRequests = 1025 }},
+
+		// 値が列挙されている項目
+		{field: "input.on_unsupported", value: "ignore", set: func(c *Config) { c.Input.OnUnsupported = "ignore" }},
+		{field: "conversion.avif.quality_scale", value: "1-10", set: func(c *Config) { c.Conversion.AVIF.QualityScale = "1-10" }},
+		{field: "conversion.avif.chroma_subsampling", value: "411", set: func(c *Config) { c.Conversion.AVIF.ChromaSubsampling = "411" }},
+		{field: "conversion.avif.bit_depth", value: 16, set: func(c *Config) { c.Conversion.AVIF.BitDepth = 16 }},
+		{field: "conversion.optimize.png_compression_level", value: "max", set: func(c *Config) { c.Conversion.Optimize.PNGCompressionLevel = "max" }},
+		{field: "conversion.watermark.position", value: "middle", set: func(c *Config) { c.Conversion.Watermark.Position = "middle" }},
+		{field: "logging.format", value: "xml", set: func(c *Config) { c.Logging.Format = "xml" }},
+		{field: "notifications.format", value: "teams", set: func(c *Config) { c.Notifications.Format = "teams" }},
+		{field: "remote.list_method", value: "ls", set: func(c *Config) { c.Remote.ListMethod = "ls" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			cfg := DefaultConfig()
+			tt.set(&cfg)
+
+			verr := ValidateStrict(cfg)
+			if verr == nil {
+				t.Fatalf("値 %v でエラーになりませんでした", tt.value)
+			}
+			if len(verr.Errors) != 1 {
+				t.Fatalf("エラー = %d件, want 1件:\n%v", len(verr.Errors), verr)
+			}
+			got := verr.Errors[0]
+			if got.Field != tt.field || got.Value != tt.value {
+				t.Errorf("エラー = %s (値 %#v), want %s (値 %#v)", got.Field, got.Value, tt.field, tt.value)
+			}
+			if !strings.HasPrefix(got.Error(), tt.field+": ") {
+				t.Errorf("Error() = %q, 項目のパスで始まることを期待しました", got.Error())
+			}
+		})
+	}
+}
+
+// TestValidateStrictBoundaries は範囲の境界の値をエラーにしないことを確認します
+func TestValidateStrictBoundaries(t *testing.T) {
+	for _, set := range []func(c *Config){
+		func(c *Config) { c.Input.SVG.Width, c.Input.SVG.Height = 1, 16384 },
+		func(c *Config) { c.Conversion.WebP.Quality, c.Conversion.WebP.CompressionLevel = 0, 6 },
+		func(c *Config) { c.Conversion.WebP.Quality, c.Conversion.WebP.CompressionLevel = 100, 0 },
+		func(c *Config) { c.Conversion.AVIF.QualityScale, c.Conversion.AVIF.Quality = AVIFQualityScaleNative, 1 },
+		func(c *Config) {
+			c.Conversion.AVIF.QualityScale, c.Conversion.AVIF.Quality = AVIFQualityScaleNative, 63
+		},
+		func(c *Config) {
+			c.Conversion.AVIF.QualityScale, c.Conversion.AVIF.Quality = AVIFQualityScalePercent, 100
+		},
+		func(c *Config) { c.Conversion.AVIF.Speed = 0 },
+		func(c *Config) { c.Conversion.AVIF.Speed = AVIFMaxSpeed },
+		func(c *Config) { c.Conversion.JXL.Quality, c.Conversion.JXL.Effort = 1, 9 },
+		func(c *Config) { c.Conversion.Optimize.JPEGQuality = 100 },
+		func(c *Config) { c.Conversion.Watermark.Opacity, c.Conversion.MinSSIM = 1, 1 },
+		func(c *Config) { c.Remote.SFTPMaxPacketBytes, c.Remote.SFTPConcurrentRequests = 1024, 1024 },
+		func(c *Config) { c.Remote.SFTPMaxPacketBytes, c.Remote.SFTPConcurrentRequests = 262144, 1 },
+		func(c *Config) { c.Remote.Enabled, c.Remote.Timeout = true, 60 },
+	} {
+		cfg := DefaultConfig()
+		set(&cfg)
+		if verr := ValidateStrict(cfg); verr != nil {
+			t.Errorf("境界の値でエラーになりました:\n%v", verr)
+		}
+	}
+}
+
+// TestValidateStrictCollectsAllErrors は複数の項目が範囲外の場合に、すべてのエラーを返すことを確認します
+func TestValidateStrictCollectsAllErrors(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Conversion.WebP.Quality = 200
+	cfg.Conversion.JXL.Effort = 0
+	cfg.Remote.BatchSize = 0
+
+	verr := ValidateStrict(cfg)
+	if verr == nil || len(verr.Errors) != 3 {
+		t.Fatalf("ValidateStrict = %v, want 3件のエラー", verr)
+	}
+	if got := strings.Count(verr.Error(), "\n"); got != 2 {
+		t.Errorf("Error() の行数 = %d, want 3:\n%s", got+1, verr.Error())
+	}
+}
@@ -0,0 +1,28 @@
+/*
+Package converter の一部として、透過（アルファチャンネル）の引き継ぎの確認を提供します。
+*/
+package converter
+
+import (
+	"image"
+
+	"github.com/223n/image-converter/pkg/imageutils"
+)
+
+// checkAlphaPreserved は元画像に透過がある場合に、変換結果で透過が失われていないかを確認します
+// 失われていた場合は警告を出力します。出力をデコードできない場合（avifdecがない場合など）は確認をスキップします
+func (ic *ImageConverter) checkAlphaPreserved(format string, src image.Image, outputPath string) {
+	if !imageutils.HasAlpha(src) {
+		return
+	}
+
+	decoded, err := decodeOutput(format, outputPath)
+	if err != nil {
+		ic.logManager.LogDebug("透過の確認をスキップします [%s]: %v", outputPath, err)
+		return
+	}
+
+	if !imageutils.HasAlpha(decoded) {
+		ic.logManager.LogWarning("元画像の透過が変換後のファイルで失われました: %s", outputPath)
+	}
+}
@@ -0,0 +1,121 @@
+package converter
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/223n/image-converter/pkg/imageutils"
+)
+
+// alphaLostWarning は透過が失われた場合の警告に含まれる文言です
+const alphaLostWarning = "透過が変換後のファイルで失われました"
+
+// transparentImage は左半分が不透明な赤、右半分が完全に透明な画像を返します
+func transparentImage(width, height int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width/2; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{R: 255, A: 255})
+		}
+	}
+	return img
+}
+
+// captureConverterLog はログの出力先をバッファに切り替え、テストの終了時に元に戻します
+func captureConverterLog(t *testing.T) *bytes.Buffer {
+	t.Helper()
+
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+	return &logs
+}
+
+// TestConvertTransparentPNG は透過PNGをWebPに変換しても透過が引き継がれ、警告が出力されないことを確認します
+func TestConvertTransparentPNG(t *testing.T) {
+	inputPath := filepath.Join(t.TempDir(), "transparent.png")
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, transparentImage(32, 16)); err != nil {
+		t.Fatalf("PNGのエンコードに失敗しました: %v", err)
+	}
+	if err := os.WriteFile(inputPath, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("入力ファイルの作成に失敗しました: %v", err)
+	}
+
+	logs := captureConverterLog(t)
+	result, err := newWebPOnlyConverter().Convert(inputPath)
+	if err != nil {
+		t.Fatalf("Convert に失敗しました: %v", err)
+	}
+	if !result.WebPSuccess {
+		t.Fatalf("WebPSuccess = false: %+v", result)
+	}
+
+	decoded, err := decodeOutput("webp", result.WebPPath)
+	if err != nil {
+		t.Fatalf("変換後のWebPをデコードできません: %v", err)
+	}
+	if !imageutils.HasAlpha(decoded) {
+		t.Error("変換後のWebPに透過がありません")
+	}
+	if _, _, _, a := decoded.At(24, 8).RGBA(); a != 0 {
+		t.Errorf("透明な部分のアルファ = %#x, want 0", a)
+	}
+	if _, _, _, a := decoded.At(4, 8).RGBA(); a != 0xffff {
+		t.Errorf("不透明な部分のアルファ = %#x, want 0xffff", a)
+	}
+	if strings.Contains(logs.String(), alphaLostWarning) {
+		t.Errorf("透過が失われた警告が出力されました: %s", logs.String())
+	}
+}
+
+func TestCheckAlphaPreserved(t *testing.T) {
+	transparent := transparentImage(8, 8)
+	opaque := image.NewNRGBA(image.Rect(0, 0, 8, 8))
+	for i := 3; i < len(opaque.Pix); i += 4 {
+		opaque.Pix[i] = 255
+	}
+
+	tests := []struct {
+		name        string
+		src         image.Image
+		output      image.Image // nil の場合はWebPとしてデコードできない出力
+		wantWarning bool
+	}{
+		{name: "透過が引き継がれた", src: transparent, output: transparent, wantWarning: false},
+		{name: "透過が失われた", src: transparent, output: opaque, wantWarning: true},
+		{name: "元画像に透過がない", src: opaque, output: opaque, wantWarning: false},
+		{name: "出力をデコードできない", src: transparent, output: nil, wantWarning: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "output.webp")
+			var data bytes.Buffer
+			if tt.output != nil {
+				if err := EncodeWebP(tt.output, &data, WebPEncodeOptions{Quality: 90, Lossless: true}); err != nil {
+					t.Fatalf("EncodeWebP に失敗しました: %v", err)
+				}
+			} else {
+				data.WriteString("not a webp")
+			}
+			if err := os.WriteFile(outputPath, data.Bytes(), 0644); err != nil {
+				t.Fatalf("出力ファイルの作成に失敗しました: %v", err)
+			}
+
+			logs := captureConverterLog(t)
+			newWebPOnlyConverter().checkAlphaPreserved("webp", tt.src, outputPath)
+
+			if got := strings.Contains(logs.String(), alphaLostWarning); got != tt.wantWarning {
+				t.Errorf("警告の出力 = %v, want %v: %s", got, tt.wantWarning, logs.String())
+			}
+		})
+	}
+}
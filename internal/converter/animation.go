@@ -0,0 +1,124 @@
+/*
+Package converter の一部として、アニメーションGIFの変換に特化した関数を提供します。
+*/
+package converter
+
+import (
+	"fmt"
+	"image"
+	"image/gif"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/223n/image-converter/internal/config"
+)
+
+// defaultAnimationDelay はフレーム間隔を維持しない場合の遅延時間です（1/100秒単位）
+const defaultAnimationDelay = 10
+
+// loadGIFFrames はGIFファイルの全フレームを読み込みます
+func loadGIFFrames(filePath string) (*gif.GIF, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("ファイルを開けません: %v", err)
+	}
+	defer file.Close()
+
+	g, err := gif.DecodeAll(file)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDecodeFailed, err)
+	}
+
+	return g, nil
+}
+
+// SaveAnimatedWebP はフレーム列をアニメーションWebPとして保存します
+// gif2webpコマンドが利用できない場合は先頭フレームのみを静止画WebPとして保存します
+func SaveAnimatedWebP(frames []*image.Paletted, delays []int, outputPath string) error {
+	return saveAnimatedWebP(frames, delays, outputPath, nil)
+}
+
+// saveAnimatedWebP はオプションで画質を上書きしてアニメーションWebPとして保存します
+func saveAnimatedWebP(frames []*image.Paletted, delays []int, outputPath string, opts *EncodeOptions) error {
+	if len(frames) == 0 {
+		return fmt.Errorf("フレームがありません")
+	}
+
+	if _, err := exec.LookPath("gif2webp"); err != nil {
+		log.Printf("警告: gif2webpコマンドが見つからないため、先頭フレームのみを変換します: %s", outputPath)
+		_, err := saveWebPWithOptions(frames[0], outputPath, opts)
+		return err
+	}
+
+	// 一時的にGIFとして保存
+	tempDir, err := os.MkdirTemp("", "animated-webp-conversion-")
+	if err != nil {
+		return fmt.Errorf("一時ディレクトリの作成に失敗しました: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	tempGIFPath := filepath.Join(tempDir, "temp.gif")
+	if err := writeTempGIF(frames, delays, tempGIFPath); err != nil {
+		return err
+	}
+
+	// gif2webpを使ってアニメーションWebPに変換
+	quality, _ := opts.webpSettings()
+	args := []string{"-lossy", "-q", fmt.Sprintf("%d", quality)}
+	if config.GetExternalThreads() > 1 {
+		args = append(args, "-mt")
+	}
+	args = append(args, tempGIFPath, "-o", outputPath)
+	cmd := exec.Command("gif2webp", args...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("gif2webpコマンドの実行に失敗しました: %v\n出力: %s", err, string(output))
+	}
+
+	return nil
+}
+
+// writeTempGIF はフレーム列を一時GIFファイルに書き込みます
+func writeTempGIF(frames []*image.Paletted, delays []int, path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("一時ファイルの作成に失敗しました: %v", err)
+	}
+	defer file.Close()
+
+	// 全フレームを含むキャンバスサイズを求める
+	bounds := frames[0].Bounds()
+	for _, frame := range frames[1:] {
+		bounds = bounds.Union(frame.Bounds())
+	}
+
+	g := &gif.GIF{
+		Image: frames,
+		Delay: delays,
+		Config: image.Config{
+			ColorModel: frames[0].Palette,
+			Width:      bounds.Max.X,
+			Height:     bounds.Max.Y,
+		},
+	}
+
+	if err := gif.EncodeAll(file, g); err != nil {
+		return fmt.Errorf("GIFエンコードに失敗しました: %v", err)
+	}
+
+	return nil
+}
+
+// animationDelays は設定に応じたフレーム間隔を返します
+func animationDelays(g *gif.GIF, preserve bool) []int {
+	delays := make([]int, len(g.Image))
+	for i := range delays {
+		if preserve && i < len(g.Delay) {
+			delays[i] = g.Delay[i]
+		} else {
+			delays[i] = defaultAnimationDelay
+		}
+	}
+	return delays
+}
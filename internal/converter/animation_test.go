@@ -0,0 +1,162 @@
+package converter
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/image/webp"
+)
+
+// writeAnimatedGIF は赤と青の2フレームからなるアニメーションGIFを作成します
+func writeAnimatedGIF(t *testing.T, path string, delays []int) {
+	t.Helper()
+
+	palette := color.Palette{color.RGBA{R: 255, A: 255}, color.RGBA{B: 255, A: 255}}
+	g := &gif.GIF{Delay: delays}
+	for i := range delays {
+		frame := image.NewPaletted(image.Rect(0, 0, 16, 12), palette)
+		for p := range frame.Pix {
+			frame.Pix[p] = uint8(i % len(palette))
+		}
+		g.Image = append(g.Image, frame)
+	}
+
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, g); err != nil {
+		t.Fatalf("GIFのエンコードに失敗しました: %v", err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("入力ファイルの作成に失敗しました: %v", err)
+	}
+}
+
+// fakeGif2webp は入力GIFを recorded にコピーし、固定の内容を出力する gif2webp を PATH の先頭に配置します
+func fakeGif2webp(t *testing.T, recorded string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	script := `#!/bin/sh
+while [ $# -gt 0 ]; do
+  case "$1" in
+    -o) out="$2"; shift ;;
+    *.gif) cp "$1" "` + recorded + `" ;;
+  esac
+  shift
+done
+printf fake > "$out"
+`
+	if err := os.WriteFile(filepath.Join(dir, "gif2webp"), []byte(script), 0755); err != nil {
+		t.Fatalf("gif2webp の作成に失敗しました: %v", err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestLoadGIFFrames(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "anim.gif")
+	writeAnimatedGIF(t, path, []int{25, 50})
+
+	g, err := loadGIFFrames(path)
+	if err != nil {
+		t.Fatalf("loadGIFFrames に失敗しました: %v", err)
+	}
+	if len(g.Image) != 2 {
+		t.Fatalf("フレーム数 = %d, want 2", len(g.Image))
+	}
+
+	if got := animationDelays(g, true); got[0] != 25 || got[1] != 50 {
+		t.Errorf("フレーム間隔を維持した場合 = %v, want [25 50]", got)
+	}
+	if got := animationDelays(g, false); got[0] != defaultAnimationDelay || got[1] != defaultAnimationDelay {
+		t.Errorf("フレーム間隔を維持しない場合 = %v, want [%d %d]", got, defaultAnimationDelay, defaultAnimationDelay)
+	}
+}
+
+// TestConvertAnimatedGIF は2フレームのアニメーションGIFの変換を確認します
+func TestConvertAnimatedGIF(t *testing.T) {
+	newConverter := func(t *testing.T, enabled bool) (*ImageConverter, string) {
+		inputPath := filepath.Join(t.TempDir(), "anim.gif")
+		writeAnimatedGIF(t, inputPath, []int{25, 50})
+
+		ic := newWebPOnlyConverter()
+		ic.config.Conversion.AnimatedGIF.Enabled = enabled
+		ic.config.Conversion.AnimatedGIF.PreserveDelay = true
+		return ic, inputPath
+	}
+
+	t.Run("gif2webpで全フレームを変換する", func(t *testing.T) {
+		recorded := filepath.Join(t.TempDir(), "recorded.gif")
+		fakeGif2webp(t, recorded)
+		ic, inputPath := newConverter(t, true)
+
+		result, err := ic.Convert(inputPath)
+		if err != nil || !result.WebPSuccess {
+			t.Fatalf("Convert に失敗しました: %v (%+v)", err, result)
+		}
+		if !result.IsAnimated {
+			t.Error("IsAnimated = false, want true")
+		}
+		if got, err := os.ReadFile(result.WebPPath); err != nil || string(got) != "fake" {
+			t.Errorf("出力ファイル = %q (%v), gif2webp の出力を期待しました", got, err)
+		}
+
+		// gif2webp に渡したGIFに全フレームとフレーム間隔が含まれる
+		g, err := loadGIFFrames(recorded)
+		if err != nil {
+			t.Fatalf("gif2webp に渡したGIFの読み込みに失敗しました: %v", err)
+		}
+		if len(g.Image) != 2 || g.Delay[0] != 25 || g.Delay[1] != 50 {
+			t.Errorf("gif2webp に渡したGIF = %dフレーム, 間隔 %v, want 2フレーム, [25 50]", len(g.Image), g.Delay)
+		}
+	})
+
+	t.Run("gif2webpがない場合は先頭フレームのみ変換する", func(t *testing.T) {
+		t.Setenv("PATH", t.TempDir())
+		ic, inputPath := newConverter(t, true)
+
+		result, err := ic.Convert(inputPath)
+		if err != nil || !result.WebPSuccess {
+			t.Fatalf("Convert に失敗しました: %v (%+v)", err, result)
+		}
+		if !result.IsAnimated {
+			t.Error("IsAnimated = false, want true")
+		}
+
+		data, err := os.ReadFile(result.WebPPath)
+		if err != nil {
+			t.Fatalf("出力ファイルの読み込みに失敗しました: %v", err)
+		}
+		img, err := webp.Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("WebPのデコードに失敗しました: %v", err)
+		}
+		if img.Bounds() != image.Rect(0, 0, 16, 12) {
+			t.Errorf("出力の寸法 = %v, want 16x12", img.Bounds())
+		}
+		// 先頭フレーム（赤）が出力される
+		if r, _, b, _ := img.At(8, 6).RGBA(); r <= b {
+			t.Errorf("出力の画素 = %v, 先頭フレームの赤を期待しました", img.At(8, 6))
+		}
+	})
+
+	t.Run("無効な場合は静止画として変換する", func(t *testing.T) {
+		recorded := filepath.Join(t.TempDir(), "recorded.gif")
+		fakeGif2webp(t, recorded)
+		ic, inputPath := newConverter(t, false)
+
+		result, err := ic.Convert(inputPath)
+		if err != nil || !result.WebPSuccess {
+			t.Fatalf("Convert に失敗しました: %v (%+v)", err, result)
+		}
+		if result.IsAnimated {
+			t.Error("IsAnimated = true, want false")
+		}
+		if _, err := os.Stat(recorded); !os.IsNotExist(err) {
+			t.Errorf("無効な場合に gif2webp が呼び出されました: %v", err)
+		}
+	})
+}
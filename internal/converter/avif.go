@@ -4,10 +4,14 @@ Package converter の一部として、AVIF変換に特化した関数を提供
 package converter
 
 import (
+	"bytes"
 	"fmt"
 	"image"
+	"image/png"
+	"io"
 	"log"
 	"os"
+	"os/exec"
 	"path/filepath"
 
 	"github.com/223n/image-converter/internal/config"
@@ -15,41 +19,81 @@ import (
 )
 
 // SaveAVIF は画像をAVIFとして保存します
+//
+// Deprecated: グローバルな設定を参照します。個別の設定で変換する場合は pkg/converter の Converter を使用してください
 func SaveAVIF(img image.Image, outputPath string) error {
-	output, err := os.Create(outputPath)
+	_, err := SaveAVIFWithChecksum(img, outputPath)
+	return err
+}
+
+// SaveAVIFWithChecksum は画像をAVIFとして保存し、書き込み中に計算したSHA256を返します
+//
+// Deprecated: グローバルな設定を参照します。個別の設定で変換する場合は pkg/converter の Converter を使用してください
+func SaveAVIFWithChecksum(img image.Image, outputPath string) (string, error) {
+	return saveAVIFWithOptions(img, outputPath, nil)
+}
+
+// saveAVIFWithOptions はオプションで画質を上書きしてAVIFとして保存します
+func saveAVIFWithOptions(img image.Image, outputPath string, opts *EncodeOptions) (string, error) {
+	settings := opts.avifSettings()
+	log.Printf("AVIF変換開始: %s (品質: %d, 速度: %d, サブサンプリング: %s)",
+		outputPath, settings.Quality, settings.Speed, settings.ChromaSubsampling)
+
+	checksum, err := saveWithEncoder(AVIFEncoder, img, outputPath, opts)
 	if err != nil {
-		return err
+		return "", err
 	}
-	defer output.Close()
 
-	// AVIFエンコードオプションの設定
-	options := prepareAVIFOptions()
+	if fi, err := os.Stat(outputPath); err == nil {
+		log.Printf("AVIF変換完了: %s (サイズ: %d バイト)", outputPath, fi.Size())
+	}
+	return checksum, nil
+}
 
-	// AVIF形式で保存
-	log.Printf("AVIF変換開始: %s (品質: %d, 速度: %d)",
-		outputPath, options.Quality, options.Speed)
+// AVIFEncodeOptions は EncodeAVIF のオプションです
+type AVIFEncodeOptions struct {
+	Quality  int  // 画質（エンコーダーの尺度の1〜63、値が小さいほど高画質。0の場合は設定ファイルの値）
+	Speed    int  // 処理速度（1〜10、値が大きいほど速いが品質は下がる。0の場合は設定ファイルの値）
+	Lossless bool // 可逆圧縮でエンコードするかどうか（avifenc が必要です）
+	// BitDepth は出力のビット深度です（8, 10, 12。0の場合は設定ファイルの値）
+	BitDepth int
+	// ChromaSubsampling は色差のサブサンプリングです（420, 422, 444。空の場合は設定ファイルの値）
+	ChromaSubsampling string
+}
 
-	if err := avif.Encode(output, img, options); err != nil {
-		return err
+// withDefaults は未指定（0または空）の値を設定ファイルの値で補ったオプションを返します
+func (o AVIFEncodeOptions) withDefaults() AVIFEncodeOptions {
+	if o.Quality <= 0 {
+		o.Quality = config.GetAVIFQuality()
 	}
-
-	// エンコード後のファイルサイズを確認
-	fi, err := os.Stat(outputPath)
-	if err != nil || fi.Size() == 0 {
-		return fmt.Errorf("AVIF変換に失敗しました: 出力ファイルサイズが0バイトです")
+	if o.Speed <= 0 {
+		o.Speed = config.GetAVIFSpeed()
+	}
+	if o.BitDepth <= 0 {
+		o.BitDepth = config.GetAVIFBitDepth()
 	}
+	if o.ChromaSubsampling == "" {
+		o.ChromaSubsampling = config.GetAVIFChromaSubsampling()
+	}
+	return o
+}
 
-	log.Printf("AVIF変換完了: %s (サイズ: %d バイト)", outputPath, fi.Size())
-	return nil
+// EncodeAVIF は画像をAVIFとして w に書き込みます
+// 範囲外の画質・処理速度は範囲内に丸めます
+func EncodeAVIF(img image.Image, w io.Writer, opts AVIFEncodeOptions) error {
+	return encodeAVIF(img, w, opts.withDefaults(), nil, nil)
 }
 
 // prepareAVIFOptions はAVIF変換オプションを準備します
-func prepareAVIFOptions() *avif.Options {
-	options := &avif.Options{}
+func prepareAVIFOptions(quality, speed int) *avif.Options {
+	options := &avif.Options{
+		// ワーカーごとのスレッド数を制限し、並列処理時のスレッド過多を防ぐ
+		Threads: config.GetExternalThreads(),
+	}
 
-	// Quality: 品質 (0-100)
+	// Quality: 品質
 	// go-avifライブラリでは1-63の範囲の値が有効
-	quality := config.GetAVIFQuality()
+	// 0-100の尺度で指定された値は config.GetAVIFQuality で換算済みのため、ここでは範囲外の値のみ補正する
 	if quality > 63 {
 		log.Printf("警告: AVIF品質値が範囲外です。63に調整します: %d -> 63", quality)
 		options.Quality = 63
@@ -60,23 +104,111 @@ func prepareAVIFOptions() *avif.Options {
 		options.Quality = quality
 	}
 
-	// Speed: 処理速度 (0-10, 値が大きいほど速いが品質は下がる)
-	// go-avifライブラリでは0-10の範囲の値が有効
-	speed := config.GetAVIFSpeed()
-	if speed > 10 {
-		log.Printf("警告: AVIF速度値が範囲外です。10に調整します: %d -> 10", speed)
-		options.Speed = 10
-	} else if speed < 0 {
-		log.Printf("警告: AVIF速度値が範囲外です。0に調整します: %d -> 0", speed)
-		options.Speed = 0
+	// Speed: 処理速度 (値が大きいほど速いが品質は下がる)
+	// go-avifライブラリでは0-8の範囲の値が有効で、範囲外の値はエンコードがエラーになる
+	if speed > avif.MaxSpeed {
+		log.Printf("警告: AVIF速度値が範囲外です。%dに調整します: %d -> %d", avif.MaxSpeed, speed, avif.MaxSpeed)
+		options.Speed = avif.MaxSpeed
+	} else if speed < avif.MinSpeed {
+		log.Printf("警告: AVIF速度値が範囲外です。%dに調整します: %d -> %d", avif.MinSpeed, speed, avif.MinSpeed)
+		options.Speed = avif.MinSpeed
 	} else {
 		options.Speed = speed
 	}
 
+	// SubsampleRatio: 色差サブサンプリング
+	// go-avif は4:2:0のみ対応（それ以外は avifenc でエンコードする）
+	subsampling := image.YCbCrSubsampleRatio420
+	options.SubsampleRatio = &subsampling
+
 	return options
 }
 
+// encodeAVIFWithAvifenc は avifenc コマンドで指定したサブサンプリング・ビット深度のAVIFにエンコードします
+// 画質・速度・スレッド数は go-avif と同じオプションを使用します。icc、xmp が指定されている場合は埋め込みます
+// lossless が true の場合は可逆圧縮でエンコードし、画質とサブサンプリングは使用しません
+func encodeAVIFWithAvifenc(img image.Image, w io.Writer, options *avif.Options, subsampling string, depth int, icc, xmp []byte, lossless bool) error {
+	if _, err := exec.LookPath("avifenc"); err != nil {
+		if lossless {
+			return fmt.Errorf("可逆圧縮のAVIFには avifencコマンドが必要です。次のコマンドでインストールしてください: sudo apt-get install libavif-bin")
+		}
+		return fmt.Errorf("avifencコマンドが見つかりません（chroma_subsampling: %s, bit_depth: %d）。次のコマンドでインストールしてください: sudo apt-get install libavif-bin", subsampling, depth)
+	}
+
+	// 一時的にPNGとして保存
+	tempDir, err := os.MkdirTemp("", "avif-conversion-")
+	if err != nil {
+		return fmt.Errorf("一時ディレクトリの作成に失敗しました: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	tempPNGPath := filepath.Join(tempDir, "temp.png")
+	tempAVIFPath := filepath.Join(tempDir, "temp.avif")
+
+	tempFile, err := os.Create(tempPNGPath)
+	if err != nil {
+		return fmt.Errorf("一時ファイルの作成に失敗しました: %v", err)
+	}
+	if err := png.Encode(tempFile, img); err != nil {
+		tempFile.Close()
+		return fmt.Errorf("PNGエンコードに失敗しました: %v", err)
+	}
+	tempFile.Close()
+
+	// go-avif の Quality は量子化値（値が小さいほど高画質）のため、min/maxに同じ値を指定する
+	args := []string{
+		"--depth", fmt.Sprintf("%d", depth),
+		"--speed", fmt.Sprintf("%d", options.Speed),
+		"--jobs", fmt.Sprintf("%d", max(1, options.Threads)),
+	}
+	if lossless {
+		// --lossless は4:4:4・量子化値0を指定したことになる
+		args = append(args, "--lossless")
+	} else {
+		args = append(args,
+			"--yuv", subsampling,
+			"--min", fmt.Sprintf("%d", options.Quality),
+			"--max", fmt.Sprintf("%d", options.Quality),
+		)
+	}
+	if len(icc) > 0 {
+		tempICCPath := filepath.Join(tempDir, "profile.icc")
+		if err := os.WriteFile(tempICCPath, icc, 0644); err != nil {
+			return fmt.Errorf("ICCプロファイルの一時ファイルの作成に失敗しました: %v", err)
+		}
+		args = append(args, "--icc", tempICCPath)
+	}
+	if len(xmp) > 0 {
+		tempXMPPath := filepath.Join(tempDir, "metadata.xmp")
+		if err := os.WriteFile(tempXMPPath, xmp, 0644); err != nil {
+			return fmt.Errorf("XMPの一時ファイルの作成に失敗しました: %v", err)
+		}
+		args = append(args, "--xmp", tempXMPPath)
+	}
+	args = append(args, tempPNGPath, tempAVIFPath)
+
+	var stderr bytes.Buffer
+	cmd := exec.Command("avifenc", args...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("avifencコマンドの実行に失敗しました: %v\n出力: %s", err, stderr.String())
+	}
+
+	encoded, err := os.Open(tempAVIFPath)
+	if err != nil {
+		return fmt.Errorf("avifencの出力ファイルを開けません: %v", err)
+	}
+	defer encoded.Close()
+
+	if _, err := io.Copy(w, encoded); err != nil {
+		return fmt.Errorf("AVIFの書き込みに失敗しました: %v", err)
+	}
+	return nil
+}
+
 // ConvertToAVIF は公開APIとして高レベルのAVIF変換機能を提供します
+//
+// Deprecated: グローバルな設定を参照します。個別の設定で変換する場合は pkg/converter の Converter を使用してください
 func ConvertToAVIF(img image.Image, outputPath string) error {
 	// パス関連の処理
 	dir := filepath.Dir(outputPath)
@@ -0,0 +1,130 @@
+package converter
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/223n/image-converter/internal/config"
+	"github.com/Kagami/go-avif"
+)
+
+// encodeTestAVIF は画像をAVIFとしてエンコードします
+// AVIFエンコーダー（libaom）を初期化できない環境ではテストをスキップします
+func encodeTestAVIF(t *testing.T, opts AVIFEncodeOptions) []byte {
+	t.Helper()
+	t.Cleanup(func() { config.LoadConfigFromBytes(nil) })
+	if err := config.LoadConfigFromBytes(nil); err != nil {
+		t.Fatalf("設定の読み込みに失敗しました: %v", err)
+	}
+
+	var buf bytes.Buffer
+	err := EncodeAVIF(decodeTestImage(t, 40, 30), &buf, opts)
+	if err != nil && strings.Contains(err.Error(), "codec init") {
+		t.Skipf("AVIFエンコーダーを初期化できません: %v", err)
+	}
+	if err != nil {
+		t.Fatalf("EncodeAVIF に失敗しました: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestEncodeAVIF(t *testing.T) {
+	tests := []struct {
+		name string
+		opts AVIFEncodeOptions
+	}{
+		{name: "画質と速度を指定", opts: AVIFEncodeOptions{Quality: 30, Speed: 8}},
+		{name: "範囲外の値", opts: AVIFEncodeOptions{Quality: 100, Speed: 99}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := encodeTestAVIF(t, tt.opts)
+			// ISOBMFF の ftyp ボックスのブランドが avif であること
+			if len(data) < 12 || string(data[4:8]) != "ftyp" || string(data[8:12]) != "avif" {
+				t.Errorf("AVIFのヘッダーではありません: % x", data[:min(len(data), 12)])
+			}
+		})
+	}
+}
+
+func TestPrepareAVIFOptions(t *testing.T) {
+	tests := []struct {
+		name                   string
+		quality, speed         int
+		wantQuality, wantSpeed int
+	}{
+		{name: "範囲内", quality: 30, speed: 6, wantQuality: 30, wantSpeed: 6},
+		{name: "上限を超える", quality: 100, speed: 10, wantQuality: avif.MaxQuality, wantSpeed: avif.MaxSpeed},
+		{name: "下限を下回る", quality: -1, speed: -1, wantQuality: 1, wantSpeed: avif.MinSpeed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := prepareAVIFOptions(tt.quality, tt.speed)
+			if got.Quality != tt.wantQuality || got.Speed != tt.wantSpeed {
+				t.Errorf("(Quality, Speed) = (%d, %d), want (%d, %d)", got.Quality, got.Speed, tt.wantQuality, tt.wantSpeed)
+			}
+		})
+	}
+}
+
+// fakeAvifenc は引数の --xmp のファイルを recorded にコピーし、出力ファイルに "fake" を書き込む avifenc を PATH に設定します
+func fakeAvifenc(t *testing.T, recorded string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	script := `#!/bin/sh
+while [ $# -gt 0 ]; do
+  if [ "$1" = "--xmp" ]; then cp "$2" "` + recorded + `"; fi
+  out="$1"
+  shift
+done
+printf fake > "$out"
+`
+	if err := os.WriteFile(filepath.Join(dir, "avifenc"), []byte(script), 0755); err != nil {
+		t.Fatalf("avifenc の作成に失敗しました: %v", err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+// TestEncodeAVIFEmbedsXMP は avifenc がある場合に --xmp でXMPを渡し、ない場合は警告を出力して go-avif でエンコードすることを確認します
+func TestEncodeAVIFEmbedsXMP(t *testing.T) {
+	opts := AVIFEncodeOptions{Quality: 30, Speed: 6, BitDepth: 8, ChromaSubsampling: config.AVIFChroma420}
+	img := decodeTestImage(t, 8, 8)
+	xmp := []byte(`<x:xmpmeta xmlns:x="adobe:ns:meta/"/>`)
+
+	t.Run("avifencあり", func(t *testing.T) {
+		recorded := filepath.Join(t.TempDir(), "recorded.xmp")
+		fakeAvifenc(t, recorded)
+
+		var buf bytes.Buffer
+		if err := encodeAVIF(img, &buf, opts, nil, xmp); err != nil {
+			t.Fatalf("encodeAVIF に失敗しました: %v", err)
+		}
+		if buf.String() != "fake" {
+			t.Errorf("出力 = %q, avifenc の出力を期待しました", buf.String())
+		}
+		if got, err := os.ReadFile(recorded); err != nil || !bytes.Equal(got, xmp) {
+			t.Errorf("avifenc に渡したXMP = %q (%v), want %q", got, err, xmp)
+		}
+	})
+
+	t.Run("avifencなし", func(t *testing.T) {
+		t.Setenv("PATH", t.TempDir())
+		var logs bytes.Buffer
+		log.SetOutput(&logs)
+		t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+		// go-avif（libaom）を初期化できない環境ではエンコード自体は失敗するため、警告のみを確認する
+		encodeAVIF(img, io.Discard, opts, nil, xmp)
+		if !strings.Contains(logs.String(), "XMPを含まないAVIF") {
+			t.Errorf("XMPを引き継げない警告が出力されませんでした: %s", logs.String())
+		}
+	})
+}
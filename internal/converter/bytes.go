@@ -0,0 +1,156 @@
+/*
+Package converter の一部として、ファイルを介さないメモリ上での変換機能を提供します。
+*/
+package converter
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"strings"
+
+	"github.com/223n/image-converter/internal/config"
+	"github.com/223n/image-converter/pkg/imageutils"
+)
+
+// memoryEncoders はメモリ上に書き込める組み込みのエンコーダーです
+// JPEG XLは外部コマンドがファイルを必要とするため対象外です
+var memoryEncoders = map[string]Encoder{
+	"webp": WebPEncoder,
+	"avif": AVIFEncoder,
+}
+
+// ConvertOptions は ConvertBytesResult の変換ごとのオプションです
+// 出力形式・画質・可逆圧縮は conversion の同名の設定に対応し、値が0（false）の項目は設定ファイルの値を使用します
+type ConvertOptions struct {
+	WebP        bool // WebPに変換するかどうか（WebP・AVIFともに false の場合は設定ファイルの enabled に従う）
+	AVIF        bool // AVIFに変換するかどうか
+	WebPQuality int  // WebPの画質（0〜100）
+	AVIFQuality int  // AVIFの画質（エンコーダーの尺度の1〜63、値が小さいほど高画質）
+	Lossless    bool // WebPを可逆圧縮でエンコードするかどうか
+	MaxWidth    int  // 出力の最大幅（超える場合は縦横比を保って縮小する。0の場合は制限しない）
+	MaxHeight   int  // 出力の最大高さ（0の場合は制限しない）
+}
+
+// ConvertBytes は画像データをデコードし、指定した形式にエンコードしたデータを返します
+// srcExt は入力データの拡張子（例: ".jpg"、".heic"）で、デコーダーの選択に使用します
+// 出力形式は opts.Format（空の場合は webp）、画質は opts.Quality（0の場合は設定ファイルの値）で指定します
+// 複数の形式を一度に変換する場合や縮小する場合は ConvertBytesResult を使用してください
+func ConvertBytes(src []byte, srcExt string, opts EncodeOptions) ([]byte, error) {
+	format := strings.ToLower(opts.Format)
+	if format == "" {
+		format = "webp"
+	}
+	enc, ok := memoryEncoders[format]
+	if !ok {
+		return nil, fmt.Errorf("%w: メモリ上での変換に対応していない出力形式 %s", ErrUnsupportedFormat, format)
+	}
+
+	img, err := decodeBytes(src, srcExt)
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	if err := enc.Encode(img, &out, &opts); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrEncodeFailed, format, err)
+	}
+	if out.Len() == 0 {
+		return nil, fmt.Errorf("%w: %s: 出力が0バイトです", ErrEncodeFailed, format)
+	}
+
+	return out.Bytes(), nil
+}
+
+// ConvertBytesResult は画像データをデコードし、WebP・AVIFにエンコードしたデータを変換結果として返します
+// ext は入力データの拡張子（例: ".jpg"、".heic"）で、デコーダーの選択に使用します
+// 変換結果の WebPBytes・AVIFBytes にエンコードしたデータを格納します。OriginalPath などのパスは空のままです
+// 一部の形式だけ失敗した場合は失敗を変換結果に記録し、すべての形式が失敗した場合はエラーを返します
+func ConvertBytesResult(input []byte, ext string, opts ConvertOptions) (*ConversionResult, error) {
+	img, err := decodeBytes(input, ext)
+	if err != nil {
+		return nil, err
+	}
+	img = fitWithin(img, opts.MaxWidth, opts.MaxHeight)
+
+	webpEnabled, avifEnabled := opts.WebP, opts.AVIF
+	if !webpEnabled && !avifEnabled {
+		webpEnabled, avifEnabled = config.IsWebPEnabled(), config.IsAVIFEnabled()
+	}
+
+	result := &ConversionResult{OriginalSize: int64(len(input))}
+	var firstErr error
+	record := func(format string, err error) {
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("%w: %s: %v", ErrEncodeFailed, format, err)
+		}
+	}
+
+	if webpEnabled {
+		result.WebPAttempted = true
+		var out bytes.Buffer
+		err := EncodeWebP(img, &out, WebPEncodeOptions{Quality: float32(opts.WebPQuality), Lossless: opts.Lossless})
+		if err == nil && out.Len() == 0 {
+			err = fmt.Errorf("出力が0バイトです")
+		}
+		if err == nil {
+			result.WebPSuccess = true
+			result.WebPBytes = out.Bytes()
+			result.WebPSize = int64(out.Len())
+		}
+		record("webp", err)
+	}
+
+	if avifEnabled {
+		result.AVIFAttempted = true
+		var out bytes.Buffer
+		err := EncodeAVIF(img, &out, AVIFEncodeOptions{Quality: opts.AVIFQuality})
+		if err == nil && out.Len() == 0 {
+			err = fmt.Errorf("出力が0バイトです")
+		}
+		if err == nil {
+			result.AVIFSuccess = true
+			result.AVIFBytes = out.Bytes()
+			result.AVIFSize = int64(out.Len())
+		}
+		record("avif", err)
+	}
+
+	if !result.WebPSuccess && !result.AVIFSuccess {
+		if firstErr == nil {
+			return nil, fmt.Errorf("%w: 出力形式が有効になっていません", ErrUnsupportedFormat)
+		}
+		return nil, firstErr
+	}
+
+	return result, nil
+}
+
+// decodeBytes はメモリ上の画像データをデコードし、ファイルから読み込んだ場合と同じくガンマ・CMYKを補正します
+// ext は入力データの拡張子で、デコーダーの選択に使用します
+func decodeBytes(input []byte, ext string) (image.Image, error) {
+	if len(input) > maxInputSize {
+		return nil, fmt.Errorf("データサイズが大きすぎます (%d バイト)", len(input))
+	}
+
+	img, err := decodeImage(bytes.NewReader(input), ext)
+	if err != nil {
+		return nil, err
+	}
+	if normalizeExt(ext) == ".png" {
+		img = normalizePNGGamma(img, bytes.NewReader(input), "メモリ上のデータ")
+	}
+	return normalizeCMYK(img, "メモリ上のデータ"), nil
+}
+
+// fitWithin は画像が最大幅・最大高さを超える場合に、縦横比を保って収まるように縮小した画像を返します
+// 最大値が0の辺は制限しません。収まっている場合は元の画像をそのまま返します
+// 縮小後の寸法は fitSize で決め、出力パスの計画（outputSize）と一致させます
+func fitWithin(img image.Image, maxWidth, maxHeight int) image.Image {
+	size := img.Bounds().Size()
+	fitted := fitSize(size, maxWidth, maxHeight)
+	if fitted == size {
+		return img
+	}
+	return imageutils.ResizeImage(img, fitted.X, fitted.Y, imageutils.ResizeStretch)
+}
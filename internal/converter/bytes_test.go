@@ -0,0 +1,121 @@
+package converter
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/chai2010/webp"
+)
+
+// encodeTestImage は指定した寸法のグラデーション画像を ext の形式でエンコードして返します
+func encodeTestImage(t *testing.T, ext string, width, height int) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{uint8(x * 255 / width), uint8(y * 255 / height), 128, 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	var err error
+	switch ext {
+	case ".png":
+		err = png.Encode(&buf, img)
+	case ".jpg":
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 90})
+	default:
+		t.Fatalf("未対応の拡張子です: %s", ext)
+	}
+	if err != nil {
+		t.Fatalf("テスト画像のエンコードに失敗しました: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// webpSize はWebPデータの寸法を返します
+func webpSize(t *testing.T, data []byte) (int, int) {
+	t.Helper()
+
+	cfg, err := webp.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("WebPのデコードに失敗しました: %v", err)
+	}
+	return cfg.Width, cfg.Height
+}
+
+func TestConvertBytesResult(t *testing.T) {
+	tests := []struct {
+		name       string
+		ext        string
+		opts       ConvertOptions
+		wantWidth  int
+		wantHeight int
+	}{
+		{name: "PNG", ext: ".png", opts: ConvertOptions{WebP: true, WebPQuality: 80}, wantWidth: 64, wantHeight: 48},
+		{name: "JPEG", ext: ".jpg", opts: ConvertOptions{WebP: true, WebPQuality: 80}, wantWidth: 64, wantHeight: 48},
+		{name: "可逆圧縮", ext: ".png", opts: ConvertOptions{WebP: true, Lossless: true}, wantWidth: 64, wantHeight: 48},
+		{name: "最大幅で縮小", ext: ".png", opts: ConvertOptions{WebP: true, MaxWidth: 32}, wantWidth: 32, wantHeight: 24},
+		{name: "最大高さで縮小", ext: ".jpg", opts: ConvertOptions{WebP: true, MaxHeight: 12}, wantWidth: 16, wantHeight: 12},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := encodeTestImage(t, tt.ext, 64, 48)
+			result, err := ConvertBytesResult(input, tt.ext, tt.opts)
+			if err != nil {
+				t.Fatalf("ConvertBytesResult に失敗しました: %v", err)
+			}
+			if !result.WebPSuccess || len(result.WebPBytes) == 0 {
+				t.Fatalf("WebPが出力されませんでした: %+v", result)
+			}
+			if result.WebPSize != int64(len(result.WebPBytes)) {
+				t.Errorf("WebPSize = %d, want %d", result.WebPSize, len(result.WebPBytes))
+			}
+			if result.OriginalSize != int64(len(input)) {
+				t.Errorf("OriginalSize = %d, want %d", result.OriginalSize, len(input))
+			}
+			if result.AVIFAttempted || len(result.AVIFBytes) != 0 {
+				t.Errorf("指定していないAVIFが出力されました")
+			}
+			if w, h := webpSize(t, result.WebPBytes); w != tt.wantWidth || h != tt.wantHeight {
+				t.Errorf("寸法 = %dx%d, want %dx%d", w, h, tt.wantWidth, tt.wantHeight)
+			}
+		})
+	}
+}
+
+func TestConvertBytesResultErrors(t *testing.T) {
+	if _, err := ConvertBytesResult([]byte("not an image"), ".png", ConvertOptions{WebP: true}); !errors.Is(err, ErrDecodeFailed) {
+		t.Errorf("壊れたデータのエラー = %v, ErrDecodeFailed を期待しました", err)
+	}
+	if _, err := ConvertBytesResult(encodeTestImage(t, ".png", 8, 8), ".bmp", ConvertOptions{WebP: true}); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("未対応の拡張子のエラー = %v, ErrUnsupportedFormat を期待しました", err)
+	}
+}
+
+// TestConvertBytes は従来の ConvertBytes の呼び出し方が引き続き使えることを確認します
+func TestConvertBytes(t *testing.T) {
+	input := encodeTestImage(t, ".png", 40, 30)
+
+	out, err := ConvertBytes(input, ".png", EncodeOptions{Quality: 70})
+	if err != nil {
+		t.Fatalf("ConvertBytes に失敗しました: %v", err)
+	}
+	if len(out) == 0 {
+		t.Fatal("出力が0バイトです")
+	}
+	if w, h := webpSize(t, out); w != 40 || h != 30 {
+		t.Errorf("寸法 = %dx%d, want 40x30", w, h)
+	}
+
+	if _, err := ConvertBytes(input, ".png", EncodeOptions{Format: "jxl"}); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("JPEG XL のエラー = %v, ErrUnsupportedFormat を期待しました", err)
+	}
+}
@@ -0,0 +1,51 @@
+/*
+Package converter の一部として、変換結果のチェックサム生成に関する関数を提供します。
+*/
+package converter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// checksumExt はチェックサムサイドカーファイルの拡張子です
+const checksumExt = ".sha256"
+
+// checksumHex はハッシュ値を16進文字列に変換します
+func checksumHex(h hash.Hash) string {
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// fileChecksum はファイル内容のSHA256を16進文字列で返します
+// 外部コマンドが直接書き込んだ出力など、書き込み中にハッシュを計算できない場合に使用します
+func fileChecksum(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, file); err != nil {
+		return "", err
+	}
+
+	return checksumHex(hasher), nil
+}
+
+// WriteChecksumFile は出力ファイルの横にsha256sum形式のサイドカーファイルを書き込みます
+func WriteChecksumFile(outputPath, checksum string) (string, error) {
+	checksumPath := outputPath + checksumExt
+	content := fmt.Sprintf("%s  %s\n", checksum, filepath.Base(outputPath))
+
+	if err := os.WriteFile(checksumPath, []byte(content), 0644); err != nil {
+		return "", fmt.Errorf("チェックサムファイルの書き込みに失敗しました: %v", err)
+	}
+
+	return checksumPath, nil
+}
@@ -0,0 +1,106 @@
+package converter
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/223n/image-converter/internal/config"
+	"github.com/223n/image-converter/internal/utils"
+)
+
+// verifySidecar は sha256sum -c と同じ手順でサイドカーファイルを検証し、記録されたチェックサムを返します
+// サイドカーの各行は「チェックサム  ファイル名」の形式で、ファイル名はサイドカーと同じディレクトリからの相対パスです
+func verifySidecar(t *testing.T, sidecarPath string) string {
+	t.Helper()
+
+	file, err := os.Open(sidecarPath)
+	if err != nil {
+		t.Fatalf("チェックサムファイルを開けません: %v", err)
+	}
+	defer file.Close()
+
+	var checksum string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		sum, name, ok := strings.Cut(scanner.Text(), "  ")
+		if !ok {
+			t.Fatalf("sha256sum形式ではない行があります: %q", scanner.Text())
+		}
+		data, err := os.ReadFile(filepath.Join(filepath.Dir(sidecarPath), name))
+		if err != nil {
+			t.Fatalf("チェックサムの対象ファイルを読み込めません: %v", err)
+		}
+		actual := sha256.Sum256(data)
+		if sum != hex.EncodeToString(actual[:]) {
+			t.Errorf("%s: チェックサムが一致しません (記録: %s, 実際: %x)", name, sum, actual)
+		}
+		checksum = sum
+	}
+	if checksum == "" {
+		t.Fatalf("チェックサムファイルが空です: %s", sidecarPath)
+	}
+	return checksum
+}
+
+func TestWriteChecksumFile(t *testing.T) {
+	dir := t.TempDir()
+	outputPath := filepath.Join(dir, "photo.webp")
+	data := []byte("converted image data")
+	if err := os.WriteFile(outputPath, data, 0644); err != nil {
+		t.Fatalf("出力ファイルの作成に失敗しました: %v", err)
+	}
+	sum := sha256.Sum256(data)
+
+	checksumPath, err := WriteChecksumFile(outputPath, hex.EncodeToString(sum[:]))
+	if err != nil {
+		t.Fatalf("WriteChecksumFile に失敗しました: %v", err)
+	}
+	if checksumPath != outputPath+".sha256" {
+		t.Errorf("チェックサムファイルのパス = %s, want %s", checksumPath, outputPath+".sha256")
+	}
+
+	content, err := os.ReadFile(checksumPath)
+	if err != nil {
+		t.Fatalf("チェックサムファイルを読み込めません: %v", err)
+	}
+	if want := hex.EncodeToString(sum[:]) + "  photo.webp\n"; string(content) != want {
+		t.Errorf("チェックサムファイルの内容 = %q, want %q", content, want)
+	}
+	verifySidecar(t, checksumPath)
+}
+
+// TestConvertWritesChecksumSidecar は変換時に書き込み中に計算したチェックサムが、出力ファイルの内容と一致することを確認します
+func TestConvertWritesChecksumSidecar(t *testing.T) {
+	dir := t.TempDir()
+	inputPath := filepath.Join(dir, "photo.png")
+	if err := os.WriteFile(inputPath, encodeTestImage(t, ".png", 32, 24), 0644); err != nil {
+		t.Fatalf("入力ファイルの作成に失敗しました: %v", err)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Conversion.WebP.Enabled = true
+	cfg.Conversion.AVIF.Enabled = false
+	cfg.Conversion.JXL.Enabled = false
+	cfg.Output.WriteChecksums = true
+	ic := NewImageConverter(&cfg, utils.NewLogManager())
+
+	result, err := ic.Convert(inputPath)
+	if err != nil {
+		t.Fatalf("Convert に失敗しました: %v", err)
+	}
+	if !result.WebPSuccess {
+		t.Fatalf("WebPへの変換に失敗しました: %+v", result)
+	}
+	if result.WebPChecksum == "" {
+		t.Fatal("WebPChecksum が設定されていません")
+	}
+
+	if got := verifySidecar(t, result.WebPPath+".sha256"); got != result.WebPChecksum {
+		t.Errorf("サイドカーのチェックサム = %s, WebPChecksum = %s", got, result.WebPChecksum)
+	}
+}
@@ -0,0 +1,44 @@
+/*
+Package converter の一部として、CMYK画像のRGBへの変換を提供します。
+*/
+package converter
+
+import (
+	"image"
+	"image/color"
+	"log"
+)
+
+// normalizeCMYK はCMYK画像（印刷用のJPEGなど）をRGBA画像に変換します
+// エンコーダーはCMYKを正しく扱えないため、エンコード前に変換します。CMYK以外の画像はそのまま返します
+// ICCプロファイルを使用しない単純な変換のため、source を含めて警告を出力します
+func normalizeCMYK(img image.Image, source string) image.Image {
+	cmyk, ok := img.(*image.CMYK)
+	if !ok {
+		return img
+	}
+
+	log.Printf("警告: CMYK画像をRGBに変換します（ICCプロファイルを使用しない近似変換のため、色味が変わる場合があります）: %s", source)
+	return cmykToRGBA(cmyk)
+}
+
+// cmykToRGBA はCMYK画像を R = 255 × (1 - C) × (1 - K) の単純な式でRGBA画像に変換します
+func cmykToRGBA(src *image.CMYK) *image.RGBA {
+	bounds := src.Bounds()
+	dst := image.NewRGBA(bounds)
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		srcOffset := src.PixOffset(bounds.Min.X, y)
+		dstOffset := dst.PixOffset(bounds.Min.X, y)
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			s := src.Pix[srcOffset : srcOffset+4 : srcOffset+4]
+			r, g, b := color.CMYKToRGB(s[0], s[1], s[2], s[3])
+			d := dst.Pix[dstOffset : dstOffset+4 : dstOffset+4]
+			d[0], d[1], d[2], d[3] = r, g, b, 0xFF
+			srcOffset += 4
+			dstOffset += 4
+		}
+	}
+
+	return dst
+}
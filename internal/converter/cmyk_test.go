@@ -0,0 +1,163 @@
+package converter
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/image/webp"
+)
+
+// cmykJPEG は単色の 16x16 のCMYK JPEG（印刷用のワークフローで作成されるAdobe形式）を作成します
+// 単色のためDCT係数は直流成分のみとし、量子化テーブルはすべて1、ハフマンテーブルは直流成分の分類ごとに4ビットの符号を使用します
+// Adobe形式のCMYKはインクの量を反転して格納します
+func cmykJPEG(c color.CMYK) []byte {
+	const size = 16
+	var out bytes.Buffer
+	segment := func(marker byte, payload ...byte) {
+		out.Write([]byte{0xff, marker, byte((len(payload) + 2) >> 8), byte(len(payload) + 2)})
+		out.Write(payload)
+	}
+
+	out.Write([]byte{0xff, 0xd8}) // SOI
+	// APP14 Adobe（変換なし = CMYK）
+	segment(0xee, 'A', 'd', 'o', 'b', 'e', 0, 100, 0, 0, 0, 0, 0)
+	// DQT: すべて1の量子化テーブル
+	segment(0xdb, append([]byte{0x00}, bytes.Repeat([]byte{1}, 64)...)...)
+	// SOF0: 4成分、サブサンプリングなし
+	segment(0xc0, 8, 0, size, 0, size, 4, 1, 0x11, 0, 2, 0x11, 0, 3, 0x11, 0, 4, 0x11, 0)
+	// DHT: 直流成分の分類0〜11に4ビットの符号、交流成分はEOBのみ（1ビット）
+	dc := []byte{0x00, 0, 0, 0, 12, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}
+	dc = append(dc, 0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11)
+	segment(0xc4, dc...)
+	segment(0xc4, 0x10, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0x00)
+	// SOS
+	segment(0xda, 4, 1, 0x00, 2, 0x00, 3, 0x00, 4, 0x00, 0, 63, 0)
+
+	// エントロピー符号化データ
+	var data []byte
+	var acc uint32
+	var nbits uint
+	writeBits := func(v uint32, n uint) {
+		for i := int(n) - 1; i >= 0; i-- {
+			acc = acc<<1 | (v>>uint(i))&1
+			nbits++
+			if nbits == 8 {
+				data = append(data, byte(acc))
+				if byte(acc) == 0xff {
+					data = append(data, 0x00)
+				}
+				acc, nbits = 0, 0
+			}
+		}
+	}
+
+	levels := []uint8{255 - c.C, 255 - c.M, 255 - c.Y, 255 - c.K}
+	var prev [4]int
+	for mcu := 0; mcu < (size/8)*(size/8); mcu++ {
+		for i, level := range levels {
+			// 単色のブロックの直流成分は 8 × (値 - 128)
+			dcValue := 8 * (int(level) - 128)
+			diff := dcValue - prev[i]
+			prev[i] = dcValue
+
+			category, bits := 0, diff
+			for v := max(diff, -diff); v > 0; v >>= 1 {
+				category++
+			}
+			if diff < 0 {
+				bits = diff + (1 << category) - 1
+			}
+			writeBits(uint32(category), 4)
+			writeBits(uint32(bits), uint(category))
+			writeBits(0, 1) // EOB
+		}
+	}
+	if nbits > 0 {
+		writeBits(0xff, 8-nbits)
+	}
+	out.Write(data)
+
+	out.Write([]byte{0xff, 0xd9}) // EOI
+	return out.Bytes()
+}
+
+// TestCMYKFixture はテスト用のCMYK JPEGが、指定したCMYKの値の image.CMYK としてデコードされることを確認します
+func TestCMYKFixture(t *testing.T) {
+	want := color.CMYK{C: 255, K: 64}
+	img, err := jpeg.Decode(bytes.NewReader(cmykJPEG(want)))
+	if err != nil {
+		t.Fatalf("CMYK JPEGのデコードに失敗しました: %v", err)
+	}
+	cmyk, ok := img.(*image.CMYK)
+	if !ok {
+		t.Fatalf("デコード結果 = %T, want *image.CMYK", img)
+	}
+	if got := cmyk.CMYKAt(9, 9); got != want {
+		t.Errorf("画素 = %v, want %v", got, want)
+	}
+}
+
+// TestNormalizeCMYK はCMYKのJPEGを反転させずにRGBへ変換してエンコードすることを確認します
+func TestNormalizeCMYK(t *testing.T) {
+	tests := []struct {
+		name string
+		cmyk color.CMYK
+		want color.RGBA
+	}{
+		{name: "シアン", cmyk: color.CMYK{C: 255}, want: color.RGBA{G: 255, B: 255, A: 255}},
+		{name: "マゼンタとイエロー", cmyk: color.CMYK{M: 255, Y: 255}, want: color.RGBA{R: 255, A: 255}},
+		{name: "墨50%", cmyk: color.CMYK{K: 128}, want: color.RGBA{R: 127, G: 127, B: 127, A: 255}},
+		{name: "インクなし", cmyk: color.CMYK{}, want: color.RGBA{R: 255, G: 255, B: 255, A: 255}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inputPath := filepath.Join(t.TempDir(), "print.jpg")
+			if err := os.WriteFile(inputPath, cmykJPEG(tt.cmyk), 0644); err != nil {
+				t.Fatalf("入力ファイルの作成に失敗しました: %v", err)
+			}
+
+			ic := newWebPOnlyConverter()
+			img, _, err := ic.Decode(inputPath)
+			if err != nil {
+				t.Fatalf("Decode に失敗しました: %v", err)
+			}
+			if _, ok := img.(*image.CMYK); ok {
+				t.Fatal("CMYKのままデコードされました")
+			}
+			if got := color.RGBAModel.Convert(img.At(5, 5)); got != tt.want {
+				t.Errorf("デコード後の画素 = %v, want %v", got, tt.want)
+			}
+
+			// エンコードした出力の色も反転していない
+			result, err := ic.Convert(inputPath)
+			if err != nil || !result.WebPSuccess {
+				t.Fatalf("Convert に失敗しました: %v (%+v)", err, result)
+			}
+			data, err := os.ReadFile(result.WebPPath)
+			if err != nil {
+				t.Fatalf("出力ファイルの読み込みに失敗しました: %v", err)
+			}
+			out, err := webp.Decode(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("WebPのデコードに失敗しました: %v", err)
+			}
+			got := color.RGBAModel.Convert(out.At(8, 8)).(color.RGBA)
+			for _, ch := range []struct {
+				name      string
+				got, want uint8
+			}{
+				{"R", got.R, tt.want.R}, {"G", got.G, tt.want.G}, {"B", got.B, tt.want.B},
+			} {
+				if d := int(ch.got) - int(ch.want); d < -24 || d > 24 {
+					t.Errorf("出力の %s = %d, want %d 付近（出力 %v）", ch.name, ch.got, ch.want, got)
+				}
+			}
+		})
+	}
+}
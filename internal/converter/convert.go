@@ -5,10 +5,11 @@ JPG、PNG、HEIC、HEIFなどの画像フォーマットをWebPとAVIFに変換
 package converter
 
 import (
+	"bytes"
 	"fmt"
 	"image"
-	"image/jpeg"
-	"image/png"
+	"image/gif"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
@@ -17,12 +18,12 @@ import (
 	"github.com/223n/image-converter/internal/config"
 	"github.com/223n/image-converter/internal/utils"
 	"github.com/223n/image-converter/pkg/imageutils"
-	"github.com/jdeng/goheif"
 )
 
 // ConversionResult は変換処理の結果を表します
 type ConversionResult struct {
 	OriginalPath  string
+	OriginalSize  int64
 	WebPPath      string
 	AVIFPath      string
 	WebPAttempted bool
@@ -31,12 +32,155 @@ type ConversionResult struct {
 	AVIFAttempted bool
 	AVIFSuccess   bool
 	AVIFSize      int64
+	WebPChecksum  string
+	AVIFChecksum  string
+
+	// ConvertBytesResult でメモリ上に変換した場合のエンコード結果（ファイルに変換した場合はnil）
+	WebPBytes []byte
+	AVIFBytes []byte
+
+	JXLPath      string
+	JXLAttempted bool
+	JXLSuccess   bool
+	JXLSize      int64
+	JXLChecksum  string
+
+	IsAnimated bool
+
+	// SSIM は検証した変換結果のうち最も低いSSIM（未検証の場合は0）です
+	SSIM float64
+
+	OptimizedPath     string
+	OptimizeAttempted bool
+	OptimizeSuccess   bool
+	OptimizedSize     int64
+
+	// 登録された組み込み以外のエンコーダーによる変換結果
+	CustomOutputs []CustomOutputResult
+}
+
+// CustomOutputResult は組み込み以外のエンコーダーによる変換結果を表します
+type CustomOutputResult struct {
+	Format   string
+	Path     string
+	Success  bool
+	Size     int64
+	Checksum string
+}
+
+// SmallestOutputSize は成功した変換結果のうち最も小さいファイルサイズを返します（成功がない場合は0）
+func (r *ConversionResult) SmallestOutputSize() int64 {
+	var smallest int64
+	record := func(success bool, size int64) {
+		if success && size > 0 && (smallest == 0 || size < smallest) {
+			smallest = size
+		}
+	}
+
+	record(r.WebPSuccess, r.WebPSize)
+	record(r.AVIFSuccess, r.AVIFSize)
+	record(r.JXLSuccess, r.JXLSize)
+	for _, output := range r.CustomOutputs {
+		record(output.Success, output.Size)
+	}
+
+	return smallest
+}
+
+// SucceededFormats は変換に成功した出力形式の一覧を返します（webp, avif, jxl、登録したエンコーダーの形式の順）
+func (r *ConversionResult) SucceededFormats() []string {
+	var formats []string
+	if r.WebPSuccess {
+		formats = append(formats, "webp")
+	}
+	if r.AVIFSuccess {
+		formats = append(formats, "avif")
+	}
+	if r.JXLSuccess {
+		formats = append(formats, "jxl")
+	}
+	for _, output := range r.CustomOutputs {
+		if output.Success {
+			formats = append(formats, output.Format)
+		}
+	}
+	return formats
+}
+
+// HasFailedFormat は変換を試行して失敗した出力形式があるかどうかを返します
+func (r *ConversionResult) HasFailedFormat() bool {
+	if (r.WebPAttempted && !r.WebPSuccess) || (r.AVIFAttempted && !r.AVIFSuccess) || (r.JXLAttempted && !r.JXLSuccess) {
+		return true
+	}
+	for _, output := range r.CustomOutputs {
+		if !output.Success {
+			return true
+		}
+	}
+	return false
+}
+
+// SucceededOutputPaths は変換に成功した出力ファイルのパスを SucceededFormats と同じ順序で返します
+func (r *ConversionResult) SucceededOutputPaths() []string {
+	var paths []string
+	if r.WebPSuccess {
+		paths = append(paths, r.WebPPath)
+	}
+	if r.AVIFSuccess {
+		paths = append(paths, r.AVIFPath)
+	}
+	if r.JXLSuccess {
+		paths = append(paths, r.JXLPath)
+	}
+	for _, output := range r.CustomOutputs {
+		if output.Success {
+			paths = append(paths, output.Path)
+		}
+	}
+	return paths
+}
+
+// TotalOutputSize は成功した変換結果のファイルサイズの合計を返します
+func (r *ConversionResult) TotalOutputSize() int64 {
+	var total int64
+	if r.WebPSuccess {
+		total += r.WebPSize
+	}
+	if r.AVIFSuccess {
+		total += r.AVIFSize
+	}
+	if r.JXLSuccess {
+		total += r.JXLSize
+	}
+	for _, output := range r.CustomOutputs {
+		if output.Success {
+			total += output.Size
+		}
+	}
+	return total
+}
+
+// recordSSIM は検証したSSIMを記録します（複数形式の場合は最も低い値を保持します）
+func (r *ConversionResult) recordSSIM(ssim float64) {
+	if ssim <= 0 {
+		return
+	}
+	if r.SSIM == 0 || ssim < r.SSIM {
+		r.SSIM = ssim
+	}
 }
 
 // ImageConverter は画像変換処理を提供します
 type ImageConverter struct {
 	config     *config.Config // ポインタとして設定
 	logManager *utils.LogManager
+	encoders   map[string]Encoder // 形式名ごとに差し込まれたエンコーダー
+
+	// デコードの同時実行数を制限するセマフォ（nilの場合は制限しない）
+	decodeSem chan struct{}
+
+	// 出力ファイルをまとめるZIPアーカイブ（nilの場合はファイルのまま出力する）
+	zipOutput *ZipOutput
 }
 
 // NewImageConverter は新しい画像変換インスタンスを作成します
@@ -47,6 +191,48 @@ func NewImageConverter(cfg *config.Config, logManager *utils.LogManager) *ImageC
 	}
 }
 
+// SetEncoder は出力形式名に対応するエンコーダーを差し込みます
+// 差し込まれていない形式は RegisterEncoder で登録されたエンコーダーを使用します
+func (ic *ImageConverter) SetEncoder(format string, enc Encoder) {
+	if ic.encoders == nil {
+		ic.encoders = make(map[string]Encoder)
+	}
+	ic.encoders[strings.ToLower(format)] = enc
+}
+
+// SetDecodeLimiter は画像のデコードの同時実行数を制限するセマフォを設定します
+// 複数の変換器で同じセマフォを共有すると、変換器をまたいで同時実行数を制限できます
+func (ic *ImageConverter) SetDecodeLimiter(sem chan struct{}) {
+	ic.decodeSem = sem
+}
+
+// SetZipOutput は出力ファイルを書き込むZIPアーカイブを設定します
+// 複数の変換器で同じアーカイブを共有できます
+func (ic *ImageConverter) SetZipOutput(z *ZipOutput) {
+	ic.zipOutput = z
+}
+
+// loadImageLimited はデコードの同時実行数の制限内で画像を読み込みます
+func (ic *ImageConverter) loadImageLimited(filePath string) (image.Image, error) {
+	// 上限を超える画像は同時デコード数の枠を待たずにスキップする
+	if err := checkDecodePixelsFile(filePath, ic.config.Conversion.MaxDecodePixels); err != nil {
+		return nil, err
+	}
+	if ic.decodeSem != nil {
+		ic.decodeSem <- struct{}{}
+		defer func() { <-ic.decodeSem }()
+	}
+	return loadImage(filePath)
+}
+
+// encode は差し込まれたエンコーダー、なければ登録されたエンコーダーで画像を保存します
+func (ic *ImageConverter) encode(format string, img image.Image, outputPath string, opts *EncodeOptions) (string, error) {
+	if enc, ok := ic.encoders[format]; ok {
+		return saveWithEncoder(enc, img, outputPath, opts)
+	}
+	return encodeAs(format, img, outputPath, opts)
+}
+
 // Service は画像変換サービスを表します
 type Service struct {
 	// 将来的な拡張のためのフィールドを追加できます
@@ -61,54 +247,221 @@ func NewService() *Service {
 }
 
 // Convert は画像を変換して結果を返します
+// Decode、Transform、Encode の各段階を順に実行し、最後に同一形式での再圧縮を行います
+// ZIPアーカイブが設定されている場合、出力ファイルはアーカイブに移動します
 func (ic *ImageConverter) Convert(filePath string) (*ConversionResult, error) {
+	// 入力画像の読み込み
+	img, info, err := ic.Decode(filePath)
+	if err != nil {
+		return nil, err
+	}
+
 	result := &ConversionResult{
 		OriginalPath: filePath,
+		OriginalSize: info.Size,
 	}
 
-	// 入力画像の読み込み
-	img, err := loadImage(filePath)
+	// 出力前の加工（元画像の再圧縮には適用しない）
+	outputImg, err := ic.Transform(img, sourceOrientation(ic.config, filePath))
 	if err != nil {
 		return nil, err
 	}
 
-	// パスの構築
-	baseFileName := strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath))
-	dir := filepath.Dir(filePath)
-
-	// WebP変換
-	if ic.config.Conversion.WebP.Enabled {
-		ic.processWebPConversion(img, dir, baseFileName, result)
+	// 出力形式ごとのエンコード
+	if err := ic.Encode(outputImg, result); err != nil {
+		return nil, err
 	}
 
-	// AVIF変換
-	if ic.config.Conversion.AVIF.Enabled {
-		ic.processAVIFConversion(img, dir, baseFileName, result)
+	// 同一形式での再圧縮
+	if ic.config.Conversion.Optimize.Enabled {
+		ic.processOptimizeConversion(img, filePath, result)
 	}
 
+	// 出力ファイルをZIPアーカイブにまとめる
+	ic.archiveOutputs(result)
+
 	return result, nil
 }
 
 // processWebPConversion はWebP形式への変換を処理します
-func (ic *ImageConverter) processWebPConversion(img image.Image, dir, baseFileName string, result *ConversionResult) {
-	webpPath := filepath.Join(dir, baseFileName+".webp")
+// exif、xmp が指定されている場合は変換後のファイルに埋め込みます
+func (ic *ImageConverter) processWebPConversion(img image.Image, names *outputNamer, exif, xmp []byte, opts *EncodeOptions, result *ConversionResult) {
+	webpPath := names.path(".webp", opts.qualityOr(0))
 	result.WebPPath = webpPath
 	result.WebPAttempted = true
 
 	// ドライランモードの場合は実際の変換をスキップ
 	if ic.config.Mode.DryRun {
-		ic.logManager.LogInfo("ドライラン: WebP変換対象: %s -> %s", baseFileName, webpPath)
+		ic.logManager.LogFileInfo("ドライラン: WebP変換対象: %s -> %s", names.name, webpPath)
 		return
 	}
 
 	// 実際の変換処理
-	if err := SaveWebP(img, webpPath); err != nil {
+	checksum, err := ic.encode("webp", img, webpPath, opts)
+	if err != nil {
 		ic.logManager.LogError("WebP変換に失敗しました: %v", err)
 		return
 	}
 
+	// 透過が引き継がれているかの確認
+	ic.checkAlphaPreserved("webp", img, webpPath)
+
+	// 可逆圧縮の場合は元画像と完全に一致するかの確認
+	if opts != nil && opts.Lossless {
+		ic.verifyLossless("webp", img, webpPath)
+	}
+
+	// SSIMによる画質の検証（基準未満の場合は画質を上げて再エンコード）
+	if ic.config.Conversion.VerifySSIM {
+		var ssim float64
+		ssim, checksum = ic.verifySSIM("webp", img, webpPath, opts, checksum)
+		result.recordSSIM(ssim)
+	}
+
+	// 出力サイズの上限の確認（上限を超える場合はこの形式を出力しない）
+	var keep bool
+	if checksum, keep = ic.limitOutputSize("webp", img, webpPath, opts, result.OriginalSize, checksum); !keep {
+		result.WebPAttempted = false
+		result.WebPPath = ""
+		return
+	}
+
+	// EXIFの埋め込み（失敗してもEXIFなしの変換結果として扱う）
+	if len(exif) > 0 {
+		if err := embedWebPEXIF(webpPath, exif, img.Bounds()); err != nil {
+			ic.logManager.LogWarning("WebPへのEXIFの埋め込みに失敗しました: %v", err)
+		} else {
+			// ファイル内容が変わったため、チェックサムはファイルから計算し直す
+			checksum = ""
+		}
+	}
+
+	// XMPの埋め込み（失敗してもXMPなしの変換結果として扱う）
+	if len(xmp) > 0 {
+		if err := embedWebPXMP(webpPath, xmp, img.Bounds()); err != nil {
+			ic.logManager.LogWarning("WebPへのXMPの埋め込みに失敗しました: %v", err)
+		} else {
+			checksum = ""
+		}
+	}
+
+	// 変換結果の確認
+	ic.validateWebPResult(webpPath, result)
+
+	// チェックサムファイルの生成
+	if result.WebPSuccess && ic.config.ChecksumsEnabled() {
+		result.WebPChecksum = ic.writeChecksum(webpPath, checksum)
+	}
+}
+
+// baseEncodeOptions は変換器の設定から決定した形式ごとのエンコードオプションを返します
+// 値はすべて決定済みとして扱うため、エンコーダーはグローバルな設定を参照しません
+func (ic *ImageConverter) baseEncodeOptions() map[string]*EncodeOptions {
+	conv := &ic.config.Conversion
+	return map[string]*EncodeOptions{
+		"webp": {Quality: conv.WebP.Quality, Method: conv.WebP.CompressionLevel, resolved: true},
+		"avif": {
+			Quality:           ic.config.AVIFNativeQuality(),
+			Speed:             conv.AVIF.Speed,
+			BitDepth:          conv.AVIF.BitDepth,
+			ChromaSubsampling: conv.AVIF.ChromaSubsampling,
+			resolved:          true,
+		},
+		"jxl": {Quality: conv.JXL.Quality, Effort: conv.JXL.Effort, resolved: true},
+	}
+}
+
+// encodeOptions は形式ごとのエンコードオプションを返します
+// 画質・圧縮方法・処理速度は変換器の設定（ディレクトリごとの上書きを含む）から取得し、自動調整が有効な場合は調整した画質を使用します
+func (ic *ImageConverter) encodeOptions(img image.Image, filePath string) map[string]*EncodeOptions {
+	opts := ic.baseEncodeOptions()
+	if !ic.config.Conversion.AdaptiveQuality {
+		return opts
+	}
+
+	// エッジ密度の計算は1画像につき1回だけ行う
+	density := edgeDensity(img)
+	for format, o := range opts {
+		o.Quality = adjustQuality(density, format, o.Quality)
+	}
+
+	ic.logManager.LogDebug("画質の自動調整 [%s]: エッジ密度 %.3f, WebP %d, AVIF %d, JPEG XL %d",
+		filePath, density, opts["webp"].Quality, opts["avif"].Quality, opts["jxl"].Quality)
+
+	return opts
+}
+
+// applyAutoLossless は conversion.webp.auto_lossless が有効な場合に、可逆圧縮に向く画像のWebPを可逆にします
+func (ic *ImageConverter) applyAutoLossless(img image.Image, filePath string, opts map[string]*EncodeOptions) {
+	if !ic.config.Conversion.WebP.AutoLossless {
+		return
+	}
+
+	lossless, reason := preferLosslessWebP(img, filePath)
+	if !lossless {
+		return
+	}
+
+	opts["webp"].Lossless = true
+	ic.logManager.LogDebug("可逆WebPでエンコードします [%s]: %s", filePath, reason)
+}
+
+// loadAnimation はアニメーションGIFの変換が有効な場合に全フレームを読み込みます
+// アニメーションでない場合や読み込みに失敗した場合はnilを返します
+func (ic *ImageConverter) loadAnimation(filePath string) *gif.GIF {
+	if !ic.config.Conversion.AnimatedGIF.Enabled || sourceExt(filePath) != ".gif" {
+		return nil
+	}
+
+	g, err := loadGIFFrames(filePath)
+	if err != nil {
+		ic.logManager.LogWarning("GIFフレームの読み込みに失敗したため静止画として変換します [%s]: %v", filePath, err)
+		return nil
+	}
+
+	if len(g.Image) <= 1 {
+		return nil
+	}
+
+	return g
+}
+
+// logStaticAnimation はアニメーション画像を静止画として変換する場合にデバッグログを出力します
+// アニメーションWebPに変換しない場合、出力は先頭フレームのみになります
+func (ic *ImageConverter) logStaticAnimation(filePath string) {
+	animated, err := imageutils.IsAnimated(filePath)
+	if err != nil || !animated {
+		return
+	}
+	ic.logManager.LogDebug("アニメーション画像ですが、先頭フレームのみを静止画として変換します: %s", filePath)
+}
+
+// processAnimatedWebPConversion はアニメーションGIFをアニメーションWebPに変換します
+func (ic *ImageConverter) processAnimatedWebPConversion(g *gif.GIF, names *outputNamer, result *ConversionResult) {
+	webpPath := names.path(".webp", ic.config.Conversion.WebP.Quality)
+	result.WebPPath = webpPath
+	result.WebPAttempted = true
+
+	// ドライランモードの場合は実際の変換をスキップ
+	if ic.config.Mode.DryRun {
+		ic.logManager.LogFileInfo("ドライラン: アニメーションWebP変換対象: %s -> %s (%dフレーム)", names.name, webpPath, len(g.Image))
+		return
+	}
+
+	// 実際の変換処理
+	delays := animationDelays(g, ic.config.Conversion.AnimatedGIF.PreserveDelay)
+	if err := saveAnimatedWebP(g.Image, delays, webpPath, ic.baseEncodeOptions()["webp"]); err != nil {
+		ic.logManager.LogError("アニメーションWebP変換に失敗しました: %v", err)
+		return
+	}
+
 	// 変換結果の確認
 	ic.validateWebPResult(webpPath, result)
+
+	// チェックサムファイルの生成（外部コマンドが書き込むためファイルから計算する）
+	if result.WebPSuccess && ic.config.ChecksumsEnabled() {
+		result.WebPChecksum = ic.writeChecksum(webpPath, "")
+	}
 }
 
 // validateWebPResult はWebP変換結果を確認します
@@ -122,32 +475,187 @@ func (ic *ImageConverter) validateWebPResult(webpPath string, result *Conversion
 	if fi.Size() > 0 {
 		result.WebPSuccess = true
 		result.WebPSize = fi.Size()
-		ic.logManager.LogInfo("WebP変換成功: %s (サイズ: %d バイト)", webpPath, fi.Size())
+		ic.logManager.LogFileInfo("WebP変換成功: %s (サイズ: %d バイト)", webpPath, fi.Size())
 	} else {
 		ic.logManager.LogWarning("WebP変換結果が0バイトです: %s", webpPath)
 	}
 }
 
 // processAVIFConversion はAVIF形式への変換を処理します
-func (ic *ImageConverter) processAVIFConversion(img image.Image, dir, baseFileName string, result *ConversionResult) {
-	avifPath := filepath.Join(dir, baseFileName+".avif")
+func (ic *ImageConverter) processAVIFConversion(img image.Image, names *outputNamer, opts *EncodeOptions, result *ConversionResult) {
+	avifPath := names.path(".avif", opts.qualityOr(0))
 	result.AVIFPath = avifPath
 	result.AVIFAttempted = true
 
 	// ドライランモードの場合は実際の変換をスキップ
 	if ic.config.Mode.DryRun {
-		ic.logManager.LogInfo("ドライラン: AVIF変換対象: %s -> %s", baseFileName, avifPath)
+		ic.logManager.LogFileInfo("ドライラン: AVIF変換対象: %s -> %s", names.name, avifPath)
 		return
 	}
 
 	// 実際の変換処理
-	if err := SaveAVIF(img, avifPath); err != nil {
+	checksum, err := ic.encode("avif", img, avifPath, opts)
+	if err != nil {
 		ic.logManager.LogError("AVIF変換に失敗しました: %v", err)
 		return
 	}
 
+	// 透過が引き継がれているかの確認
+	ic.checkAlphaPreserved("avif", img, avifPath)
+
+	// SSIMによる画質の検証（基準未満の場合は画質を上げて再エンコード）
+	if ic.config.Conversion.VerifySSIM {
+		var ssim float64
+		ssim, checksum = ic.verifySSIM("avif", img, avifPath, opts, checksum)
+		result.recordSSIM(ssim)
+	}
+
+	// 出力サイズの上限の確認（上限を超える場合はこの形式を出力しない）
+	var keep bool
+	if checksum, keep = ic.limitOutputSize("avif", img, avifPath, opts, result.OriginalSize, checksum); !keep {
+		result.AVIFAttempted = false
+		result.AVIFPath = ""
+		return
+	}
+
 	// 変換結果の確認
 	ic.validateAVIFResult(avifPath, result)
+
+	// チェックサムファイルの生成
+	if result.AVIFSuccess && ic.config.ChecksumsEnabled() {
+		result.AVIFChecksum = ic.writeChecksum(avifPath, checksum)
+	}
+}
+
+// processJXLConversion はJPEG XL形式への変換を処理します
+func (ic *ImageConverter) processJXLConversion(img image.Image, names *outputNamer, opts *EncodeOptions, result *ConversionResult) {
+	jxlPath := names.path(".jxl", opts.qualityOr(0))
+	result.JXLPath = jxlPath
+	result.JXLAttempted = true
+
+	// ドライランモードの場合は実際の変換をスキップ
+	if ic.config.Mode.DryRun {
+		ic.logManager.LogFileInfo("ドライラン: JPEG XL変換対象: %s -> %s", names.name, jxlPath)
+		return
+	}
+
+	// 実際の変換処理
+	checksum, err := ic.encode("jxl", img, jxlPath, opts)
+	if err != nil {
+		ic.logManager.LogError("JPEG XL変換に失敗しました: %v", err)
+		return
+	}
+
+	// 出力サイズの上限の確認（上限を超える場合はこの形式を出力しない）
+	var keep bool
+	if checksum, keep = ic.limitOutputSize("jxl", img, jxlPath, opts, result.OriginalSize, checksum); !keep {
+		result.JXLAttempted = false
+		result.JXLPath = ""
+		return
+	}
+
+	// 変換結果の確認
+	ic.validateJXLResult(jxlPath, result)
+
+	// チェックサムファイルの生成
+	if result.JXLSuccess && ic.config.ChecksumsEnabled() {
+		result.JXLChecksum = ic.writeChecksum(jxlPath, checksum)
+	}
+}
+
+// validateJXLResult はJPEG XL変換結果を確認します
+// JPEG XLはGoの標準デコーダーがないため、マジックバイトで整合性を確認します
+func (ic *ImageConverter) validateJXLResult(jxlPath string, result *ConversionResult) {
+	fi, err := os.Stat(jxlPath)
+	if err != nil {
+		ic.logManager.LogError("JPEG XL出力ファイル検証エラー: %v", err)
+		return
+	}
+
+	if fi.Size() == 0 {
+		ic.logManager.LogWarning("JPEG XL変換結果が0バイトです: %s", jxlPath)
+		return
+	}
+
+	if err := imageutils.CheckMagicBytes(jxlPath); err != nil {
+		os.Remove(jxlPath)
+		ic.logManager.LogWarning("JPEG XL変換結果が破損しています: %s", jxlPath)
+		return
+	}
+
+	result.JXLSuccess = true
+	result.JXLSize = fi.Size()
+	ic.logManager.LogFileInfo("JPEG XL変換成功: %s (サイズ: %d バイト)", jxlPath, fi.Size())
+}
+
+// processCustomConversion は組み込み以外の登録済みエンコーダーによる変換を処理します
+func (ic *ImageConverter) processCustomConversion(format string, img image.Image, names *outputNamer, result *ConversionResult) {
+	entry, ok := lookupEncoder(format)
+	if !ok {
+		return
+	}
+
+	output := CustomOutputResult{
+		Format: format,
+		Path:   names.path(entry.ext, 0),
+	}
+	defer func() {
+		result.CustomOutputs = append(result.CustomOutputs, output)
+	}()
+
+	// ドライランモードの場合は実際の変換をスキップ
+	if ic.config.Mode.DryRun {
+		ic.logManager.LogFileInfo("ドライラン: %s変換対象: %s -> %s", format, names.name, output.Path)
+		return
+	}
+
+	checksum, err := entry.encode(img, output.Path, nil)
+	if err != nil {
+		ic.logManager.LogError("%s変換に失敗しました: %v", format, err)
+		return
+	}
+
+	// 変換結果の確認（サイズとマジックバイト）
+	fi, err := os.Stat(output.Path)
+	if err != nil || fi.Size() == 0 {
+		ic.logManager.LogWarning("%s変換結果が0バイトです: %s", format, output.Path)
+		return
+	}
+	if err := imageutils.CheckMagicBytes(output.Path); err != nil {
+		os.Remove(output.Path)
+		ic.logManager.LogWarning("%s変換結果が破損しています: %s", format, output.Path)
+		return
+	}
+
+	output.Success = true
+	output.Size = fi.Size()
+	ic.logManager.LogFileInfo("%s変換成功: %s (サイズ: %d バイト)", format, output.Path, fi.Size())
+
+	// チェックサムファイルの生成
+	if ic.config.ChecksumsEnabled() {
+		output.Checksum = ic.writeChecksum(output.Path, checksum)
+	}
+}
+
+// writeChecksum はチェックサムのサイドカーファイルを書き込み、書き込めた場合はチェックサムを返します
+// checksum が空の場合はファイル内容から計算します
+func (ic *ImageConverter) writeChecksum(outputPath, checksum string) string {
+	if checksum == "" {
+		var err error
+		if checksum, err = fileChecksum(outputPath); err != nil {
+			ic.logManager.LogError("チェックサムの計算に失敗しました [%s]: %v", outputPath, err)
+			return ""
+		}
+	}
+
+	checksumPath, err := WriteChecksumFile(outputPath, checksum)
+	if err != nil {
+		ic.logManager.LogError("チェックサムファイルの生成に失敗しました [%s]: %v", outputPath, err)
+		return ""
+	}
+
+	ic.logManager.LogFileInfo("チェックサムファイルを生成しました: %s", checksumPath)
+	return checksum
 }
 
 // validateAVIFResult はAVIF変換結果を確認します
@@ -163,7 +671,7 @@ func (ic *ImageConverter) validateAVIFResult(avifPath string, result *Conversion
 		if valid {
 			result.AVIFSuccess = true
 			result.AVIFSize = fi.Size()
-			ic.logManager.LogInfo("AVIF変換成功: %s (サイズ: %d バイト)", avifPath, fi.Size())
+			ic.logManager.LogFileInfo("AVIF変換成功: %s (サイズ: %d バイト)", avifPath, fi.Size())
 		} else {
 			os.Remove(avifPath)
 			ic.logManager.LogWarning("AVIF変換結果が破損しています: %s", avifPath)
@@ -173,37 +681,174 @@ func (ic *ImageConverter) validateAVIFResult(avifPath string, result *Conversion
 	}
 }
 
+// processOptimizeConversion はJPEG/PNGを同一形式のまま再圧縮します
+func (ic *ImageConverter) processOptimizeConversion(img image.Image, filePath string, result *ConversionResult) {
+	ext := strings.ToLower(filepath.Ext(filePath))
+	if ext != ".jpg" && ext != ".jpeg" && ext != ".png" {
+		// JPEG/PNG以外は再圧縮の対象外
+		return
+	}
+
+	optPath := OptimizedPath(filePath)
+	result.OptimizedPath = optPath
+	if ic.config.Conversion.Optimize.Overwrite {
+		result.OptimizedPath = filePath
+	}
+	result.OptimizeAttempted = true
+
+	// ドライランモードの場合は実際の変換をスキップ
+	if ic.config.Mode.DryRun {
+		ic.logManager.LogFileInfo("ドライラン: 再圧縮対象: %s -> %s", filePath, result.OptimizedPath)
+		return
+	}
+
+	// 上書きモードでは元ファイルより小さくなる場合のみ置き換える
+	if ic.config.Conversion.Optimize.Overwrite {
+		ic.overwriteOptimized(img, filePath, ext, result)
+		return
+	}
+
+	// 実際の変換処理（一時ファイルに書き込み、成功した場合のみ出力先に置き換える）
+	var err error
+	if ext == ".png" {
+		err = SaveOptimizedPNG(img, optPath, ic.config.Conversion.Optimize.PNGCompressionLevel)
+	} else {
+		err = SaveOptimizedJPEG(img, optPath, ic.config.Conversion.Optimize.JPEGQuality)
+	}
+	if err != nil {
+		ic.logManager.LogError("再圧縮に失敗しました: %v", err)
+		return
+	}
+
+	// 変換結果の確認
+	ic.validateOptimizeResult(optPath, result)
+}
+
+// validateOptimizeResult は再圧縮結果のファイルを確認し、変換結果に記録します
+func (ic *ImageConverter) validateOptimizeResult(optPath string, result *ConversionResult) {
+	fi, err := os.Stat(optPath)
+	if err != nil {
+		ic.logManager.LogError("再圧縮出力ファイル検証エラー: %v", err)
+		return
+	}
+
+	if fi.Size() == 0 {
+		os.Remove(optPath)
+		ic.logManager.LogWarning("再圧縮結果が0バイトです: %s", optPath)
+		return
+	}
+
+	result.OptimizeSuccess = true
+	result.OptimizedSize = fi.Size()
+	ic.logManager.LogFileInfo("再圧縮成功: %s (サイズ: %d バイト)", optPath, fi.Size())
+}
+
+// overwriteOptimized は画像を再圧縮し、元ファイルより小さくなる場合のみ元ファイルを置き換えます
+// 再圧縮結果はメモリ上で元ファイルのサイズと比較し、置き換えは一時ファイルへの書き込みと名前の変更で行うため、失敗しても元ファイルは壊れません
+func (ic *ImageConverter) overwriteOptimized(img image.Image, filePath, ext string, result *ConversionResult) {
+	var encoded bytes.Buffer
+	var err error
+	if ext == ".png" {
+		err = encodeOptimizedPNG(&encoded, img, ic.config.Conversion.Optimize.PNGCompressionLevel)
+	} else {
+		err = encodeOptimizedJPEG(&encoded, img, ic.config.Conversion.Optimize.JPEGQuality)
+	}
+	if err != nil {
+		ic.logManager.LogError("再圧縮に失敗しました: %v", err)
+		return
+	}
+	if encoded.Len() == 0 {
+		ic.logManager.LogWarning("再圧縮結果が0バイトです: %s", filePath)
+		return
+	}
+
+	original, err := os.Stat(filePath)
+	if err != nil {
+		ic.logManager.LogError("元ファイルの情報取得に失敗しました: %v", err)
+		return
+	}
+
+	optimizedSize := int64(encoded.Len())
+	if optimizedSize >= original.Size() {
+		result.OptimizeSuccess = true
+		result.OptimizedSize = original.Size()
+		ic.logManager.LogFileInfo("再圧縮しても小さくならないため元ファイルを維持します: %s", filePath)
+		return
+	}
+
+	if err := writeFileAtomic(filePath, func(w io.Writer) error {
+		_, err := encoded.WriteTo(w)
+		return err
+	}); err != nil {
+		ic.logManager.LogError("元ファイルの置き換えに失敗しました: %v", err)
+		return
+	}
+
+	result.OptimizeSuccess = true
+	result.OptimizedSize = optimizedSize
+	ic.logManager.LogFileInfo("再圧縮成功（上書き）: %s (サイズ: %d -> %d バイト)", filePath, original.Size(), optimizedSize)
+}
+
 // ConvertImage は画像をWebPとAVIFに変換します
 func (s *Service) ConvertImage(filePath string) error {
+	_, err := s.ConvertImageResult(filePath)
+	return err
+}
+
+// ConvertImageResult は画像を変換し、出力ファイルのパスを記録した変換結果を返します
+// 出力ファイル名は output.filename_template に従います。エラーの場合も、それまでに決定した出力パスを記録した変換結果を返します
+func (s *Service) ConvertImageResult(filePath string) (*ConversionResult, error) {
+	result := &ConversionResult{OriginalPath: filePath}
+	cfg := config.GetConfig()
+
 	// 入力画像の読み込み
+	if err := checkDecodePixelsFile(filePath, cfg.Conversion.MaxDecodePixels); err != nil {
+		return result, err
+	}
 	img, err := loadImage(filePath)
 	if err != nil {
-		return err
+		return result, err
 	}
 
-	// パスの構築
-	baseFileName := strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath))
-	dir := filepath.Dir(filePath)
+	// リサイズとEXIFの向きの補正（ImageConverter.Transform と同じ寸法にする）
+	img = resizeAndOrient(&cfg, img, sourceOrientation(&cfg, filePath))
+
+	// パスの構築（ファイル名テンプレートの寸法は加工後の画像から取得する）
+	names := newOutputNamer(cfg.Output.FilenameTemplate, filePath, img.Bounds())
 
 	// WebP変換
-	if err := s.convertToWebP(img, dir, baseFileName); err != nil {
-		return err
+	if config.IsWebPEnabled() {
+		result.WebPPath = names.path(".webp", configuredQuality(&cfg, "webp"))
+		if err := s.convertToWebP(img, result.WebPPath); err != nil {
+			return result, err
+		}
 	}
 
 	// AVIF変換
-	if err := s.convertToAVIF(img, dir, baseFileName); err != nil {
-		return err
+	if config.IsAVIFEnabled() {
+		result.AVIFPath = names.path(".avif", configuredQuality(&cfg, "avif"))
+		if err := s.convertToAVIF(img, result.AVIFPath); err != nil {
+			return result, err
+		}
+	}
+
+	// JPEG XL変換
+	if config.IsJXLEnabled() {
+		result.JXLPath = names.path(".jxl", configuredQuality(&cfg, "jxl"))
+		if err := s.convertToJXL(img, result.JXLPath); err != nil {
+			return result, err
+		}
 	}
 
 	log.Printf("変換処理完了: %s", filePath)
-	return nil
+	return result, nil
 }
 
 // loadImage は画像を読み込んでデコードします
 func loadImage(filePath string) (image.Image, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
-		return nil, fmt.Errorf("ファイルを開けません: %v", err)
+		return nil, fmt.Errorf("ファイルを開けません: %w", err)
 	}
 	defer file.Close()
 
@@ -214,47 +859,111 @@ func loadImage(filePath string) (image.Image, error) {
 	}
 
 	// 大きすぎるファイルは処理しない（例: 20MB以上）
-	if fi.Size() > 20*1024*1024 {
+	if fi.Size() > maxInputSize {
 		return nil, fmt.Errorf("ファイルサイズが大きすぎます (%d バイト)", fi.Size())
 	}
 
-	ext := strings.ToLower(filepath.Ext(filePath))
-	var img image.Image
+	ext := sourceExt(filePath)
+	img, err := decodeImage(file, ext)
+	if err != nil {
+		return nil, err
+	}
+
+	// ガンマ値が指定されたPNGはsRGBに揃えてからエンコードする
+	if ext == ".png" {
+		if _, err := file.Seek(0, io.SeekStart); err == nil {
+			img = normalizePNGGamma(img, file, filePath)
+		}
+	}
 
-	switch ext {
-	case ".jpg", ".jpeg":
-		img, err = jpeg.Decode(file)
-	case ".png":
-		img, err = png.Decode(file)
-	case ".heic", ".heif":
-		img, err = goheif.Decode(file)
-	default:
-		return nil, fmt.Errorf("サポートされていない画像形式です: %s", ext)
+	// CMYKのJPEGはRGBに変換してからエンコードする
+	return normalizeCMYK(img, filePath), nil
+}
+
+// sourceExt はデコーダーの選択に使用する拡張子を返します
+// 拡張子とマジックバイトの形式が一致しない場合は、検出した形式のデコーダーがあればその拡張子を返します
+// （imageutils.IsValidFile は拡張子の付け間違いを検出した形式として扱うため、デコードもそれに合わせる）
+func sourceExt(filePath string) string {
+	ext := normalizeExt(filepath.Ext(filePath))
+	detected, err := imageutils.DetectFormat(filePath)
+	if err != nil || detected == imageutils.FormatUnknown || detected == imageutils.GetFormatFromExt(ext) {
+		return ext
+	}
+
+	detectedExt := imageutils.GetExtFromFormat(detected)
+	if _, ok := lookupDecoder(detectedExt); !ok {
+		return ext
+	}
+	log.Printf("拡張子と内容の形式が一致しないため、%s としてデコードします: %s", detected, filePath)
+	return detectedExt
+}
+
+// maxInputSize は処理する入力画像の最大バイト数です
+const maxInputSize = 20 * 1024 * 1024
+
+// checkDecodePixels は画像のヘッダーから寸法を読み取り、画素数が limit を超える場合は ErrTooManyPixels を返します
+// Goのデコーダーは縮小しながらのデコード（JPEGのDCTスケーリングなど）に対応していないため、上限を超える画像はデコードしません
+// limit が0以下の場合や、ヘッダーから寸法を取得できない形式の場合は確認しません
+func checkDecodePixels(r io.Reader, limit int64) error {
+	if limit <= 0 {
+		return nil
 	}
 
+	imgConfig, _, err := image.DecodeConfig(r)
 	if err != nil {
-		return nil, fmt.Errorf("画像のデコードに失敗しました: %v", err)
+		return nil
 	}
 
-	return img, nil
+	pixels := int64(imgConfig.Width) * int64(imgConfig.Height)
+	if pixels > limit {
+		return fmt.Errorf("%w: %dx%d (%d 画素、上限: %d 画素)", ErrTooManyPixels, imgConfig.Width, imgConfig.Height, pixels, limit)
+	}
+	return nil
 }
 
-// convertToWebP は画像をWebP形式に変換します
-// このメソッドはwebp.goで実装される具体的な変換処理を呼び出します
-func (s *Service) convertToWebP(img image.Image, dir, baseFileName string) error {
-	if !config.IsWebPEnabled() {
+// checkDecodePixelsFile はファイルの画素数が limit を超えていないかを確認します
+// ファイルを開けない場合は確認せず、読み込み時のエラーに任せます
+func checkDecodePixelsFile(filePath string, limit int64) error {
+	if limit <= 0 {
+		return nil
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
 		return nil
 	}
+	defer file.Close()
 
-	webpPath := filepath.Join(dir, baseFileName+".webp")
+	return checkDecodePixels(file, limit)
+}
+
+// decodeImage は拡張子に対応する登録済みデコーダーで画像をデコードします
+func decodeImage(r io.Reader, ext string) (image.Image, error) {
+	ext = normalizeExt(ext)
+	decode, ok := lookupDecoder(ext)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, ext)
+	}
 
+	img, err := decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDecodeFailed, err)
+	}
+
+	return img, nil
+}
+
+// convertToWebP は画像をWebP形式に変換します
+// このメソッドはwebp.goで実装される具体的な変換処理を呼び出します
+func (s *Service) convertToWebP(img image.Image, webpPath string) error {
 	// ドライランモードではスキップ
 	if config.IsDryRun() {
 		log.Printf("ドライラン: WebP変換のスキップ")
 		return nil
 	}
 
-	if err := SaveWebP(img, webpPath); err != nil {
+	checksum, err := SaveWebPWithChecksum(img, webpPath)
+	if err != nil {
 		log.Printf("WebP変換に失敗しました: %v", err)
 		return err
 	}
@@ -262,6 +971,7 @@ func (s *Service) convertToWebP(img image.Image, dir, baseFileName string) error
 	// ファイルサイズをチェック
 	if fi, err := os.Stat(webpPath); err == nil && fi.Size() > 0 {
 		log.Printf("WebP変換成功: %s (サイズ: %d バイト)", webpPath, fi.Size())
+		s.writeChecksum(webpPath, checksum)
 		return nil
 	}
 
@@ -271,20 +981,15 @@ func (s *Service) convertToWebP(img image.Image, dir, baseFileName string) error
 
 // convertToAVIF は画像をAVIF形式に変換します
 // このメソッドはavif.goで実装される具体的な変換処理を呼び出します
-func (s *Service) convertToAVIF(img image.Image, dir, baseFileName string) error {
-	if !config.IsAVIFEnabled() {
-		return nil
-	}
-
-	avifPath := filepath.Join(dir, baseFileName+".avif")
-
+func (s *Service) convertToAVIF(img image.Image, avifPath string) error {
 	// ドライランモードではスキップ
 	if config.IsDryRun() {
-		log.Printf("ドライラン: AVIF変換対象: %s -> %s", baseFileName, avifPath)
+		log.Printf("ドライラン: AVIF変換対象: %s", avifPath)
 		return nil
 	}
 
-	if err := SaveAVIF(img, avifPath); err != nil {
+	checksum, err := SaveAVIFWithChecksum(img, avifPath)
+	if err != nil {
 		log.Printf("AVIF変換に失敗しました: %v", err)
 		return err
 	}
@@ -293,6 +998,7 @@ func (s *Service) convertToAVIF(img image.Image, dir, baseFileName string) error
 	valid, fileSize := imageutils.IsValidFile(avifPath)
 	if valid {
 		log.Printf("AVIF変換成功: %s (サイズ: %d バイト)", avifPath, fileSize)
+		s.writeChecksum(avifPath, checksum)
 		return nil
 	}
 
@@ -302,72 +1008,138 @@ func (s *Service) convertToAVIF(img image.Image, dir, baseFileName string) error
 	return fmt.Errorf("AVIF変換後のファイルが無効です")
 }
 
+// convertToJXL は画像をJPEG XL形式に変換します
+// このメソッドはjxl.goで実装される具体的な変換処理を呼び出します
+func (s *Service) convertToJXL(img image.Image, jxlPath string) error {
+	// ドライランモードではスキップ
+	if config.IsDryRun() {
+		log.Printf("ドライラン: JPEG XL変換対象: %s", jxlPath)
+		return nil
+	}
+
+	if err := SaveJXL(img, jxlPath); err != nil {
+		log.Printf("JPEG XL変換に失敗しました: %v", err)
+		return err
+	}
+
+	// ファイルサイズと整合性をチェック
+	valid, fileSize := imageutils.IsValidFile(jxlPath)
+	if valid && imageutils.CheckMagicBytes(jxlPath) == nil {
+		log.Printf("JPEG XL変換成功: %s (サイズ: %d バイト)", jxlPath, fileSize)
+		s.writeChecksum(jxlPath, "")
+		return nil
+	}
+
+	log.Printf("警告: JPEG XL変換結果が無効です: %s", jxlPath)
+	// 無効なファイルを削除
+	os.Remove(jxlPath)
+	return fmt.Errorf("JPEG XL変換後のファイルが無効です")
+}
+
+// writeChecksum はチェックサムが有効な場合にサイドカーファイルを書き込みます
+// checksum が空の場合はファイル内容から計算します。失敗しても変換自体は成功として扱います
+func (s *Service) writeChecksum(outputPath, checksum string) {
+	if !config.IsChecksumEnabled() {
+		return
+	}
+
+	if checksum == "" {
+		var err error
+		if checksum, err = fileChecksum(outputPath); err != nil {
+			log.Printf("警告: チェックサムの計算に失敗しました [%s]: %v", outputPath, err)
+			return
+		}
+	}
+
+	if _, err := WriteChecksumFile(outputPath, checksum); err != nil {
+		log.Printf("警告: %v", err)
+	}
+}
+
 // CheckConversionResults は変換結果をチェックし、統計情報を更新します
+// 出力ファイルのパスは output.filename_template から求めます
 func (s *Service) CheckConversionResults(file string, stats *config.ConversionStats) {
-	ext := filepath.Ext(file)
-	baseName := strings.TrimSuffix(file, ext)
-	dir := filepath.Dir(file)
+	cfg := config.GetConfig()
+	paths := OutputPaths(&cfg, file)
 
 	// WebPファイルのチェック
-	if config.IsWebPEnabled() {
-		s.checkWebPResult(dir, baseName, stats)
+	if path, ok := paths["webp"]; ok {
+		s.checkWebPResult(path, stats)
 	}
 
 	// AVIFファイルのチェック
-	if config.IsAVIFEnabled() {
-		s.checkAVIFResult(dir, baseName, stats)
+	if path, ok := paths["avif"]; ok {
+		s.checkAVIFResult(path, stats)
+	}
+
+	// JPEG XLファイルのチェック
+	if path, ok := paths["jxl"]; ok {
+		s.checkJXLResult(path, stats)
 	}
 }
 
 // checkWebPResult はWebP変換結果をチェックします
-func (s *Service) checkWebPResult(dir, baseName string, stats *config.ConversionStats) {
-	webpPath := filepath.Join(dir, baseName+".webp")
+func (s *Service) checkWebPResult(webpPath string, stats *config.ConversionStats) {
 	if fi, err := os.Stat(webpPath); err == nil && fi.Size() > 0 {
-		stats.WebPSuccess++
+		stats.WebPSuccess.Add(1)
 		log.Printf("WebP変換成功: %s (サイズ: %d バイト)", webpPath, fi.Size())
 	} else if err == nil {
-		stats.WebPFailed++
+		stats.WebPFailed.Add(1)
 		log.Printf("警告: WebP変換結果が0バイトです: %s", webpPath)
 	}
 }
 
 // checkAVIFResult はAVIF変換結果をチェックします
-func (s *Service) checkAVIFResult(dir, baseName string, stats *config.ConversionStats) {
-	avifPath := filepath.Join(dir, baseName+".avif")
+func (s *Service) checkAVIFResult(avifPath string, stats *config.ConversionStats) {
 	if fi, err := os.Stat(avifPath); err == nil && fi.Size() > 0 {
 		// ファイルの整合性チェック
 		if imageutils.IsValidImage(avifPath) {
-			stats.AVIFSuccess++
+			stats.AVIFSuccess.Add(1)
 			log.Printf("AVIF変換成功: %s (サイズ: %d バイト)", avifPath, fi.Size())
 		} else {
-			stats.AVIFFailed++
+			stats.AVIFFailed.Add(1)
 			log.Printf("警告: AVIF変換結果が破損しています: %s", avifPath)
 			// 破損ファイルを削除
 			os.Remove(avifPath)
 		}
 	} else if err == nil {
-		stats.AVIFFailed++
+		stats.AVIFFailed.Add(1)
 		log.Printf("警告: AVIF変換結果が0バイトです: %s", avifPath)
 		// 0バイトファイルを削除
 		os.Remove(avifPath)
 	}
 }
 
-// CleanupFiles は処理済みのファイルを削除します
-func (s *Service) CleanupFiles(localPath, baseName string) {
-	// 元ファイルを削除
-	os.Remove(localPath)
-
-	// 変換後のファイルを削除
-	dir := filepath.Dir(localPath)
-
-	webpPath := filepath.Join(dir, baseName+".webp")
-	if _, err := os.Stat(webpPath); err == nil {
-		os.Remove(webpPath)
+// checkJXLResult はJPEG XL変換結果をチェックします
+func (s *Service) checkJXLResult(jxlPath string, stats *config.ConversionStats) {
+	if fi, err := os.Stat(jxlPath); err == nil && fi.Size() > 0 {
+		// ファイルの整合性チェック
+		if imageutils.CheckMagicBytes(jxlPath) == nil {
+			stats.JXLSuccess.Add(1)
+			log.Printf("JPEG XL変換成功: %s (サイズ: %d バイト)", jxlPath, fi.Size())
+		} else {
+			stats.JXLFailed.Add(1)
+			log.Printf("警告: JPEG XL変換結果が破損しています: %s", jxlPath)
+			// 破損ファイルを削除
+			os.Remove(jxlPath)
+		}
+	} else if err == nil {
+		stats.JXLFailed.Add(1)
+		log.Printf("警告: JPEG XL変換結果が0バイトです: %s", jxlPath)
+		// 0バイトファイルを削除
+		os.Remove(jxlPath)
 	}
+}
 
-	avifPath := filepath.Join(dir, baseName+".avif")
-	if _, err := os.Stat(avifPath); err == nil {
-		os.Remove(avifPath)
+// CleanupFiles は処理済みの元ファイルと変換後のファイルを削除します
+// 変換後のファイルのパスは output.filename_template から求めるため、元ファイルより先に削除します
+func (s *Service) CleanupFiles(localPath string) {
+	cfg := config.GetConfig()
+	for _, path := range OutputPaths(&cfg, localPath) {
+		os.Remove(path)
+		os.Remove(path + checksumExt)
 	}
+
+	// 元ファイルを削除
+	os.Remove(localPath)
 }
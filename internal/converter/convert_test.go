@@ -0,0 +1,67 @@
+package converter
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckDecodePixels(t *testing.T) {
+	png64x48 := encodeTestImage(t, ".png", 64, 48)
+
+	tests := []struct {
+		name    string
+		data    []byte
+		limit   int64
+		wantErr bool
+	}{
+		{name: "制限なし", data: png64x48, limit: 0, wantErr: false},
+		{name: "上限と同じ画素数", data: png64x48, limit: 64 * 48, wantErr: false},
+		{name: "上限を超える", data: png64x48, limit: 64*48 - 1, wantErr: true},
+		{name: "JPEGも判定する", data: encodeTestImage(t, ".jpg", 64, 48), limit: 1000, wantErr: true},
+		{name: "寸法を取得できない", data: []byte("not an image"), limit: 1, wantErr: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkDecodePixels(bytes.NewReader(tt.data), tt.limit)
+			if tt.wantErr && !errors.Is(err, ErrTooManyPixels) {
+				t.Errorf("checkDecodePixels = %v, ErrTooManyPixels を期待しました", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("checkDecodePixels = %v, want nil", err)
+			}
+		})
+	}
+}
+
+func TestSourceExt(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name string
+		file string
+		data []byte
+		want string
+	}{
+		{name: "拡張子と一致する", file: "photo.png", data: encodeTestImage(t, ".png", 4, 4), want: ".png"},
+		{name: "大文字の拡張子", file: "photo.JPG", data: encodeTestImage(t, ".jpg", 4, 4), want: ".jpg"},
+		{name: "JPEGの拡張子のPNG", file: "misnamed.jpg", data: encodeTestImage(t, ".png", 4, 4), want: ".png"},
+		{name: "PNGの拡張子のJPEG", file: "misnamed.png", data: encodeTestImage(t, ".jpg", 4, 4), want: ".jpg"},
+		{name: "判定できない内容", file: "unknown.png", data: []byte("not an image"), want: ".png"},
+		{name: "デコーダーのない形式", file: "image.png", data: []byte("RIFF\x00\x00\x00\x00WEBPVP8 "), want: ".png"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.file)
+			if err := os.WriteFile(path, tt.data, 0644); err != nil {
+				t.Fatalf("入力ファイルの作成に失敗しました: %v", err)
+			}
+			if got := sourceExt(path); got != tt.want {
+				t.Errorf("sourceExt(%s) = %q, want %q", tt.file, got, tt.want)
+			}
+		})
+	}
+}
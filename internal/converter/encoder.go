@@ -0,0 +1,130 @@
+/*
+Package converter の一部として、画像を書き込み先にエンコードするエンコーダーを提供します。
+*/
+package converter
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"image"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+
+	"github.com/223n/image-converter/internal/config"
+	"github.com/223n/image-converter/pkg/imageutils"
+	"github.com/Kagami/go-avif"
+)
+
+// Encoder は画像を特定の形式で書き込み先にエンコードします
+// ImageConverter に差し込むことで、ファイルの保存先や統計の処理をエンコードと切り離して扱えます
+type Encoder interface {
+	Encode(img image.Image, w io.Writer, opts *EncodeOptions) error
+}
+
+// webpEncoder は設定に従ってWebPにエンコードします
+type webpEncoder struct{}
+
+// Encode は画像をWebPとして書き込みます
+// opts.ICCProfile が指定されている場合は、エンコード結果にICCPチャンクを追加して書き込みます
+func (webpEncoder) Encode(img image.Image, w io.Writer, opts *EncodeOptions) error {
+	quality, method := opts.webpSettings()
+	lossless := opts != nil && opts.Lossless
+	if opts == nil || len(opts.ICCProfile) == 0 {
+		return encodeWebP(img, w, float32(min(quality, 100)), lossless, min(method, 6))
+	}
+
+	var buf bytes.Buffer
+	if err := encodeWebP(img, &buf, float32(min(quality, 100)), lossless, min(method, 6)); err != nil {
+		return err
+	}
+	data, err := addWebPChunk(buf.Bytes(), webpChunk{fourCC: "ICCP", payload: opts.ICCProfile}, webpVP8XFlagICC, img.Bounds())
+	if err != nil {
+		return fmt.Errorf("ICCプロファイルの埋め込みに失敗しました: %v", err)
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// avifEncoder は設定に従ってAVIFにエンコードします
+type avifEncoder struct{}
+
+// Encode は画像をAVIFとして書き込みます
+// opts.ICCProfile、opts.XMP が指定されている場合は埋め込みます
+func (avifEncoder) Encode(img image.Image, w io.Writer, opts *EncodeOptions) error {
+	var icc, xmp []byte
+	if opts != nil {
+		icc, xmp = opts.ICCProfile, opts.XMP
+	}
+	return encodeAVIF(img, w, opts.avifSettings(), icc, xmp)
+}
+
+// encodeAVIF は画像をAVIFとして書き込みます。icc、xmp が指定されている場合は埋め込みます
+// opts の値はそのまま使用するため、未指定の値は呼び出し側で補ってください
+// go-avif は8ビット・4:2:0の不透明な画像のみに対応するため、それ以外と可逆圧縮は avifenc でエンコードします
+// 透過のある画像やICCプロファイル・XMPを埋め込む場合に avifenc が利用できないときは、警告を出力して go-avif でエンコードします
+func encodeAVIF(img image.Image, w io.Writer, opts AVIFEncodeOptions, icc, xmp []byte) error {
+	options := prepareAVIFOptions(opts.Quality, opts.Speed)
+
+	// 元画像より高いビット深度には変換せず、低い場合はディザリングして減色する
+	depth := min(opts.BitDepth, imageutils.SourceBitDepth(img))
+	if depth < imageutils.SourceBitDepth(img) {
+		img = imageutils.ReduceBitDepth(img, depth)
+	}
+
+	subsampling := opts.ChromaSubsampling
+
+	if opts.Lossless || subsampling != config.AVIFChroma420 || depth > 8 {
+		return encodeAVIFWithAvifenc(img, w, options, subsampling, depth, icc, xmp, opts.Lossless)
+	}
+	if hasAlpha := imageutils.HasAlpha(img); hasAlpha || len(icc) > 0 || len(xmp) > 0 {
+		if _, err := exec.LookPath("avifenc"); err == nil {
+			return encodeAVIFWithAvifenc(img, w, options, subsampling, depth, icc, xmp, false)
+		}
+		if hasAlpha {
+			log.Printf("警告: avifencコマンドが見つからないため、透過を含まないAVIFとして保存します")
+		}
+		if len(icc) > 0 {
+			log.Printf("警告: avifencコマンドが見つからないため、ICCプロファイルを含まないAVIFとして保存します")
+		}
+		if len(xmp) > 0 {
+			log.Printf("警告: avifencコマンドが見つからないため、XMPを含まないAVIFとして保存します")
+		}
+	}
+	return avif.Encode(w, img, options)
+}
+
+var (
+	// WebPEncoder は組み込みのWebPエンコーダーです
+	WebPEncoder Encoder = webpEncoder{}
+	// AVIFEncoder は組み込みのAVIFエンコーダーです
+	AVIFEncoder Encoder = avifEncoder{}
+)
+
+// saveWithEncoder はエンコーダーの出力をファイルに保存し、書き込み中に計算したSHA256を返します
+func saveWithEncoder(enc Encoder, img image.Image, outputPath string, opts *EncodeOptions) (string, error) {
+	output, err := os.Create(outputPath)
+	if err != nil {
+		return "", fmt.Errorf("出力ファイルの作成に失敗しました: %v", err)
+	}
+
+	// 書き込みと同時にハッシュを計算する
+	hasher := sha256.New()
+	if err := enc.Encode(img, io.MultiWriter(output, hasher), opts); err != nil {
+		output.Close()
+		return "", fmt.Errorf("%w: %v", ErrEncodeFailed, err)
+	}
+	if err := output.Close(); err != nil {
+		return "", fmt.Errorf("出力ファイルの書き込みに失敗しました: %v", err)
+	}
+
+	// エンコード後のファイルサイズを確認
+	fi, err := os.Stat(outputPath)
+	if err != nil || fi.Size() == 0 {
+		return "", fmt.Errorf("%w: 出力ファイルサイズが0バイトです: %s", ErrEncodeFailed, outputPath)
+	}
+
+	return checksumHex(hasher), nil
+}
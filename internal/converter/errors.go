@@ -0,0 +1,21 @@
+/*
+Package converter の一部として、変換処理のエラーの種類を表すエラー値を定義します。
+*/
+package converter
+
+import "errors"
+
+// 変換処理のエラーの種類です。errors.Is で判定できるよう、各処理は %w でラップして返します
+var (
+	// ErrUnsupportedFormat は入力または出力の形式に対応していないことを表します
+	ErrUnsupportedFormat = errors.New("対応していない画像形式です")
+
+	// ErrDecodeFailed は入力画像のデコードに失敗した（破損している可能性がある）ことを表します
+	ErrDecodeFailed = errors.New("画像のデコードに失敗しました")
+
+	// ErrEncodeFailed は出力形式へのエンコードに失敗したことを表します
+	ErrEncodeFailed = errors.New("画像のエンコードに失敗しました")
+
+	// ErrTooManyPixels は入力画像の画素数が conversion.max_decode_pixels を超えるためデコードしなかったことを表します
+	ErrTooManyPixels = errors.New("画像の画素数が上限を超えています")
+)
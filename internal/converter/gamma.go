@@ -0,0 +1,34 @@
+/*
+Package converter の一部として、PNGのガンマ値のsRGBへの正規化を提供します。
+*/
+package converter
+
+import (
+	"image"
+	"io"
+	"log"
+	"math"
+
+	"github.com/223n/image-converter/pkg/imageutils"
+)
+
+// gammaTolerance はsRGBと同じガンマ値とみなす差の範囲です
+const gammaTolerance = 0.01
+
+// normalizePNGGamma はgAMAチャンクでsRGB以外のガンマ値が指定されたPNGを、sRGBのガンマに変換します
+// エンコーダー（特にAVIF）は入力をsRGBとして扱うため、変換しないと白っぽく、または暗く表示されます
+// sRGB・iCCPチャンクがある場合やガンマ値の指定がない場合は、そのまま返します
+func normalizePNGGamma(img image.Image, r io.Reader, source string) image.Image {
+	gamma, err := imageutils.ReadPNGGamma(r)
+	if err != nil {
+		log.Printf("警告: PNGのガンマ値を読み込めないため、そのまま変換します: %s: %v", source, err)
+		return img
+	}
+	if gamma <= 0 || math.Abs(gamma-imageutils.SRGBGamma) < gammaTolerance {
+		return img
+	}
+
+	// 保存値 = 輝度^gamma のため、輝度^(1/2.2) に変換する指数は 1 / (gamma × 2.2)
+	log.Printf("PNGのガンマ値をsRGBに変換します (gAMA: %.5f): %s", gamma, source)
+	return imageutils.ApplyGamma(img, imageutils.SRGBGamma/gamma)
+}
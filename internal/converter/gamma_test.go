@@ -0,0 +1,121 @@
+package converter
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// pngWithGamma は img をPNGにエンコードし、IHDRチャンクの直後に gamma を記録したgAMAチャンクを挿入します
+// gamma が0の場合はgAMAチャンクを挿入しません
+func pngWithGamma(t *testing.T, img image.Image, gamma float64) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("PNGのエンコードに失敗しました: %v", err)
+	}
+	data := buf.Bytes()
+	if gamma == 0 {
+		return data
+	}
+
+	// シグネチャ（8バイト）とIHDRチャンク（長さ・種類・13バイトのデータ・CRC）の後に挿入する
+	const ihdrEnd = 8 + 4 + 4 + 13 + 4
+	chunk := make([]byte, 4, 16)
+	binary.BigEndian.PutUint32(chunk, 4)
+	chunk = append(chunk, "gAMA"...)
+	chunk = binary.BigEndian.AppendUint32(chunk, uint32(math.Round(gamma*100000)))
+	chunk = binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+
+	return append(append(append([]byte{}, data[:ihdrEnd]...), chunk...), data[ihdrEnd:]...)
+}
+
+// meanLuminance は保存値を exponent 乗して光の強さに戻した、画像全体の平均輝度（0〜1、Rec. 709）を返します
+func meanLuminance(img image.Image, exponent float64) float64 {
+	linear := func(v uint16) float64 { return math.Pow(float64(v)/65535, exponent) }
+
+	bounds := img.Bounds()
+	var sum float64
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBA64Model.Convert(img.At(x, y)).(color.NRGBA64)
+			sum += 0.2126*linear(c.R) + 0.7152*linear(c.G) + 0.0722*linear(c.B)
+		}
+	}
+	return sum / float64(bounds.Dx()*bounds.Dy())
+}
+
+// TestConvertPNGGammaLuminance はgAMAチャンクを持つPNGをAVIFエンコーダーに渡す前にsRGBへ揃え、
+// 表示上の平均輝度が変わらないことを確認します（揃えないと白っぽく、または暗くなります）
+func TestConvertPNGGammaLuminance(t *testing.T) {
+	// 暗部から明部までを含むグラデーション
+	src := image.NewNRGBA(image.Rect(0, 0, 64, 16))
+	for y := 0; y < 16; y++ {
+		for x := 0; x < 64; x++ {
+			src.SetNRGBA(x, y, color.NRGBA{R: uint8(x * 4), G: uint8(x*3 + y), B: uint8(255 - x*4), A: 255})
+		}
+	}
+
+	// sRGBの画像は保存値を2.2乗すると光の強さになる
+	const srgbExponent = 2.2
+	const tolerance = 0.01
+
+	tests := []struct {
+		name  string
+		gamma float64
+	}{
+		{name: "リニア（gAMA 1.0）", gamma: 1.0},
+		{name: "暗い（gAMA 0.6）", gamma: 0.6},
+		{name: "明るい（gAMA 0.3）", gamma: 0.3},
+		{name: "sRGBと同じ（gAMA 0.45455）", gamma: 0.45455},
+		{name: "gAMAチャンクなし", gamma: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inputPath := filepath.Join(t.TempDir(), "gamma.png")
+			if err := os.WriteFile(inputPath, pngWithGamma(t, src, tt.gamma), 0644); err != nil {
+				t.Fatalf("入力ファイルの作成に失敗しました: %v", err)
+			}
+
+			ic := newWebPOnlyConverter()
+			ic.config.Conversion.WebP.Enabled = false
+			ic.config.Conversion.AVIF.Enabled = true
+			stub := &stubEncoder{}
+			ic.SetEncoder("avif", stub)
+
+			if _, err := ic.Convert(inputPath); err != nil {
+				t.Fatalf("Convert に失敗しました: %v", err)
+			}
+			if stub.calls != 1 {
+				t.Fatalf("AVIFエンコーダーの呼び出し = %d 回, want 1 回", stub.calls)
+			}
+
+			// 入力は保存値 = 光の強さ^gamma（gAMAがない場合はsRGBとみなす）
+			inputExponent := srgbExponent
+			if tt.gamma != 0 {
+				inputExponent = 1 / tt.gamma
+			}
+			want := meanLuminance(src, inputExponent)
+			got := meanLuminance(stub.img, srgbExponent)
+			if math.Abs(got-want) > tolerance {
+				t.Errorf("平均輝度 = %.4f, want %.4f (許容差 %.2f)", got, want, tolerance)
+			}
+
+			// sRGBと異なるガンマ値では、補正しない場合に許容差を超えて輝度が変わること
+			if tt.gamma != 0 && math.Abs(tt.gamma-1/srgbExponent) >= gammaTolerance {
+				if raw := meanLuminance(src, srgbExponent); math.Abs(raw-want) <= tolerance {
+					t.Errorf("補正前の平均輝度 %.4f が許容差内です。テスト画像で差を検出できません", raw)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package converter
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/223n/image-converter/internal/config"
+	"github.com/jdeng/goheif/libde265"
+)
+
+var (
+	heicProbeOnce sync.Once
+	heicSupported bool
+)
+
+// IsHEICSupported はHEIC/HEIFのデコードがこの環境で使用できるかどうかを確認します
+// HEVCデコーダー（libde265）を実際に作成して確認し、結果はプロセス内でキャッシュします
+func IsHEICSupported() bool {
+	heicProbeOnce.Do(func() {
+		heicSupported = probeHEIC()
+	})
+	return heicSupported
+}
+
+// probeHEIC はHEVCデコーダーを作成・解放できるかどうかを確認します
+func probeHEIC() (ok bool) {
+	if _, found := lookupDecoder(".heic"); !found {
+		return false
+	}
+
+	// ライブラリの初期化に失敗した環境ではパニックする場合があるため、回復して未対応として扱う
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("警告: HEVCデコーダーの初期化に失敗しました: %v", r)
+			ok = false
+		}
+	}()
+
+	dec, err := libde265.NewDecoder()
+	if err != nil {
+		return false
+	}
+	dec.Free()
+	return true
+}
+
+// isHEICExt はHEIC/HEIFの拡張子かどうかを返します
+func isHEICExt(ext string) bool {
+	ext = normalizeExt(ext)
+	return ext == ".heic" || ext == ".heif"
+}
+
+// UnsupportedInputExtensions は exts のうち、この環境でデコードできない拡張子を返します
+func UnsupportedInputExtensions(exts []string) []string {
+	var unsupported []string
+	for _, ext := range exts {
+		if isHEICExt(ext) && !IsHEICSupported() {
+			unsupported = append(unsupported, normalizeExt(ext))
+		}
+	}
+	return unsupported
+}
+
+// FilterUnsupportedInputs はこの環境でデコードできない形式のファイルを除外し、除外した件数を返します
+// input.supported_extensions にデコードできない拡張子が含まれる場合は警告を出力します
+// input.on_unsupported が error の場合は、該当するファイルがあれば除外せずにエラーを返します
+func FilterUnsupportedInputs(files []string, cfg *config.Config) ([]string, int, error) {
+	unsupported := UnsupportedInputExtensions(cfg.Input.SupportedExtensions)
+	if len(unsupported) == 0 {
+		return files, 0, nil
+	}
+
+	log.Printf("警告: この環境ではHEIC/HEIFのデコーダーが使用できないため、次の拡張子のファイルは変換できません: %s",
+		strings.Join(unsupported, ", "))
+
+	unsupportedSet := make(map[string]bool, len(unsupported))
+	for _, ext := range unsupported {
+		unsupportedSet[ext] = true
+	}
+
+	var kept []string
+	skipped := 0
+	for _, file := range files {
+		if unsupportedSet[strings.ToLower(filepath.Ext(file))] {
+			skipped++
+			continue
+		}
+		kept = append(kept, file)
+	}
+
+	if skipped > 0 && cfg.Input.OnUnsupported == config.OnUnsupportedError {
+		return nil, 0, fmt.Errorf("デコードできない形式のファイルが %d 個あります（input.on_unsupported: skip でスキップできます）", skipped)
+	}
+	if skipped > 0 {
+		log.Printf("デコードできない形式のため %d 個のファイルをスキップします", skipped)
+	}
+
+	return kept, skipped, nil
+}
@@ -0,0 +1,82 @@
+/*
+Package converter の一部として、JPEG XL変換に特化した関数を提供します。
+*/
+package converter
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/223n/image-converter/internal/config"
+)
+
+// SaveJXL は画像をJPEG XLとして保存します
+// エンコードには外部コマンド（cjxl）を使用します
+func SaveJXL(img image.Image, outputPath string) error {
+	return saveJXLWithOptions(img, outputPath, nil)
+}
+
+// saveJXLWithOptions はオプションで画質を上書きしてJPEG XLとして保存します
+func saveJXLWithOptions(img image.Image, outputPath string, opts *EncodeOptions) error {
+	// cjxlコマンドが利用可能か確認
+	if _, err := exec.LookPath("cjxl"); err != nil {
+		return fmt.Errorf("cjxlコマンドが見つかりません。次のコマンドでインストールしてください: sudo apt-get install libjxl-tools")
+	}
+
+	// 一時的にPNGとして保存
+	tempDir, err := os.MkdirTemp("", "jxl-conversion-")
+	if err != nil {
+		return fmt.Errorf("一時ディレクトリの作成に失敗しました: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	tempPNGPath := filepath.Join(tempDir, "temp.png")
+
+	tempFile, err := os.Create(tempPNGPath)
+	if err != nil {
+		return fmt.Errorf("一時ファイルの作成に失敗しました: %v", err)
+	}
+
+	if err := png.Encode(tempFile, img); err != nil {
+		tempFile.Close()
+		return fmt.Errorf("PNGエンコードに失敗しました: %v", err)
+	}
+	tempFile.Close()
+
+	quality, effort := opts.jxlSettings()
+
+	log.Printf("JPEG XL変換開始: %s (品質: %d, エフォート: %d)", outputPath, quality, effort)
+
+	// cjxlを使ってJPEG XLに変換
+	var stderr bytes.Buffer
+	cmd := exec.Command("cjxl", tempPNGPath, outputPath,
+		"-q", fmt.Sprintf("%d", quality),
+		"-e", fmt.Sprintf("%d", effort),
+		fmt.Sprintf("--num_threads=%d", config.GetExternalThreads()))
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		os.Remove(outputPath)
+		return fmt.Errorf("%w: cjxlコマンドの実行に失敗しました: %v\n出力: %s", ErrEncodeFailed, err, stderr.String())
+	}
+
+	// エンコード後のファイルサイズを確認
+	fi, err := os.Stat(outputPath)
+	if err != nil || fi.Size() == 0 {
+		return fmt.Errorf("%w: JPEG XLの出力ファイルサイズが0バイトです", ErrEncodeFailed)
+	}
+
+	log.Printf("JPEG XL変換完了: %s (サイズ: %d バイト)", outputPath, fi.Size())
+	return nil
+}
+
+// IsJXLSupported はJPEG XL変換がサポートされているかどうかを確認します
+func IsJXLSupported() bool {
+	_, err := exec.LookPath("cjxl")
+	return err == nil
+}
@@ -0,0 +1,84 @@
+package converter
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// requireCjxl は cjxl コマンドがない環境ではテストをスキップします
+func requireCjxl(t *testing.T) {
+	t.Helper()
+	if !IsJXLSupported() {
+		t.Skip("cjxl コマンドがないためスキップします")
+	}
+}
+
+// isJXL はデータがJPEG XLのコードストリームまたはコンテナの先頭かどうかを返します
+func isJXL(data []byte) bool {
+	return bytes.HasPrefix(data, []byte{0xff, 0x0a}) ||
+		bytes.HasPrefix(data, []byte{0, 0, 0, 0x0c, 'J', 'X', 'L', ' ', 0x0d, 0x0a, 0x87, 0x0a})
+}
+
+func TestSaveJXL(t *testing.T) {
+	requireCjxl(t)
+
+	outputPath := filepath.Join(t.TempDir(), "photo.jxl")
+	if err := SaveJXL(decodeTestImage(t, 32, 24), outputPath); err != nil {
+		t.Fatalf("SaveJXL に失敗しました: %v", err)
+	}
+
+	data, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("出力ファイルの読み込みに失敗しました: %v", err)
+	}
+	if !isJXL(data) {
+		t.Errorf("出力の先頭 = % x, JPEG XL のシグネチャを期待しました", data[:min(len(data), 12)])
+	}
+}
+
+// TestSaveJXLWithoutCjxl は cjxl がない場合に出力せずエラーを返すことを確認します
+func TestSaveJXLWithoutCjxl(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	outputPath := filepath.Join(t.TempDir(), "photo.jxl")
+	if err := SaveJXL(decodeTestImage(t, 8, 8), outputPath); err == nil {
+		t.Fatal("cjxl がない環境でエラーになりませんでした")
+	}
+	if _, err := os.Stat(outputPath); !os.IsNotExist(err) {
+		t.Errorf("出力ファイルが作成されました: %v", err)
+	}
+}
+
+// TestConvertJXL は変換結果に JPEG XL の出力パスとサイズを記録することを確認します
+func TestConvertJXL(t *testing.T) {
+	requireCjxl(t)
+
+	inputPath := filepath.Join(t.TempDir(), "photo.png")
+	if err := os.WriteFile(inputPath, encodeTestImage(t, ".png", 40, 30), 0644); err != nil {
+		t.Fatalf("入力ファイルの作成に失敗しました: %v", err)
+	}
+
+	ic := newWebPOnlyConverter()
+	ic.config.Conversion.WebP.Enabled = false
+	ic.config.Conversion.JXL.Enabled = true
+	ic.config.Conversion.JXL.Quality = 80
+	ic.config.Conversion.JXL.Effort = 3
+
+	result, err := ic.Convert(inputPath)
+	if err != nil || !result.JXLSuccess {
+		t.Fatalf("Convert に失敗しました: %v (%+v)", err, result)
+	}
+	if filepath.Ext(result.JXLPath) != ".jxl" || result.WebPAttempted {
+		t.Errorf("変換結果 = %+v, JPEG XL のみの出力を期待しました", result)
+	}
+
+	data, err := os.ReadFile(result.JXLPath)
+	if err != nil {
+		t.Fatalf("出力ファイルの読み込みに失敗しました: %v", err)
+	}
+	if !isJXL(data) || result.JXLSize != int64(len(data)) {
+		t.Errorf("出力 = %d バイト（JPEG XL: %v）, JXLSize = %d", len(data), isJXL(data), result.JXLSize)
+	}
+}
@@ -0,0 +1,54 @@
+/*
+Package converter の一部として、可逆圧縮に向く画像の判定を提供します。
+*/
+package converter
+
+import (
+	"image"
+	"path/filepath"
+
+	"github.com/223n/image-converter/pkg/imageutils"
+)
+
+const (
+	// losslessSampleLimit は色数の判定に使用する1辺あたりの最大サンプル数です
+	losslessSampleLimit = 256
+	// losslessMaxColors 以下の色数の画像は、スクリーンショットや線画とみなして可逆圧縮にします
+	losslessMaxColors = 256
+)
+
+// preferLosslessWebP は画像を可逆WebPでエンコードすべきかどうかと、その理由を返します
+// PNG、透過のある画像、色数の少ない画像は可逆圧縮の方が小さく劣化もないため可逆にし、写真は非可逆のままにします
+// 大きな画像は間引いてサンプリングするため、色数は目安です
+func preferLosslessWebP(img image.Image, filePath string) (bool, string) {
+	if normalizeExt(filepath.Ext(filePath)) == ".png" {
+		return true, "PNG画像"
+	}
+	if imageutils.HasAlpha(img) {
+		return true, "透過あり"
+	}
+
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width == 0 || height == 0 {
+		return false, ""
+	}
+
+	step := 1
+	if longest := max(width, height); longest > losslessSampleLimit {
+		step = (longest + losslessSampleLimit - 1) / losslessSampleLimit
+	}
+
+	colors := make(map[[3]uint32]struct{})
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += step {
+		for x := bounds.Min.X; x < bounds.Max.X; x += step {
+			r, g, b, _ := img.At(x, y).RGBA()
+			colors[[3]uint32{r, g, b}] = struct{}{}
+			if len(colors) > losslessMaxColors {
+				return false, ""
+			}
+		}
+	}
+
+	return true, "色数が少ない画像"
+}
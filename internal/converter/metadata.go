@@ -0,0 +1,243 @@
+/*
+Package converter の一部として、変換後の画像へのメタデータ（EXIF・XMP・ICCプロファイル）の引き継ぎを提供します。
+*/
+package converter
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"os"
+
+	"github.com/223n/image-converter/pkg/imageutils"
+)
+
+// WebPコンテナ（RIFF）のチャンク関連の定数
+const (
+	webpVP8XFlagXMP   = 0x04 // VP8XフラグのXMPビット
+	webpVP8XFlagEXIF  = 0x08 // VP8XフラグのEXIFビット
+	webpVP8XFlagAlpha = 0x10 // VP8Xフラグのアルファビット
+	webpVP8XFlagICC   = 0x20 // VP8XフラグのICCプロファイルビット
+	webpVP8XSize      = 10   // VP8Xチャンクのペイロードサイズ
+	riffHeaderSize    = 12   // "RIFF" + サイズ + "WEBP"
+	chunkHeaderSize   = 8    // FourCC + サイズ
+)
+
+// webpChunk はWebPコンテナ内のチャンクを表します
+type webpChunk struct {
+	fourCC  string
+	payload []byte
+}
+
+// prepareEXIF は元画像のEXIFを読み込み、削除対象のタグを取り除いたデータを返します
+// EXIFの引き継ぎが無効な場合（strip_metadata が有効な場合を含む）や、EXIFが存在しない場合は nil を返します
+func (ic *ImageConverter) prepareEXIF(filePath string) []byte {
+	if !ic.config.Conversion.PreserveEXIF || ic.config.Conversion.StripMetadata {
+		return nil
+	}
+
+	exif, err := imageutils.ExtractEXIF(filePath)
+	if err != nil {
+		ic.logManager.LogWarning("EXIFの読み込みに失敗しました: %s: %v", filePath, err)
+		return nil
+	}
+	if len(exif) == 0 {
+		return nil
+	}
+
+	// 向きを補正した画素に元の Orientation が残るとビューアーで二重に回転されるため、補正時は削除する
+	tags := ic.config.Conversion.StripEXIFTags
+	if ic.config.Conversion.AutoOrient {
+		tags = append(append([]string(nil), tags...), imageutils.EXIFTagOrientation)
+	}
+
+	stripped, err := imageutils.StripEXIFTags(exif, tags)
+	if err != nil {
+		// タグを確実に削除できない場合は、情報漏洩を避けるためEXIF全体を引き継がない
+		ic.logManager.LogWarning("EXIFの書き換えに失敗したため、EXIFを引き継ぎません: %s: %v", filePath, err)
+		return nil
+	}
+
+	if len(ic.config.Conversion.StripEXIFTags) > 0 {
+		ic.logManager.LogDebug("EXIFから削除したタグ: %v (%s)", ic.config.Conversion.StripEXIFTags, filePath)
+	}
+
+	return stripped
+}
+
+// prepareColorProfile は元画像に埋め込まれたICCプロファイルを読み込みます
+// ICCプロファイルの引き継ぎが無効な場合（strip_metadata が有効な場合を含む）や、プロファイルが存在しない場合は nil を返します
+func (ic *ImageConverter) prepareColorProfile(filePath string) []byte {
+	if !ic.config.Conversion.PreserveColorProfile || ic.config.Conversion.StripMetadata {
+		return nil
+	}
+
+	profile, name, err := imageutils.GetColorProfile(filePath)
+	if err != nil {
+		ic.logManager.LogWarning("ICCプロファイルの読み込みに失敗しました: %s: %v", filePath, err)
+		return nil
+	}
+	if len(profile) > 0 {
+		ic.logManager.LogDebug("ICCプロファイルを引き継ぎます: %s (%s)", filePath, name)
+	}
+
+	return profile
+}
+
+// prepareXMP は元画像のXMPパケット（評価やキーワードなど）を読み込みます
+// XMPの引き継ぎが無効な場合（strip_metadata が有効な場合を含む）や、XMPが存在しない場合は nil を返します
+func (ic *ImageConverter) prepareXMP(filePath string) []byte {
+	if !ic.config.Conversion.PreserveXMP || ic.config.Conversion.StripMetadata {
+		return nil
+	}
+
+	xmp, err := imageutils.ExtractXMP(filePath)
+	if err != nil {
+		ic.logManager.LogWarning("XMPの読み込みに失敗しました: %s: %v", filePath, err)
+		return nil
+	}
+
+	return xmp
+}
+
+// embedWebPEXIF はWebPファイルにEXIFチャンクを埋め込みます
+// 単純形式（VP8/VP8L）のファイルは拡張形式（VP8X）に変換します
+func embedWebPEXIF(path string, exif []byte, bounds image.Rectangle) error {
+	return embedWebPChunk(path, webpChunk{fourCC: "EXIF", payload: exif}, webpVP8XFlagEXIF, bounds)
+}
+
+// embedWebPXMP はWebPファイルにXMPチャンクを埋め込みます
+// 単純形式（VP8/VP8L）のファイルは拡張形式（VP8X）に変換します
+func embedWebPXMP(path string, xmp []byte, bounds image.Rectangle) error {
+	return embedWebPChunk(path, webpChunk{fourCC: "XMP ", payload: xmp}, webpVP8XFlagXMP, bounds)
+}
+
+// embedWebPChunk はWebPファイルにメタデータチャンクを埋め込み、VP8Xに対応するフラグを立てます
+func embedWebPChunk(path string, metadata webpChunk, flag byte, bounds image.Rectangle) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("WebPファイルの読み込みに失敗しました: %v", err)
+	}
+
+	data, err = addWebPChunk(data, metadata, flag, bounds)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("WebPファイルの書き込みに失敗しました: %v", err)
+	}
+
+	return nil
+}
+
+// addWebPChunk はWebPデータにメタデータチャンクを追加したデータを返します
+// ICCプロファイルはVP8Xの直後に、EXIFはXMPチャンクの前（なければ末尾）に、XMPは末尾に配置します
+func addWebPChunk(data []byte, metadata webpChunk, flag byte, bounds image.Rectangle) ([]byte, error) {
+	chunks, err := parseWebPChunks(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// VP8Xチャンクを用意し、メタデータのフラグを立てる
+	if chunks[0].fourCC != "VP8X" {
+		vp8x := make([]byte, webpVP8XSize)
+		if chunks[0].fourCC == "VP8L" && webpLosslessHasAlpha(chunks[0].payload) {
+			vp8x[0] |= webpVP8XFlagAlpha
+		}
+		putUint24(vp8x[4:], uint32(bounds.Dx()-1))
+		putUint24(vp8x[7:], uint32(bounds.Dy()-1))
+		chunks = append([]webpChunk{{fourCC: "VP8X", payload: vp8x}}, chunks...)
+	}
+	if len(chunks[0].payload) < webpVP8XSize {
+		return nil, fmt.Errorf("VP8Xチャンクが不正です")
+	}
+	chunks[0].payload[0] |= flag
+
+	// 既存の同種チャンクを除いて配置する
+	var result []webpChunk
+	inserted := false
+	for i, chunk := range chunks {
+		if chunk.fourCC == metadata.fourCC {
+			continue
+		}
+		if metadata.fourCC == "EXIF" && chunk.fourCC == "XMP " && !inserted {
+			result = append(result, metadata)
+			inserted = true
+		}
+		result = append(result, chunk)
+		if metadata.fourCC == "ICCP" && i == 0 {
+			result = append(result, metadata)
+			inserted = true
+		}
+	}
+	if !inserted {
+		result = append(result, metadata)
+	}
+
+	return buildWebPContainer(result), nil
+}
+
+// parseWebPChunks はWebPファイルをチャンク単位に分解します
+func parseWebPChunks(data []byte) ([]webpChunk, error) {
+	if len(data) < riffHeaderSize || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, fmt.Errorf("WebPの形式が不正です")
+	}
+
+	var chunks []webpChunk
+	pos := riffHeaderSize
+	for pos+chunkHeaderSize <= len(data) {
+		fourCC := string(data[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(data[pos+4 : pos+8]))
+		start := pos + chunkHeaderSize
+		if size < 0 || start+size > len(data) {
+			return nil, fmt.Errorf("WebPチャンク %q のサイズが不正です", fourCC)
+		}
+		chunks = append(chunks, webpChunk{fourCC: fourCC, payload: data[start : start+size]})
+		// チャンクは偶数バイト境界に揃えられる
+		pos = start + size + size%2
+	}
+
+	if len(chunks) == 0 {
+		return nil, fmt.Errorf("WebPにチャンクが含まれていません")
+	}
+
+	return chunks, nil
+}
+
+// buildWebPContainer はチャンクからWebPファイルを組み立てます
+func buildWebPContainer(chunks []webpChunk) []byte {
+	var body bytes.Buffer
+	body.WriteString("WEBP")
+	for _, chunk := range chunks {
+		body.WriteString(chunk.fourCC)
+		binary.Write(&body, binary.LittleEndian, uint32(len(chunk.payload)))
+		body.Write(chunk.payload)
+		if len(chunk.payload)%2 == 1 {
+			body.WriteByte(0)
+		}
+	}
+
+	var out bytes.Buffer
+	out.WriteString("RIFF")
+	binary.Write(&out, binary.LittleEndian, uint32(body.Len()))
+	out.Write(body.Bytes())
+	return out.Bytes()
+}
+
+// webpLosslessHasAlpha はVP8Lビットストリームのヘッダーからアルファの使用有無を判定します
+func webpLosslessHasAlpha(payload []byte) bool {
+	if len(payload) < 5 || payload[0] != 0x2F {
+		return false
+	}
+	bits := binary.LittleEndian.Uint32(payload[1:5])
+	return (bits>>28)&1 == 1
+}
+
+// putUint24 は24ビットのリトルエンディアン値を書き込みます
+func putUint24(b []byte, v uint32) {
+	b[0] = byte(v)
+	b[1] = byte(v >> 8)
+	b[2] = byte(v >> 16)
+}
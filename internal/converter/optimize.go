@@ -0,0 +1,113 @@
+/*
+Package converter の一部として、JPEG/PNGを同一形式のまま再圧縮する関数を提供します。
+*/
+package converter
+
+import (
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// optimizedSuffix は最適化済みファイルに付与するサフィックスです
+const optimizedSuffix = ".opt"
+
+// SaveOptimizedJPEG は画像を指定された品質のJPEGとして保存します
+// 書き込みは一時ファイルに行い、成功した場合のみ outputPath に置き換えます
+func SaveOptimizedJPEG(img image.Image, outputPath string, quality int) error {
+	return writeFileAtomic(outputPath, func(w io.Writer) error {
+		return encodeOptimizedJPEG(w, img, quality)
+	})
+}
+
+// SaveOptimizedPNG は画像を指定された圧縮レベルのPNGとして保存します
+// 書き込みは一時ファイルに行い、成功した場合のみ outputPath に置き換えます
+func SaveOptimizedPNG(img image.Image, outputPath string, level string) error {
+	return writeFileAtomic(outputPath, func(w io.Writer) error {
+		return encodeOptimizedPNG(w, img, level)
+	})
+}
+
+// encodeOptimizedJPEG は画像を指定された品質のJPEGとして w に書き込みます
+func encodeOptimizedJPEG(w io.Writer, img image.Image, quality int) error {
+	if err := jpeg.Encode(w, img, &jpeg.Options{Quality: quality}); err != nil {
+		return fmt.Errorf("JPEGエンコードに失敗しました: %v", err)
+	}
+	return nil
+}
+
+// encodeOptimizedPNG は画像を指定された圧縮レベルのPNGとして w に書き込みます
+func encodeOptimizedPNG(w io.Writer, img image.Image, level string) error {
+	encoder := &png.Encoder{CompressionLevel: pngCompressionLevel(level)}
+	if err := encoder.Encode(w, img); err != nil {
+		return fmt.Errorf("PNGエンコードに失敗しました: %v", err)
+	}
+	return nil
+}
+
+// writeFileAtomic は outputPath と同じディレクトリの一時ファイルに write で書き込み、成功した場合のみ outputPath に置き換えます
+// 書き込み・クローズ・置き換えに失敗した場合は一時ファイルを削除し、outputPath の既存ファイルは変更しません
+// 既存ファイルがある場合はそのパーミッションを引き継ぎます
+func writeFileAtomic(outputPath string, write func(w io.Writer) error) error {
+	mode := os.FileMode(0644)
+	if fi, err := os.Stat(outputPath); err == nil {
+		mode = fi.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(outputPath), "."+filepath.Base(outputPath)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("一時ファイルの作成に失敗しました: %v", err)
+	}
+	tmpPath := tmp.Name()
+
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("一時ファイルのパーミッションの設定に失敗しました: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("出力ファイルの書き込みに失敗しました: %v", err)
+	}
+	if err := os.Rename(tmpPath, outputPath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("出力ファイルの置き換えに失敗しました: %v", err)
+	}
+	return nil
+}
+
+// pngCompressionLevel は設定値をPNGの圧縮レベルに変換します
+func pngCompressionLevel(level string) png.CompressionLevel {
+	switch strings.ToLower(level) {
+	case "none":
+		return png.NoCompression
+	case "speed":
+		return png.BestSpeed
+	case "default":
+		return png.DefaultCompression
+	default:
+		return png.BestCompression
+	}
+}
+
+// OptimizedPath は最適化済みファイルの出力パスを返します（例: photo.jpg -> photo.opt.jpg）
+func OptimizedPath(filePath string) string {
+	ext := filepath.Ext(filePath)
+	return strings.TrimSuffix(filePath, ext) + optimizedSuffix + ext
+}
+
+// IsOptimizedPath はパスが最適化済みファイルを指しているかどうかを返します
+func IsOptimizedPath(filePath string) bool {
+	ext := filepath.Ext(filePath)
+	return strings.HasSuffix(strings.TrimSuffix(filePath, ext), optimizedSuffix)
+}
@@ -0,0 +1,131 @@
+package converter
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// dirEntries はディレクトリ内のファイル名を返します
+func dirEntries(t *testing.T, dir string) []string {
+	t.Helper()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ディレクトリの読み込みに失敗しました: %v", err)
+	}
+	names := make([]string, len(entries))
+	for i, e := range entries {
+		names[i] = e.Name()
+	}
+	return names
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "photo.jpg")
+	if err := os.WriteFile(path, []byte("original"), 0600); err != nil {
+		t.Fatalf("ファイルの作成に失敗しました: %v", err)
+	}
+
+	t.Run("書き込みに失敗した場合は既存のファイルを変更しない", func(t *testing.T) {
+		errWrite := errors.New("書き込みエラー")
+		err := writeFileAtomic(path, func(w io.Writer) error {
+			w.Write([]byte("partial"))
+			return errWrite
+		})
+		if !errors.Is(err, errWrite) {
+			t.Errorf("writeFileAtomic = %v, want %v", err, errWrite)
+		}
+		if got, _ := os.ReadFile(path); string(got) != "original" {
+			t.Errorf("ファイルの内容 = %q, want %q", got, "original")
+		}
+		if names := dirEntries(t, dir); len(names) != 1 {
+			t.Errorf("一時ファイルが残っています: %v", names)
+		}
+	})
+
+	t.Run("成功した場合は置き換えてパーミッションを引き継ぐ", func(t *testing.T) {
+		err := writeFileAtomic(path, func(w io.Writer) error {
+			_, err := w.Write([]byte("optimized"))
+			return err
+		})
+		if err != nil {
+			t.Fatalf("writeFileAtomic に失敗しました: %v", err)
+		}
+		if got, _ := os.ReadFile(path); string(got) != "optimized" {
+			t.Errorf("ファイルの内容 = %q, want %q", got, "optimized")
+		}
+		if fi, err := os.Stat(path); err != nil || fi.Mode().Perm() != 0600 {
+			t.Errorf("パーミッション = %v (%v), want 0600", fi.Mode().Perm(), err)
+		}
+		if names := dirEntries(t, dir); len(names) != 1 {
+			t.Errorf("一時ファイルが残っています: %v", names)
+		}
+	})
+}
+
+// TestConvertOptimizeOverwrite は上書きモードの再圧縮で、小さくなったJPEGだけが元ファイルを置き換えることを確認します
+func TestConvertOptimizeOverwrite(t *testing.T) {
+	// 画質100で保存した細かい模様のJPEGは、画質50で再圧縮すると小さくなる
+	src := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 64; x++ {
+			src.Set(x, y, color.RGBA{R: uint8(x * 37), G: uint8(y * 53), B: uint8(x * y), A: 255})
+		}
+	}
+	var original bytes.Buffer
+	if err := jpeg.Encode(&original, src, &jpeg.Options{Quality: 100}); err != nil {
+		t.Fatalf("JPEGのエンコードに失敗しました: %v", err)
+	}
+
+	for _, tt := range []struct {
+		name        string
+		quality     int
+		wantReplace bool
+	}{
+		{name: "小さくなる場合は置き換える", quality: 50, wantReplace: true},
+		{name: "小さくならない場合は維持する", quality: 100, wantReplace: false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			inputPath := filepath.Join(dir, "photo.jpg")
+			if err := os.WriteFile(inputPath, original.Bytes(), 0644); err != nil {
+				t.Fatalf("入力ファイルの作成に失敗しました: %v", err)
+			}
+
+			ic := newWebPOnlyConverter()
+			ic.config.Conversion.WebP.Enabled = false
+			ic.config.Conversion.Optimize.Enabled = true
+			ic.config.Conversion.Optimize.Overwrite = true
+			ic.config.Conversion.Optimize.JPEGQuality = tt.quality
+			result, err := ic.Convert(inputPath)
+			if err != nil {
+				t.Fatalf("Convert に失敗しました: %v", err)
+			}
+
+			if !result.OptimizeSuccess || result.OptimizedPath != inputPath {
+				t.Errorf("再圧縮の結果 = (成功 %v, パス %s), want (true, %s)", result.OptimizeSuccess, result.OptimizedPath, inputPath)
+			}
+			data, err := os.ReadFile(inputPath)
+			if err != nil {
+				t.Fatalf("元ファイルの読み込みに失敗しました: %v", err)
+			}
+			if replaced := !bytes.Equal(data, original.Bytes()); replaced != tt.wantReplace {
+				t.Errorf("元ファイルの置き換え = %v, want %v", replaced, tt.wantReplace)
+			}
+			if result.OptimizedSize != int64(len(data)) {
+				t.Errorf("OptimizedSize = %d, want %d", result.OptimizedSize, len(data))
+			}
+			if names := dirEntries(t, dir); len(names) != 1 {
+				t.Errorf("元ファイル以外のファイルが残っています: %v", names)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+/*
+Package converter の一部として、ファイル名テンプレートによる出力パスの組み立てを提供します。
+*/
+package converter
+
+import (
+	"image"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/223n/image-converter/internal/config"
+)
+
+// outputNamer は元画像の情報から出力ファイルのパスを組み立てます
+type outputNamer struct {
+	template string // ファイル名テンプレート（例: {name}.{format}）
+	dir      string // 出力先ディレクトリ（元画像と同じ）
+	name     string // 拡張子を除いた元画像のファイル名
+	ext      string // ドットを除いた元画像の拡張子
+	width    int    // デコード後の画像の幅
+	height   int    // デコード後の画像の高さ
+}
+
+// newOutputNamer は元画像のパスと寸法から outputNamer を作成します
+// 変換処理・ドライランの計画・変換済みの判定・リモートのアップロードは、すべてこの関数で出力パスを組み立てます
+// bounds が空の場合（元画像の寸法を読み取れない場合）は {width}・{height} を展開しません
+func newOutputNamer(template, filePath string, bounds image.Rectangle) *outputNamer {
+	ext := filepath.Ext(filePath)
+	return &outputNamer{
+		template: template,
+		dir:      filepath.Dir(filePath),
+		name:     strings.TrimSuffix(filepath.Base(filePath), ext),
+		ext:      strings.TrimPrefix(ext, "."),
+		width:    bounds.Dx(),
+		height:   bounds.Dy(),
+	}
+}
+
+// newOutputNamer は変換器の設定のファイル名テンプレートで outputNamer を作成します
+func (ic *ImageConverter) newOutputNamer(filePath string, bounds image.Rectangle) *outputNamer {
+	return newOutputNamer(ic.config.Output.FilenameTemplate, filePath, bounds)
+}
+
+// path は出力形式の拡張子と画質でテンプレートを展開し、出力ファイルのパスを返します
+// outputExt はドット付きの拡張子です。画質を持たない形式では quality に0を指定します
+func (n *outputNamer) path(outputExt string, quality int) string {
+	pairs := []string{
+		"{name}", n.name,
+		"{ext}", n.ext,
+		"{quality}", strconv.Itoa(quality),
+		"{format}", strings.TrimPrefix(outputExt, "."),
+	}
+	if n.width > 0 && n.height > 0 {
+		pairs = append(pairs, "{width}", strconv.Itoa(n.width), "{height}", strconv.Itoa(n.height))
+	}
+	return filepath.Join(n.dir, strings.NewReplacer(pairs...).Replace(n.template))
+}
+
+// configuredQuality は形式ごとの設定の画質を、ファイル名テンプレートの {quality} に展開する値で返します
+// AVIFはエンコーダーの尺度（1〜63）で返します。画質を持たない形式は0を返します
+// 画質の自動調整（conversion.adaptive_quality）による調整前の値です
+func configuredQuality(cfg *config.Config, format string) int {
+	switch format {
+	case "webp":
+		return cfg.Conversion.WebP.Quality
+	case "avif":
+		return cfg.AVIFNativeQuality()
+	case "jxl":
+		return cfg.Conversion.JXL.Quality
+	default:
+		return 0
+	}
+}
+
+// OutputPaths は設定のファイル名テンプレートに従って、元画像から出力される形式ごとのパスを返します
+// 寸法は元画像のヘッダーから読み取り、画質は自動調整前の設定値を使用します
+// 元画像を読み取れない場合（リモートのファイルなど）は {width}・{height} を展開しません
+func OutputPaths(cfg *config.Config, source string) map[string]string {
+	plan := PlanConversion(cfg, source)
+	paths := make(map[string]string, len(plan.Formats))
+	for i, format := range plan.Formats {
+		paths[format] = plan.Outputs[i]
+	}
+	return paths
+}
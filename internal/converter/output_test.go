@@ -0,0 +1,59 @@
+package converter
+
+import (
+	"image"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/223n/image-converter/internal/config"
+)
+
+func TestOutputNamerPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		bounds   image.Rectangle
+		want     string
+	}{
+		{name: "デフォルト", template: config.DefaultFilenameTemplate, bounds: image.Rect(0, 0, 40, 30), want: "photo.webp"},
+		{name: "寸法と画質", template: "{name}_{width}x{height}_q{quality}.{format}", bounds: image.Rect(0, 0, 40, 30), want: "photo_40x30_q75.webp"},
+		{name: "元の拡張子", template: "{name}.{ext}.{format}", bounds: image.Rect(0, 0, 40, 30), want: "photo.jpg.webp"},
+		{name: "寸法が不明な場合は展開しない", template: "{name}_{width}x{height}.{format}", want: "photo_{width}x{height}.webp"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			names := newOutputNamer(tt.template, filepath.Join("images", "photo.jpg"), tt.bounds)
+			if got := names.path(".webp", 75); got != filepath.Join("images", tt.want) {
+				t.Errorf("path = %q, want %q", got, filepath.Join("images", tt.want))
+			}
+		})
+	}
+}
+
+// TestOutputPathsMatchConvert はファイル名テンプレートを指定した場合に、OutputPaths が Convert の出力パスと一致することを確認します
+func TestOutputPathsMatchConvert(t *testing.T) {
+	inputPath := filepath.Join(t.TempDir(), "photo.png")
+	if err := os.WriteFile(inputPath, encodeTestImage(t, ".png", 40, 30), 0644); err != nil {
+		t.Fatalf("入力ファイルの作成に失敗しました: %v", err)
+	}
+
+	ic := newWebPOnlyConverter()
+	ic.config.Output.FilenameTemplate = "{name}_{width}x{height}_q{quality}.{format}"
+	ic.config.Conversion.WebP.Quality = 70
+
+	want := map[string]string{"webp": filepath.Join(filepath.Dir(inputPath), "photo_40x30_q70.webp")}
+	if got := OutputPaths(ic.config, inputPath); !reflect.DeepEqual(got, want) {
+		t.Errorf("OutputPaths = %v, want %v", got, want)
+	}
+
+	result, err := ic.Convert(inputPath)
+	if err != nil {
+		t.Fatalf("Convert に失敗しました: %v", err)
+	}
+	if result.WebPPath != want["webp"] || !result.WebPSuccess {
+		t.Errorf("WebPPath = %q (成功: %v), want %q", result.WebPPath, result.WebPSuccess, want["webp"])
+	}
+}
@@ -0,0 +1,73 @@
+/*
+Package converter の一部として、元画像に対する出力ファイルサイズの制限を提供します。
+*/
+package converter
+
+import (
+	"image"
+	"os"
+
+	"github.com/223n/image-converter/internal/utils"
+)
+
+// sizeQualityStep は出力サイズが上限を超えた場合に画質を下げる幅です
+const sizeQualityStep = 10
+
+// limitOutputSize は出力サイズが conversion.max_output_ratio による上限を超えていないかを確認します
+// 超えている場合は画質を1段階下げて1回だけ再エンコードし、それでも超える場合は出力ファイルを削除します
+// 出力を残す場合は最新のチェックサムと true を、削除した場合は false を返します
+func (ic *ImageConverter) limitOutputSize(format string, img image.Image, outputPath string, opts *EncodeOptions, originalSize int64, checksum string) (string, bool) {
+	ratio := ic.config.Conversion.MaxOutputRatio
+	if ratio <= 0 || originalSize <= 0 {
+		return checksum, true
+	}
+	limit := int64(float64(originalSize) * ratio)
+
+	size, err := utils.GetFileSize(outputPath)
+	if err != nil || size <= limit {
+		return checksum, true
+	}
+
+	// 可逆圧縮は画質を下げても小さくならないため再エンコードしない
+	quality := ic.baseQuality(format, opts)
+	next, ok := lowerSizeQuality(format, quality)
+	if ok && (opts == nil || !opts.Lossless) {
+		ic.logManager.LogFileInfo("出力サイズが上限を超えたため画質を下げて再エンコードします [%s]: %d > %d バイト (品質: %d -> %d)",
+			outputPath, size, limit, quality, next)
+
+		// 画質以外のオプション（ICCプロファイルなど）は引き継ぐ
+		var retry EncodeOptions
+		if opts != nil {
+			retry = *opts
+		}
+		retry.Quality = next
+		if checksum, err = ic.encode(format, img, outputPath, &retry); err != nil {
+			ic.logManager.LogError("再エンコードに失敗しました [%s]: %v", outputPath, err)
+		} else if size, err = utils.GetFileSize(outputPath); err == nil && size <= limit {
+			return checksum, true
+		}
+	}
+
+	if err := os.Remove(outputPath); err != nil && !os.IsNotExist(err) {
+		ic.logManager.LogWarning("上限を超えた出力ファイルの削除に失敗しました [%s]: %v", outputPath, err)
+	}
+	ic.logManager.LogFileInfo("出力サイズが元画像の %.0f%% を超えるため出力せず、元画像を使用します [%s]: %d > %d バイト",
+		ratio*100, outputPath, size, limit)
+	return "", false
+}
+
+// lowerSizeQuality は1段階低画質な品質値を返します。既に最低画質の場合は false を返します
+// go-avifの品質値は大きいほど低画質のため、AVIFは値を上げます
+func lowerSizeQuality(format string, quality int) (int, bool) {
+	if format == "avif" {
+		if quality >= 63 {
+			return quality, false
+		}
+		return min(quality+sizeQualityStep, 63), true
+	}
+
+	if quality <= 1 {
+		return quality, false
+	}
+	return max(quality-sizeQualityStep, 1), true
+}
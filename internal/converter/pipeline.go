@@ -0,0 +1,165 @@
+/*
+Package converter の一部として、変換処理をデコード・加工・エンコードの段階に分けて提供します。
+*/
+package converter
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/gif"
+	"io"
+	"os"
+
+	"github.com/223n/image-converter/pkg/imageutils"
+)
+
+// Decode は入力画像を読み込み、デコードした画像と基本情報を返します
+// 基本情報はファイルの再読み込みを避けるため、寸法をデコード後の画像から取得します（EXIFなどは含みません）
+func (ic *ImageConverter) Decode(filePath string) (image.Image, *imageutils.ImageInfo, error) {
+	img, err := ic.loadImageLimited(filePath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	bounds := img.Bounds()
+	info := &imageutils.ImageInfo{
+		Path:    filePath,
+		Width:   bounds.Dx(),
+		Height:  bounds.Dy(),
+		IsValid: true,
+	}
+	if fi, err := os.Stat(filePath); err == nil {
+		info.Size = fi.Size()
+		info.ModTime = fi.ModTime()
+	}
+	if format, err := imageutils.DetectFormat(filePath); err == nil {
+		info.Format = format
+	}
+
+	return img, info, nil
+}
+
+// DecodeStream は r の画像データを読み込んでデコードします
+// ext は入力データの拡張子（例: ".jpg"）です。画素数が conversion.max_decode_pixels を超える場合はデコードせずに ErrTooManyPixels を返します
+func (ic *ImageConverter) DecodeStream(r io.Reader, ext string) (image.Image, error) {
+	// PNGのガンマ補正で読み直すため、入力はメモリに読み込む
+	input, err := io.ReadAll(io.LimitReader(r, maxInputSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("入力データの読み込みに失敗しました: %v", err)
+	}
+	if err := checkDecodePixels(bytes.NewReader(input), ic.config.Conversion.MaxDecodePixels); err != nil {
+		return nil, err
+	}
+	return decodeBytes(input, ext)
+}
+
+// Transform はデコードした画像に出力前の加工を適用します
+// 加工はリサイズ（conversion.resize）、回転（conversion.auto_orient）、透かしの合成、メタデータの除去の順に行います
+// orientation は元画像のEXIFの Orientation（1〜8）です。元画像がない場合や向きが不明な場合は1を指定します
+// 透かしを読み込めない場合は警告を出力して加工せずに続行します
+func (ic *ImageConverter) Transform(img image.Image, orientation int) (image.Image, error) {
+	if img == nil {
+		return nil, fmt.Errorf("加工する画像がありません")
+	}
+
+	// リサイズとEXIFの向きの補正（透かしが縮小・回転されないよう先に適用する）
+	img = resizeAndOrient(ic.config, img, orientation)
+
+	// 透かしの合成
+	img = ic.applyWatermark(img)
+
+	// メタデータを持たないピクセルのみの画像に置き換える
+	if ic.config.Conversion.StripMetadata {
+		img = imageutils.StripMetadata(img)
+	}
+
+	return img, nil
+}
+
+// Encode は加工済みの画像を有効な出力形式ごとにエンコードし、結果を result に記録します
+// EXIF・XMP・ICCプロファイル・アニメーションは result.OriginalPath の元画像から取得します
+// 元画像のファイルがない場合（メモリ上の画像を変換する場合）は、これらを引き継がずに出力パスの決定にのみ使用します
+// 各形式の変換の失敗は result に記録し、エラーとしては返しません
+func (ic *ImageConverter) Encode(img image.Image, result *ConversionResult) error {
+	if result == nil || result.OriginalPath == "" {
+		return fmt.Errorf("エンコードには元画像のパスを設定した変換結果が必要です")
+	}
+	filePath := result.OriginalPath
+
+	// 出力パスの構築（ファイル名テンプレートの寸法はデコード後の画像から取得する）
+	names := ic.newOutputNamer(filePath, img.Bounds())
+
+	// 画像の複雑さに応じた画質の決定
+	opts := ic.encodeOptions(img, filePath)
+	ic.applyAutoLossless(img, filePath, opts)
+
+	var animation *gif.GIF
+	var exif, xmp []byte
+	if isRegularFile(filePath) {
+		// アニメーションGIFの判定
+		animation = ic.loadAnimation(filePath)
+		result.IsAnimated = animation != nil
+		if animation == nil {
+			ic.logStaticAnimation(filePath)
+		}
+
+		// 引き継ぐEXIFの準備（削除対象タグはここで取り除く）
+		exif = ic.prepareEXIF(filePath)
+
+		// 引き継ぐXMP（評価・キーワードなど）の準備
+		xmp = ic.prepareXMP(filePath)
+
+		// 引き継ぐICCプロファイルはエンコード時に埋め込む
+		if icc := ic.prepareColorProfile(filePath); len(icc) > 0 {
+			opts["webp"].ICCProfile = icc
+			opts["avif"].ICCProfile = icc
+		}
+	}
+
+	// 登録されたエンコーダーを順に実行
+	for _, format := range RegisteredEncoders() {
+		switch format {
+		case "webp":
+			if !ic.config.Conversion.WebP.Enabled {
+				continue
+			}
+			if animation != nil {
+				ic.processAnimatedWebPConversion(animation, names, result)
+			} else {
+				ic.processWebPConversion(img, names, exif, xmp, opts["webp"], result)
+			}
+		case "avif":
+			if ic.config.Conversion.AVIF.Enabled {
+				// XMPは avifenc でエンコードする場合に埋め込む（go-avif の場合は警告を出力して引き継がない）
+				opts["avif"].XMP = xmp
+				ic.processAVIFConversion(img, names, opts["avif"], result)
+			}
+		case "jxl":
+			if ic.config.Conversion.JXL.Enabled {
+				ic.processJXLConversion(img, names, opts["jxl"], result)
+			}
+		default:
+			ic.processCustomConversion(format, img, names, result)
+		}
+	}
+
+	return nil
+}
+
+// EncodeStreams は加工済みの画像をWebPは webpW、AVIFは avifW に書き込みます
+// 書き込み先が nil の形式、または設定で無効な形式は出力しません
+func (ic *ImageConverter) EncodeStreams(img image.Image, webpW, avifW io.Writer) error {
+	opts := ic.encodeOptions(img, "")
+	if webpW != nil && ic.config.Conversion.WebP.Enabled {
+		if err := WebPEncoder.Encode(img, webpW, opts["webp"]); err != nil {
+			return fmt.Errorf("%w: webp: %v", ErrEncodeFailed, err)
+		}
+	}
+	if avifW != nil && ic.config.Conversion.AVIF.Enabled {
+		if err := AVIFEncoder.Encode(img, avifW, opts["avif"]); err != nil {
+			return fmt.Errorf("%w: avif: %v", ErrEncodeFailed, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,229 @@
+package converter
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/223n/image-converter/internal/config"
+	"github.com/223n/image-converter/internal/utils"
+)
+
+// newWebPOnlyConverter はWebPのみを出力する変換器を作成します
+func newWebPOnlyConverter() *ImageConverter {
+	cfg := config.DefaultConfig()
+	cfg.Conversion.WebP.Enabled = true
+	cfg.Conversion.AVIF.Enabled = false
+	cfg.Conversion.JXL.Enabled = false
+	return NewImageConverter(&cfg, utils.NewLogManager())
+}
+
+// stubEncoder は受け取った画像を記録し、固定の内容を書き込む Encoder です
+type stubEncoder struct {
+	img   image.Image
+	calls int
+}
+
+// Encode は受け取った画像を記録し、固定の内容を書き込みます
+func (s *stubEncoder) Encode(img image.Image, w io.Writer, opts *EncodeOptions) error {
+	s.img = img
+	s.calls++
+	_, err := w.Write([]byte("stub"))
+	return err
+}
+
+func TestDecode(t *testing.T) {
+	dir := t.TempDir()
+	inputPath := filepath.Join(dir, "photo.png")
+	data := encodeTestImage(t, ".png", 40, 30)
+	if err := os.WriteFile(inputPath, data, 0644); err != nil {
+		t.Fatalf("入力ファイルの作成に失敗しました: %v", err)
+	}
+
+	ic := newWebPOnlyConverter()
+	img, info, err := ic.Decode(inputPath)
+	if err != nil {
+		t.Fatalf("Decode に失敗しました: %v", err)
+	}
+	if img.Bounds().Dx() != 40 || img.Bounds().Dy() != 30 {
+		t.Errorf("画像の寸法 = %v, want 40x30", img.Bounds())
+	}
+	if info.Width != 40 || info.Height != 30 || info.Size != int64(len(data)) || info.Format != "png" || !info.IsValid {
+		t.Errorf("基本情報 = %+v", info)
+	}
+
+	t.Run("ファイルがない", func(t *testing.T) {
+		if _, _, err := ic.Decode(filepath.Join(dir, "missing.png")); err == nil {
+			t.Error("存在しないファイルでエラーになりませんでした")
+		}
+	})
+
+	t.Run("画像ではない", func(t *testing.T) {
+		brokenPath := filepath.Join(dir, "broken.png")
+		if err := os.WriteFile(brokenPath, []byte("not an image"), 0644); err != nil {
+			t.Fatalf("入力ファイルの作成に失敗しました: %v", err)
+		}
+		if _, _, err := ic.Decode(brokenPath); !errors.Is(err, ErrDecodeFailed) {
+			t.Errorf("Decode = %v, ErrDecodeFailed を期待しました", err)
+		}
+	})
+}
+
+// TestTransform はデコードを経由せずに作成した画像を加工できることを確認します
+func TestTransform(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 20, 10))
+	src.Set(3, 4, color.RGBA{R: 255, A: 255})
+
+	tests := []struct {
+		name      string
+		configure func(cfg *config.Config)
+	}{
+		{name: "加工なし", configure: func(cfg *config.Config) { cfg.Conversion.StripMetadata = false }},
+		{name: "メタデータの除去", configure: func(cfg *config.Config) { cfg.Conversion.StripMetadata = true }},
+		{name: "透かしを読み込めない場合は続行する", configure: func(cfg *config.Config) {
+			cfg.Conversion.Watermark.Enabled = true
+			cfg.Conversion.Watermark.ImagePath = filepath.Join(t.TempDir(), "missing.png")
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ic := newWebPOnlyConverter()
+			tt.configure(ic.config)
+
+			img, err := ic.Transform(src, 1)
+			if err != nil {
+				t.Fatalf("Transform に失敗しました: %v", err)
+			}
+			if img.Bounds() != src.Bounds() {
+				t.Errorf("画像の範囲 = %v, want %v", img.Bounds(), src.Bounds())
+			}
+			if r, _, _, _ := img.At(3, 4).RGBA(); r != 0xffff {
+				t.Errorf("画素が変更されました: %v", img.At(3, 4))
+			}
+		})
+	}
+
+	t.Run("画像がない", func(t *testing.T) {
+		if _, err := newWebPOnlyConverter().Transform(nil, 1); err == nil {
+			t.Error("nil の画像でエラーになりませんでした")
+		}
+	})
+}
+
+// useWatermark は watermark をPNGで保存し、透かしとして右下に不透明度1で合成するように設定します
+// 透かし画像のキャッシュはテストの前後で破棄します
+func useWatermark(t *testing.T, cfg *config.Config, watermark image.Image) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "watermark.png")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("透かし画像の作成に失敗しました: %v", err)
+	}
+	defer file.Close()
+	if err := png.Encode(file, watermark); err != nil {
+		t.Fatalf("透かし画像のエンコードに失敗しました: %v", err)
+	}
+
+	cfg.Conversion.Watermark.Enabled = true
+	cfg.Conversion.Watermark.ImagePath = path
+	cfg.Conversion.Watermark.Position = "bottom-right"
+	cfg.Conversion.Watermark.Opacity = 1
+
+	reset := func() {
+		watermarkOnce = sync.Once{}
+		watermarkImage, watermarkErr = nil, nil
+	}
+	reset()
+	t.Cleanup(reset)
+}
+
+// TestTransformStageOrder はリサイズ、回転、透かしの合成の順に加工することを確認します
+// 透かしは縮小・回転されず、補正後の画像の右下に元の寸法のまま合成されます
+func TestTransformStageOrder(t *testing.T) {
+	// 20x10 の白い画像の左上の四分の一を青にする
+	blue := color.NRGBA{B: 255, A: 255}
+	src := image.NewNRGBA(image.Rect(0, 0, 20, 10))
+	draw.Draw(src, src.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(src, image.Rect(0, 0, 10, 5), image.NewUniform(blue), image.Point{}, draw.Src)
+
+	red := color.NRGBA{R: 255, A: 255}
+	watermark := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	draw.Draw(watermark, watermark.Bounds(), image.NewUniform(red), image.Point{}, draw.Src)
+
+	ic := newWebPOnlyConverter()
+	ic.config.Conversion.AutoOrient = true
+	ic.config.Conversion.Resize.MaxWidth = 5
+	ic.config.Conversion.Resize.MaxHeight = 10
+	ic.config.Conversion.StripMetadata = true
+	useWatermark(t, ic.config, watermark)
+
+	// Orientation 6 は時計回りに90度回転して補正する
+	img, err := ic.Transform(src, 6)
+	if err != nil {
+		t.Fatalf("Transform に失敗しました: %v", err)
+	}
+
+	// 回転後に 5x10 の枠に収まるよう、回転前に 10x5 へ縮小される
+	if got := img.Bounds(); got != image.Rect(0, 0, 5, 10) {
+		t.Fatalf("画像の範囲 = %v, want 5x10", got)
+	}
+	// 元画像の左上（青）は回転により右上に移る
+	if r, _, b, _ := img.At(4, 0).RGBA(); b <= r {
+		t.Errorf("右上の画素 = %v, 青を期待しました", img.At(4, 0))
+	}
+	if r, g, b, _ := img.At(0, 0).RGBA(); r < 0xf000 || g < 0xf000 || b < 0xf000 {
+		t.Errorf("左上の画素 = %v, 白を期待しました", img.At(0, 0))
+	}
+	// 透かしは補正後の右下に 2x2 のまま合成される
+	for _, p := range []image.Point{{3, 8}, {4, 9}} {
+		if c := color.NRGBAModel.Convert(img.At(p.X, p.Y)); c != red {
+			t.Errorf("透かしの画素 %v = %v, want %v", p, c, red)
+		}
+	}
+	if c := color.NRGBAModel.Convert(img.At(2, 7)); c == red {
+		t.Errorf("透かしの外側の画素 (2, 7) = %v, 透かしが拡大されています", c)
+	}
+}
+
+// TestEncode はデコードと加工を経由せずに作成した画像を、差し替えたエンコーダーに渡すことを確認します
+func TestEncode(t *testing.T) {
+	dir := t.TempDir()
+	img := image.NewRGBA(image.Rect(0, 0, 16, 8))
+
+	ic := newWebPOnlyConverter()
+	stub := &stubEncoder{}
+	ic.SetEncoder("webp", stub)
+
+	// 元画像のファイルは作成せず、出力パスの決定にのみ使用する
+	result := &ConversionResult{OriginalPath: filepath.Join(dir, "photo.png")}
+	if err := ic.Encode(img, result); err != nil {
+		t.Fatalf("Encode に失敗しました: %v", err)
+	}
+
+	if stub.calls != 1 || stub.img != image.Image(img) {
+		t.Errorf("エンコーダーの呼び出し = %d 回, 画像 %p, want 1 回, %p", stub.calls, stub.img, img)
+	}
+	if !result.WebPSuccess {
+		t.Errorf("WebPSuccess = false: %+v", result)
+	}
+	if got, err := os.ReadFile(result.WebPPath); err != nil || string(got) != "stub" {
+		t.Errorf("出力ファイル %s = %q, %v", result.WebPPath, got, err)
+	}
+
+	t.Run("変換結果がない", func(t *testing.T) {
+		for _, result := range []*ConversionResult{nil, {}} {
+			if err := ic.Encode(img, result); err == nil {
+				t.Errorf("変換結果 %+v でエラーになりませんでした", result)
+			}
+		}
+	})
+}
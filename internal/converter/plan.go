@@ -0,0 +1,105 @@
+/*
+Package converter の一部として、ドライランで変換予定の内容をファイルに出力する機能を提供します。
+*/
+package converter
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/223n/image-converter/internal/config"
+)
+
+// PlannedConversion は1つの元画像について予定している変換内容です
+type PlannedConversion struct {
+	Source  string   `json:"source"`  // 元画像のパス
+	Formats []string `json:"formats"` // 出力する形式
+	Outputs []string `json:"outputs"` // 出力予定のファイルパス（Formats と同じ順序）
+}
+
+// dryRunPlan はJSON形式で出力するドライランの計画です
+type dryRunPlan struct {
+	Total int                 `json:"total"`
+	Files []PlannedConversion `json:"files"`
+}
+
+// PlanConversion は設定に従って元画像から出力される予定のファイルを返します
+// ファイル名テンプレートの {width}・{height} は元画像の寸法を読み取れた場合のみ、リサイズと向きの補正後の寸法で展開されます（リモートのファイルなどは展開しません）
+// {quality} は設定の画質で展開します。画質の自動調整が有効な場合、実際の出力とは異なることがあります
+func PlanConversion(cfg *config.Config, source string) PlannedConversion {
+	var bounds image.Rectangle
+	if file, err := os.Open(source); err == nil {
+		if imgConfig, _, err := image.DecodeConfig(file); err == nil {
+			size := outputSize(cfg, image.Pt(imgConfig.Width, imgConfig.Height), sourceOrientation(cfg, source))
+			bounds = image.Rect(0, 0, size.X, size.Y)
+		}
+		file.Close()
+	}
+	names := newOutputNamer(cfg.Output.FilenameTemplate, source, bounds)
+
+	plan := PlannedConversion{Source: source}
+	add := func(format, outputExt string) {
+		plan.Formats = append(plan.Formats, format)
+		plan.Outputs = append(plan.Outputs, names.path(outputExt, configuredQuality(cfg, format)))
+	}
+
+	for _, format := range RegisteredEncoders() {
+		switch format {
+		case "webp":
+			if cfg.Conversion.WebP.Enabled {
+				add(format, ".webp")
+			}
+		case "avif":
+			if cfg.Conversion.AVIF.Enabled {
+				add(format, ".avif")
+			}
+		case "jxl":
+			if cfg.Conversion.JXL.Enabled {
+				add(format, ".jxl")
+			}
+		default:
+			if entry, ok := lookupEncoder(format); ok {
+				add(format, entry.ext)
+			}
+		}
+	}
+
+	return plan
+}
+
+// WriteDryRunPlan はドライランで変換予定の内容をファイルに書き込みます
+// 拡張子が .json の場合はJSON、それ以外は1行に1ファイルのテキスト形式で出力します
+func WriteDryRunPlan(path string, plans []PlannedConversion) error {
+	var data []byte
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		if plans == nil {
+			plans = []PlannedConversion{}
+		}
+		encoded, err := json.MarshalIndent(dryRunPlan{Total: len(plans), Files: plans}, "", "  ")
+		if err != nil {
+			return fmt.Errorf("ドライランの計画の作成に失敗しました: %v", err)
+		}
+		data = append(encoded, '\n')
+	} else {
+		var b strings.Builder
+		for _, plan := range plans {
+			fmt.Fprintf(&b, "%s -> %s [%s]\n", plan.Source, strings.Join(plan.Outputs, ", "), strings.Join(plan.Formats, ","))
+		}
+		fmt.Fprintf(&b, "合計: %d個のファイル\n", len(plans))
+		data = []byte(b.String())
+	}
+
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("出力先ディレクトリの作成に失敗しました: %v", err)
+		}
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("ドライランの計画の書き込みに失敗しました: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+/*
+Package converter の一部として、画像の複雑さに応じた画質の自動調整を提供します。
+*/
+package converter
+
+import (
+	"image"
+	"math"
+
+	"github.com/223n/image-converter/internal/config"
+)
+
+const (
+	// edgeThreshold は隣接ピクセルの輝度差をエッジとみなす閾値（0-255）です
+	edgeThreshold = 24
+	// edgeSampleLimit は解析に使用する1辺あたりの最大サンプル数です
+	edgeSampleLimit = 512
+	// lowEdgeDensity 以下の画像は滑らか（グラデーション主体）とみなします
+	lowEdgeDensity = 0.02
+	// highEdgeDensity 以上の画像は細部が多い（劣化が目立ちにくい）とみなします
+	highEdgeDensity = 0.20
+	// maxQualityAdjustment は基準画質からの最大調整幅です
+	maxQualityAdjustment = 10
+)
+
+// EncodeOptions はエンコード時に設定値を上書きするオプションです
+// nil の場合や値が0の場合は設定ファイルの値を使用します
+type EncodeOptions struct {
+	Quality int    // 画質（形式ごとの範囲で指定）
+	Format  string // 出力形式名（ConvertBytes で使用し、空の場合は webp）
+	Method  int    // WebPの圧縮方法（1〜6）
+	Speed   int    // AVIFの処理速度（1〜10）
+	Effort  int    // JPEG XLのエフォート（1〜9）
+	// BitDepth はAVIFの出力ビット深度です（8, 10, 12。0の場合は設定ファイルの値）
+	BitDepth int
+	// ChromaSubsampling はAVIFの色差サブサンプリングです（420, 422, 444。空の場合は設定ファイルの値）
+	ChromaSubsampling string
+	// Lossless は可逆圧縮でエンコードするかどうかです（WebPのみ）
+	Lossless bool
+	// ICCProfile は出力に埋め込むICCプロファイルです（WebP・AVIF。nil の場合は埋め込まない）
+	ICCProfile []byte
+	// XMP は出力に埋め込むXMPです（AVIFのみ、avifenc が必要。WebPは変換後のファイルに埋め込む）
+	XMP []byte
+
+	// resolved は変換器の設定からすべての値を決定済みかどうかです
+	// true の場合は値が0でも設定ファイルの値で補わないため、変換器ごとの設定がグローバルな設定の影響を受けません
+	resolved bool
+}
+
+// qualityOr は上書きする画質があればそれを、なければ既定値を返します
+func (o *EncodeOptions) qualityOr(defaultQuality int) int {
+	if o == nil {
+		return defaultQuality
+	}
+	return o.intOr(o.Quality, defaultQuality)
+}
+
+// intOr は決定済み、または0以外の値であれば value を、それ以外は既定値を返します
+func (o *EncodeOptions) intOr(value, defaultValue int) int {
+	if o.resolved || value != 0 {
+		return value
+	}
+	return defaultValue
+}
+
+// webpSettings はWebPの画質と圧縮方法を返します。未指定の値は設定ファイルの値で補います
+func (o *EncodeOptions) webpSettings() (quality, method int) {
+	if o == nil {
+		return config.GetWebPQuality(), config.GetWebPCompressionLevel()
+	}
+	return o.intOr(o.Quality, config.GetWebPQuality()), o.intOr(o.Method, config.GetWebPCompressionLevel())
+}
+
+// avifSettings はAVIFのエンコードオプションを返します。未指定の値は設定ファイルの値で補います
+func (o *EncodeOptions) avifSettings() AVIFEncodeOptions {
+	if o == nil {
+		return AVIFEncodeOptions{}.withDefaults()
+	}
+	opts := AVIFEncodeOptions{
+		Quality:           o.Quality,
+		Speed:             o.Speed,
+		BitDepth:          o.BitDepth,
+		ChromaSubsampling: o.ChromaSubsampling,
+	}
+	if o.resolved {
+		return opts
+	}
+	return opts.withDefaults()
+}
+
+// jxlSettings はJPEG XLの画質とエフォートを返します。未指定の値は設定ファイルの値で補います
+func (o *EncodeOptions) jxlSettings() (quality, effort int) {
+	if o == nil {
+		return config.GetJXLQuality(), config.GetJXLEffort()
+	}
+	return o.intOr(o.Quality, config.GetJXLQuality()), o.intOr(o.Effort, config.GetJXLEffort())
+}
+
+// EstimateOptimalQuality は画像のエッジ密度から形式ごとの最適な画質を推定します
+// 滑らかな画像はバンディングを避けるため画質を上げ、細部の多い画像は画質を下げます
+// 調整幅は baseQuality の±10です
+func EstimateOptimalQuality(img image.Image, format string, baseQuality int) int {
+	return adjustQuality(edgeDensity(img), format, baseQuality)
+}
+
+// adjustQuality はエッジ密度に応じて基準画質を調整します
+func adjustQuality(density float64, format string, baseQuality int) int {
+	// 密度を [low, high] の範囲で 0〜1 に正規化する
+	ratio := (density - lowEdgeDensity) / (highEdgeDensity - lowEdgeDensity)
+	ratio = math.Max(0, math.Min(1, ratio))
+
+	// 滑らかな画像: +10、細部の多い画像: -10
+	adjustment := int(math.Round(float64(maxQualityAdjustment) * (1 - 2*ratio)))
+
+	switch format {
+	case "avif":
+		// go-avifの品質値は小さいほど高画質のため、調整方向を反転する
+		return clampQuality(baseQuality-adjustment, 1, 63)
+	case "webp":
+		return clampQuality(baseQuality+adjustment, 0, 100)
+	default:
+		return clampQuality(baseQuality+adjustment, 1, 100)
+	}
+}
+
+// clampQuality は画質を指定範囲に収めます
+func clampQuality(quality, minQuality, maxQuality int) int {
+	if quality < minQuality {
+		return minQuality
+	}
+	if quality > maxQuality {
+		return maxQuality
+	}
+	return quality
+}
+
+// edgeDensity は隣接ピクセルとの輝度差が閾値を超えるピクセルの割合を返します
+// 大きな画像は間引いてサンプリングします
+func edgeDensity(img image.Image) float64 {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width < 2 || height < 2 {
+		return 0
+	}
+
+	step := 1
+	if longest := max(width, height); longest > edgeSampleLimit {
+		step = (longest + edgeSampleLimit - 1) / edgeSampleLimit
+	}
+
+	var edges, samples int
+	for y := bounds.Min.Y; y+step < bounds.Max.Y; y += step {
+		for x := bounds.Min.X; x+step < bounds.Max.X; x += step {
+			current := luminance(img, x, y)
+			dx := absInt(current - luminance(img, x+step, y))
+			dy := absInt(current - luminance(img, x, y+step))
+			if dx > edgeThreshold || dy > edgeThreshold {
+				edges++
+			}
+			samples++
+		}
+	}
+
+	if samples == 0 {
+		return 0
+	}
+	return float64(edges) / float64(samples)
+}
+
+// luminance はピクセルの輝度（0-255）を返します
+func luminance(img image.Image, x, y int) int {
+	r, g, b, _ := img.At(x, y).RGBA()
+	return int((299*r + 587*g + 114*b) / 1000 >> 8)
+}
+
+// absInt は整数の絶対値を返します
+func absInt(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
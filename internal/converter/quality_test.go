@@ -0,0 +1,132 @@
+package converter
+
+import (
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+
+	"github.com/223n/image-converter/internal/config"
+)
+
+// TestBaseEncodeOptionsIgnoreGlobalConfig は変換器の設定から決定したオプションが、グローバルな設定で補われないことを確認します
+func TestBaseEncodeOptionsIgnoreGlobalConfig(t *testing.T) {
+	t.Cleanup(func() { config.LoadConfigFromBytes(nil) })
+	global := "conversion:\n  webp:\n    quality: 80\n    compression_level: 6\n  avif:\n    speed: 7\n    bit_depth: 12\n    chroma_subsampling: \"444\"\n  jxl:\n    effort: 9\n"
+	if err := config.LoadConfigFromBytes([]byte(global)); err != nil {
+		t.Fatalf("設定の読み込みに失敗しました: %v", err)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Conversion.WebP.Quality = 0
+	cfg.Conversion.WebP.CompressionLevel = 0
+	cfg.Conversion.AVIF.Speed = 0
+	cfg.Conversion.AVIF.BitDepth = 8
+	cfg.Conversion.AVIF.ChromaSubsampling = config.AVIFChroma420
+	cfg.Conversion.JXL.Effort = 3
+	ic := NewImageConverter(&cfg, nil)
+	opts := ic.baseEncodeOptions()
+
+	if quality, method := opts["webp"].webpSettings(); quality != 0 || method != 0 {
+		t.Errorf("WebP = (画質 %d, 圧縮方法 %d), want (0, 0)", quality, method)
+	}
+	avifOpts := opts["avif"].avifSettings()
+	if avifOpts.Speed != 0 || avifOpts.BitDepth != 8 || avifOpts.ChromaSubsampling != config.AVIFChroma420 {
+		t.Errorf("AVIF = %+v, want 速度 0, ビット深度 8, サブサンプリング 420", avifOpts)
+	}
+	if _, effort := opts["jxl"].jxlSettings(); effort != 3 {
+		t.Errorf("JPEG XL のエフォート = %d, want 3", effort)
+	}
+}
+
+// TestEncodeOptionsFallBackToGlobalConfig は変換器を使わずに指定したオプションの未指定の値が、グローバルな設定で補われることを確認します
+func TestEncodeOptionsFallBackToGlobalConfig(t *testing.T) {
+	t.Cleanup(func() { config.LoadConfigFromBytes(nil) })
+	global := "conversion:\n  webp:\n    quality: 80\n    compression_level: 5\n  avif:\n    speed: 7\n    bit_depth: 10\n    chroma_subsampling: \"422\"\n"
+	if err := config.LoadConfigFromBytes([]byte(global)); err != nil {
+		t.Fatalf("設定の読み込みに失敗しました: %v", err)
+	}
+
+	var nilOpts *EncodeOptions
+	if quality, method := nilOpts.webpSettings(); quality != 80 || method != 5 {
+		t.Errorf("nil の WebP = (画質 %d, 圧縮方法 %d), want (80, 5)", quality, method)
+	}
+
+	partial := &EncodeOptions{Quality: 30}
+	if quality, method := partial.webpSettings(); quality != 30 || method != 5 {
+		t.Errorf("画質のみ指定した WebP = (画質 %d, 圧縮方法 %d), want (30, 5)", quality, method)
+	}
+	avifOpts := partial.avifSettings()
+	if avifOpts.Quality != 30 || avifOpts.Speed != 7 || avifOpts.BitDepth != 10 || avifOpts.ChromaSubsampling != config.AVIFChroma422 {
+		t.Errorf("画質のみ指定した AVIF = %+v, want 画質 30, 速度 7, ビット深度 10, サブサンプリング 422", avifOpts)
+	}
+}
+
+// qualitySampleImages は画質の自動調整を比較するための10枚の画像を作成します
+// 滑らかなグラデーションから細かいノイズまで、細部の量が段階的に増えるようにします
+func qualitySampleImages() []image.Image {
+	const width, height = 128, 96
+
+	images := make([]image.Image, 10)
+	for i := range images {
+		rng := rand.New(rand.NewSource(int64(i)))
+		noise := i * 25
+		img := image.NewNRGBA(image.Rect(0, 0, width, height))
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				base := x * 255 / width
+				v := base
+				if noise > 0 {
+					v += rng.Intn(2*noise+1) - noise
+				}
+				c := uint8(clampQuality(v, 0, 255))
+				img.SetNRGBA(x, y, color.NRGBA{R: c, G: uint8(y * 255 / height), B: 255 - c, A: 255})
+			}
+		}
+		images[i] = img
+	}
+	return images
+}
+
+// countingWriter は書き込まれたバイト数を数える io.Writer です
+type countingWriter struct {
+	n int64
+}
+
+// Write は書き込まれたバイト数を加算します
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
+
+// BenchmarkAdaptiveQuality は固定画質と自動調整した画質で、WebPの1枚あたりの平均ファイルサイズを比較します
+// bytes/image が平均ファイルサイズ、quality/image が平均画質です
+func BenchmarkAdaptiveQuality(b *testing.B) {
+	const baseQuality = 80
+	images := qualitySampleImages()
+
+	for _, bc := range []struct {
+		name    string
+		quality func(img image.Image) int
+	}{
+		{name: "fixed", quality: func(image.Image) int { return baseQuality }},
+		{name: "adaptive", quality: func(img image.Image) int { return EstimateOptimalQuality(img, "webp", baseQuality) }},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			var total countingWriter
+			var qualities int
+			for i := 0; i < b.N; i++ {
+				for _, img := range images {
+					quality := bc.quality(img)
+					if err := encodeWebP(img, &total, float32(quality), false, 4); err != nil {
+						b.Fatal(err)
+					}
+					qualities += quality
+				}
+			}
+			encoded := float64(b.N * len(images))
+			b.ReportMetric(float64(total.n)/encoded, "bytes/image")
+			b.ReportMetric(float64(qualities)/encoded, "quality/image")
+		})
+	}
+}
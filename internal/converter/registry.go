@@ -0,0 +1,121 @@
+/*
+Package converter の一部として、入力形式のデコーダーと出力形式のエンコーダーの登録機能を提供します。
+*/
+package converter
+
+import (
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/jdeng/goheif"
+)
+
+// DecodeFunc は入力データを画像にデコードする関数です
+type DecodeFunc func(r io.Reader) (image.Image, error)
+
+// EncodeFunc は画像を指定パスに保存する関数です
+// opts が nil でない場合は設定値より優先します
+// 書き込んだ内容のSHA256を16進文字列で返します（計算しない場合は空文字列を返します）
+type EncodeFunc func(img image.Image, outputPath string, opts *EncodeOptions) (string, error)
+
+// encoderEntry は登録されたエンコーダーの情報を保持します
+type encoderEntry struct {
+	ext    string
+	encode EncodeFunc
+}
+
+var (
+	registryMu sync.RWMutex
+	decoders   = make(map[string]DecodeFunc)
+	encoders   = make(map[string]encoderEntry)
+	// encoderOrder はエンコーダーの登録順（変換処理の実行順）です
+	encoderOrder []string
+)
+
+func init() {
+	// 組み込みのデコーダー
+	RegisterDecoder(".jpg", jpeg.Decode)
+	RegisterDecoder(".jpeg", jpeg.Decode)
+	RegisterDecoder(".png", png.Decode)
+	RegisterDecoder(".gif", gif.Decode)
+	RegisterDecoder(".svg", decodeSVG)
+	RegisterDecoder(".heic", goheif.Decode)
+	RegisterDecoder(".heif", goheif.Decode)
+
+	// 組み込みのエンコーダー（有効/無効は設定ファイルで切り替える）
+	RegisterEncoder("webp", ".webp", saveWebPWithOptions)
+	RegisterEncoder("avif", ".avif", saveAVIFWithOptions)
+	RegisterEncoder("jxl", ".jxl", func(img image.Image, outputPath string, opts *EncodeOptions) (string, error) {
+		return "", saveJXLWithOptions(img, outputPath, opts)
+	})
+}
+
+// normalizeExt は拡張子を小文字のドット付き形式に揃えます
+func normalizeExt(ext string) string {
+	ext = strings.ToLower(ext)
+	if !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	return ext
+}
+
+// RegisterDecoder は拡張子に対応するデコーダーを登録します
+// 既に登録されている拡張子の場合は置き換えます
+// 変換対象とするには設定ファイルの input.supported_extensions にも拡張子を追加してください
+func RegisterDecoder(ext string, fn DecodeFunc) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	decoders[normalizeExt(ext)] = fn
+}
+
+// RegisterEncoder は出力形式名に対応するエンコーダーを登録します
+// 既に登録されている形式の場合は実行順を保ったまま置き換えます
+// 組み込み以外の形式は、登録されていれば常に変換対象になります
+func RegisterEncoder(format, ext string, fn EncodeFunc) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	format = strings.ToLower(format)
+	if _, exists := encoders[format]; !exists {
+		encoderOrder = append(encoderOrder, format)
+	}
+	encoders[format] = encoderEntry{ext: normalizeExt(ext), encode: fn}
+}
+
+// RegisteredEncoders は登録されている出力形式名を登録順に返します
+func RegisteredEncoders() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return append([]string(nil), encoderOrder...)
+}
+
+// lookupDecoder は拡張子に対応するデコーダーを返します
+func lookupDecoder(ext string) (DecodeFunc, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	fn, ok := decoders[normalizeExt(ext)]
+	return fn, ok
+}
+
+// lookupEncoder は出力形式名に対応するエンコーダーを返します
+func lookupEncoder(format string) (encoderEntry, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	entry, ok := encoders[format]
+	return entry, ok
+}
+
+// encodeAs は登録されたエンコーダーで画像を保存します
+func encodeAs(format string, img image.Image, outputPath string, opts *EncodeOptions) (string, error) {
+	entry, ok := lookupEncoder(format)
+	if !ok {
+		return "", fmt.Errorf("エンコーダーが登録されていません: %s", format)
+	}
+	return entry.encode(img, outputPath, opts)
+}
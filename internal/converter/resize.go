@@ -0,0 +1,84 @@
+/*
+Package converter の一部として、出力前のリサイズとEXIFの向きの補正を提供します。
+*/
+package converter
+
+import (
+	"image"
+	"math"
+
+	"github.com/223n/image-converter/internal/config"
+	"github.com/223n/image-converter/pkg/imageutils"
+)
+
+// fitSize は size が最大幅・最大高さに収まるように、縦横比を保って縮小した寸法を返します
+// 最大値が0以下の辺は制限しません。収まっている場合は size をそのまま返します
+func fitSize(size image.Point, maxWidth, maxHeight int) image.Point {
+	if maxWidth <= 0 {
+		maxWidth = math.MaxInt32
+	}
+	if maxHeight <= 0 {
+		maxHeight = math.MaxInt32
+	}
+	if size.X <= maxWidth && size.Y <= maxHeight {
+		return size
+	}
+
+	// 幅と高さのうち、より縮小率の大きい方に合わせる
+	scale := min(float64(maxWidth)/float64(size.X), float64(maxHeight)/float64(size.Y))
+	return image.Pt(max(1, int(float64(size.X)*scale+0.5)), max(1, int(float64(size.Y)*scale+0.5)))
+}
+
+// autoOrientation は conversion.auto_orient が有効な場合に補正するEXIFの向きを返します
+// 無効な場合や補正が不要な場合（1、範囲外の値）は1を返します
+func autoOrientation(cfg *config.Config, orientation int) int {
+	if !cfg.Conversion.AutoOrient || orientation < 2 || orientation > 8 {
+		return 1
+	}
+	return orientation
+}
+
+// resizeBox は向きの補正前の画像に適用するリサイズの最大幅・最大高さを返します
+// 向きの補正で90度回転する場合（5〜8）は、補正後の画像が枠に収まるよう幅と高さを入れ替えます
+func resizeBox(cfg *config.Config, orientation int) (int, int) {
+	maxWidth, maxHeight := cfg.Conversion.Resize.MaxWidth, cfg.Conversion.Resize.MaxHeight
+	if orientation >= 5 {
+		return maxHeight, maxWidth
+	}
+	return maxWidth, maxHeight
+}
+
+// resizeAndOrient は conversion.resize による縮小と、EXIFの向きの補正をこの順に適用した画像を返します
+// orientation は元画像のEXIFの Orientation です。どちらも不要な場合は元の画像をそのまま返します
+func resizeAndOrient(cfg *config.Config, img image.Image, orientation int) image.Image {
+	orientation = autoOrientation(cfg, orientation)
+
+	maxWidth, maxHeight := resizeBox(cfg, orientation)
+	img = fitWithin(img, maxWidth, maxHeight)
+	if orientation != 1 {
+		img = imageutils.ApplyOrientation(img, orientation)
+	}
+	return img
+}
+
+// outputSize は元画像の寸法から、resizeAndOrient を適用した後の寸法を返します
+// 画像をデコードせずに出力パスの {width}・{height} を求めるために使用します
+func outputSize(cfg *config.Config, size image.Point, orientation int) image.Point {
+	orientation = autoOrientation(cfg, orientation)
+
+	maxWidth, maxHeight := resizeBox(cfg, orientation)
+	size = fitSize(size, maxWidth, maxHeight)
+	if orientation >= 5 {
+		return image.Pt(size.Y, size.X)
+	}
+	return size
+}
+
+// sourceOrientation は conversion.auto_orient が有効な場合に元画像のEXIFの向きを読み取ります
+// 無効な場合は読み取らずに1を返します
+func sourceOrientation(cfg *config.Config, filePath string) int {
+	if !cfg.Conversion.AutoOrient {
+		return 1
+	}
+	return imageutils.ReadOrientation(filePath)
+}
@@ -0,0 +1,128 @@
+package converter
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/223n/image-converter/internal/config"
+	"github.com/rwcarlsen/goexif/exif"
+)
+
+// TestOutputSizeMatchesResizeAndOrient は出力パスの計画に使う寸法が、実際に加工した画像の寸法と一致することを確認します
+func TestOutputSizeMatchesResizeAndOrient(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 300, 200))
+
+	tests := []struct {
+		name        string
+		maxWidth    int
+		maxHeight   int
+		autoOrient  bool
+		orientation int
+		want        image.Point
+	}{
+		{name: "加工なし", orientation: 1, autoOrient: true, want: image.Pt(300, 200)},
+		{name: "最大幅で縮小", maxWidth: 100, orientation: 1, autoOrient: true, want: image.Pt(100, 67)},
+		{name: "最大高さで縮小", maxHeight: 50, orientation: 3, autoOrient: true, want: image.Pt(75, 50)},
+		{name: "収まる場合は拡大しない", maxWidth: 1000, maxHeight: 1000, orientation: 1, autoOrient: true, want: image.Pt(300, 200)},
+		{name: "90度回転", orientation: 6, autoOrient: true, want: image.Pt(200, 300)},
+		{name: "270度回転して縮小", maxWidth: 100, maxHeight: 100, orientation: 8, autoOrient: true, want: image.Pt(67, 100)},
+		{name: "回転後の枠に収める", maxWidth: 40, maxHeight: 300, orientation: 5, autoOrient: true, want: image.Pt(40, 60)},
+		{name: "向きの補正が無効", maxWidth: 150, orientation: 6, autoOrient: false, want: image.Pt(150, 100)},
+		{name: "範囲外の向き", orientation: 9, autoOrient: true, want: image.Pt(300, 200)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			cfg.Conversion.Resize.MaxWidth = tt.maxWidth
+			cfg.Conversion.Resize.MaxHeight = tt.maxHeight
+			cfg.Conversion.AutoOrient = tt.autoOrient
+
+			planned := outputSize(&cfg, src.Bounds().Size(), tt.orientation)
+			actual := resizeAndOrient(&cfg, src, tt.orientation).Bounds().Size()
+			if planned != tt.want || actual != tt.want {
+				t.Errorf("outputSize = %v, resizeAndOrient = %v, want %v", planned, actual, tt.want)
+			}
+		})
+	}
+}
+
+// jpegWithOrientation は Orientation と ResolutionUnit のEXIFを持つ width x height のJPEGを返します
+func jpegWithOrientation(t *testing.T, width, height int, orientation uint16) []byte {
+	t.Helper()
+
+	// IFD0に SHORT 型の Orientation（0x0112）と ResolutionUnit（0x0128）を持つリトルエンディアンのTIFF
+	var tiff bytes.Buffer
+	le := binary.LittleEndian
+	tiff.WriteString("II")
+	binary.Write(&tiff, le, uint16(42))
+	binary.Write(&tiff, le, uint32(8))
+	binary.Write(&tiff, le, uint16(2))
+	for _, e := range [][2]uint16{{0x0112, orientation}, {0x0128, 2}} {
+		binary.Write(&tiff, le, e[0])
+		binary.Write(&tiff, le, uint16(3))
+		binary.Write(&tiff, le, uint32(1))
+		binary.Write(&tiff, le, e[1])
+		binary.Write(&tiff, le, uint16(0))
+	}
+	binary.Write(&tiff, le, uint32(0))
+
+	// SOIの直後にAPP1セグメントとして挿入する
+	encoded := encodeTestImage(t, ".jpg", width, height)
+	payload := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+	var out bytes.Buffer
+	out.Write([]byte{0xFF, 0xD8, 0xFF, 0xE1})
+	binary.Write(&out, binary.BigEndian, uint16(len(payload)+2))
+	out.Write(payload)
+	out.Write(encoded[2:])
+	return out.Bytes()
+}
+
+// TestConvertAutoOrient はEXIFの Orientation に従って画素を回転して出力し、引き継ぐEXIFから Orientation を削除することを確認します
+func TestConvertAutoOrient(t *testing.T) {
+	inputPath := filepath.Join(t.TempDir(), "rotated.jpg")
+	if err := os.WriteFile(inputPath, jpegWithOrientation(t, 40, 20, 6), 0644); err != nil {
+		t.Fatalf("入力ファイルの作成に失敗しました: %v", err)
+	}
+
+	ic := newWebPOnlyConverter()
+	ic.config.Conversion.PreserveEXIF = true
+	result, err := ic.Convert(inputPath)
+	if err != nil || !result.WebPSuccess {
+		t.Fatalf("Convert に失敗しました: %v (%+v)", err, result)
+	}
+
+	data, err := os.ReadFile(result.WebPPath)
+	if err != nil {
+		t.Fatalf("出力ファイルの読み込みに失敗しました: %v", err)
+	}
+	// Orientation 6 は90度回転のため、40x20 の元画像は 20x40 で出力される
+	if w, h := webpSize(t, data); w != 20 || h != 40 {
+		t.Errorf("出力の寸法 = %dx%d, want 20x40", w, h)
+	}
+
+	chunks, err := parseWebPChunks(data)
+	if err != nil {
+		t.Fatalf("WebPのチャンクの解析に失敗しました: %v", err)
+	}
+	var exifData []byte
+	for _, chunk := range chunks {
+		if chunk.fourCC == "EXIF" {
+			exifData = chunk.payload
+		}
+	}
+	x, err := exif.Decode(bytes.NewReader(exifData))
+	if err != nil {
+		t.Fatalf("引き継いだEXIFの解析に失敗しました: %v", err)
+	}
+	if _, err := x.Get(exif.Orientation); err == nil {
+		t.Error("補正済みの画像のEXIFに Orientation が残っています")
+	}
+	if _, err := x.Get(exif.ResolutionUnit); err != nil {
+		t.Errorf("Orientation 以外のタグが引き継がれていません: %v", err)
+	}
+}
@@ -0,0 +1,158 @@
+/*
+Package converter の一部として、変換結果の画質（SSIM）検証を提供します。
+*/
+package converter
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/223n/image-converter/pkg/imageutils"
+	"github.com/chai2010/webp"
+)
+
+// ssimQualityStep はSSIMが基準を下回った場合に画質を引き上げる幅です
+const ssimQualityStep = 5
+
+// verifySSIM は変換結果のSSIMを元画像と比較し、基準を下回る場合は画質を上げて再エンコードします
+// 最大画質に達するか基準を満たすまで繰り返し、最終的なSSIMとチェックサムを返します
+// 出力をデコードできない場合は検証をスキップし、SSIMとして0を返します
+func (ic *ImageConverter) verifySSIM(format string, src image.Image, outputPath string, opts *EncodeOptions, checksum string) (float64, string) {
+	minSSIM := ic.config.Conversion.MinSSIM
+
+	quality := ic.baseQuality(format, opts)
+	for {
+		decoded, err := decodeOutput(format, outputPath)
+		if err != nil {
+			ic.logManager.LogWarning("SSIM検証をスキップします [%s]: %v", outputPath, err)
+			return 0, checksum
+		}
+
+		ssim := imageutils.ComputeSSIM(src, decoded)
+		if ssim >= minSSIM {
+			ic.logManager.LogDebug("SSIM検証OK [%s]: %.4f (品質: %d)", outputPath, ssim, quality)
+			return ssim, checksum
+		}
+
+		next, ok := nextSSIMQuality(format, quality)
+		if !ok {
+			ic.logManager.LogWarning("最大画質でもSSIMが基準を下回りました [%s]: %.4f < %.4f", outputPath, ssim, minSSIM)
+			return ssim, checksum
+		}
+
+		ic.logManager.LogFileInfo("SSIMが基準を下回ったため再エンコードします [%s]: %.4f < %.4f (品質: %d -> %d)",
+			outputPath, ssim, minSSIM, quality, next)
+		quality = next
+
+		// 画質以外のオプション（可逆圧縮・ICCプロファイル）は引き継ぐ
+		var retry EncodeOptions
+		if opts != nil {
+			retry = *opts
+		}
+		retry.Quality = quality
+		checksum, err = ic.encode(format, src, outputPath, &retry)
+		if err != nil {
+			ic.logManager.LogError("再エンコードに失敗しました [%s]: %v", outputPath, err)
+			return ssim, ""
+		}
+	}
+}
+
+// verifyLossless は可逆圧縮した変換結果が元画像とピクセル単位で一致するかを確認します
+// 一致しない場合は警告を出力します。出力をデコードできない場合は確認をスキップします
+// 透過を含む画像はデコーダーが乗算済みアルファとして正しく扱えないため確認しません
+func (ic *ImageConverter) verifyLossless(format string, src image.Image, outputPath string) {
+	if imageutils.HasAlpha(src) {
+		return
+	}
+
+	decoded, err := decodeOutput(format, outputPath)
+	if err != nil {
+		ic.logManager.LogDebug("可逆圧縮の検証をスキップします [%s]: %v", outputPath, err)
+		return
+	}
+
+	if equal, differing := imageutils.ImagesEqual(src, decoded); !equal {
+		ic.logManager.LogWarning("可逆圧縮の変換結果が元画像と一致しません [%s]: %d ピクセルが異なります", outputPath, differing)
+	}
+}
+
+// baseQuality は形式ごとの実際に使用された画質を返します
+func (ic *ImageConverter) baseQuality(format string, opts *EncodeOptions) int {
+	switch format {
+	case "avif":
+		return opts.qualityOr(ic.config.AVIFNativeQuality())
+	case "jxl":
+		return opts.qualityOr(ic.config.Conversion.JXL.Quality)
+	default:
+		return opts.qualityOr(ic.config.Conversion.WebP.Quality)
+	}
+}
+
+// nextSSIMQuality は1段階高画質な品質値を返します。既に最大画質の場合は false を返します
+// go-avifの品質値は小さいほど高画質のため、AVIFは値を下げます
+func nextSSIMQuality(format string, quality int) (int, bool) {
+	if format == "avif" {
+		if quality <= 1 {
+			return quality, false
+		}
+		return max(quality-ssimQualityStep, 1), true
+	}
+
+	if quality >= 100 {
+		return quality, false
+	}
+	return min(quality+ssimQualityStep, 100), true
+}
+
+// decodeOutput は変換後のファイルをデコードします
+// AVIFはGoのデコーダーがないため、avifdecコマンドが利用可能な場合のみデコードします
+func decodeOutput(format, path string) (image.Image, error) {
+	switch format {
+	case "webp":
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("ファイルの読み込みに失敗しました: %v", err)
+		}
+		return webp.Decode(bytes.NewReader(data))
+	case "avif":
+		return decodeAVIFUsingCommand(path)
+	default:
+		return nil, fmt.Errorf("%s形式のデコードには対応していません", format)
+	}
+}
+
+// decodeAVIFUsingCommand は外部コマンド（avifdec）でAVIFをPNGに変換してデコードします
+func decodeAVIFUsingCommand(path string) (image.Image, error) {
+	if _, err := exec.LookPath("avifdec"); err != nil {
+		return nil, fmt.Errorf("avifdecコマンドが見つかりません。次のコマンドでインストールしてください: sudo apt-get install libavif-bin")
+	}
+
+	tempDir, err := os.MkdirTemp("", "avif-decode-")
+	if err != nil {
+		return nil, fmt.Errorf("一時ディレクトリの作成に失敗しました: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	tempPNGPath := filepath.Join(tempDir, "decoded.png")
+
+	var stderr bytes.Buffer
+	cmd := exec.Command("avifdec", path, tempPNGPath)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("avifdecコマンドの実行に失敗しました: %v\n出力: %s", err, stderr.String())
+	}
+
+	file, err := os.Open(tempPNGPath)
+	if err != nil {
+		return nil, fmt.Errorf("デコード結果を開けません: %v", err)
+	}
+	defer file.Close()
+
+	return png.Decode(file)
+}
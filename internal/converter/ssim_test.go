@@ -0,0 +1,64 @@
+package converter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/223n/image-converter/internal/config"
+	"github.com/223n/image-converter/internal/utils"
+)
+
+func TestNextSSIMQuality(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		quality int
+		want    int
+		wantOK  bool
+	}{
+		{name: "WebPは画質を上げる", format: "webp", quality: 80, want: 85, wantOK: true},
+		{name: "WebPは100を上限とする", format: "webp", quality: 98, want: 100, wantOK: true},
+		{name: "WebPの最大画質", format: "webp", quality: 100, want: 100, wantOK: false},
+		{name: "AVIFは値を下げる", format: "avif", quality: 30, want: 25, wantOK: true},
+		{name: "AVIFは1を下限とする", format: "avif", quality: 3, want: 1, wantOK: true},
+		{name: "AVIFの最大画質", format: "avif", quality: 1, want: 1, wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := nextSSIMQuality(tt.format, tt.quality)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("nextSSIMQuality(%q, %d) = (%d, %v), want (%d, %v)", tt.format, tt.quality, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+// TestConvertVerifySSIM は低画質でSSIMが基準を下回る場合に、画質を上げて再エンコードし、SSIMを記録することを確認します
+func TestConvertVerifySSIM(t *testing.T) {
+	inputPath := filepath.Join(t.TempDir(), "photo.png")
+	if err := os.WriteFile(inputPath, encodeTestImage(t, ".png", 64, 48), 0644); err != nil {
+		t.Fatalf("入力ファイルの作成に失敗しました: %v", err)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Conversion.WebP.Enabled = true
+	cfg.Conversion.WebP.Quality = 1
+	cfg.Conversion.AVIF.Enabled = false
+	cfg.Conversion.JXL.Enabled = false
+	cfg.Conversion.VerifySSIM = true
+	cfg.Conversion.MinSSIM = 0.99
+	ic := NewImageConverter(&cfg, utils.NewLogManager())
+
+	result, err := ic.Convert(inputPath)
+	if err != nil {
+		t.Fatalf("Convert に失敗しました: %v", err)
+	}
+	if !result.WebPSuccess {
+		t.Fatalf("WebPへの変換に失敗しました: %+v", result)
+	}
+	if result.SSIM < cfg.Conversion.MinSSIM {
+		t.Errorf("SSIM = %f, %f 以上を期待しました", result.SSIM, cfg.Conversion.MinSSIM)
+	}
+}
@@ -0,0 +1,32 @@
+/*
+Package converter の一部として、SVG画像のラスタライズに特化した関数を提供します。
+*/
+package converter
+
+import (
+	"fmt"
+	"image"
+	"io"
+
+	"github.com/srwiley/oksvg"
+	"github.com/srwiley/rasterx"
+
+	"github.com/223n/image-converter/internal/config"
+)
+
+// decodeSVG はSVGを解析し、設定されたキャンバスサイズのRGBA画像にラスタライズします
+func decodeSVG(r io.Reader) (image.Image, error) {
+	icon, err := oksvg.ReadIconStream(r)
+	if err != nil {
+		return nil, fmt.Errorf("SVGの解析に失敗しました: %v", err)
+	}
+
+	width, height := config.GetSVGCanvasSize()
+	icon.SetTarget(0, 0, float64(width), float64(height))
+
+	rgba := image.NewRGBA(image.Rect(0, 0, width, height))
+	scanner := rasterx.NewScannerGV(width, height, rgba, rgba.Bounds())
+	icon.Draw(rasterx.NewDasher(width, height, scanner), 1.0)
+
+	return rgba, nil
+}
@@ -0,0 +1,84 @@
+package converter
+
+import (
+	"image"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/223n/image-converter/internal/config"
+)
+
+// rectSVG は 20x10 の領域の左半分を赤い <rect> で塗るSVGです
+const rectSVG = `<svg xmlns="http://www.w3.org/2000/svg" width="20" height="10" viewBox="0 0 20 10">
+  <rect x="0" y="0" width="10" height="10" fill="#ff0000"/>
+</svg>`
+
+// useSVGCanvas はSVGのキャンバスサイズを設定し、テストの後に設定を元に戻します
+func useSVGCanvas(t *testing.T, width, height int) {
+	t.Helper()
+
+	data := []byte("input:\n  svg:\n    width: " + strconv.Itoa(width) + "\n    height: " + strconv.Itoa(height) + "\n")
+	if err := config.LoadConfigFromBytes(data); err != nil {
+		t.Fatalf("設定の読み込みに失敗しました: %v", err)
+	}
+	t.Cleanup(func() { config.LoadConfigFromBytes(nil) })
+}
+
+func TestDecodeSVG(t *testing.T) {
+	tests := []struct {
+		name          string
+		width, height int
+	}{
+		{name: "SVGと同じ寸法", width: 20, height: 10},
+		{name: "キャンバスに合わせて拡大", width: 40, height: 20},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useSVGCanvas(t, tt.width, tt.height)
+
+			img, err := decodeSVG(strings.NewReader(rectSVG))
+			if err != nil {
+				t.Fatalf("decodeSVG に失敗しました: %v", err)
+			}
+			if got := img.Bounds(); got != image.Rect(0, 0, tt.width, tt.height) {
+				t.Fatalf("画像の範囲 = %v, want %dx%d", got, tt.width, tt.height)
+			}
+
+			// 左半分は不透明な赤、右半分は透明になる
+			if r, g, b, a := img.At(tt.width/4, tt.height/2).RGBA(); r != 0xffff || g != 0 || b != 0 || a != 0xffff {
+				t.Errorf("矩形の内側の画素 = %v, want 不透明な赤", img.At(tt.width/4, tt.height/2))
+			}
+			if _, _, _, a := img.At(tt.width*3/4, tt.height/2).RGBA(); a != 0 {
+				t.Errorf("矩形の外側の画素 = %v, want 透明", img.At(tt.width*3/4, tt.height/2))
+			}
+		})
+	}
+
+	t.Run("XMLとして不正", func(t *testing.T) {
+		if _, err := decodeSVG(strings.NewReader("<svg><rect")); err == nil {
+			t.Error("不正なSVGでエラーになりませんでした")
+		}
+	})
+}
+
+// TestDecodeSVGFile は拡張子 .svg のファイルを登録済みのデコーダーでラスタライズすることを確認します
+func TestDecodeSVGFile(t *testing.T) {
+	useSVGCanvas(t, 20, 10)
+
+	inputPath := filepath.Join(t.TempDir(), "rect.svg")
+	if err := os.WriteFile(inputPath, []byte(rectSVG), 0644); err != nil {
+		t.Fatalf("入力ファイルの作成に失敗しました: %v", err)
+	}
+
+	img, info, err := newWebPOnlyConverter().Decode(inputPath)
+	if err != nil {
+		t.Fatalf("Decode に失敗しました: %v", err)
+	}
+	if img.Bounds().Dx() != 20 || img.Bounds().Dy() != 10 || info.Width != 20 || info.Height != 10 {
+		t.Errorf("画像の寸法 = %v (%dx%d), want 20x10", img.Bounds(), info.Width, info.Height)
+	}
+}
@@ -0,0 +1,58 @@
+/*
+Package converter の一部として、変換前の透かし（ウォーターマーク）合成を提供します。
+*/
+package converter
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"sync"
+
+	"github.com/223n/image-converter/pkg/imageutils"
+)
+
+var (
+	// 透かし画像は全ファイルで共通のため、最初の1回だけ読み込む
+	watermarkOnce  sync.Once
+	watermarkImage image.Image
+	watermarkErr   error
+)
+
+// loadWatermark は透かし画像（アルファ付きPNG）を読み込みます
+// 読み込みは1回だけ行い、以降はキャッシュした結果を返します
+func loadWatermark(path string) (image.Image, error) {
+	watermarkOnce.Do(func() {
+		file, err := os.Open(path)
+		if err != nil {
+			watermarkErr = fmt.Errorf("透かし画像を開けません: %v", err)
+			return
+		}
+		defer file.Close()
+
+		watermarkImage, err = png.Decode(file)
+		if err != nil {
+			watermarkErr = fmt.Errorf("透かし画像のデコードに失敗しました: %v", err)
+		}
+	})
+
+	return watermarkImage, watermarkErr
+}
+
+// applyWatermark は透かしが有効な場合に画像へ透かしを合成します
+// 透かし画像を読み込めない場合は警告を出力し、元の画像をそのまま返します
+func (ic *ImageConverter) applyWatermark(img image.Image) image.Image {
+	settings := ic.config.Conversion.Watermark
+	if !settings.Enabled {
+		return img
+	}
+
+	watermark, err := loadWatermark(settings.ImagePath)
+	if err != nil {
+		ic.logManager.LogWarning("透かしを適用できません: %v", err)
+		return img
+	}
+
+	return imageutils.ApplyWatermark(img, watermark, settings.Position, settings.Opacity)
+}
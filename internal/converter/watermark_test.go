@@ -0,0 +1,51 @@
+package converter
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"os"
+	"testing"
+)
+
+// TestApplyWatermarkCorner は 2x2 の赤い透かしを右下に合成し、角の画素が赤になることを確認します
+func TestApplyWatermarkCorner(t *testing.T) {
+	white := color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+	red := color.NRGBA{R: 255, A: 255}
+
+	src := image.NewNRGBA(image.Rect(0, 0, 8, 6))
+	draw.Draw(src, src.Bounds(), image.NewUniform(white), image.Point{}, draw.Src)
+	watermark := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	draw.Draw(watermark, watermark.Bounds(), image.NewUniform(red), image.Point{}, draw.Src)
+
+	ic := newWebPOnlyConverter()
+	useWatermark(t, ic.config, watermark)
+
+	img := ic.applyWatermark(src)
+	for _, p := range []image.Point{{6, 4}, {7, 5}} {
+		if c := color.NRGBAModel.Convert(img.At(p.X, p.Y)); c != red {
+			t.Errorf("右下の画素 %v = %v, want %v", p, c, red)
+		}
+	}
+	for _, p := range []image.Point{{5, 5}, {7, 3}, {0, 0}} {
+		if c := color.NRGBAModel.Convert(img.At(p.X, p.Y)); c != white {
+			t.Errorf("透かしの外側の画素 %v = %v, want %v", p, c, white)
+		}
+	}
+
+	// 2回目以降はキャッシュした透かしを使用する
+	if err := os.Remove(ic.config.Conversion.Watermark.ImagePath); err != nil {
+		t.Fatalf("透かし画像の削除に失敗しました: %v", err)
+	}
+	img = ic.applyWatermark(src)
+	if c := color.NRGBAModel.Convert(img.At(7, 5)); c != red {
+		t.Errorf("キャッシュした透かしの画素 = %v, want %v", c, red)
+	}
+
+	t.Run("無効な場合は合成しない", func(t *testing.T) {
+		ic.config.Conversion.Watermark.Enabled = false
+		if img := ic.applyWatermark(src); img != image.Image(src) {
+			t.Error("透かしが無効な場合に元の画像以外が返されました")
+		}
+	})
+}
@@ -4,9 +4,11 @@ Package converter の一部として、WebP変換に特化した関数を提供
 package converter
 
 import (
+	"bytes"
 	"fmt"
 	"image"
 	"image/png"
+	"io"
 	"log"
 	"os"
 	"os/exec"
@@ -17,46 +19,82 @@ import (
 )
 
 // SaveWebP は画像をWebPとして保存します
+//
+// Deprecated: グローバルな設定を参照します。個別の設定で変換する場合は pkg/converter の Converter を使用してください
 func SaveWebP(img image.Image, outputPath string) error {
-	// 最適なWebPエンコーダーを選択
-	encoder := selectBestWebPEncoder()
+	_, err := SaveWebPWithChecksum(img, outputPath)
+	return err
+}
+
+// SaveWebPWithChecksum は画像をWebPとして保存し、書き込み中に計算したSHA256を返します
+//
+// Deprecated: グローバルな設定を参照します。個別の設定で変換する場合は pkg/converter の Converter を使用してください
+func SaveWebPWithChecksum(img image.Image, outputPath string) (string, error) {
+	return saveWebPWithOptions(img, outputPath, nil)
+}
+
+// saveWebPWithOptions はオプションで画質を上書きしてWebPとして保存します
+func saveWebPWithOptions(img image.Image, outputPath string, opts *EncodeOptions) (string, error) {
+	return saveWithEncoder(WebPEncoder, img, outputPath, opts)
+}
+
+// WebPEncodeOptions は EncodeWebP のオプションです
+type WebPEncodeOptions struct {
+	Quality  float32 // 画質（0〜100、0の場合は設定ファイルの値）。可逆圧縮では圧縮の努力度として扱われます
+	Lossless bool    // 可逆圧縮でエンコードするかどうか
+	Method   int     // 圧縮方法（1〜6、値が大きいほど遅いが小さくなる。0の場合は設定ファイルの値）。cwebpのみ有効です
+}
+
+// EncodeWebP は画像をWebPとして w に書き込みます
+// 範囲外の画質・圧縮方法は範囲内に丸めます
+func EncodeWebP(img image.Image, w io.Writer, opts WebPEncodeOptions) error {
+	quality := opts.Quality
+	if quality <= 0 {
+		quality = float32(config.GetWebPQuality())
+	}
+	method := opts.Method
+	if method <= 0 {
+		method = config.GetWebPCompressionLevel()
+	}
 
-	switch encoder {
+	return encodeWebP(img, w, min(quality, 100), opts.Lossless, min(method, 6))
+}
+
+// encodeWebP は最適なエンコーダーを選択して画像をWebPとして書き込みます
+// lossless が true の場合は可逆圧縮でエンコードし、quality は圧縮の努力度として扱われます
+func encodeWebP(img image.Image, w io.Writer, quality float32, lossless bool, method int) error {
+	switch selectBestWebPEncoder() {
 	case "cwebp":
 		// cwebpコマンドを使用
-		return saveWebPUsingCommand(img, outputPath, config.GetWebPQuality())
+		return encodeWebPUsingCommand(img, w, quality, lossless, method)
 	case "libwebp":
 		// libwebpを直接使用（必要に応じて実装）
-		// 現在はsaveWebPUsingCommandを使用
-		return saveWebPUsingCommand(img, outputPath, config.GetWebPQuality())
+		// 現在はencodeWebPUsingCommandを使用
+		return encodeWebPUsingCommand(img, w, quality, lossless, method)
 	default:
 		// Goのwebpライブラリを使用
-		return saveWebPUsingLibrary(img, outputPath)
+		return encodeWebPUsingLibrary(img, w, quality, lossless)
 	}
 }
 
-// saveWebPUsingLibrary はGoのWebPライブラリを使用して保存します
-func saveWebPUsingLibrary(img image.Image, outputPath string) error {
-	output, err := os.Create(outputPath)
-	if err != nil {
-		return fmt.Errorf("出力ファイルの作成に失敗しました: %v", err)
-	}
-	defer output.Close()
-
+// encodeWebPUsingLibrary はGoのWebPライブラリを使用して書き込みます
+// ライブラリは圧縮方法を指定できないため、ライブラリの既定値でエンコードします
+func encodeWebPUsingLibrary(img image.Image, w io.Writer, quality float32, lossless bool) error {
 	opts := &webp.Options{
-		Lossless: false,
-		Quality:  float32(config.GetWebPQuality()),
+		Lossless: lossless,
+		Quality:  quality,
 	}
 
-	if err := webp.Encode(output, img, opts); err != nil {
+	if err := webp.Encode(w, img, opts); err != nil {
 		return fmt.Errorf("WebPエンコードに失敗しました: %v", err)
 	}
 
 	return nil
 }
 
-// saveWebPUsingCommand は外部コマンド（cwebpツール）を使用してWebP画像を保存します
-func saveWebPUsingCommand(img image.Image, outputPath string, quality int) error {
+// encodeWebPUsingCommand は外部コマンド（cwebpツール）を使用してWebP画像を書き込みます
+// cwebpの出力は標準出力経由で受け取り、そのまま w へ流します
+func encodeWebPUsingCommand(img image.Image, w io.Writer, quality float32, lossless bool, method int) error {
 	// 一時的にPNGとして保存
 	tempDir, err := os.MkdirTemp("", "webp-conversion-")
 	if err != nil {
@@ -86,14 +124,31 @@ func saveWebPUsingCommand(img image.Image, outputPath string, quality int) error
 	}
 
 	// cwebpを使ってWebPに変換
-	cmd := exec.Command("cwebp", "-q", fmt.Sprintf("%d", quality), tempPNGPath, "-o", outputPath)
-	if output, err := cmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("cwebpコマンドの実行に失敗しました: %v\n出力: %s", err, string(output))
+	var stderr bytes.Buffer
+	cmd := exec.Command("cwebp", cwebpArgs(tempPNGPath, quality, lossless, method)...)
+	cmd.Stdout = w
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("cwebpコマンドの実行に失敗しました: %v\n出力: %s", err, stderr.String())
 	}
 
 	return nil
 }
 
+// cwebpArgs は inputPath をWebPに変換するcwebpの引数を作成します
+// 変換結果は "-o -" で標準出力に書き出します
+func cwebpArgs(inputPath string, quality float32, lossless bool, method int) []string {
+	args := []string{"-q", fmt.Sprintf("%g", quality), "-m", fmt.Sprintf("%d", method)}
+	if lossless {
+		args = append(args, "-lossless")
+	}
+	if config.GetExternalThreads() > 1 {
+		// cwebpはスレッド数を指定できないため、マルチスレッドの有無のみを切り替える
+		args = append(args, "-mt")
+	}
+	return append(args, inputPath, "-o", "-")
+}
+
 // selectBestWebPEncoder はWebP変換の最適な方法を選択します
 func selectBestWebPEncoder() string {
 	// 優先順位:
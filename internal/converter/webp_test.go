@@ -0,0 +1,186 @@
+package converter
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/223n/image-converter/internal/config"
+	"github.com/chai2010/webp"
+)
+
+// decodeTestImage は encodeTestImage のPNGをデコードした画像を返します
+func decodeTestImage(t *testing.T, width, height int) image.Image {
+	t.Helper()
+
+	img, err := png.Decode(bytes.NewReader(encodeTestImage(t, ".png", width, height)))
+	if err != nil {
+		t.Fatalf("テスト画像のデコードに失敗しました: %v", err)
+	}
+	return img
+}
+
+// firstWebPChunk はWebPデータの最初のチャンク名（"VP8 " は非可逆、"VP8L" は可逆圧縮）を返します
+func firstWebPChunk(t *testing.T, data []byte) string {
+	t.Helper()
+
+	if len(data) < 16 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		t.Fatalf("WebPのヘッダーではありません: % x", data[:min(len(data), 16)])
+	}
+	return string(data[12:16])
+}
+
+// TestCwebpArgsDefaultConfig はデフォルト設定の圧縮レベルが "-m 4" としてcwebpに渡されることを確認します
+func TestCwebpArgsDefaultConfig(t *testing.T) {
+	t.Cleanup(func() { config.LoadConfigFromBytes(nil) })
+	if err := config.LoadConfigFromBytes(nil); err != nil {
+		t.Fatalf("設定の読み込みに失敗しました: %v", err)
+	}
+
+	quality := float32(config.GetWebPQuality())
+	cmd := exec.Command("cwebp", cwebpArgs("in.png", quality, false, config.GetWebPCompressionLevel())...)
+
+	found := false
+	for i := 0; i+1 < len(cmd.Args); i++ {
+		if cmd.Args[i] == "-m" && cmd.Args[i+1] == "4" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("cmd.Args に -m 4 がありません: %v", cmd.Args)
+	}
+}
+
+func TestCwebpArgs(t *testing.T) {
+	t.Cleanup(func() { config.LoadConfigFromBytes(nil) })
+
+	tests := []struct {
+		name     string
+		threads  string
+		quality  float32
+		lossless bool
+		method   int
+		want     []string
+	}{
+		{name: "非可逆圧縮", threads: "1", quality: 80, method: 4, want: []string{"-q", "80", "-m", "4", "in.png", "-o", "-"}},
+		{name: "小数の画質", threads: "1", quality: 72.5, method: 6, want: []string{"-q", "72.5", "-m", "6", "in.png", "-o", "-"}},
+		{name: "可逆圧縮", threads: "1", quality: 100, lossless: true, method: 1, want: []string{"-q", "100", "-m", "1", "-lossless", "in.png", "-o", "-"}},
+		{name: "マルチスレッド", threads: "4", quality: 80, method: 4, want: []string{"-q", "80", "-m", "4", "-mt", "in.png", "-o", "-"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := config.LoadConfigFromBytes([]byte("conversion:\n  external_threads: " + tt.threads + "\n")); err != nil {
+				t.Fatalf("設定の読み込みに失敗しました: %v", err)
+			}
+			if got := cwebpArgs("in.png", tt.quality, tt.lossless, tt.method); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("cwebpArgs = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeWebP(t *testing.T) {
+	t.Cleanup(func() { config.LoadConfigFromBytes(nil) })
+	if err := config.LoadConfigFromBytes(nil); err != nil {
+		t.Fatalf("設定の読み込みに失敗しました: %v", err)
+	}
+	img := decodeTestImage(t, 40, 30)
+
+	tests := []struct {
+		name      string
+		opts      WebPEncodeOptions
+		wantChunk string
+	}{
+		{name: "非可逆圧縮", opts: WebPEncodeOptions{Quality: 80, Method: 4}, wantChunk: "VP8 "},
+		{name: "未指定の値は設定ファイルの値", opts: WebPEncodeOptions{}, wantChunk: "VP8 "},
+		{name: "範囲外の値", opts: WebPEncodeOptions{Quality: 500, Method: 99}, wantChunk: "VP8 "},
+		{name: "可逆圧縮", opts: WebPEncodeOptions{Quality: 100, Lossless: true}, wantChunk: "VP8L"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := EncodeWebP(img, &buf, tt.opts); err != nil {
+				t.Fatalf("EncodeWebP に失敗しました: %v", err)
+			}
+			if got := firstWebPChunk(t, buf.Bytes()); got != tt.wantChunk {
+				t.Errorf("チャンク = %q, want %q", got, tt.wantChunk)
+			}
+			if w, h := webpSize(t, buf.Bytes()); w != 40 || h != 30 {
+				t.Errorf("寸法 = %dx%d, want 40x30", w, h)
+			}
+		})
+	}
+}
+
+// TestEncodeWebPClampsQuality は範囲外の画質が100に丸められ、画質100と同じ出力になることを確認します
+func TestEncodeWebPClampsQuality(t *testing.T) {
+	img := decodeTestImage(t, 40, 30)
+
+	var clamped, max bytes.Buffer
+	if err := EncodeWebP(img, &clamped, WebPEncodeOptions{Quality: 500, Method: 99}); err != nil {
+		t.Fatalf("EncodeWebP に失敗しました: %v", err)
+	}
+	if err := EncodeWebP(img, &max, WebPEncodeOptions{Quality: 100, Method: 6}); err != nil {
+		t.Fatalf("EncodeWebP に失敗しました: %v", err)
+	}
+	if !bytes.Equal(clamped.Bytes(), max.Bytes()) {
+		t.Errorf("画質500の出力（%d バイト）が画質100の出力（%d バイト）と一致しません", clamped.Len(), max.Len())
+	}
+}
+
+// TestEncodeWebPLossless は可逆圧縮の出力をデコードすると元の画素に戻ることを確認します
+func TestEncodeWebPLossless(t *testing.T) {
+	img := decodeTestImage(t, 40, 30)
+
+	var buf bytes.Buffer
+	if err := EncodeWebP(img, &buf, WebPEncodeOptions{Quality: 100, Lossless: true}); err != nil {
+		t.Fatalf("EncodeWebP に失敗しました: %v", err)
+	}
+	decoded, err := webp.Decode(&buf)
+	if err != nil {
+		t.Fatalf("WebPのデコードに失敗しました: %v", err)
+	}
+
+	for y := 0; y < 30; y++ {
+		for x := 0; x < 40; x++ {
+			r1, g1, b1, a1 := img.At(x, y).RGBA()
+			r2, g2, b2, a2 := decoded.At(x, y).RGBA()
+			if r1>>8 != r2>>8 || g1>>8 != g2>>8 || b1>>8 != b2>>8 || a1>>8 != a2>>8 {
+				t.Fatalf("(%d, %d) の画素 = %v, want %v", x, y, decoded.At(x, y), img.At(x, y))
+			}
+		}
+	}
+}
+
+// TestSaveWebP はファイルに保存した内容が EncodeWebP の出力と同じになることを確認します
+func TestSaveWebP(t *testing.T) {
+	t.Cleanup(func() { config.LoadConfigFromBytes(nil) })
+	if err := config.LoadConfigFromBytes(nil); err != nil {
+		t.Fatalf("設定の読み込みに失敗しました: %v", err)
+	}
+	img := decodeTestImage(t, 40, 30)
+
+	outputPath := filepath.Join(t.TempDir(), "photo.webp")
+	if err := SaveWebP(img, outputPath); err != nil {
+		t.Fatalf("SaveWebP に失敗しました: %v", err)
+	}
+	saved, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("出力ファイルを読み込めません: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := EncodeWebP(img, &buf, WebPEncodeOptions{}); err != nil {
+		t.Fatalf("EncodeWebP に失敗しました: %v", err)
+	}
+	if !bytes.Equal(saved, buf.Bytes()) {
+		t.Errorf("保存した内容（%d バイト）が EncodeWebP の出力（%d バイト）と一致しません", len(saved), buf.Len())
+	}
+}
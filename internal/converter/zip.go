@@ -0,0 +1,168 @@
+/*
+Package converter の一部として、変換結果を1つのZIPアーカイブにまとめる機能を提供します。
+*/
+package converter
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ZipOutput は変換結果のファイルを1つのZIPアーカイブに書き込みます
+// 複数のワーカーから同時に追加できるよう、アーカイブへの書き込みはミューテックスで直列化します
+type ZipOutput struct {
+	path    string
+	baseDir string // エントリ名の基準にするディレクトリ（入力ディレクトリ）
+
+	mu     sync.Mutex
+	file   *os.File
+	writer *zip.Writer
+}
+
+// NewZipOutput は path にZIPアーカイブを作成します
+// 追加するファイルは baseDir からの相対パスをエントリ名として格納します
+func NewZipOutput(path, baseDir string) (*ZipOutput, error) {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("ZIPアーカイブの出力先ディレクトリの作成に失敗しました: %v", err)
+		}
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("ZIPアーカイブの作成に失敗しました: %v", err)
+	}
+
+	return &ZipOutput{
+		path:    path,
+		baseDir: baseDir,
+		file:    file,
+		writer:  zip.NewWriter(file),
+	}, nil
+}
+
+// Path はZIPアーカイブのパスを返します
+func (z *ZipOutput) Path() string {
+	return z.path
+}
+
+// Add はファイルをアーカイブに追加し、追加したファイルを削除します
+// 基準ディレクトリの外にあるファイルはファイル名だけをエントリ名にします。追加したエントリ名を返します
+func (z *ZipOutput) Add(path string) (string, error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("アーカイブに追加するファイルを開けません: %v", err)
+	}
+	defer src.Close()
+
+	fi, err := src.Stat()
+	if err != nil {
+		return "", fmt.Errorf("アーカイブに追加するファイルの情報を取得できません: %v", err)
+	}
+
+	name := z.entryName(path)
+	header := &zip.FileHeader{
+		Name:     name,
+		Modified: fi.ModTime(),
+		// 画像は既に圧縮されているため、再圧縮せずに格納する
+		Method: zip.Store,
+	}
+
+	z.mu.Lock()
+	err = z.writeEntry(header, src)
+	z.mu.Unlock()
+	if err != nil {
+		return "", fmt.Errorf("ZIPアーカイブへの書き込みに失敗しました [%s]: %v", name, err)
+	}
+
+	src.Close()
+	if err := os.Remove(path); err != nil {
+		return name, fmt.Errorf("アーカイブに追加したファイルの削除に失敗しました: %v", err)
+	}
+
+	return name, nil
+}
+
+// writeEntry はエントリを1つ書き込みます（呼び出し側でロックを取得します）
+func (z *ZipOutput) writeEntry(header *zip.FileHeader, r io.Reader) error {
+	if z.writer == nil {
+		return fmt.Errorf("ZIPアーカイブは既に閉じられています")
+	}
+
+	w, err := z.writer.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, r)
+	return err
+}
+
+// entryName はファイルのパスから基準ディレクトリからの相対パスのエントリ名を返します
+func (z *ZipOutput) entryName(path string) string {
+	rel, err := filepath.Rel(z.baseDir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		rel = filepath.Base(path)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// Close はアーカイブの中央ディレクトリを書き込んでファイルを閉じます
+// 2回目以降の呼び出しでは何もしません
+func (z *ZipOutput) Close() error {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+
+	if z.writer == nil {
+		return nil
+	}
+
+	err := z.writer.Close()
+	z.writer = nil
+	if cerr := z.file.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("ZIPアーカイブの書き込みに失敗しました: %v", err)
+	}
+	return nil
+}
+
+// archiveOutputs は変換に成功した出力ファイルとチェックサムファイルをZIPアーカイブに移動します
+// 出力先のZIPアーカイブが設定されていない場合やドライランの場合は何もしません
+func (ic *ImageConverter) archiveOutputs(result *ConversionResult) {
+	if ic.zipOutput == nil || ic.config.Mode.DryRun {
+		return
+	}
+
+	paths := result.SucceededOutputPaths()
+	if result.OptimizeSuccess {
+		paths = append(paths, result.OptimizedPath)
+	}
+
+	for _, path := range paths {
+		files := []string{path}
+		if checksumPath := path + checksumExt; isRegularFile(checksumPath) {
+			files = append(files, checksumPath)
+		}
+
+		for _, file := range files {
+			name, err := ic.zipOutput.Add(file)
+			if err != nil {
+				ic.logManager.LogError("%v", err)
+				continue
+			}
+			ic.logManager.LogFileInfo("ZIPアーカイブに追加しました: %s -> %s:%s", file, ic.zipOutput.Path(), name)
+		}
+	}
+}
+
+// isRegularFile はパスが通常のファイルとして存在するかどうかを返します
+func isRegularFile(path string) bool {
+	fi, err := os.Stat(path)
+	return err == nil && fi.Mode().IsRegular()
+}
@@ -0,0 +1,81 @@
+/*
+Package hooks は変換したファイルごとに外部コマンドを実行する機能を提供します。
+*/
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/223n/image-converter/internal/config"
+)
+
+// Output は変換で生成された1つの出力ファイルです
+type Output struct {
+	Format string // 出力形式（webp, avif など）
+	Path   string // 出力ファイルのパス
+}
+
+// RunPostConvert は変換に成功した出力ファイルごとに hooks.post_convert のコマンドを実行します
+// コマンドが未設定の場合は何もしません。失敗したコマンドのエラーをまとめて返します
+func RunPostConvert(cfg *config.Config, source string, outputs []Output) error {
+	template := cfg.Hooks.PostConvert
+	if template == "" {
+		return nil
+	}
+
+	timeout := time.Duration(cfg.Hooks.Timeout) * time.Second
+
+	var errs []error
+	for _, output := range outputs {
+		command := expand(template, source, output)
+		if err := run(command, timeout); err != nil {
+			errs = append(errs, fmt.Errorf("変換後フックの実行に失敗しました [%s]: %w", output.Path, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// expand はコマンドテンプレートのプレースホルダーを置き換えます
+// {source}: 元画像のパス、{output}: 出力ファイルのパス、{format}: 出力形式
+// パスはシェルで解釈されないよう引用符で囲んで埋め込みます
+func expand(template, source string, output Output) string {
+	return strings.NewReplacer(
+		"{source}", shellQuote(source),
+		"{output}", shellQuote(output.Path),
+		"{format}", shellQuote(output.Format),
+	).Replace(template)
+}
+
+// run はシェル経由でコマンドを実行し、タイムアウトした場合や0以外で終了した場合はエラーを返します
+func run(command string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var output bytes.Buffer
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("%s でタイムアウトしました", timeout)
+	}
+	if err != nil {
+		if out := strings.TrimSpace(output.String()); out != "" {
+			return fmt.Errorf("%v\n出力: %s", err, out)
+		}
+		return err
+	}
+	return nil
+}
+
+// shellQuote は文字列をシェルの単一引用符で囲みます
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
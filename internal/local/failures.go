@@ -0,0 +1,56 @@
+package local
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ErrFailuresRemain は失敗したファイルの再試行後も失敗が残っていることを表します
+var ErrFailuresRemain = errors.New("再試行後も変換に失敗したファイルが残っています")
+
+// ReadFailuresFile は失敗したファイルの一覧（1行に1つのパス）を読み込みます
+// 空行と # で始まる行は無視します
+func ReadFailuresFile(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("失敗ファイル一覧を開けません: %v", err)
+	}
+	defer file.Close()
+
+	var files []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		files = append(files, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("失敗ファイル一覧の読み込みに失敗しました: %v", err)
+	}
+
+	return files, nil
+}
+
+// WriteFailuresFile は失敗したファイルの一覧を1行に1つのパスで書き込みます
+// 失敗がない場合も空のファイルを書き込み、前回の一覧が残らないようにします
+func WriteFailuresFile(path string, files []string) error {
+	sorted := append([]string(nil), files...)
+	sort.Strings(sorted)
+
+	var b strings.Builder
+	for _, file := range sorted {
+		b.WriteString(file)
+		b.WriteString("\n")
+	}
+
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("失敗ファイル一覧の書き込みに失敗しました: %v", err)
+	}
+	return nil
+}
@@ -2,17 +2,97 @@ package local
 
 import (
 	"fmt"
+	"log"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/223n/image-converter/internal/config"
+	"github.com/223n/image-converter/internal/converter"
+	"github.com/223n/image-converter/pkg/imageutils"
 )
 
+// FileInfo は検索で見つかった変換対象のファイルです
+// サイズと更新日時は検索時に取得した値で、後続の処理でファイル情報を再取得せずに使用できます
+type FileInfo struct {
+	Path    string    // ファイルのパス
+	Size    int64     // ファイルサイズ（バイト）
+	ModTime time.Time // 最終更新日時
+}
+
+// newFileInfo はパスとファイル情報から FileInfo を作成します
+func newFileInfo(path string, info os.FileInfo) FileInfo {
+	return FileInfo{Path: path, Size: info.Size(), ModTime: info.ModTime()}
+}
+
+// statFileInfo はファイル情報を取得して FileInfo を作成します
+// シンボリックリンクの場合は参照先の情報を使用します
+func statFileInfo(path string) (FileInfo, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return FileInfo{}, err
+	}
+	return newFileInfo(path, info), nil
+}
+
+// filePaths はファイルのパスの一覧を返します
+func filePaths(files []FileInfo) []string {
+	paths := make([]string, len(files))
+	for i, file := range files {
+		paths[i] = file.Path
+	}
+	return paths
+}
+
+// keepFiles は files のうち、パスが paths に含まれるものだけを返します
+func keepFiles(files []FileInfo, paths []string) []FileInfo {
+	keep := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		keep[path] = true
+	}
+
+	var kept []FileInfo
+	for _, file := range files {
+		if keep[file.Path] {
+			kept = append(kept, file)
+		}
+	}
+	return kept
+}
+
+// totalSize はファイルサイズの合計を返します
+func totalSize(files []FileInfo) int64 {
+	var total int64
+	for _, file := range files {
+		total += file.Size
+	}
+	return total
+}
+
 // FileFinder はローカルファイルシステムからの画像ファイル検索を担当します
 type FileFinder struct {
 	config              *config.Config
 	supportedExtensions map[string]bool
+
+	// 寸法チェック用の画像情報キャッシュ（パス -> 画像情報）
+	infoCache map[string]*imageutils.ImageInfo
+	infoMu    sync.Mutex
+
+	// ディレクトリごとの上書き設定を反映した設定（ディレクトリ -> 設定）
+	// 上書きファイルのないディレクトリは親ディレクトリの設定を共有します
+	dirConfigs map[string]*config.Config
+
+	// ディレクトリに適用される除外パターン（ディレクトリ -> 親ディレクトリから引き継いだものを含むパターン）
+	dirIgnores map[string][]ignoreRule
+
+	// 内容が変わっていないファイルのスキップに使用するハッシュインデックス（nilの場合は使用しません）
+	hashIndex        *HashIndex
+	skippedUnchanged int
+
+	// ディレクトリを検索する代わりに変換対象とするファイル（失敗したファイルの再試行用。nilの場合は検索します）
+	retryFiles []string
 }
 
 // NewFileFinder は新しいファイル検索インスタンスを作成します
@@ -26,25 +106,118 @@ func NewFileFinder(cfg *config.Config) *FileFinder {
 	return &FileFinder{
 		config:              cfg,
 		supportedExtensions: supportedExtensions,
+		infoCache:           make(map[string]*imageutils.ImageInfo),
+		dirConfigs:          make(map[string]*config.Config),
+		dirIgnores:          make(map[string][]ignoreRule),
 	}
 }
 
-// FindFiles は対象ディレクトリから変換対象の画像ファイルを検索します
-func (f *FileFinder) FindFiles() ([]string, int, error) {
-	// 入力ディレクトリの存在チェック
-	if err := f.validateDirectory(); err != nil {
-		return nil, 0, err
+// FindFiles は対象ディレクトリから変換対象の画像ファイルを検索し、サイズと更新日時とともに返します
+func (f *FileFinder) FindFiles() ([]FileInfo, int, error) {
+	var files []FileInfo
+	if f.retryFiles != nil {
+		// 失敗したファイルの再試行ではディレクトリを検索しない
+		files = f.existingRetryFiles()
+	} else {
+		// 入力ディレクトリの存在チェック
+		if err := f.validateDirectory(); err != nil {
+			return nil, 0, err
+		}
+
+		// ファイル検索
+		var err error
+		files, err = f.searchFiles()
+		if err != nil {
+			return nil, 0, fmt.Errorf("ファイル検索に失敗しました: %w", err)
+		}
 	}
 
-	// ファイル検索
-	files, err := f.searchFiles()
-	if err != nil {
-		return nil, 0, fmt.Errorf("ファイル検索に失敗しました: %w", err)
+	// 前回の実行から内容が変わっていないファイルを除外
+	if f.hashIndex != nil {
+		files = f.filterUnchanged(files)
 	}
 
 	return files, len(files), nil
 }
 
+// SetRetryFiles はディレクトリを検索する代わりに変換対象とするファイルを設定します
+// 前回の実行で失敗したファイルだけを再変換する場合に使用します
+func (f *FileFinder) SetRetryFiles(files []string) {
+	f.retryFiles = append([]string{}, files...)
+}
+
+// existingRetryFiles は再試行するファイルのうち、現在も存在するファイルを返します
+// ディレクトリごとの上書き設定は、検索時と同じく入力ディレクトリから親の順に読み込みます
+func (f *FileFinder) existingRetryFiles() []FileInfo {
+	var files []FileInfo
+	for _, file := range f.retryFiles {
+		info, err := statFileInfo(file)
+		if err != nil {
+			log.Printf("警告: 再試行するファイルが存在しないためスキップします: %s", file)
+			continue
+		}
+		f.loadDirectoryConfigs(filepath.Dir(file))
+		files = append(files, info)
+	}
+
+	log.Printf("失敗したファイルの再試行: %d個のファイルのうち、%d個を変換します", len(f.retryFiles), len(files))
+	return files
+}
+
+// loadDirectoryConfigs は入力ディレクトリから dir までの各ディレクトリの上書き設定を、親から順に読み込みます
+// 入力ディレクトリの外にあるファイルには基本設定を適用します
+func (f *FileFinder) loadDirectoryConfigs(dir string) {
+	if _, ok := f.dirConfigs[dir]; ok {
+		return
+	}
+
+	rel, err := filepath.Rel(f.config.Input.Directory, dir)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return
+	}
+	if rel != "." {
+		f.loadDirectoryConfigs(filepath.Dir(dir))
+	}
+	f.loadDirectoryConfig(dir)
+}
+
+// SetHashIndex は内容が変わっていないファイルのスキップに使用するハッシュインデックスを設定します
+func (f *FileFinder) SetHashIndex(index *HashIndex) {
+	f.hashIndex = index
+}
+
+// HashIndex は設定されたハッシュインデックスを返します（未設定の場合はnil）
+func (f *FileFinder) HashIndex() *HashIndex {
+	return f.hashIndex
+}
+
+// SkippedUnchanged はハッシュインデックスにより除外したファイル数を返します
+func (f *FileFinder) SkippedUnchanged() int {
+	return f.skippedUnchanged
+}
+
+// filterUnchanged はハッシュインデックスと内容が一致するファイルを除外します
+// ハッシュを計算できないファイルは変更ありとして変換対象に残します
+func (f *FileFinder) filterUnchanged(files []FileInfo) []FileInfo {
+	var changed []FileInfo
+	for _, file := range files {
+		hash, err := imageutils.ComputeHash(file.Path)
+		if err != nil {
+			log.Printf("警告: ハッシュを計算できないため変換対象とします [%s]: %v", file.Path, err)
+			changed = append(changed, file)
+			continue
+		}
+		if f.hashIndex.Unchanged(file.Path, hash) {
+			continue
+		}
+		changed = append(changed, file)
+	}
+
+	f.skippedUnchanged = len(files) - len(changed)
+	log.Printf("ハッシュインデックス: %d個のファイルのうち、内容が変更された %d個を変換します", len(files), len(changed))
+	return changed
+}
+
 // validateDirectory は入力ディレクトリの存在を確認します
 func (f *FileFinder) validateDirectory() error {
 	info, err := os.Stat(f.config.Input.Directory)
@@ -63,35 +236,196 @@ func (f *FileFinder) validateDirectory() error {
 }
 
 // searchFiles は再帰的にファイルを検索します
-func (f *FileFinder) searchFiles() ([]string, error) {
-	var filesToConvert []string
+func (f *FileFinder) searchFiles() ([]FileInfo, error) {
+	var filesToConvert []FileInfo
+
+	// シンボリックリンクによる循環を防ぐため、走査したディレクトリの実体パスを記録する
+	visited := make(map[string]bool)
+	root := f.config.Input.Directory
+	if err := f.walkDirectory(root, root, visited, &filesToConvert); err != nil {
+		return nil, err
+	}
 
-	err := filepath.Walk(f.config.Input.Directory, func(path string, info os.FileInfo, err error) error {
+	// サポートされるファイルが見つからない場合
+	if len(filesToConvert) == 0 {
+		return nil, fmt.Errorf("対象ディレクトリに変換対象のファイルが見つかりません: %s", f.config.Input.Directory)
+	}
+
+	return filesToConvert, nil
+}
+
+// walkDirectory は dir 以下を走査し、変換対象のファイルを files に追加します
+// logical は結果に使用するパスです（シンボリックリンク経由で走査する場合はリンク側のパス）
+func (f *FileFinder) walkDirectory(dir, logical string, visited map[string]bool, files *[]FileInfo) error {
+	if realPath, err := filepath.EvalSymlinks(dir); err == nil {
+		visited[realPath] = true
+	}
+
+	return filepath.Walk(dir, func(actualPath string, info os.FileInfo, err error) error {
 		if err != nil {
+			// 一覧の取得後に削除されたファイル・ディレクトリは走査中の変更として読み飛ばす
+			if os.IsNotExist(err) && actualPath != dir {
+				log.Printf("検索中に削除されたためスキップします: %s", actualPath)
+				return nil
+			}
 			return err
 		}
+
+		// 実体のパスをリンク側のパスに読み替える
+		path := actualPath
+		if rel, relErr := filepath.Rel(dir, actualPath); relErr == nil {
+			path = filepath.Join(logical, rel)
+		}
+
 		if info.IsDir() {
+			if f.exceedsMaxDepth(path) || f.isIgnored(path) {
+				return filepath.SkipDir
+			}
+			f.loadDirectoryConfig(path)
+			f.loadIgnoreRules(path)
+			return nil
+		}
+
+		// 除外パターンに一致するファイルは対象外
+		if f.isIgnored(path) {
+			return nil
+		}
+
+		// filepath.Walk はシンボリックリンクのディレクトリを辿らないため、必要に応じて個別に走査する
+		if info.Mode()&os.ModeSymlink != 0 && f.config.Input.FollowSymlinks {
+			if target, ok := f.symlinkedDirectory(actualPath); ok {
+				if visited[target] {
+					log.Printf("警告: 循環するシンボリックリンクをスキップします: %s", path)
+					return nil
+				}
+				if f.exceedsMaxDepth(path) {
+					return nil
+				}
+				return f.walkDirectory(target, path, visited, files)
+			}
+		}
+
+		// 再圧縮で生成したファイルは変換対象から除外
+		if converter.IsOptimizedPath(path) {
 			return nil
 		}
 
 		// 拡張子がサポート対象かチェック
 		ext := strings.ToLower(filepath.Ext(path))
 		if f.supportedExtensions[ext] {
-			filesToConvert = append(filesToConvert, path)
+			// シンボリックリンクのファイルは参照先のサイズと更新日時を使用する
+			if info.Mode()&os.ModeSymlink != 0 {
+				if target, err := os.Stat(actualPath); err == nil {
+					info = target
+				}
+			}
+			*files = append(*files, newFileInfo(path, info))
 		}
 		return nil
 	})
+}
 
+// symlinkedDirectory はシンボリックリンクの参照先がディレクトリの場合にその実体パスを返します
+func (f *FileFinder) symlinkedDirectory(path string) (string, bool) {
+	target, err := filepath.EvalSymlinks(path)
 	if err != nil {
-		return nil, err
+		log.Printf("警告: シンボリックリンクの参照先を解決できません [%s]: %v", path, err)
+		return "", false
 	}
 
-	// サポートされるファイルが見つからない場合
-	if len(filesToConvert) == 0 {
-		return nil, fmt.Errorf("対象ディレクトリに変換対象のファイルが見つかりません: %s", f.config.Input.Directory)
+	info, err := os.Stat(target)
+	if err != nil || !info.IsDir() {
+		return "", false
 	}
 
-	return filesToConvert, nil
+	return target, true
+}
+
+// exceedsMaxDepth はディレクトリの深さが走査の上限を超えているかどうかを返します
+// 入力ディレクトリ直下のディレクトリを深さ1とし、max_depth が1の場合は直下のファイルのみを対象とします
+func (f *FileFinder) exceedsMaxDepth(dir string) bool {
+	maxDepth := f.config.Input.MaxDepth
+	if maxDepth <= 0 {
+		return false
+	}
+
+	rel, err := filepath.Rel(f.config.Input.Directory, dir)
+	if err != nil || rel == "." {
+		return false
+	}
+
+	depth := strings.Count(rel, string(filepath.Separator)) + 1
+	return depth >= maxDepth
+}
+
+// loadDirectoryConfig はディレクトリの上書き設定ファイルを読み込み、親ディレクトリの設定にマージします
+// filepath.Walk は親ディレクトリを先に訪れるため、親の設定は既に確定しています
+func (f *FileFinder) loadDirectoryConfig(dir string) {
+	parent := f.ConfigFor(dir)
+
+	overridePath := filepath.Join(dir, config.DirectoryOverrideFile)
+	if !fileExists(overridePath) {
+		f.dirConfigs[dir] = parent
+		return
+	}
+
+	override, err := config.LoadDirectoryOverride(overridePath)
+	if err != nil {
+		log.Printf("警告: 上書き設定ファイルを無視します [%s]: %v", overridePath, err)
+		f.dirConfigs[dir] = parent
+		return
+	}
+
+	merged := config.ApplyDirectoryOverride(*parent, override)
+	f.dirConfigs[dir] = &merged
+	log.Printf("ディレクトリの上書き設定を適用しました: %s", overridePath)
+}
+
+// loadIgnoreRules はディレクトリの除外ファイルを読み込み、親ディレクトリのパターンに追加します
+// filepath.Walk は親ディレクトリを先に訪れるため、親のパターンは既に確定しています
+func (f *FileFinder) loadIgnoreRules(dir string) {
+	parent := f.dirIgnores[filepath.Dir(dir)]
+
+	ignorePath := filepath.Join(dir, IgnoreFileName)
+	if !fileExists(ignorePath) {
+		f.dirIgnores[dir] = parent
+		return
+	}
+
+	patterns, err := loadIgnoreFile(ignorePath)
+	if err != nil {
+		log.Printf("警告: 除外ファイルを無視します [%s]: %v", ignorePath, err)
+		f.dirIgnores[dir] = parent
+		return
+	}
+
+	// 親のスライスを書き換えないよう、新しいスライスにコピーして追加する
+	rules := make([]ignoreRule, 0, len(parent)+len(patterns))
+	rules = append(rules, parent...)
+	for _, pattern := range patterns {
+		rules = append(rules, ignoreRule{dir: dir, pattern: pattern})
+	}
+	f.dirIgnores[dir] = rules
+	log.Printf("除外ファイルを適用しました: %s (%d 件のパターン)", ignorePath, len(patterns))
+}
+
+// isIgnored はパスが親ディレクトリまでの除外パターンのいずれかに一致するかどうかを返します
+func (f *FileFinder) isIgnored(path string) bool {
+	for _, rule := range f.dirIgnores[filepath.Dir(path)] {
+		if rule.matches(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// ConfigFor はファイルのディレクトリに適用される設定を返します
+// 上書き設定がない場合は基本設定を返します
+func (f *FileFinder) ConfigFor(file string) *config.Config {
+	if cfg, ok := f.dirConfigs[filepath.Dir(file)]; ok {
+		return cfg
+	}
+	return f.config
 }
 
 // GetSupportedExtensions はサポートされている拡張子のマップを返します
@@ -100,34 +434,26 @@ func (f *FileFinder) GetSupportedExtensions() map[string]bool {
 }
 
 // FilterDuplicates は既に変換済みのファイルをフィルタリングします
-func (f *FileFinder) FilterDuplicates(files []string) []string {
-	var filtered []string
+// 有効な出力形式のファイルがすべて存在し、いずれも元画像の更新日時より新しい場合にスキップします
+// 出力ファイルのパスはディレクトリごとの設定の output.filename_template から求め、元画像の更新日時は検索時に取得した値を使用します
+func (f *FileFinder) FilterDuplicates(files []FileInfo) []FileInfo {
+	var filtered []FileInfo
 
 	for _, file := range files {
-		// 既にWebPまたはAVIFファイルが存在するかチェック
-		baseName := strings.TrimSuffix(file, filepath.Ext(file))
-		dir := filepath.Dir(file)
-
-		// WebPファイルの存在チェック
-		webpPath := filepath.Join(dir, baseName+".webp")
-		webpExists := fileExists(webpPath)
-
-		// AVIFファイルの存在チェック
-		avifPath := filepath.Join(dir, baseName+".avif")
-		avifExists := fileExists(avifPath)
-
-		// WebPとAVIFの両方が既に存在する場合はスキップ
-		if f.config.Conversion.WebP.Enabled && f.config.Conversion.AVIF.Enabled && webpExists && avifExists {
-			continue
-		}
+		outputs := converter.OutputPaths(f.ConfigFor(file.Path), file.Path)
 
-		// WebPのみが有効で、WebPが既に存在する場合はスキップ
-		if f.config.Conversion.WebP.Enabled && !f.config.Conversion.AVIF.Enabled && webpExists {
-			continue
+		// 有効な出力形式の変換結果がすべて存在し、元画像より新しいかチェック
+		allConverted := len(outputs) > 0
+		for _, outputPath := range outputs {
+			output, err := os.Stat(outputPath)
+			if err != nil || output.ModTime().Before(file.ModTime) {
+				allConverted = false
+				break
+			}
 		}
 
-		// AVIFのみが有効で、AVIFが既に存在する場合はスキップ
-		if !f.config.Conversion.WebP.Enabled && f.config.Conversion.AVIF.Enabled && avifExists {
+		// 変換済みの場合はスキップ
+		if allConverted {
 			continue
 		}
 
@@ -138,6 +464,44 @@ func (f *FileFinder) FilterDuplicates(files []string) []string {
 	return filtered
 }
 
+// GetImageInfo は画像情報を返します
+// 初回のみファイルを開いて取得し、以降はキャッシュした情報を返します
+func (f *FileFinder) GetImageInfo(path string) (*imageutils.ImageInfo, error) {
+	f.infoMu.Lock()
+	info, ok := f.infoCache[path]
+	f.infoMu.Unlock()
+	if ok {
+		return info, nil
+	}
+
+	info, err := imageutils.GetImageInfo(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f.infoMu.Lock()
+	f.infoCache[path] = info
+	f.infoMu.Unlock()
+
+	return info, nil
+}
+
+// IsBelowMinDimensions は画像が設定の最小寸法（input.min_width / min_height）を下回るかを判定します
+// 最小寸法が0の場合や寸法を取得できない場合（SVGなど）は false を返します
+func (f *FileFinder) IsBelowMinDimensions(path string) (bool, *imageutils.ImageInfo) {
+	minWidth, minHeight := f.config.Input.MinWidth, f.config.Input.MinHeight
+	if minWidth <= 0 && minHeight <= 0 {
+		return false, nil
+	}
+
+	info, err := f.GetImageInfo(path)
+	if err != nil {
+		return false, nil
+	}
+
+	return info.Width < minWidth || info.Height < minHeight, info
+}
+
 // fileExists はファイルが存在するかどうかをチェックします
 func fileExists(path string) bool {
 	_, err := os.Stat(path)
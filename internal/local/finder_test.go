@@ -0,0 +1,146 @@
+package local
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/223n/image-converter/internal/config"
+)
+
+// writeFileWithModTime は size バイトのファイルを作成し、更新日時を modTime に設定します
+func writeFileWithModTime(t *testing.T, path string, size int, modTime time.Time) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("ディレクトリの作成に失敗しました: %v", err)
+	}
+	if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+		t.Fatalf("ファイルの作成に失敗しました: %v", err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatalf("更新日時の設定に失敗しました: %v", err)
+	}
+}
+
+func TestFindFilesSizeAndModTime(t *testing.T) {
+	dir := t.TempDir()
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	want := map[string]FileInfo{
+		filepath.Join(dir, "a.png"):        {Size: 100, ModTime: base},
+		filepath.Join(dir, "sub", "b.jpg"): {Size: 250, ModTime: base.Add(time.Hour)},
+	}
+	for path, info := range want {
+		writeFileWithModTime(t, path, int(info.Size), info.ModTime)
+	}
+	writeFileWithModTime(t, filepath.Join(dir, "notes.txt"), 10, base)
+
+	cfg := config.DefaultConfig()
+	cfg.Input.Directory = dir
+	files, count, err := NewFileFinder(&cfg).FindFiles()
+	if err != nil {
+		t.Fatalf("FindFiles に失敗しました: %v", err)
+	}
+
+	if count != len(want) || len(files) != len(want) {
+		t.Fatalf("見つかったファイル = %d件 (%v), want %d件", count, files, len(want))
+	}
+	for _, file := range files {
+		w, ok := want[file.Path]
+		if !ok {
+			t.Errorf("対象外のファイルが見つかりました: %s", file.Path)
+			continue
+		}
+		if file.Size != w.Size {
+			t.Errorf("%s の Size = %d, want %d", file.Path, file.Size, w.Size)
+		}
+		if !file.ModTime.Equal(w.ModTime) {
+			t.Errorf("%s の ModTime = %v, want %v", file.Path, file.ModTime, w.ModTime)
+		}
+	}
+	if got := totalSize(files); got != 350 {
+		t.Errorf("totalSize = %d, want 350", got)
+	}
+}
+
+// TestFilterDuplicatesUsesCachedModTime は変換済みかどうかの判定に、検索時に取得した更新日時を使用することを確認します
+func TestFilterDuplicatesUsesCachedModTime(t *testing.T) {
+	dir := t.TempDir()
+	outputTime := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	// 実際の元画像は変換結果より新しいが、検索時の更新日時は古い
+	source := filepath.Join(dir, "photo.png")
+	writeFileWithModTime(t, source, 10, outputTime.Add(time.Hour))
+	writeFileWithModTime(t, filepath.Join(dir, "photo.webp"), 10, outputTime)
+
+	cfg := config.DefaultConfig()
+	cfg.Conversion.WebP.Enabled = true
+	cfg.Conversion.AVIF.Enabled = false
+	cfg.Conversion.JXL.Enabled = false
+	finder := NewFileFinder(&cfg)
+
+	older := []FileInfo{{Path: source, Size: 10, ModTime: outputTime.Add(-time.Hour)}}
+	if got := finder.FilterDuplicates(older); len(got) != 0 {
+		t.Errorf("変換結果より古いファイルが除外されませんでした: %v", got)
+	}
+
+	newer := []FileInfo{{Path: source, Size: 10, ModTime: outputTime.Add(time.Hour)}}
+	if got := finder.FilterDuplicates(newer); len(got) != 1 {
+		t.Errorf("変換結果より新しいファイルが除外されました: %v", got)
+	}
+}
+
+// TestFindFilesDirectoryOverrides はサブディレクトリごとの上書き設定ファイルの画質が、そのディレクトリのファイルにだけ適用されることを確認します
+// 上書き設定はさらに下のディレクトリにも引き継がれます
+func TestFindFilesDirectoryOverrides(t *testing.T) {
+	dir := t.TempDir()
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	overrides := map[string]string{
+		"thumbnails": "conversion:\n  webp:\n    quality: 60\n  avif:\n    quality: 40\n",
+		"originals":  "conversion:\n  webp:\n    quality: 85\n",
+	}
+	for sub, content := range overrides {
+		writeFileWithModTime(t, filepath.Join(dir, sub, "photo.png"), 10, base)
+		if err := os.WriteFile(filepath.Join(dir, sub, config.DirectoryOverrideFile), []byte(content), 0644); err != nil {
+			t.Fatalf("上書き設定ファイルの作成に失敗しました: %v", err)
+		}
+	}
+	writeFileWithModTime(t, filepath.Join(dir, "thumbnails", "small", "icon.png"), 10, base)
+	writeFileWithModTime(t, filepath.Join(dir, "root.png"), 10, base)
+
+	cfg := config.DefaultConfig()
+	cfg.Input.Directory = dir
+	cfg.Conversion.WebP.Quality = 75
+	cfg.Conversion.AVIF.Quality = 50
+	finder := NewFileFinder(&cfg)
+	if _, _, err := finder.FindFiles(); err != nil {
+		t.Fatalf("FindFiles に失敗しました: %v", err)
+	}
+
+	tests := []struct {
+		path             string
+		webp, avif       int
+		sharesRootConfig bool
+	}{
+		{path: filepath.Join(dir, "root.png"), webp: 75, avif: 50, sharesRootConfig: true},
+		{path: filepath.Join(dir, "thumbnails", "photo.png"), webp: 60, avif: 40},
+		{path: filepath.Join(dir, "thumbnails", "small", "icon.png"), webp: 60, avif: 40},
+		{path: filepath.Join(dir, "originals", "photo.png"), webp: 85, avif: 50},
+	}
+	for _, tt := range tests {
+		got := finder.ConfigFor(tt.path)
+		if got.Conversion.WebP.Quality != tt.webp || got.Conversion.AVIF.Quality != tt.avif {
+			t.Errorf("%s の画質 = WebP %d, AVIF %d, want WebP %d, AVIF %d",
+				tt.path, got.Conversion.WebP.Quality, got.Conversion.AVIF.Quality, tt.webp, tt.avif)
+		}
+		if (got == &cfg) != tt.sharesRootConfig {
+			t.Errorf("%s の設定が基本設定と同一か = %v, want %v", tt.path, got == &cfg, tt.sharesRootConfig)
+		}
+	}
+
+	// 上書きは基本設定を変更しない
+	if cfg.Conversion.WebP.Quality != 75 || cfg.Conversion.AVIF.Quality != 50 {
+		t.Errorf("基本設定の画質 = WebP %d, AVIF %d, want WebP 75, AVIF 50", cfg.Conversion.WebP.Quality, cfg.Conversion.AVIF.Quality)
+	}
+}
@@ -0,0 +1,102 @@
+package local
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// HashIndex は変換元ファイルの内容のSHA256を実行間で保持し、変更のないファイルのスキップに使用します
+// 前回の実行で変換に成功したファイルのうち、内容が変わっていないものをスキップ対象とします
+// 更新日時を使わないため、rsync やコピーで更新日時が変わっても内容が同じであればスキップできます
+type HashIndex struct {
+	path string
+
+	mu       sync.Mutex
+	previous map[string]string // 前回の実行で記録したハッシュ
+	observed map[string]string // 今回の実行で計算したハッシュ
+	next     map[string]string // 次回の実行に引き継ぐハッシュ
+}
+
+// LoadHashIndex はハッシュインデックスのファイルを読み込みます
+// ファイルが存在しない場合は空のインデックスを返します（初回の実行ではすべてのファイルを変換します）
+func LoadHashIndex(path string) (*HashIndex, error) {
+	index := &HashIndex{
+		path:     path,
+		previous: make(map[string]string),
+		observed: make(map[string]string),
+		next:     make(map[string]string),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return index, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ハッシュインデックスの読み込みに失敗しました: %v", err)
+	}
+
+	if err := json.Unmarshal(data, &index.previous); err != nil {
+		return nil, fmt.Errorf("ハッシュインデックスの解析に失敗しました: %v", err)
+	}
+
+	return index, nil
+}
+
+// Unchanged はハッシュを記録し、前回の実行から内容が変わっていない場合に true を返します
+// 変わっていないファイルはそのまま次回の実行にも引き継ぎます
+func (h *HashIndex) Unchanged(path, hash string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.observed[path] = hash
+	if h.previous[path] != hash {
+		return false
+	}
+
+	h.next[path] = hash
+	return true
+}
+
+// MarkConverted はファイルの変換に成功したことを記録します
+// 記録したファイルは次回の実行で、内容が変わっていなければスキップされます
+// 複数のワーカーから同時に呼び出すことができます
+func (h *HashIndex) MarkConverted(path string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if hash, ok := h.observed[path]; ok {
+		h.next[path] = hash
+	}
+}
+
+// Save は次回の実行に引き継ぐハッシュをファイルに書き込みます
+// 書き込み途中で中断しても既存のファイルが壊れないよう、一時ファイルに書き込んでから置き換えます
+func (h *HashIndex) Save() error {
+	h.mu.Lock()
+	data, err := json.MarshalIndent(h.next, "", "  ")
+	h.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("ハッシュインデックスの変換に失敗しました: %v", err)
+	}
+
+	if dir := filepath.Dir(h.path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("ハッシュインデックスのディレクトリの作成に失敗しました: %v", err)
+		}
+	}
+
+	tempPath := h.path + ".tmp"
+	if err := os.WriteFile(tempPath, data, 0644); err != nil {
+		return fmt.Errorf("ハッシュインデックスの書き込みに失敗しました: %v", err)
+	}
+	if err := os.Rename(tempPath, h.path); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("ハッシュインデックスの書き込みに失敗しました: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package local
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// IgnoreFileName はディレクトリごとの除外パターンを記述するファイルの名前です
+const IgnoreFileName = ".imgconvignore"
+
+// ignoreRule は除外ファイルに記述された1つのパターンです
+// パターンは記述されたディレクトリ（dir）以下のファイルとディレクトリに適用されます
+type ignoreRule struct {
+	dir     string
+	pattern string
+}
+
+// matches はパスがパターンに一致するかどうかを返します
+// ファイル名と、パターンを記述したディレクトリからの相対パスのどちらかが一致すれば除外対象とします
+func (r ignoreRule) matches(path string) bool {
+	if ok, _ := filepath.Match(r.pattern, filepath.Base(path)); ok {
+		return true
+	}
+
+	rel, err := filepath.Rel(r.dir, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return false
+	}
+	ok, _ := filepath.Match(r.pattern, rel)
+	return ok
+}
+
+// loadIgnoreFile は除外ファイルを読み込み、パターンの一覧を返します
+// 空行と # で始まる行は無視します。パターンの構文が不正な場合はエラーを返します
+func loadIgnoreFile(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("除外ファイルの読み込みに失敗しました: %v", err)
+	}
+	defer file.Close()
+
+	var patterns []string
+	scanner := bufio.NewScanner(file)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// 区切り文字はOSに合わせ、末尾の / はディレクトリの指定として扱う
+		pattern := strings.TrimSuffix(filepath.FromSlash(line), string(filepath.Separator))
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("%d 行目のパターン %q が不正です: %v", lineNo, line, err)
+		}
+		patterns = append(patterns, pattern)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("除外ファイルの読み込みに失敗しました: %v", err)
+	}
+
+	return patterns, nil
+}
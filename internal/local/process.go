@@ -1,13 +1,22 @@
 package local
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/223n/image-converter/internal/config"
 	"github.com/223n/image-converter/internal/converter"
+	"github.com/223n/image-converter/internal/hooks"
 	"github.com/223n/image-converter/internal/utils"
+	"github.com/223n/image-converter/pkg/imageutils"
 )
 
 // FileProcessor はローカルファイルの処理を担当します
@@ -16,23 +25,129 @@ type FileProcessor struct {
 	stats      *config.ConversionStats
 	converter  *converter.ImageConverter
 	logManager *utils.LogManager
+	finder     *FileFinder
+
+	// 重複検出用（ハッシュ -> 最初に見つかったファイルパス）
+	seenHashes map[string]string
+	hashMu     sync.Mutex
+
+	// ディレクトリごとの上書き設定用の変換器（設定 -> 変換器）
+	converters  map[*config.Config]*converter.ImageConverter
+	converterMu sync.Mutex
+
+	// ファイルごとの処理時間
+	timings *TimingCollector
+
+	// 変換に失敗したファイル
+	failures  []string
+	failureMu sync.Mutex
+
+	// デコードの同時実行数を制限するセマフォ（すべての変換器で共有）
+	decodeSem chan struct{}
+
+	// 出力ファイルをまとめるZIPアーカイブ（すべての変換器で共有、nilの場合は使用しない）
+	zipOutput *converter.ZipOutput
 }
 
 // NewFileProcessor は新しいファイル処理インスタンスを作成します
-func NewFileProcessor(cfg *config.Config, stats *config.ConversionStats, logManager *utils.LogManager) *FileProcessor {
+// finder は寸法による除外判定に使用します（nilの場合は判定しません）
+func NewFileProcessor(cfg *config.Config, stats *config.ConversionStats, logManager *utils.LogManager, finder *FileFinder) *FileProcessor {
+	// デコード済み画像によるメモリ使用量を抑えるため、ワーカー数とは別にデコードの同時実行数を制限する
+	decodeLimit := cfg.Conversion.MaxDecodeConcurrency
+	if decodeLimit <= 0 {
+		decodeLimit = max(1, cfg.Conversion.Workers)
+	}
+	decodeSem := make(chan struct{}, decodeLimit)
+
+	ic := converter.NewImageConverter(cfg, logManager)
+	ic.SetDecodeLimiter(decodeSem)
+
 	return &FileProcessor{
 		config:     cfg,
 		stats:      stats,
-		converter:  converter.NewImageConverter(cfg, logManager),
+		converter:  ic,
 		logManager: logManager,
+		finder:     finder,
+		seenHashes: make(map[string]string),
+		converters: make(map[*config.Config]*converter.ImageConverter),
+		timings:    NewTimingCollector(),
+		decodeSem:  decodeSem,
 	}
 }
 
+// Failures は変換に失敗したファイル（いずれかの出力形式の変換に失敗したファイルを含む）を返します
+func (p *FileProcessor) Failures() []string {
+	p.failureMu.Lock()
+	defer p.failureMu.Unlock()
+	return append([]string(nil), p.failures...)
+}
+
+// recordFailure は変換に失敗したファイルを記録します
+func (p *FileProcessor) recordFailure(file string) {
+	p.failureMu.Lock()
+	defer p.failureMu.Unlock()
+	p.failures = append(p.failures, file)
+}
+
+// SetZipOutput は出力ファイルを書き込むZIPアーカイブを設定します
+// ディレクトリごとの上書き設定の変換器も同じアーカイブに書き込みます
+func (p *FileProcessor) SetZipOutput(z *converter.ZipOutput) {
+	p.converterMu.Lock()
+	defer p.converterMu.Unlock()
+
+	p.zipOutput = z
+	p.converter.SetZipOutput(z)
+	for _, ic := range p.converters {
+		ic.SetZipOutput(z)
+	}
+}
+
+// Timings はファイルごとの処理時間の収集器を返します
+func (p *FileProcessor) Timings() *TimingCollector {
+	return p.timings
+}
+
+// converterFor はファイルのディレクトリに適用される設定の変換器を返します
+// 上書き設定がない場合は基本設定の変換器を返します
+func (p *FileProcessor) converterFor(file string) *converter.ImageConverter {
+	if p.finder == nil {
+		return p.converter
+	}
+
+	cfg := p.finder.ConfigFor(file)
+	if cfg == p.config {
+		return p.converter
+	}
+
+	p.converterMu.Lock()
+	defer p.converterMu.Unlock()
+
+	ic, ok := p.converters[cfg]
+	if !ok {
+		ic = converter.NewImageConverter(cfg, p.logManager)
+		ic.SetDecodeLimiter(p.decodeSem)
+		ic.SetZipOutput(p.zipOutput)
+		p.converters[cfg] = ic
+	}
+	return ic
+}
+
+// ErrMaxRuntimeExceeded は実行時間の上限に達して処理を打ち切ったことを表します
+var ErrMaxRuntimeExceeded = errors.New("実行時間の上限に達しました")
+
 // ProcessFiles は複数のファイルを並行処理します
-func (p *FileProcessor) ProcessFiles(files []string, totalFiles int) error {
+// ctx の期限に達した場合は新しいファイルの処理を開始せず、処理中のファイルの完了を待って ErrMaxRuntimeExceeded を返します
+func (p *FileProcessor) ProcessFiles(ctx context.Context, files []FileInfo, totalFiles int) error {
 	// 進捗トラッカーを作成
 	tracker := utils.NewMultiProgressTracker(totalFiles, "変換処理")
 
+	// 外部の監視用の進捗の出力（開けない場合も変換は続ける）
+	closeStream, err := tracker.AttachEventStream(p.config.Reporting.ProgressStream)
+	if err != nil {
+		p.logManager.LogWarning("%v", err)
+	}
+	defer closeStream()
+
 	// ワーカープールを使用した並列処理
 	var wg sync.WaitGroup
 	semaphore := make(chan struct{}, p.config.Conversion.Workers)
@@ -40,9 +155,30 @@ func (p *FileProcessor) ProcessFiles(files []string, totalFiles int) error {
 	// エラー収集用のチャネル
 	errorCh := make(chan error, len(files))
 
-	for _, file := range files {
+	// SIGINT/SIGTERMを受信したら新しいファイルの処理を開始せず、処理中のファイルの完了を待って中断する
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	var cancelReason string
+	pending := 0
+dispatch:
+	for i, file := range files {
+		// 空きワーカーがある場合も期限切れを優先する
+		if ctx.Err() != nil {
+			pending = len(files) - i
+			break
+		}
+		select {
+		case semaphore <- struct{}{}:
+		case sig := <-signals:
+			cancelReason = fmt.Sprintf("シグナル %v を受信しました", sig)
+			break dispatch
+		case <-ctx.Done():
+			pending = len(files) - i
+			break dispatch
+		}
 		wg.Add(1)
-		semaphore <- struct{}{}
 
 		go func(file string) {
 			defer wg.Done()
@@ -51,13 +187,30 @@ func (p *FileProcessor) ProcessFiles(files []string, totalFiles int) error {
 			if err := p.processFile(file, tracker); err != nil {
 				errorCh <- fmt.Errorf("ファイル %s の処理に失敗しました: %v", file, err)
 			}
-		}(file)
+		}(file.Path)
 	}
 
 	// すべてのワーカーの終了を待機
 	wg.Wait()
 	close(errorCh)
 
+	// 実行時間の上限に達した場合は未処理のファイルを記録して打ち切る
+	if pending > 0 {
+		tracker.Cancel(ErrMaxRuntimeExceeded.Error())
+		p.logManager.LogWarning("実行時間の上限に達したため、%d個のファイルを未処理のまま終了します", pending)
+		for _, file := range files[len(files)-pending:] {
+			p.logManager.LogDebug("未処理: %s", file.Path)
+		}
+		return ErrMaxRuntimeExceeded
+	}
+
+	// 中断した場合は途中までの結果を表示して終了する
+	if cancelReason != "" {
+		tracker.Cancel(cancelReason)
+		p.logManager.LogWarning("変換処理を中断しました: %s", cancelReason)
+		return fmt.Errorf("変換処理を中断しました: %s", cancelReason)
+	}
+
 	// 進捗トラッカーを完了
 	tracker.Complete()
 
@@ -74,42 +227,197 @@ func (p *FileProcessor) processFile(file string, tracker *utils.MultiProgressTra
 	// ファイル処理の開始時間を記録
 	startTime := time.Now()
 
+	// 検索後に削除されたファイルは失敗ではなくスキップとして扱う
+	if _, err := os.Stat(file); os.IsNotExist(err) {
+		p.skipMissing(file, tracker)
+		return nil
+	}
+
+	// 最小寸法を下回る画像（アイコンやトラッキングピクセルなど）はスキップ
+	if p.finder != nil {
+		if tooSmall, info := p.finder.IsBelowMinDimensions(file); tooSmall {
+			p.logManager.LogFileInfo("最小寸法未満のためスキップします [%s]: %s (最小: %dx%d)",
+				file, imageutils.FormatImageDimensions(info.Width, info.Height),
+				p.config.Input.MinWidth, p.config.Input.MinHeight)
+			p.stats.SkippedTooSmall.Add(1)
+			tracker.IncrementSkipped()
+			return nil
+		}
+	}
+
+	// 内容が同一のファイルが既に処理されている場合はスキップ
+	if p.config.Conversion.DeduplicateByHash {
+		if firstPath, duplicate := p.checkDuplicate(file); duplicate {
+			p.logManager.LogFileInfo("重複ファイルのためスキップします [%s]: %s と同一内容です", file, firstPath)
+			tracker.IncrementSkipped()
+			return nil
+		}
+	}
+
 	// 変換処理の実行
-	result, err := p.converter.Convert(file)
+	result, err := p.converterFor(file).Convert(file)
+	if errors.Is(err, os.ErrNotExist) {
+		p.skipMissing(file, tracker)
+		return nil
+	}
+	if errors.Is(err, converter.ErrTooManyPixels) {
+		p.logManager.LogWarning("画素数が上限を超えるためスキップします [%s]: %v", file, err)
+		p.stats.SkippedTooLarge.Add(1)
+		tracker.IncrementSkipped()
+		return nil
+	}
 	if err != nil {
 		p.logManager.LogError("変換エラー [%s]: %v", file, err)
 		tracker.IncrementFailed()
+
+		// 破損画像は隔離ディレクトリに移動する
+		if errors.Is(err, converter.ErrDecodeFailed) && p.config.Conversion.QuarantineDir != "" {
+			if qErr := p.quarantineFile(file, err); qErr == nil {
+				return nil
+			}
+		}
+		p.recordFailure(file)
 		return err
 	}
 
+	// 出力ファイルごとの変換後フック
+	if err := p.runPostConvertHooks(file, result); err != nil {
+		if p.config.Hooks.FailOnError {
+			p.logManager.LogError("%v", err)
+			tracker.IncrementFailed()
+			p.recordFailure(file)
+			return err
+		}
+		p.logManager.LogWarning("%v", err)
+	}
+
 	// 統計情報の更新
 	p.updateStats(result)
 
+	// 一部の出力形式だけ失敗した場合も再試行の対象とする
+	if result.HasFailedFormat() {
+		p.recordFailure(file)
+	}
+
+	// 次回の実行で内容が変わっていなければスキップできるよう記録する
+	if p.finder != nil && p.finder.HashIndex() != nil {
+		p.finder.HashIndex().MarkConverted(file)
+	}
+
 	// 処理時間をログに記録
-	p.logManager.LogInfo("ファイル処理完了 [%s]: 所要時間 %v", file, time.Since(startTime))
+	elapsed := time.Since(startTime)
+	p.timings.Record(file, elapsed)
+	p.logManager.WithFields(map[string]interface{}{
+		"file":              file,
+		"format":            strings.Join(result.SucceededFormats(), ","),
+		"duration_ms":       elapsed.Milliseconds(),
+		"output_size_bytes": result.TotalOutputSize(),
+	}).Info("ファイル処理完了")
 
 	// 成功としてカウント
-	p.stats.TotalProcessed++
+	p.stats.TotalProcessed.Add(1)
 	tracker.IncrementSuccess()
 
 	return nil
 }
 
+// skipMissing は検索後に削除された（ライブディレクトリで移動・削除された）ファイルをスキップとして記録します
+func (p *FileProcessor) skipMissing(file string, tracker *utils.MultiProgressTracker) {
+	p.logManager.LogFileInfo("検索後に削除されたためスキップします: %s", file)
+	p.stats.SkippedMissing.Add(1)
+	tracker.IncrementSkipped()
+}
+
+// runPostConvertHooks は変換に成功した出力ファイルごとに hooks.post_convert を実行します
+// ドライランやZIPアーカイブへの出力では出力ファイルがディスクに残らないため実行しません
+func (p *FileProcessor) runPostConvertHooks(file string, result *converter.ConversionResult) error {
+	if p.config.Hooks.PostConvert == "" || p.config.Mode.DryRun || p.zipOutput != nil {
+		return nil
+	}
+
+	formats := result.SucceededFormats()
+	paths := result.SucceededOutputPaths()
+	outputs := make([]hooks.Output, len(formats))
+	for i := range formats {
+		outputs[i] = hooks.Output{Format: formats[i], Path: paths[i]}
+	}
+	return hooks.RunPostConvert(p.config, file, outputs)
+}
+
+// quarantineFile は破損画像を隔離ディレクトリに移動します
+// 入力ディレクトリからの相対パスを維持して移動します
+func (p *FileProcessor) quarantineFile(file string, reason error) error {
+	relPath, err := filepath.Rel(p.config.Input.Directory, file)
+	if err != nil || strings.HasPrefix(relPath, "..") {
+		relPath = filepath.Base(file)
+	}
+	dst := filepath.Join(p.config.Conversion.QuarantineDir, relPath)
+
+	if err := utils.MoveFile(file, dst); err != nil {
+		p.logManager.LogError("破損画像の隔離に失敗しました [%s]: %v", file, err)
+		return err
+	}
+
+	p.stats.Quarantined.Add(1)
+
+	p.logManager.LogWarning("破損画像を隔離しました: %s -> %s (理由: %v)", file, dst, reason)
+	return nil
+}
+
+// checkDuplicate はファイル内容のハッシュが既出かどうかを確認します
+// 既出の場合は最初に見つかったファイルのパスとtrueを返します
+func (p *FileProcessor) checkDuplicate(file string) (string, bool) {
+	hash, err := imageutils.ComputeHash(file)
+	if err != nil {
+		p.logManager.LogWarning("ハッシュの計算に失敗したため重複チェックをスキップします [%s]: %v", file, err)
+		return "", false
+	}
+
+	p.hashMu.Lock()
+	defer p.hashMu.Unlock()
+
+	if firstPath, ok := p.seenHashes[hash]; ok {
+		return firstPath, true
+	}
+
+	p.seenHashes[hash] = file
+	return "", false
+}
+
 // updateStats は変換結果に基づいて統計情報を更新します
 func (p *FileProcessor) updateStats(result *converter.ConversionResult) {
 	if result.WebPSuccess {
-		p.stats.WebPSuccess++
-		p.logManager.LogInfo("WebP変換成功: %s (サイズ: %d バイト)", result.WebPPath, result.WebPSize)
+		p.stats.WebPSuccess.Add(1)
+		p.logManager.LogFileInfo("WebP変換成功: %s (サイズ: %d バイト)", result.WebPPath, result.WebPSize)
 	} else if result.WebPAttempted {
-		p.stats.WebPFailed++
+		p.stats.WebPFailed.Add(1)
 		p.logManager.LogWarning("WebP変換失敗: %s", result.WebPPath)
 	}
 
 	if result.AVIFSuccess {
-		p.stats.AVIFSuccess++
-		p.logManager.LogInfo("AVIF変換成功: %s (サイズ: %d バイト)", result.AVIFPath, result.AVIFSize)
+		p.stats.AVIFSuccess.Add(1)
+		p.logManager.LogFileInfo("AVIF変換成功: %s (サイズ: %d バイト)", result.AVIFPath, result.AVIFSize)
 	} else if result.AVIFAttempted {
-		p.stats.AVIFFailed++
+		p.stats.AVIFFailed.Add(1)
 		p.logManager.LogWarning("AVIF変換失敗: %s", result.AVIFPath)
 	}
+
+	if result.JXLSuccess {
+		p.stats.JXLSuccess.Add(1)
+		p.logManager.LogFileInfo("JPEG XL変換成功: %s (サイズ: %d バイト)", result.JXLPath, result.JXLSize)
+	} else if result.JXLAttempted {
+		p.stats.JXLFailed.Add(1)
+		p.logManager.LogWarning("JPEG XL変換失敗: %s", result.JXLPath)
+	}
+
+	if smallest := result.SmallestOutputSize(); smallest > 0 {
+		p.stats.RecordBytes(result.OriginalSize, smallest)
+	}
+
+	if result.OptimizeSuccess {
+		p.stats.OptimizeSuccess.Add(1)
+	} else if result.OptimizeAttempted {
+		p.stats.OptimizeFailed.Add(1)
+		p.logManager.LogWarning("再圧縮失敗: %s", result.OptimizedPath)
+	}
 }
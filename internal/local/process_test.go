@@ -0,0 +1,242 @@
+package local
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/223n/image-converter/internal/config"
+	"github.com/223n/image-converter/internal/converter"
+	"github.com/223n/image-converter/internal/utils"
+	"github.com/chai2010/webp"
+)
+
+// copyFixture は testdata のファイルを dir/relPath にコピーし、そのパスを返します
+func copyFixture(t *testing.T, name, dir, relPath string) string {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("テストデータの読み込みに失敗しました: %v", err)
+	}
+	path := filepath.Join(dir, relPath)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("ディレクトリの作成に失敗しました: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("テストデータのコピーに失敗しました: %v", err)
+	}
+	return path
+}
+
+// webpOnlyConfig は入力ディレクトリと隔離ディレクトリを設定した、WebPのみを出力する設定を返します
+func webpOnlyConfig(inputDir, quarantineDir string) config.Config {
+	cfg := config.DefaultConfig()
+	cfg.Input.Directory = inputDir
+	cfg.Conversion.WebP.Enabled = true
+	cfg.Conversion.AVIF.Enabled = false
+	cfg.Conversion.JXL.Enabled = false
+	cfg.Conversion.Workers = 1
+	cfg.Conversion.QuarantineDir = quarantineDir
+	return cfg
+}
+
+// TestConvertTruncatedJPEG は途中で切れたJPEGのデコードが ErrDecodeFailed になることを確認します
+func TestConvertTruncatedJPEG(t *testing.T) {
+	inputDir := t.TempDir()
+	file := copyFixture(t, "truncated.jpg", inputDir, "truncated.jpg")
+
+	cfg := webpOnlyConfig(inputDir, "")
+	ic := converter.NewImageConverter(&cfg, utils.NewLogManager())
+
+	if _, err := ic.Convert(file); !errors.Is(err, converter.ErrDecodeFailed) {
+		t.Errorf("Convert のエラー = %v, ErrDecodeFailed を期待しました", err)
+	}
+}
+
+// TestProcessFilesQuarantinesTruncatedJPEG は途中で切れたJPEGが入力ディレクトリからの相対パスを維持して隔離されることを確認します
+func TestProcessFilesQuarantinesTruncatedJPEG(t *testing.T) {
+	inputDir := t.TempDir()
+	quarantineDir := t.TempDir()
+	file := copyFixture(t, "truncated.jpg", inputDir, filepath.Join("photos", "truncated.jpg"))
+
+	cfg := webpOnlyConfig(inputDir, quarantineDir)
+	stats := &config.ConversionStats{}
+	p := NewFileProcessor(&cfg, stats, utils.NewLogManager(), nil)
+
+	if err := p.ProcessFiles(context.Background(), []FileInfo{{Path: file}}, 1); err != nil {
+		t.Fatalf("隔離したファイルがエラーとして返されました: %v", err)
+	}
+
+	if _, err := os.Stat(file); !os.IsNotExist(err) {
+		t.Errorf("入力ディレクトリにファイルが残っています: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(quarantineDir, "photos", "truncated.jpg")); err != nil {
+		t.Errorf("隔離ディレクトリにファイルがありません: %v", err)
+	}
+	if got := stats.QuarantinedCount(); got != 1 {
+		t.Errorf("Quarantined = %d, want 1", got)
+	}
+	if failures := p.Failures(); len(failures) != 0 {
+		t.Errorf("隔離したファイルが失敗として記録されました: %v", failures)
+	}
+}
+
+// TestProcessFilesSkipsTooManyPixels は画素数が max_decode_pixels を超える画像を、デコードせずにスキップすることを確認します
+// testdata/oversized_header.png はIHDRのみを含む50000x40000のPNGです
+func TestProcessFilesSkipsTooManyPixels(t *testing.T) {
+	inputDir := t.TempDir()
+	file := copyFixture(t, "oversized_header.png", inputDir, "oversized.png")
+
+	cfg := webpOnlyConfig(inputDir, "")
+	cfg.Conversion.MaxDecodePixels = 10_000_000
+	stats := &config.ConversionStats{}
+	p := NewFileProcessor(&cfg, stats, utils.NewLogManager(), nil)
+
+	if err := p.ProcessFiles(context.Background(), []FileInfo{{Path: file}}, 1); err != nil {
+		t.Fatalf("スキップしたファイルがエラーとして返されました: %v", err)
+	}
+
+	if got := stats.SkippedTooLargeCount(); got != 1 {
+		t.Errorf("SkippedTooLarge = %d, want 1", got)
+	}
+	if failures := p.Failures(); len(failures) != 0 {
+		t.Errorf("スキップしたファイルが失敗として記録されました: %v", failures)
+	}
+	if _, err := os.Stat(filepath.Join(inputDir, "oversized.webp")); !os.IsNotExist(err) {
+		t.Errorf("スキップしたファイルのWebPが出力されました: %v", err)
+	}
+}
+
+// writePNG は width x height のPNG画像を path に書き込みます
+func writePNG(t *testing.T, path string, width, height int) {
+	t.Helper()
+
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.NRGBA{uint8(x * 8), uint8(y * 8), 128, 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("PNGのエンコードに失敗しました: %v", err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("PNGの書き込みに失敗しました: %v", err)
+	}
+}
+
+// TestProcessFilesDeduplicateByHash は内容が同じファイルのうち1つだけを変換することを確認します
+func TestProcessFilesDeduplicateByHash(t *testing.T) {
+	inputDir := t.TempDir()
+	first := filepath.Join(inputDir, "first.png")
+	second := filepath.Join(inputDir, "second.png")
+	writePNG(t, first, 16, 12)
+	data, err := os.ReadFile(first)
+	if err != nil {
+		t.Fatalf("ファイルの読み込みに失敗しました: %v", err)
+	}
+	if err := os.WriteFile(second, data, 0644); err != nil {
+		t.Fatalf("ファイルの複製に失敗しました: %v", err)
+	}
+
+	cfg := webpOnlyConfig(inputDir, "")
+	cfg.Conversion.Workers = 2
+	cfg.Conversion.DeduplicateByHash = true
+	stats := &config.ConversionStats{}
+	p := NewFileProcessor(&cfg, stats, utils.NewLogManager(), nil)
+
+	files := []FileInfo{{Path: first}, {Path: second}}
+	if err := p.ProcessFiles(context.Background(), files, len(files)); err != nil {
+		t.Fatalf("ProcessFiles に失敗しました: %v", err)
+	}
+
+	if got := stats.TotalProcessedCount(); got != 1 {
+		t.Errorf("TotalProcessed = %d, want 1", got)
+	}
+	if got := stats.WebPSuccessCount(); got != 1 {
+		t.Errorf("WebPSuccess = %d, want 1", got)
+	}
+	outputs, _ := filepath.Glob(filepath.Join(inputDir, "*.webp"))
+	if len(outputs) != 1 {
+		t.Errorf("WebPの出力 = %v, want 1件", outputs)
+	}
+}
+
+// TestProcessFilesConvertsMisnamedFile は拡張子が .jpg のPNGを、隔離せずにPNGとしてデコードして変換することを確認します
+func TestProcessFilesConvertsMisnamedFile(t *testing.T) {
+	inputDir := t.TempDir()
+	quarantineDir := t.TempDir()
+	file := filepath.Join(inputDir, "misnamed.jpg")
+	writePNG(t, file, 16, 12)
+
+	cfg := webpOnlyConfig(inputDir, quarantineDir)
+	stats := &config.ConversionStats{}
+	p := NewFileProcessor(&cfg, stats, utils.NewLogManager(), nil)
+
+	if err := p.ProcessFiles(context.Background(), []FileInfo{{Path: file}}, 1); err != nil {
+		t.Fatalf("ProcessFiles に失敗しました: %v", err)
+	}
+
+	if failures := p.Failures(); len(failures) != 0 {
+		t.Errorf("変換に失敗しました: %v", failures)
+	}
+	if got := stats.QuarantinedCount(); got != 0 {
+		t.Errorf("Quarantined = %d, want 0", got)
+	}
+	webpPath := filepath.Join(inputDir, "misnamed.webp")
+	data, err := os.ReadFile(webpPath)
+	if err != nil {
+		t.Fatalf("WebPが出力されていません: %v", err)
+	}
+	if cfg, err := webp.DecodeConfig(bytes.NewReader(data)); err != nil || cfg.Width != 16 || cfg.Height != 12 {
+		t.Errorf("WebPの寸法 = %dx%d, %v, want 16x12", cfg.Width, cfg.Height, err)
+	}
+}
+
+// TestProcessFilesFilenameTemplate はローカルモードで output.filename_template の名前で出力し、
+// ドライランの計画と変換済みの判定が同じ名前を使用することを確認します
+func TestProcessFilesFilenameTemplate(t *testing.T) {
+	inputDir := t.TempDir()
+	file := filepath.Join(inputDir, "photo.png")
+	writePNG(t, file, 16, 12)
+
+	cfg := webpOnlyConfig(inputDir, "")
+	cfg.Output.FilenameTemplate = "{name}_{width}x{height}_q{quality}.{format}"
+	cfg.Conversion.WebP.Quality = 70
+	want := filepath.Join(inputDir, "photo_16x12_q70.webp")
+
+	if plan := converter.PlanConversion(&cfg, file); len(plan.Outputs) != 1 || plan.Outputs[0] != want {
+		t.Errorf("変換予定 = %v, want [%s]", plan.Outputs, want)
+	}
+
+	// 変換前は変換済みとみなさない
+	info, err := statFileInfo(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	finder := NewFileFinder(&cfg)
+	if got := finder.FilterDuplicates([]FileInfo{info}); len(got) != 1 {
+		t.Errorf("変換前の FilterDuplicates = %v, want 1件", filePaths(got))
+	}
+
+	p := NewFileProcessor(&cfg, &config.ConversionStats{}, utils.NewLogManager(), nil)
+	if err := p.ProcessFiles(context.Background(), []FileInfo{info}, 1); err != nil {
+		t.Fatalf("ProcessFiles に失敗しました: %v", err)
+	}
+	if _, err := os.Stat(want); err != nil {
+		t.Errorf("テンプレートの名前で出力されていません: %v", err)
+	}
+
+	// 変換後はテンプレートの名前の出力で変換済みと判定する
+	if got := finder.FilterDuplicates([]FileInfo{info}); len(got) != 0 {
+		t.Errorf("変換後の FilterDuplicates = %v, want 0件", filePaths(got))
+	}
+}
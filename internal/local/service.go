@@ -2,11 +2,15 @@
 package local
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"time"
 
 	"github.com/223n/image-converter/internal/config"
+	"github.com/223n/image-converter/internal/converter"
+	"github.com/223n/image-converter/internal/notify"
 	"github.com/223n/image-converter/internal/utils"
 )
 
@@ -16,6 +20,9 @@ type Service struct {
 	stats      *config.ConversionStats
 	startTime  time.Time
 	logManager *utils.LogManager
+
+	// 前回失敗したファイルだけを再変換する場合の対象ファイル（nilの場合はディレクトリを検索します）
+	retryFiles []string
 }
 
 // NewService は新しいローカルサービスインスタンスを作成します
@@ -28,48 +35,231 @@ func NewService(cfg *config.Config, logManager *utils.LogManager) *Service {
 	}
 }
 
+// SetRetryFiles はディレクトリを検索せずに、指定したファイルだけを変換するよう設定します
+// 前回の実行で失敗したファイルの再試行に使用し、再試行後も失敗が残る場合 Execute は ErrFailuresRemain を返します
+func (s *Service) SetRetryFiles(files []string) {
+	s.retryFiles = append([]string{}, files...)
+}
+
 // Execute はローカル変換処理を実行します
 func (s *Service) Execute() error {
 	log.Printf("ローカルモードでの変換を開始します...")
 	s.logManager.LogInfo("ローカルモードでの変換を開始します。設定: %s", s.config.Input.Directory)
 
+	// ファイル検索も含めたジョブ全体の実行時間を制限する
+	ctx, cancel := s.runContext()
+	defer cancel()
+
 	// ファイル検索
 	finder := NewFileFinder(s.config)
+	if s.retryFiles != nil {
+		finder.SetRetryFiles(s.retryFiles)
+	}
+	var hashIndex *HashIndex
+	if s.config.Mode.HashIndex != "" {
+		var err error
+		if hashIndex, err = LoadHashIndex(s.config.Mode.HashIndex); err != nil {
+			return err
+		}
+		finder.SetHashIndex(hashIndex)
+	}
 	files, totalFiles, err := finder.FindFiles()
 	if err != nil {
-		return fmt.Errorf("ファイル検索に失敗しました: %w", err)
+		// URL入力がある場合は入力ディレクトリの画像がなくても処理を続ける
+		if len(s.config.Input.URLs) == 0 {
+			return fmt.Errorf("ファイル検索に失敗しました: %w", err)
+		}
+		s.logManager.LogWarning("入力ディレクトリの検索をスキップします: %v", err)
 	}
+	s.stats.SkippedUnchanged.Store(int64(finder.SkippedUnchanged()))
 
 	s.logManager.LogInfo("検索完了: %d個のファイルが見つかりました", totalFiles)
 
+	// この環境でデコードできない形式のファイルを除外
+	supported, skipped, err := converter.FilterUnsupportedInputs(filePaths(files), s.config)
+	if err != nil {
+		return err
+	}
+	files = keepFiles(files, supported)
+	s.stats.SkippedUnsupported.Store(int64(skipped))
+	totalFiles = len(files)
+	s.logManager.LogInfo("入力ファイルの合計サイズ: %s", utils.FormatFileSize(totalSize(files)))
+
 	// ドライランモードの場合
 	if s.config.Mode.DryRun {
 		s.logManager.LogInfo("ドライランモード: 変換は行われません")
-		s.printFileList(files)
-		return nil
+		s.printFileList(append(filePaths(files), s.config.Input.URLs...))
+		return s.writeDryRunPlan(filePaths(files))
+	}
+
+	// URLで指定された画像をダウンロードして変換対象に加える
+	// 失敗したファイルの再試行ではURLの画像は取得しない
+	var fetcher *URLFetcher
+	if len(s.config.Input.URLs) > 0 && s.retryFiles == nil {
+		fetcher = NewURLFetcher(s.config)
+		defer fetcher.Cleanup()
+
+		urlFiles, failed, err := fetcher.FetchAll()
+		if err != nil {
+			return err
+		}
+		s.stats.DownloadFailed.Add(int64(failed))
+		for _, file := range urlFiles {
+			info, err := statFileInfo(file)
+			if err != nil {
+				return fmt.Errorf("ダウンロードした画像の情報を取得できません: %v", err)
+			}
+			files = append(files, info)
+		}
+		totalFiles = len(files)
 	}
 
 	// 処理実行
-	processor := NewFileProcessor(s.config, s.stats, s.logManager)
-	if err := processor.ProcessFiles(files, totalFiles); err != nil {
-		return fmt.Errorf("ファイル処理に失敗しました: %w", err)
+	processor := NewFileProcessor(s.config, s.stats, s.logManager, finder)
+	if s.config.Output.Zip != "" {
+		zipOutput, err := converter.NewZipOutput(s.config.Output.Zip, s.config.Input.Directory)
+		if err != nil {
+			return err
+		}
+		defer s.closeZipOutput(zipOutput)
+		processor.SetZipOutput(zipOutput)
+	}
+	if len(files) == 0 {
+		// スキップによりすべて除外された場合
+		s.logManager.LogInfo("変換が必要なファイルはありません")
+	} else if err := processor.ProcessFiles(ctx, files, totalFiles); err != nil {
+		// 実行時間の上限に達した場合は、処理済みの結果を保存・出力して正常終了する
+		if !errors.Is(err, ErrMaxRuntimeExceeded) {
+			s.recordFailures(processor.Failures(), fetcher)
+			return fmt.Errorf("ファイル処理に失敗しました: %w", err)
+		}
 	}
 
+	// URLの画像から生成した出力ファイルを入力ディレクトリに移動
+	// 出力ファイル名の重複は、他の結果を保存・出力してから終了コードに反映する
+	var publishErr error
+	if fetcher != nil {
+		if publishErr = fetcher.Publish(); publishErr != nil && !errors.Is(publishErr, ErrURLOutputCollision) {
+			return publishErr
+		}
+	}
+
+	// ハッシュインデックスを保存
+	if hashIndex != nil {
+		if err := hashIndex.Save(); err != nil {
+			s.logManager.LogWarning("%v", err)
+		}
+	}
+
+	// 失敗したファイルの一覧を保存
+	failures := s.recordFailures(processor.Failures(), fetcher)
+
 	// 結果出力
-	s.logSummary(totalFiles)
-	return nil
+	s.logSummary(totalFiles, processor.Timings())
+
+	// 完了通知
+	notify.NotifyCompletion(s.config, notify.NewCompletionPayload("local", totalFiles, s.stats))
+
+	// 再試行で失敗が解消されなかった場合は終了コードに反映する
+	if s.retryFiles != nil && len(failures) > 0 {
+		return fmt.Errorf("%w: %d個", ErrFailuresRemain, len(failures))
+	}
+	return publishErr
+}
+
+// recordFailures は失敗したファイルの一覧を reporting.failures_file に書き込み、記録したファイルを返します
+// URLからダウンロードしたファイルは一時ファイルのため、一覧には含めません
+func (s *Service) recordFailures(failures []string, fetcher *URLFetcher) []string {
+	var recorded []string
+	for _, file := range failures {
+		if fetcher != nil && fetcher.IsFetched(file) {
+			continue
+		}
+		recorded = append(recorded, file)
+	}
+
+	if path := s.config.Reporting.FailuresFile; path != "" {
+		if err := WriteFailuresFile(path, recorded); err != nil {
+			s.logManager.LogWarning("%v", err)
+		} else if len(recorded) > 0 {
+			s.logManager.LogInfo("失敗したファイルの一覧を書き込みました: %s (%d個)", path, len(recorded))
+		}
+	}
+
+	return recorded
+}
+
+// closeZipOutput はZIPアーカイブを書き込んで閉じます
+// 閉じられなかった場合、アーカイブは不完全なため警告を出力します
+func (s *Service) closeZipOutput(z *converter.ZipOutput) {
+	if err := z.Close(); err != nil {
+		s.logManager.LogWarning("%v", err)
+		return
+	}
+	s.logManager.LogInfo("変換結果をZIPアーカイブにまとめました: %s", z.Path())
+}
+
+// runContext は mode.max_runtime_seconds を期限とするコンテキストを返します
+// 上限が0の場合は期限のないコンテキストを返します
+func (s *Service) runContext() (context.Context, context.CancelFunc) {
+	if s.config.Mode.MaxRuntimeSeconds <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	limit := time.Duration(s.config.Mode.MaxRuntimeSeconds) * time.Second
+	s.logManager.LogInfo("実行時間の上限: %s", limit)
+	return context.WithDeadline(context.Background(), s.startTime.Add(limit))
 }
 
 // logSummary は変換結果のサマリーをログに出力します
-func (s *Service) logSummary(totalFiles int) {
+func (s *Service) logSummary(totalFiles int, timings *TimingCollector) {
 	s.logManager.LogInfo("=== 変換処理結果 ===")
 	s.logManager.LogInfo("処理ファイル数: %d", totalFiles)
-	s.logManager.LogInfo("WebP変換成功: %d, 失敗: %d", s.stats.WebPSuccess, s.stats.WebPFailed)
-	s.logManager.LogInfo("AVIF変換成功: %d, 失敗: %d", s.stats.AVIFSuccess, s.stats.AVIFFailed)
-	s.logManager.LogInfo("処理時間: %s", time.Since(s.startTime))
+	s.logManager.LogInfo("成功率: %.1f%%", s.stats.SuccessRate()*100)
+	s.logManager.LogInfo("WebP変換成功: %d, 失敗: %d (成功率: %.1f%%)", s.stats.WebPSuccessCount(), s.stats.WebPFailedCount(), s.stats.WebPSuccessRate()*100)
+	s.logManager.LogInfo("AVIF変換成功: %d, 失敗: %d (成功率: %.1f%%)", s.stats.AVIFSuccessCount(), s.stats.AVIFFailedCount(), s.stats.AVIFSuccessRate()*100)
+	if s.config.Conversion.JXL.Enabled {
+		s.logManager.LogInfo("JPEG XL変換成功: %d, 失敗: %d", s.stats.JXLSuccessCount(), s.stats.JXLFailedCount())
+	}
+	if s.config.Conversion.Optimize.Enabled {
+		s.logManager.LogInfo("再圧縮成功: %d, 失敗: %d", s.stats.OptimizeSuccessCount(), s.stats.OptimizeFailedCount())
+	}
+	if s.config.Input.MinWidth > 0 || s.config.Input.MinHeight > 0 {
+		s.logManager.LogInfo("最小寸法未満でスキップ: %d", s.stats.SkippedTooSmallCount())
+	}
+	if s.config.Mode.HashIndex != "" {
+		s.logManager.LogInfo("内容に変更がないためスキップ: %d", s.stats.SkippedUnchangedCount())
+	}
+	if s.stats.SkippedMissingCount() > 0 {
+		s.logManager.LogInfo("検索後に削除されたためスキップ: %d", s.stats.SkippedMissingCount())
+	}
+	if s.stats.SkippedTooLargeCount() > 0 {
+		s.logManager.LogInfo("画素数が上限を超えるためスキップ: %d", s.stats.SkippedTooLargeCount())
+	}
+	if s.stats.SkippedUnsupportedCount() > 0 {
+		s.logManager.LogInfo("未対応の形式でスキップ: %d", s.stats.SkippedUnsupportedCount())
+	}
+	if s.config.Conversion.QuarantineDir != "" {
+		s.logManager.LogInfo("隔離した破損画像: %d (隔離先: %s)", s.stats.QuarantinedCount(), s.config.Conversion.QuarantineDir)
+	}
+	s.logManager.LogInfo("削減サイズ: %d バイト", s.stats.BytesSaved())
+	s.logManager.LogInfo("処理時間: %s (%.1f files/sec)", time.Since(s.startTime), s.stats.Throughput())
+	s.logSlowestFiles(timings)
 	s.logManager.LogInfo("=== 画像変換処理終了: %s ===", time.Now().Format("2006-01-02 15:04:05"))
 }
 
+// logSlowestFiles は処理時間の長いファイルを上位から出力します
+func (s *Service) logSlowestFiles(timings *TimingCollector) {
+	slowest := timings.Slowest(s.config.Reporting.TopSlowCount)
+	if len(slowest) == 0 {
+		return
+	}
+
+	s.logManager.LogInfo("処理時間の長いファイル（上位%d件）:", len(slowest))
+	for i, timing := range slowest {
+		s.logManager.LogInfo("%d: %s (%s)", i+1, timing.Path, timing.Duration.Round(time.Millisecond))
+	}
+}
+
 // printFileList はドライランモードでファイルリストを表示します
 func (s *Service) printFileList(files []string) {
 	s.logManager.LogInfo("=== 変換対象ファイル ===")
@@ -79,6 +269,26 @@ func (s *Service) printFileList(files []string) {
 	s.logManager.LogInfo("合計: %d個のファイル", len(files))
 }
 
+// writeDryRunPlan は mode.dry_run_output が設定されている場合に変換予定の内容をファイルに書き込みます
+// URL入力はダウンロード先が決まらないため含みません
+func (s *Service) writeDryRunPlan(files []string) error {
+	path := s.config.Mode.DryRunOutput
+	if path == "" {
+		return nil
+	}
+
+	plans := make([]converter.PlannedConversion, 0, len(files))
+	for _, file := range files {
+		plans = append(plans, converter.PlanConversion(s.config, file))
+	}
+	if err := converter.WriteDryRunPlan(path, plans); err != nil {
+		return err
+	}
+
+	s.logManager.LogInfo("ドライランの変換予定を書き込みました: %s", path)
+	return nil
+}
+
 // GetStats は現在の統計情報を返します
 func (s *Service) GetStats() *config.ConversionStats {
 	return s.stats
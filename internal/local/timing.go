@@ -0,0 +1,47 @@
+package local
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// FileTiming はファイルごとの処理時間を表します
+type FileTiming struct {
+	Path     string
+	Duration time.Duration
+}
+
+// TimingCollector は並列に処理されるファイルの処理時間を収集します
+type TimingCollector struct {
+	mu      sync.Mutex
+	timings []FileTiming
+}
+
+// NewTimingCollector は新しい処理時間の収集器を作成します
+func NewTimingCollector() *TimingCollector {
+	return &TimingCollector{}
+}
+
+// Record はファイルの処理時間を記録します
+func (c *TimingCollector) Record(path string, duration time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.timings = append(c.timings, FileTiming{Path: path, Duration: duration})
+}
+
+// Slowest は処理時間の長い順に最大 n 件を返します
+func (c *TimingCollector) Slowest(n int) []FileTiming {
+	c.mu.Lock()
+	sorted := append([]FileTiming(nil), c.timings...)
+	c.mu.Unlock()
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Duration > sorted[j].Duration
+	})
+
+	if n < len(sorted) {
+		sorted = sorted[:n]
+	}
+	return sorted
+}
@@ -0,0 +1,223 @@
+package local
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/223n/image-converter/internal/config"
+	"github.com/223n/image-converter/internal/utils"
+)
+
+// ErrURLOutputCollision は異なるURLの画像の出力ファイル名が重複したため、保存しなかった出力がある場合のエラーです
+var ErrURLOutputCollision = errors.New("出力ファイル名が他のURLと重複するため保存しなかった出力があります")
+
+// urlContentTypes はURL入力として受け付けるContent-Typeと保存時の拡張子です
+var urlContentTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+}
+
+// urlExtensions はURL入力として受け付ける拡張子です
+var urlExtensions = map[string]bool{
+	".jpg":  true,
+	".jpeg": true,
+	".png":  true,
+}
+
+// URLFetcher はURLで指定された画像を一時ディレクトリにダウンロードします
+// 変換後のファイルは Publish で入力ディレクトリに移動し、ダウンロードした元画像は Cleanup で削除します
+type URLFetcher struct {
+	config  *config.Config
+	client  *http.Client
+	tempDir string
+	sources map[string]string // ダウンロードしたファイルのパス -> URL
+}
+
+// NewURLFetcher は新しいURLフェッチャーを作成します
+func NewURLFetcher(cfg *config.Config) *URLFetcher {
+	return &URLFetcher{
+		config:  cfg,
+		client:  &http.Client{Timeout: time.Duration(cfg.Input.URLTimeout) * time.Second},
+		sources: make(map[string]string),
+	}
+}
+
+// FetchAll は設定されたすべてのURLをダウンロードし、保存したファイルのパスを返します
+// ダウンロードに失敗したURLは警告を出力してスキップし、失敗件数を返します
+func (u *URLFetcher) FetchAll() ([]string, int, error) {
+	tempDir, err := os.MkdirTemp("", "image-converter-urls-")
+	if err != nil {
+		return nil, 0, fmt.Errorf("一時ディレクトリの作成に失敗しました: %v", err)
+	}
+	u.tempDir = tempDir
+
+	var files []string
+	failed := 0
+	for i, rawURL := range u.config.Input.URLs {
+		// 同名のファイルが衝突しないよう、URLごとにディレクトリを分ける
+		dir := filepath.Join(tempDir, fmt.Sprintf("%03d", i))
+		file, err := u.fetch(rawURL, dir)
+		if err != nil {
+			log.Printf("警告: URLの画像を取得できませんでした [%s]: %v", rawURL, err)
+			failed++
+			continue
+		}
+		u.sources[file] = rawURL
+		files = append(files, file)
+	}
+
+	log.Printf("URL入力: %d件中 %d件の画像をダウンロードしました", len(u.config.Input.URLs), len(files))
+	return files, failed, nil
+}
+
+// fetch は1つのURLをダウンロードして dir に保存します
+func (u *URLFetcher) fetch(rawURL, dir string) (string, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("URLの解析に失敗しました: %v", err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return "", fmt.Errorf("未対応のスキームです: %q", parsed.Scheme)
+	}
+
+	resp, err := u.client.Get(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("リクエストに失敗しました: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("予期しない応答が返されました: %s", resp.Status)
+	}
+
+	name, err := urlFileName(parsed, resp.Header.Get("Content-Type"))
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("保存先ディレクトリの作成に失敗しました: %v", err)
+	}
+	file := filepath.Join(dir, name)
+
+	out, err := os.Create(file)
+	if err != nil {
+		return "", fmt.Errorf("ファイルの作成に失敗しました: %v", err)
+	}
+	if _, err := io.Copy(out, resp.Body); err != nil {
+		out.Close()
+		return "", fmt.Errorf("ダウンロードに失敗しました: %v", err)
+	}
+	if err := out.Close(); err != nil {
+		return "", fmt.Errorf("ファイルの保存に失敗しました: %v", err)
+	}
+
+	return file, nil
+}
+
+// urlFileName はURLとContent-Typeを検証し、保存するファイル名を決定します
+// Content-Typeが画像でない場合や、JPEG・PNG以外の拡張子の場合はエラーを返します
+func urlFileName(parsed *url.URL, contentType string) (string, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if contentType != "" && err != nil {
+		return "", fmt.Errorf("Content-Typeの解析に失敗しました: %v", err)
+	}
+
+	typeExt, typeOK := urlContentTypes[mediaType]
+	if contentType != "" && !typeOK {
+		return "", fmt.Errorf("未対応のContent-Typeです: %s", mediaType)
+	}
+
+	base := path.Base(parsed.Path)
+	ext := strings.ToLower(path.Ext(base))
+	switch {
+	case urlExtensions[ext]:
+		return base, nil
+	case ext != "" && base != "/" && base != ".":
+		return "", fmt.Errorf("未対応の拡張子です: %s", ext)
+	case typeOK:
+		// 拡張子のないURLはContent-Typeから拡張子を補う
+		if base == "/" || base == "." {
+			base = "image"
+		}
+		return base + typeExt, nil
+	default:
+		return "", fmt.Errorf("画像の形式を判定できません（拡張子・Content-Typeがありません）")
+	}
+}
+
+// IsFetched はパスがURLからダウンロードしたファイルかどうかを返します
+func (u *URLFetcher) IsFetched(path string) bool {
+	_, ok := u.sources[path]
+	return ok
+}
+
+// Publish はダウンロードした画像から生成された出力ファイルを入力ディレクトリに移動します
+// ダウンロードした元画像自体は移動しません
+// 異なるURLの出力ファイルが同じ名前になる場合（例: .../a/image.jpg と .../b/image.jpg）は、
+// 設定のURLの順で最初のものだけを保存し、残りは保存せずにエラーを返します
+func (u *URLFetcher) Publish() error {
+	destDir := u.config.Input.Directory
+
+	// 一時ディレクトリはURLの順に連番で作成しているため、パスの順に処理するとURLの順になる
+	files := make([]string, 0, len(u.sources))
+	for file := range u.sources {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	published := make(map[string]string) // 保存先のパス -> URL
+	var collisions []string
+	for _, file := range files {
+		rawURL := u.sources[file]
+		dir := filepath.Dir(file)
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return fmt.Errorf("出力ファイルの一覧取得に失敗しました: %v", err)
+		}
+
+		for _, entry := range entries {
+			src := filepath.Join(dir, entry.Name())
+			if src == file || entry.IsDir() {
+				continue
+			}
+			dst := filepath.Join(destDir, entry.Name())
+			if firstURL, ok := published[dst]; ok {
+				log.Printf("警告: 出力ファイル名が他のURLと重複するため保存しません: %s (%s と %s)", dst, firstURL, rawURL)
+				collisions = append(collisions, rawURL)
+				continue
+			}
+			if err := utils.MoveFile(src, dst); err != nil {
+				return fmt.Errorf("出力ファイルの移動に失敗しました [%s]: %v", rawURL, err)
+			}
+			published[dst] = rawURL
+			log.Printf("URLの画像の変換結果を保存しました: %s -> %s", rawURL, dst)
+		}
+	}
+
+	if len(collisions) > 0 {
+		return fmt.Errorf("%w: %s", ErrURLOutputCollision, strings.Join(collisions, ", "))
+	}
+	return nil
+}
+
+// Cleanup はダウンロードに使用した一時ディレクトリを削除します
+func (u *URLFetcher) Cleanup() {
+	if u.tempDir == "" {
+		return
+	}
+	if err := os.RemoveAll(u.tempDir); err != nil {
+		log.Printf("警告: 一時ディレクトリの削除に失敗しました: %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+package local
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/223n/image-converter/internal/utils"
+	"github.com/chai2010/webp"
+)
+
+// pngHandler は size x size のPNG画像を返すハンドラーです
+func pngHandler(t *testing.T, size int) http.HandlerFunc {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, size, size))); err != nil {
+		t.Fatalf("PNGのエンコードに失敗しました: %v", err)
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(buf.Bytes())
+	}
+}
+
+// TestServiceExecuteURLs はURLの画像をダウンロードして変換し、出力を入力ディレクトリに保存することを確認します
+// 別のURLで同じファイル名の画像は、最初のURLの出力だけを保存してエラーにします
+func TestServiceExecuteURLs(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("/a/image.png", pngHandler(t, 8))
+	mux.Handle("/b/image.png", pngHandler(t, 16))
+	mux.Handle("/c/other.png", pngHandler(t, 4))
+	mux.HandleFunc("/d/doc.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("not an image"))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	inputDir := t.TempDir()
+	cfg := webpOnlyConfig(inputDir, "")
+	cfg.Input.URLTimeout = 5
+	cfg.Input.URLs = []string{
+		server.URL + "/a/image.png",
+		server.URL + "/b/image.png",
+		server.URL + "/c/other.png",
+		server.URL + "/d/doc.png",
+		server.URL + "/missing.png",
+	}
+	s := NewService(&cfg, utils.NewLogManager())
+
+	if err := s.Execute(); !errors.Is(err, ErrURLOutputCollision) {
+		t.Fatalf("Execute のエラー = %v, ErrURLOutputCollision を期待しました", err)
+	}
+
+	// 同じ名前の出力は最初のURL（8x8）のものが保存される
+	for name, wantSize := range map[string]int{"image.webp": 8, "other.webp": 4} {
+		data, err := os.ReadFile(filepath.Join(inputDir, name))
+		if err != nil {
+			t.Errorf("%s が保存されていません: %v", name, err)
+			continue
+		}
+		imgConfig, err := webp.DecodeConfig(bytes.NewReader(data))
+		if err != nil || imgConfig.Width != wantSize {
+			t.Errorf("%s の幅 = %d (%v), want %d", name, imgConfig.Width, err, wantSize)
+		}
+	}
+	// ダウンロードした元画像は入力ディレクトリに保存しない
+	if _, err := os.Stat(filepath.Join(inputDir, "image.png")); !os.IsNotExist(err) {
+		t.Errorf("ダウンロードした元画像が保存されました: %v", err)
+	}
+	if got := s.GetStats().DownloadFailed.Load(); got != 2 {
+		t.Errorf("DownloadFailed = %d, want 2", got)
+	}
+}
+
+func TestURLFileName(t *testing.T) {
+	tests := []struct {
+		name        string
+		rawURL      string
+		contentType string
+		want        string
+		wantErr     bool
+	}{
+		{name: "拡張子あり", rawURL: "http://example.com/a/photo.JPG", contentType: "image/jpeg", want: "photo.JPG"},
+		{name: "Content-Typeなし", rawURL: "http://example.com/photo.png", want: "photo.png"},
+		{name: "拡張子なし", rawURL: "http://example.com/images/123", contentType: "image/png", want: "123.png"},
+		{name: "パスなし", rawURL: "http://example.com/", contentType: "image/jpeg; charset=binary", want: "image.jpg"},
+		{name: "画像以外のContent-Type", rawURL: "http://example.com/photo.jpg", contentType: "text/html", wantErr: true},
+		{name: "未対応の拡張子", rawURL: "http://example.com/photo.gif", contentType: "image/png", wantErr: true},
+		{name: "形式を判定できない", rawURL: "http://example.com/download", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := url.Parse(tt.rawURL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := urlFileName(parsed, tt.contentType)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("urlFileName のエラー = %v, エラーの有無 %v を期待しました", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("urlFileName = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
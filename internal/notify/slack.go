@@ -0,0 +1,48 @@
+package notify
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/223n/image-converter/internal/utils"
+)
+
+// slackMessage はSlackのIncoming Webhookが受け付けるメッセージです
+type slackMessage struct {
+	Text string `json:"text"`
+}
+
+// FormatSlackMessage は完了通知をSlack向けの読みやすい1行のメッセージにします
+// 失敗したファイルがある場合は警告の絵文字と失敗件数を含めます
+func FormatSlackMessage(payload *CompletionPayload) string {
+	status := ":white_check_mark:"
+	if payload.Failed > 0 {
+		status = ":warning:"
+	}
+
+	message := fmt.Sprintf("%s [%s] %s 枚の画像を変換しました（削減: %s、処理時間: %s）",
+		status, payload.Mode, formatCount(payload.Converted),
+		utils.FormatFileSize(max(payload.BytesSaved, 0)),
+		utils.FormatDuration(time.Duration(payload.DurationSeconds*float64(time.Second))))
+
+	if payload.Failed > 0 {
+		message += fmt.Sprintf(" 失敗: %s 件", formatCount(payload.Failed))
+	}
+
+	return message
+}
+
+// formatCount は数値を3桁区切りの文字列にします
+func formatCount(n int) string {
+	if n < 0 {
+		return "-" + formatCount(-n)
+	}
+
+	s := strconv.Itoa(n)
+
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
@@ -0,0 +1,120 @@
+/*
+Package notify は変換処理の完了を外部に通知する機能を提供します。
+*/
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/223n/image-converter/internal/config"
+)
+
+// CompletionPayload は完了通知で送信する内容です
+type CompletionPayload struct {
+	Mode            string                  `json:"mode"` // local または remote
+	TotalFiles      int                     `json:"total_files"`
+	Converted       int                     `json:"converted"`
+	Failed          int                     `json:"failed"`
+	BytesSaved      int64                   `json:"bytes_saved"`
+	DurationSeconds float64                 `json:"duration_seconds"`
+	Duration        string                  `json:"duration"`
+	Stats           *config.ConversionStats `json:"stats"`
+}
+
+// NewCompletionPayload は統計情報から完了通知の内容を作成します
+func NewCompletionPayload(mode string, totalFiles int, stats *config.ConversionStats) *CompletionPayload {
+	duration := time.Since(stats.StartTime)
+	return &CompletionPayload{
+		Mode:            mode,
+		TotalFiles:      totalFiles,
+		Converted:       stats.TotalProcessedCount(),
+		Failed:          stats.DownloadFailedCount() + stats.ConvertFailedCount() + stats.WebPFailedCount() + stats.AVIFFailedCount() + stats.JXLFailedCount(),
+		BytesSaved:      stats.BytesSaved(),
+		DurationSeconds: duration.Seconds(),
+		Duration:        duration.Round(time.Second).String(),
+		Stats:           stats,
+	}
+}
+
+// retryBaseDelay はリトライ間隔の基準値です（試行ごとに2倍にします）
+const retryBaseDelay = time.Second
+
+// NotifyCompletion は設定されたWebhookに完了通知を送信します
+// WebhookのURLが未設定の場合は何もしません。通知の失敗は警告としてログに出力し、処理自体は失敗させません
+func NotifyCompletion(cfg *config.Config, payload *CompletionPayload) {
+	url := cfg.Notifications.WebhookURL
+	if url == "" {
+		return
+	}
+
+	body, err := buildBody(cfg.Notifications.Format, payload)
+	if err != nil {
+		log.Printf("警告: 完了通知の作成に失敗しました: %v", err)
+		return
+	}
+
+	client := &http.Client{Timeout: time.Duration(cfg.Notifications.Timeout) * time.Second}
+	if err := postWithRetry(client, url, body, cfg.Notifications.MaxRetries); err != nil {
+		log.Printf("警告: 完了通知の送信に失敗しました: %v", err)
+		return
+	}
+
+	log.Printf("完了通知を送信しました: %s", url)
+}
+
+// buildBody は通知の形式に応じて送信するJSONを作成します
+func buildBody(format string, payload *CompletionPayload) ([]byte, error) {
+	if format == config.NotificationFormatSlack {
+		return json.Marshal(slackMessage{Text: FormatSlackMessage(payload)})
+	}
+	return json.Marshal(payload)
+}
+
+// postWithRetry はJSONをPOSTし、通信エラーまたは5xxの場合は間隔を空けて再試行します
+func postWithRetry(client *http.Client, url string, body []byte, maxRetries int) error {
+	var lastErr error
+
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		if attempt > 0 {
+			delay := retryBaseDelay * time.Duration(1<<(attempt-1))
+			log.Printf("完了通知を再試行します (%d/%d): %v後", attempt, maxRetries, delay)
+			time.Sleep(delay)
+		}
+
+		retryable, err := post(client, url, body)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+
+		if !retryable {
+			break
+		}
+	}
+
+	return lastErr
+}
+
+// post はJSONを1回POSTします
+// 失敗した場合は再試行すべきかどうかとエラーを返します
+func post(client *http.Client, url string, body []byte) (bool, error) {
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return true, fmt.Errorf("リクエストに失敗しました: %v", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 500:
+		return true, fmt.Errorf("サーバーエラーが返されました: %s", resp.Status)
+	case resp.StatusCode >= 300:
+		return false, fmt.Errorf("予期しない応答が返されました: %s", resp.Status)
+	}
+
+	return false, nil
+}
@@ -0,0 +1,295 @@
+package remote
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	gliderssh "github.com/gliderlabs/ssh"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/223n/image-converter/internal/config"
+)
+
+// newTestSigner はテスト用のECDSA鍵を生成して署名者を返します
+func newTestSigner(t *testing.T) (*ecdsa.PrivateKey, ssh.Signer) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("鍵の生成に失敗しました: %v", err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatalf("署名者の作成に失敗しました: %v", err)
+	}
+	return key, signer
+}
+
+// writeTestKey は秘密鍵を key_path で読み込めるPEM形式で保存し、そのパスを返します
+func writeTestKey(t *testing.T, key *ecdsa.PrivateKey) string {
+	t.Helper()
+
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("秘密鍵の変換に失敗しました: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "id_ecdsa")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		t.Fatalf("秘密鍵の保存に失敗しました: %v", err)
+	}
+	return path
+}
+
+// testCert は署名する証明書の内容です
+type testCert struct {
+	certType    uint32
+	principals  []string
+	validAfter  uint64
+	validBefore uint64
+}
+
+// validUserCert は現在有効な deploy 用のユーザー証明書の内容を返します
+func validUserCert() testCert {
+	now := time.Now()
+	return testCert{
+		certType:    ssh.UserCert,
+		principals:  []string{"deploy"},
+		validAfter:  uint64(now.Add(-time.Hour).Unix()),
+		validBefore: uint64(now.Add(time.Hour).Unix()),
+	}
+}
+
+// writeTestCert は CA で公開鍵に署名した証明書を authorized_keys 形式（*-cert.pub）で保存し、そのパスを返します
+func writeTestCert(t *testing.T, ca ssh.Signer, pub ssh.PublicKey, c testCert) string {
+	t.Helper()
+
+	cert := &ssh.Certificate{
+		Key:             pub,
+		Serial:          1,
+		CertType:        c.certType,
+		KeyId:           "test",
+		ValidPrincipals: c.principals,
+		ValidAfter:      c.validAfter,
+		ValidBefore:     c.validBefore,
+	}
+	if err := cert.SignCert(rand.Reader, ca); err != nil {
+		t.Fatalf("証明書の署名に失敗しました: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "id_ecdsa-cert.pub")
+	if err := os.WriteFile(path, ssh.MarshalAuthorizedKey(cert), 0644); err != nil {
+		t.Fatalf("証明書の保存に失敗しました: %v", err)
+	}
+	return path
+}
+
+// newCertTrustingServer は ca が署名したユーザー証明書だけを受け付けるSSH/SFTPサーバー（gliderlabs/ssh）を起動し、そのアドレスを返します
+// 証明書のプリンシパルには接続するユーザー名が含まれている必要があります
+func newCertTrustingServer(t *testing.T, ca ssh.PublicKey) *net.TCPAddr {
+	t.Helper()
+
+	_, hostSigner := newTestSigner(t)
+	checker := &ssh.CertChecker{
+		IsUserAuthority: func(auth ssh.PublicKey) bool {
+			return bytes.Equal(auth.Marshal(), ca.Marshal())
+		},
+	}
+
+	server := &gliderssh.Server{
+		Handler: func(s gliderssh.Session) { s.Exit(0) },
+		PublicKeyHandler: func(ctx gliderssh.Context, key gliderssh.PublicKey) bool {
+			cert, ok := key.(*ssh.Certificate)
+			if !ok || !checker.IsUserAuthority(cert.SignatureKey) {
+				return false
+			}
+			return checker.CheckCert(ctx.User(), cert) == nil
+		},
+		SubsystemHandlers: map[string]gliderssh.SubsystemHandler{
+			"sftp": func(s gliderssh.Session) {
+				server, err := sftp.NewServer(s)
+				if err != nil {
+					return
+				}
+				server.Serve()
+				server.Close()
+			},
+		},
+	}
+	server.AddHostKey(hostSigner)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("待ち受けを開始できません: %v", err)
+	}
+	go server.Serve(listener)
+	t.Cleanup(func() { server.Close() })
+
+	return listener.Addr().(*net.TCPAddr)
+}
+
+// TestCertificateAuth はローカルのCAが署名した証明書で、CAを信頼するサーバーに接続できることを確認します
+func TestCertificateAuth(t *testing.T) {
+	_, ca := newTestSigner(t)
+	_, otherCA := newTestSigner(t)
+	addr := newCertTrustingServer(t, ca.PublicKey())
+
+	userKey, userSigner := newTestSigner(t)
+	keyPath := writeTestKey(t, userKey)
+
+	remoteConfig := func(certPath string) *config.RemoteConfig {
+		return &config.RemoteConfig{
+			Enabled:       true,
+			Host:          addr.IP.String(),
+			Port:          addr.Port,
+			User:          "deploy",
+			KeyPath:       keyPath,
+			CertPath:      certPath,
+			CertPrincipal: "deploy",
+			Timeout:       5,
+		}
+	}
+
+	t.Run("CAが署名した証明書", func(t *testing.T) {
+		certPath := writeTestCert(t, ca, userSigner.PublicKey(), validUserCert())
+		client, err := NewClient(remoteConfig(certPath))
+		if err != nil {
+			t.Fatalf("NewClient に失敗しました: %v", err)
+		}
+		defer client.Close()
+
+		if _, err := client.sftpClient.sftp.Getwd(); err != nil {
+			t.Errorf("証明書で接続したSFTP操作に失敗しました: %v", err)
+		}
+	})
+
+	t.Run("証明書なしの鍵", func(t *testing.T) {
+		if client, err := NewClient(remoteConfig("")); err == nil {
+			client.Close()
+			t.Error("証明書なしの鍵で接続できました")
+		}
+	})
+
+	t.Run("信頼されていないCAの証明書", func(t *testing.T) {
+		certPath := writeTestCert(t, otherCA, userSigner.PublicKey(), validUserCert())
+		if client, err := NewClient(remoteConfig(certPath)); err == nil {
+			client.Close()
+			t.Error("信頼されていないCAの証明書で接続できました")
+		}
+	})
+
+	t.Run("秘密鍵のない証明書", func(t *testing.T) {
+		cfg := remoteConfig(writeTestCert(t, ca, userSigner.PublicKey(), validUserCert()))
+		cfg.KeyPath = ""
+		if _, err := NewClient(cfg); err == nil || !strings.Contains(err.Error(), "key_path") {
+			t.Errorf("NewClient = %v, key_path の指定を求めるエラーを期待しました", err)
+		}
+	})
+}
+
+// TestNewCertSigner は証明書の種類・有効期間・プリンシパル・秘密鍵との対応を検証することを確認します
+func TestNewCertSigner(t *testing.T) {
+	_, ca := newTestSigner(t)
+	_, userSigner := newTestSigner(t)
+	_, otherSigner := newTestSigner(t)
+	now := time.Now()
+
+	// 証明書ではない公開鍵のファイル
+	plainPath := filepath.Join(t.TempDir(), "id_ecdsa.pub")
+	if err := os.WriteFile(plainPath, ssh.MarshalAuthorizedKey(userSigner.PublicKey()), 0644); err != nil {
+		t.Fatalf("公開鍵の保存に失敗しました: %v", err)
+	}
+
+	with := func(modify func(c *testCert)) testCert {
+		c := validUserCert()
+		modify(&c)
+		return c
+	}
+
+	tests := []struct {
+		name      string
+		certPath  string
+		principal string
+		wantErr   string // 空の場合は成功を期待する
+	}{
+		{
+			name:      "プリンシパルが一致",
+			certPath:  writeTestCert(t, ca, userSigner.PublicKey(), validUserCert()),
+			principal: "deploy",
+		},
+		{
+			name:     "プリンシパルを検証しない",
+			certPath: writeTestCert(t, ca, userSigner.PublicKey(), with(func(c *testCert) { c.principals = []string{"admin"} })),
+		},
+		{
+			name:     "無期限",
+			certPath: writeTestCert(t, ca, userSigner.PublicKey(), with(func(c *testCert) { c.validBefore = ssh.CertTimeInfinity })),
+		},
+		{
+			name:      "プリンシパルが一致しない",
+			certPath:  writeTestCert(t, ca, userSigner.PublicKey(), with(func(c *testCert) { c.principals = []string{"admin", "backup"} })),
+			principal: "deploy",
+			wantErr:   "プリンシパル",
+		},
+		{
+			name: "期限切れ",
+			certPath: writeTestCert(t, ca, userSigner.PublicKey(), with(func(c *testCert) {
+				c.validAfter, c.validBefore = uint64(now.Add(-2*time.Hour).Unix()), uint64(now.Add(-time.Hour).Unix())
+			})),
+			wantErr: "有効期間外",
+		},
+		{
+			name:     "有効期間の開始前",
+			certPath: writeTestCert(t, ca, userSigner.PublicKey(), with(func(c *testCert) { c.validAfter = uint64(now.Add(time.Hour).Unix()) })),
+			wantErr:  "有効期間外",
+		},
+		{
+			name:     "ホスト証明書",
+			certPath: writeTestCert(t, ca, userSigner.PublicKey(), with(func(c *testCert) { c.certType = ssh.HostCert })),
+			wantErr:  "ユーザー証明書ではありません",
+		},
+		{
+			name:     "別の鍵の証明書",
+			certPath: writeTestCert(t, ca, otherSigner.PublicKey(), validUserCert()),
+			wantErr:  "一致しません",
+		},
+		{
+			name:     "証明書ではない公開鍵",
+			certPath: plainPath,
+			wantErr:  "SSH証明書が含まれていません",
+		},
+		{
+			name:     "ファイルがない",
+			certPath: filepath.Join(t.TempDir(), "missing-cert.pub"),
+			wantErr:  "読み込みに失敗しました",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer, err := newCertSigner(userSigner, tt.certPath, tt.principal)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("newCertSigner = %v, %q を含むエラーを期待しました", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("newCertSigner に失敗しました: %v", err)
+			}
+			if _, ok := signer.PublicKey().(*ssh.Certificate); !ok {
+				t.Errorf("署名者の公開鍵 = %T, 証明書を期待しました", signer.PublicKey())
+			}
+		})
+	}
+}
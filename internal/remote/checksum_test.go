@@ -0,0 +1,104 @@
+package remote
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// sha256sumExec はリモートの sha256sum コマンドを再現します
+// sum を指定した場合はファイルの内容によらずその値を出力します
+func sha256sumExec(sum string) func(command string) (string, uint32) {
+	return func(command string) (string, uint32) {
+		path, ok := strings.CutPrefix(command, "sha256sum ")
+		if !ok {
+			return "command not found\n", 127
+		}
+		path = strings.Trim(path, "'")
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "sha256sum: " + path + ": No such file or directory\n", 1
+		}
+		if sum == "" {
+			actual := sha256.Sum256(data)
+			sum = hex.EncodeToString(actual[:])
+		}
+		return sum + "  " + path + "\n", 0
+	}
+}
+
+// uploadedFile はリモート（テスト用サーバーが公開するローカルのディレクトリ）にファイルを作成し、そのパスとSHA256を返します
+func uploadedFile(t *testing.T) (string, string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "photo.webp")
+	data := []byte("uploaded image data")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("リモートファイルの作成に失敗しました: %v", err)
+	}
+	sum := sha256.Sum256(data)
+	return path, hex.EncodeToString(sum[:])
+}
+
+func TestVerifyUploadChecksum(t *testing.T) {
+	tests := []struct {
+		name        string
+		exec        func(command string) (string, uint32)
+		wantErr     bool
+		wantRemoved bool
+	}{
+		{name: "チェックサムが一致する", exec: sha256sumExec("")},
+		{name: "チェックサムが一致しない", exec: sha256sumExec(strings.Repeat("0", 64)), wantErr: true, wantRemoved: true},
+		{name: "sha256sum がない", exec: func(string) (string, uint32) { return "sha256sum: command not found\n", 127 }},
+		{name: "コマンドを実行できない", exec: nil},
+		{name: "出力が空", exec: func(string) (string, uint32) { return "", 0 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestSSHServer(t)
+			server.exec = tt.exec
+			client := newTestClient(t, server)
+
+			remotePath, sum := uploadedFile(t)
+			pool, sc, err := client.acquireSFTP()
+			if err != nil {
+				t.Fatalf("acquireSFTP に失敗しました: %v", err)
+			}
+			defer pool.Release(sc)
+
+			err = client.verifyUploadChecksum(sc, remotePath, sum)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("verifyUploadChecksum のエラー = %v, エラーの有無 %v を期待しました", err, tt.wantErr)
+			}
+			_, statErr := os.Stat(remotePath)
+			if removed := os.IsNotExist(statErr); removed != tt.wantRemoved {
+				t.Errorf("リモートファイルの削除 = %v, want %v", removed, tt.wantRemoved)
+			}
+		})
+	}
+}
+
+// TestUploadFileVerifiesChecksum は verify_checksums が有効な場合に、アップロード後のチェックサムの確認を通過することを確認します
+func TestUploadFileVerifiesChecksum(t *testing.T) {
+	server := newTestSSHServer(t)
+	server.exec = sha256sumExec("")
+	client := newTestClient(t, server)
+	client.config.VerifyUploads = true
+	client.config.VerifyChecksums = true
+
+	localPath, want := localPNG(t)
+	remotePath := filepath.Join(t.TempDir(), "photo.png")
+
+	if err := client.UploadFile(localPath, remotePath); err != nil {
+		t.Fatalf("UploadFile に失敗しました: %v", err)
+	}
+	data, err := os.ReadFile(remotePath)
+	if err != nil || !bytes.Equal(data, want) {
+		t.Errorf("アップロードしたファイルが一致しません: %v", err)
+	}
+}
@@ -4,6 +4,8 @@ Package remote はリモートサーバーとの接続と操作に関する機
 package remote
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"log"
@@ -11,14 +13,17 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/pkg/sftp"
 	"golang.org/x/crypto/ssh"
 	"golang.org/x/crypto/ssh/agent"
 	"golang.org/x/crypto/ssh/knownhosts"
+	"golang.org/x/time/rate"
 
 	"github.com/223n/image-converter/internal/config"
+	"github.com/223n/image-converter/internal/utils"
 	"github.com/223n/image-converter/pkg/imageutils"
 )
 
@@ -27,6 +32,25 @@ type Client struct {
 	config     *config.RemoteConfig
 	client     *ssh.Client
 	sftpClient *SFTPClient
+
+	// ダウンロード・アップロード用のSFTPクライアントのプール
+	pool *SFTPPool
+
+	// キープアライブを停止するためのチャネル（無効な場合はnil）
+	keepAliveDone chan struct{}
+
+	// 接続の入れ替えを保護します。再接続中の接続処理の間は保持しないため、正常なクライアントを使用する転送は待機しません
+	connMu sync.RWMutex
+
+	// 並列転送時に複数のゴルーチンが同時に再接続しないようにします
+	reconnectMu sync.Mutex
+
+	// 作成済みのリモートディレクトリ（同じディレクトリへの MkdirAll を繰り返さないため）
+	dirMu       sync.Mutex
+	createdDirs map[string]bool
+
+	// 全転送で共有する帯域制限（remote.max_bandwidth_kbps が0の場合はnil）
+	limiter *rate.Limiter
 }
 
 // SFTPClient はSFTPプロトコルによるファイル転送を管理します
@@ -55,19 +79,60 @@ func NewClient(cfg *config.RemoteConfig) (*Client, error) {
 	}
 
 	// SFTPクライアントの作成
-	sftpClient, err := newSFTPClient(client)
+	options := sftpClientOptions(cfg)
+	sftpClient, err := newSFTPClient(client, options...)
 	if err != nil {
 		client.Close()
 		return nil, err
 	}
 
 	return &Client{
-		config:     cfg,
-		client:     client,
-		sftpClient: sftpClient,
+		config:        cfg,
+		client:        client,
+		sftpClient:    sftpClient,
+		pool:          NewSFTPPool(client, cfg.ConcurrentTransfers, options...),
+		keepAliveDone: startKeepAlive(client, time.Duration(cfg.KeepAliveIntervalSeconds)*time.Second),
+		limiter:       newBandwidthLimiter(cfg.MaxBandwidthKBps),
 	}, nil
 }
 
+// startKeepAlive は一定間隔でキープアライブを送信するゴルーチンを開始します
+// バッチ間の待機中にファイアウォールがアイドル接続を切断するのを防ぎます
+// interval が0以下の場合は開始せずにnilを返します。返されたチャネルを閉じると停止します
+func startKeepAlive(client *ssh.Client, interval time.Duration) chan struct{} {
+	if interval <= 0 {
+		return nil
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if _, _, err := client.SendRequest("keepalive@openssh.com", true, nil); err != nil {
+					log.Printf("警告: キープアライブの送信に失敗しました: %v", err)
+					return
+				}
+			}
+		}
+	}()
+
+	return done
+}
+
+// stopKeepAlive はキープアライブを停止します
+func (c *Client) stopKeepAlive() {
+	if c.keepAliveDone != nil {
+		close(c.keepAliveDone)
+		c.keepAliveDone = nil
+	}
+}
+
 // createSSHClientConfig はSSHクライアント設定を作成します
 func createSSHClientConfig(cfg *config.RemoteConfig) (*ssh.ClientConfig, error) {
 	clientConfig := &ssh.ClientConfig{
@@ -107,12 +172,16 @@ func setupKnownHosts(cfg *config.RemoteConfig, clientConfig *ssh.ClientConfig) e
 
 // setupAuthentication は認証設定を行います
 func setupAuthentication(cfg *config.RemoteConfig, clientConfig *ssh.ClientConfig) error {
+	if cfg.CertPath != "" && cfg.KeyPath == "" {
+		return fmt.Errorf("SSH証明書を使用するには秘密鍵のパス（key_path）も指定してください")
+	}
+
 	if cfg.UseSSHAgent {
 		// SSH Agentを使用した認証
 		return setupSSHAgentAuth(clientConfig)
 	} else if cfg.KeyPath != "" {
 		// 秘密鍵ファイルを使用した認証
-		return setupKeyFileAuth(cfg.KeyPath, clientConfig)
+		return setupKeyFileAuth(cfg, clientConfig)
 	}
 
 	return fmt.Errorf("認証方法が指定されていません")
@@ -136,11 +205,9 @@ func setupSSHAgentAuth(clientConfig *ssh.ClientConfig) error {
 }
 
 // setupKeyFileAuth は秘密鍵ファイルによる認証を設定します
-func setupKeyFileAuth(keyPath string, clientConfig *ssh.ClientConfig) error {
-	expandedPath := os.ExpandEnv(keyPath)
-	expandedPath = strings.Replace(expandedPath, "~", os.Getenv("HOME"), 1)
-
-	keyData, err := os.ReadFile(expandedPath)
+// 証明書のパスが指定されている場合は、秘密鍵と証明書を組み合わせて認証します
+func setupKeyFileAuth(cfg *config.RemoteConfig, clientConfig *ssh.ClientConfig) error {
+	keyData, err := os.ReadFile(expandPath(cfg.KeyPath))
 	if err != nil {
 		return fmt.Errorf("秘密鍵ファイルの読み込みに失敗しました: %v", err)
 	}
@@ -150,24 +217,96 @@ func setupKeyFileAuth(keyPath string, clientConfig *ssh.ClientConfig) error {
 		return fmt.Errorf("秘密鍵の解析に失敗しました: %v", err)
 	}
 
+	if cfg.CertPath != "" {
+		signer, err = newCertSigner(signer, cfg.CertPath, cfg.CertPrincipal)
+		if err != nil {
+			return err
+		}
+	}
+
 	clientConfig.Auth = []ssh.AuthMethod{ssh.PublicKeys(signer)}
 	return nil
 }
 
-// Close は接続を閉じます
-func (c *Client) Close() {
-	if c.sftpClient != nil && c.sftpClient.sftp != nil {
-		c.sftpClient.sftp.Close()
+// newCertSigner はSSH証明書を読み込み、秘密鍵と組み合わせた署名者を返します
+// principal が指定されている場合は、証明書の有効なプリンシパルに含まれているかを検証します
+func newCertSigner(signer ssh.Signer, certPath, principal string) (ssh.Signer, error) {
+	certData, err := os.ReadFile(expandPath(certPath))
+	if err != nil {
+		return nil, fmt.Errorf("証明書ファイルの読み込みに失敗しました: %v", err)
 	}
 
-	if c.client != nil {
-		c.client.Close()
+	// 証明書ファイルは authorized_keys 形式（ssh-keygen -s で作成される *-cert.pub）
+	pub, _, _, _, err := ssh.ParseAuthorizedKey(certData)
+	if err != nil {
+		return nil, fmt.Errorf("証明書の解析に失敗しました: %v", err)
+	}
+	pub, err = ssh.ParsePublicKey(pub.Marshal())
+	if err != nil {
+		return nil, fmt.Errorf("証明書の解析に失敗しました: %v", err)
+	}
+
+	cert, ok := pub.(*ssh.Certificate)
+	if !ok {
+		return nil, fmt.Errorf("証明書ファイルにSSH証明書が含まれていません: %s", certPath)
+	}
+	if cert.CertType != ssh.UserCert {
+		return nil, fmt.Errorf("ユーザー証明書ではありません: %s", certPath)
+	}
+
+	// 有効期限の確認（ValidBefore が CertTimeInfinity の場合は無期限）
+	now := uint64(time.Now().Unix())
+	if now < cert.ValidAfter || (cert.ValidBefore != ssh.CertTimeInfinity && now >= cert.ValidBefore) {
+		return nil, fmt.Errorf("証明書の有効期間外です: %s", certPath)
+	}
+
+	if principal != "" && !containsString(cert.ValidPrincipals, principal) {
+		return nil, fmt.Errorf("証明書のプリンシパルに %q が含まれていません（有効なプリンシパル: %s）",
+			principal, strings.Join(cert.ValidPrincipals, ", "))
+	}
+
+	certSigner, err := ssh.NewCertSigner(cert, signer)
+	if err != nil {
+		return nil, fmt.Errorf("証明書と秘密鍵が一致しません: %v", err)
+	}
+
+	return certSigner, nil
+}
+
+// expandPath はパス中の環境変数と先頭の ~ をホームディレクトリに展開します
+func expandPath(path string) string {
+	expandedPath := os.ExpandEnv(path)
+	return strings.Replace(expandedPath, "~", os.Getenv("HOME"), 1)
+}
+
+// containsString はスライスに文字列が含まれているかを判定します
+func containsString(values []string, target string) bool {
+	for _, v := range values {
+		if v == target {
+			return true
+		}
 	}
+	return false
+}
+
+// Close は接続を閉じます
+func (c *Client) Close() {
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
+
+	c.closeConnection()
 }
 
 // ExecuteCommand はリモートサーバーでコマンドを実行します
 func (c *Client) ExecuteCommand(command string) (string, error) {
-	session, err := c.client.NewSession()
+	c.connMu.RLock()
+	client := c.client
+	c.connMu.RUnlock()
+	if client == nil {
+		return "", fmt.Errorf("SSH接続が閉じられています")
+	}
+
+	session, err := client.NewSession()
 	if err != nil {
 		return "", fmt.Errorf("セッションの作成に失敗しました: %v", err)
 	}
@@ -183,8 +322,15 @@ func (c *Client) ExecuteCommand(command string) (string, error) {
 
 // DownloadFile はリモートサーバーからファイルをダウンロードします
 func (c *Client) DownloadFile(remotePath, localPath string) error {
+	return c.DownloadFileWithProgress(remotePath, localPath, nil)
+}
+
+// DownloadFileWithProgress はリモートサーバーからファイルをダウンロードし、転送量を progress に記録します
+// progress が nil の場合は記録しません。リモートファイルのサイズは転送予定のバイト数に加算します
+func (c *Client) DownloadFileWithProgress(remotePath, localPath string, progress *utils.FileProgressBar) error {
 	// リトライ設定
 	retryConfig := newDefaultRetryConfig()
+	sizeCounted := false
 
 	return withRetry(func() error {
 		// ローカルディレクトリを作成
@@ -197,66 +343,144 @@ func (c *Client) DownloadFile(remotePath, localPath string) error {
 			return err
 		}
 
+		// プールからSFTPクライアントを取得
+		pool, sc, err := c.acquireSFTP()
+		if err != nil {
+			return err
+		}
+		defer pool.Release(sc)
+
 		// リモートファイルを開く
-		srcFile, err := c.openRemoteFile(remotePath)
+		srcFile, err := c.openRemoteFile(pool, sc, remotePath)
 		if err != nil {
 			return err
 		}
 		defer srcFile.Close()
 
+		// 転送前にリモートファイルのサイズを取得
+		remoteInfo, err := srcFile.Stat()
+		if err != nil {
+			return fmt.Errorf("リモートファイルの情報取得に失敗しました: %v", err)
+		}
+
+		if !sizeCounted {
+			progress.AddTotal(remoteInfo.Size())
+			sizeCounted = true
+		}
+
+		// 再開が有効な場合は再開用ディレクトリに取得し、途中まで取得済みのファイルがあれば続きから再開する
+		target, offset := localPath, int64(0)
+		if c.config.ResumeDownloads {
+			if target, offset, err = c.preparePartial(remotePath, remoteInfo); err != nil {
+				return err
+			}
+		}
+		if offset > 0 {
+			if _, err := srcFile.Seek(offset, io.SeekStart); err != nil {
+				return fmt.Errorf("リモートファイルのシークに失敗しました: %v", err)
+			}
+			log.Printf("ダウンロードを再開します: %s (%d / %d バイト取得済み)", remotePath, offset, remoteInfo.Size())
+		}
+
 		// ローカルファイルにコピー
-		return c.copyToLocalFile(srcFile, localPath, remotePath)
+		if err := c.copyToLocalFile(srcFile, target, remotePath, offset, progress); err != nil {
+			return err
+		}
+
+		// 転送が途中で途切れていないか確認（不一致の場合は破棄してリトライ）
+		if complete, err := imageutils.VerifyComplete(target, remoteInfo.Size()); !complete {
+			if c.config.ResumeDownloads {
+				c.discardPartial(remotePath)
+			} else {
+				os.Remove(localPath)
+			}
+			return fmt.Errorf("ダウンロードが不完全です %s: %v", remotePath, err)
+		}
+
+		if c.config.ResumeDownloads {
+			return c.completePartial(remotePath, localPath)
+		}
+		return nil
 	}, retryConfig)
 }
 
 // ensureConnection は接続状態を確認し、必要に応じて再接続します
 func (c *Client) ensureConnection() error {
-	if c.client == nil || c.sftpClient == nil || c.sftpClient.sftp == nil {
+	c.connMu.RLock()
+	pool := c.pool
+	connected := c.client != nil && c.sftpClient != nil && c.sftpClient.sftp != nil
+	c.connMu.RUnlock()
+
+	if !connected {
 		log.Printf("警告: SSH/SFTP接続が閉じられています。再接続を試みます...")
-		if err := c.reconnect(); err != nil {
-			return fmt.Errorf("再接続に失敗しました: %v", err)
+		if err := c.reconnect(pool); err != nil {
+			return fmt.Errorf("再接続に失敗しました: %w", err)
 		}
 	}
 	return nil
 }
 
-// openRemoteFile はリモートファイルをオープンします
-func (c *Client) openRemoteFile(remotePath string) (*sftp.File, error) {
-	srcFile, err := c.sftpClient.sftp.Open(remotePath)
+// acquireSFTP は現在の接続のプールからSFTPクライアントを取得します
+// 返却先のプールも返すため、再接続でプールが入れ替わっても取得元に返却できます
+func (c *Client) acquireSFTP() (*SFTPPool, *sftp.Client, error) {
+	c.connMu.RLock()
+	pool := c.pool
+	c.connMu.RUnlock()
+
+	sc, err := pool.Acquire()
 	if err != nil {
-		// 接続エラーの場合は再接続を試みる
-		if isConnectionError(err) {
-			log.Printf("接続エラーが発生しました。再接続を試みます...")
-			if reconnErr := c.reconnect(); reconnErr != nil {
-				return nil, fmt.Errorf("リモートファイルのオープンに失敗し、再接続もできませんでした: %v, 再接続エラー: %v", err, reconnErr)
-			}
+		return nil, nil, err
+	}
+	return pool, sc, nil
+}
 
-			// 再接続後に再度ファイルを開く
-			srcFile, err = c.sftpClient.sftp.Open(remotePath)
-			if err != nil {
-				return nil, fmt.Errorf("再接続後もリモートファイルを開くことができません: %v", err)
-			}
-		} else {
-			return nil, fmt.Errorf("リモートファイルを開くことができません: %v", err)
-		}
+// handleSFTPError は接続エラーの場合に再接続してからエラーを返します
+// 再接続後の再試行は呼び出し元のリトライ処理に任せます
+func (c *Client) handleSFTPError(pool *SFTPPool, err error, message string) error {
+	if !isConnectionError(err) {
+		return fmt.Errorf("%s: %v", message, err)
+	}
+
+	log.Printf("接続エラーが発生しました。再接続を試みます...")
+	if reconnErr := c.reconnect(pool); reconnErr != nil {
+		return fmt.Errorf("%s（再接続もできませんでした）: %v, 再接続エラー: %w", message, err, reconnErr)
+	}
+	return fmt.Errorf("%s（再接続しました）: %v", message, err)
+}
+
+// openRemoteFile はリモートファイルをオープンします
+func (c *Client) openRemoteFile(pool *SFTPPool, sc *sftp.Client, remotePath string) (*sftp.File, error) {
+	srcFile, err := sc.Open(remotePath)
+	if err != nil {
+		return nil, c.handleSFTPError(pool, err, "リモートファイルを開くことができません")
 	}
 	return srcFile, nil
 }
 
 // copyToLocalFile はリモートファイルをローカルにコピーします
-func (c *Client) copyToLocalFile(srcFile *sftp.File, localPath, remotePath string) error {
-	// ローカルファイルを作成
-	dstFile, err := os.Create(localPath)
+// offset が0より大きい場合は既存のローカルファイルに追記します。転送量は progress に記録します
+func (c *Client) copyToLocalFile(srcFile *sftp.File, localPath, remotePath string, offset int64, progress *utils.FileProgressBar) error {
+	// ローカルファイルを作成（再開時は追記で開く）
+	var dstFile *os.File
+	var err error
+	if offset > 0 {
+		dstFile, err = os.OpenFile(localPath, os.O_WRONLY|os.O_APPEND, 0644)
+	} else {
+		dstFile, err = os.Create(localPath)
+	}
 	if err != nil {
 		return fmt.Errorf("ローカルファイルを作成できません: %v", err)
 	}
 	defer dstFile.Close()
 
-	// ファイルをコピー
-	_, err = io.Copy(dstFile, srcFile)
+	// ファイルをコピー（帯域制限はローカル側の書き込みに掛け、SFTPの並列読み込みは維持する）
+	_, err = io.Copy(io.MultiWriter(newThrottledWriter(dstFile, c.limiter), progress), srcFile)
 	if err != nil {
-		// 接続エラーの場合、ファイルを閉じて削除し、次のリトライでまた最初から
-		os.Remove(localPath)
+		// 再開が有効な場合は取得済みの部分を残し、次のリトライで続きから取得する
+		// 無効な場合はファイルを削除し、次のリトライでまた最初から
+		if !c.config.ResumeDownloads {
+			os.Remove(localPath)
+		}
 		return fmt.Errorf("ファイルのコピーに失敗しました: %v", err)
 	}
 
@@ -266,9 +490,19 @@ func (c *Client) copyToLocalFile(srcFile *sftp.File, localPath, remotePath strin
 
 // UploadFile はリモートサーバーにファイルをアップロードします
 func (c *Client) UploadFile(localPath, remotePath string) error {
+	return c.UploadFileWithProgress(localPath, remotePath, nil)
+}
+
+// UploadFileWithProgress はリモートサーバーにファイルをアップロードし、転送量を progress に記録します
+// progress が nil の場合は記録しません。ローカルファイルのサイズは転送予定のバイト数に加算します
+func (c *Client) UploadFileWithProgress(localPath, remotePath string, progress *utils.FileProgressBar) error {
 	// リトライ設定
 	retryConfig := newDefaultRetryConfig()
 
+	if fi, err := os.Stat(localPath); err == nil {
+		progress.AddTotal(fi.Size())
+	}
+
 	return withRetry(func() error {
 		// ファイルの整合性チェック
 		if err := c.validateLocalFile(localPath); err != nil {
@@ -280,13 +514,20 @@ func (c *Client) UploadFile(localPath, remotePath string) error {
 			return err
 		}
 
+		// プールからSFTPクライアントを取得
+		pool, sc, err := c.acquireSFTP()
+		if err != nil {
+			return err
+		}
+		defer pool.Release(sc)
+
 		// リモートディレクトリを作成
-		if err := c.ensureRemoteDirectory(remotePath); err != nil {
+		if err := c.ensureRemoteDirectory(pool, sc, remotePath); err != nil {
 			return err
 		}
 
 		// ファイル転送を実行
-		return c.transferFileToRemote(localPath, remotePath)
+		return c.transferFileToRemote(pool, sc, localPath, remotePath, progress)
 	}, retryConfig)
 }
 
@@ -303,31 +544,32 @@ func (c *Client) validateLocalFile(localPath string) error {
 }
 
 // ensureRemoteDirectory はリモートディレクトリが存在することを確認します
-func (c *Client) ensureRemoteDirectory(remotePath string) error {
-	remoteDir := filepath.Dir(remotePath)
-	err := c.sftpClient.sftp.MkdirAll(remoteDir)
-
-	// 接続エラーの場合は再接続を試みる
-	if err != nil && isConnectionError(err) {
-		log.Printf("接続エラーが発生しました。再接続を試みます...")
-		if reconnErr := c.reconnect(); reconnErr != nil {
-			return fmt.Errorf("リモートディレクトリの作成に失敗し、再接続もできませんでした: %v, 再接続エラー: %v", err, reconnErr)
-		}
+// 一度作成を確認したディレクトリは記録し、同じディレクトリへのアップロードでは確認を省略します
+func (c *Client) ensureRemoteDirectory(pool *SFTPPool, sc *sftp.Client, remotePath string) error {
+	dir := filepath.Dir(remotePath)
 
-		// 再接続後に再度ディレクトリを作成
-		err = c.sftpClient.sftp.MkdirAll(remoteDir)
-		if err != nil {
-			return fmt.Errorf("再接続後もリモートディレクトリを作成できません: %v", err)
-		}
-	} else if err != nil {
-		return fmt.Errorf("リモートディレクトリの作成に失敗しました: %v", err)
+	c.dirMu.Lock()
+	created := c.createdDirs[dir]
+	c.dirMu.Unlock()
+	if created {
+		return nil
+	}
+
+	if err := sc.MkdirAll(dir); err != nil {
+		return c.handleSFTPError(pool, err, "リモートディレクトリの作成に失敗しました")
 	}
 
+	c.dirMu.Lock()
+	if c.createdDirs == nil {
+		c.createdDirs = make(map[string]bool)
+	}
+	c.createdDirs[dir] = true
+	c.dirMu.Unlock()
 	return nil
 }
 
 // transferFileToRemote はファイルをリモートサーバーに転送します
-func (c *Client) transferFileToRemote(localPath, remotePath string) error {
+func (c *Client) transferFileToRemote(pool *SFTPPool, sc *sftp.Client, localPath, remotePath string, progress *utils.FileProgressBar) error {
 	// ローカルファイルを開く
 	srcFile, err := os.Open(localPath)
 	if err != nil {
@@ -336,80 +578,216 @@ func (c *Client) transferFileToRemote(localPath, remotePath string) error {
 	defer srcFile.Close()
 
 	// リモートファイルを作成
-	dstFile, err := c.createRemoteFile(remotePath)
+	dstFile, err := c.createRemoteFile(pool, sc, remotePath)
 	if err != nil {
 		return err
 	}
 	defer dstFile.Close()
 
-	// ファイルをコピー
-	_, err = io.Copy(dstFile, srcFile)
+	// ファイルをコピー（帯域制限はローカル側の読み込みに掛け、SFTPの並列書き込みは維持する）
+	// チェックサムを確認する場合は、送信した内容のSHA256を転送と同時に計算する
+	hasher := sha256.New()
+	_, err = io.Copy(dstFile, io.TeeReader(newThrottledReader(srcFile, c.limiter), io.MultiWriter(hasher, progress)))
 	if err != nil {
 		return fmt.Errorf("ファイルのコピーに失敗しました: %v", err)
 	}
 
+	// サイズを確認する前に書き込みを確定させる
+	if err := dstFile.Close(); err != nil {
+		return c.handleSFTPError(pool, err, "リモートファイルの書き込みを完了できません")
+	}
+
 	// 成功したら、ファイルサイズを取得してログに出力
 	fileInfo, err := os.Stat(localPath)
-	if err == nil {
-		log.Printf("ローカルファイルのアップロード: %s -> %s (サイズ: %d バイト)", localPath, remotePath, fileInfo.Size())
-	} else {
+	if err != nil {
 		log.Printf("ローカルファイルのアップロード: %s -> %s", localPath, remotePath)
+		return nil
 	}
 
+	if c.config.VerifyUploads {
+		if err := c.verifyUpload(pool, sc, remotePath, fileInfo.Size()); err != nil {
+			return err
+		}
+		if c.config.VerifyChecksums {
+			if err := c.verifyUploadChecksum(sc, remotePath, hex.EncodeToString(hasher.Sum(nil))); err != nil {
+				return err
+			}
+		}
+	}
+
+	log.Printf("ローカルファイルのアップロード: %s -> %s (サイズ: %d バイト)", localPath, remotePath, fileInfo.Size())
 	return nil
 }
 
-// createRemoteFile はリモートファイルを作成します
-func (c *Client) createRemoteFile(remotePath string) (*sftp.File, error) {
-	dstFile, err := c.sftpClient.sftp.Create(remotePath)
-
-	// 接続エラーの場合は再接続を試みる
-	if err != nil && isConnectionError(err) {
-		log.Printf("接続エラーが発生しました。再接続を試みます...")
-		if reconnErr := c.reconnect(); reconnErr != nil {
-			return nil, fmt.Errorf("リモートファイルの作成に失敗し、再接続もできませんでした: %v, 再接続エラー: %v", err, reconnErr)
+// verifyUpload はアップロードしたリモートファイルのサイズがローカルと一致するかを確認します
+// 一致しない場合は不完全なリモートファイルを削除し、再試行させるためにエラーを返します
+func (c *Client) verifyUpload(pool *SFTPPool, sc *sftp.Client, remotePath string, expectedSize int64) error {
+	remoteInfo, err := sc.Stat(remotePath)
+	if err != nil {
+		return c.handleSFTPError(pool, err, "アップロードしたファイルの情報を取得できません")
+	}
+
+	if remoteInfo.Size() != expectedSize {
+		log.Printf("アップロードしたファイルのサイズが一致しません: %s (ローカル: %d バイト, リモート: %d バイト)",
+			remotePath, expectedSize, remoteInfo.Size())
+		if err := sc.Remove(remotePath); err != nil {
+			log.Printf("警告: 不完全なリモートファイルを削除できませんでした: %s: %v", remotePath, err)
 		}
+		return fmt.Errorf("アップロードしたファイルのサイズが一致しません: %s (ローカル: %d バイト, リモート: %d バイト)",
+			remotePath, expectedSize, remoteInfo.Size())
+	}
 
-		// 再接続後に再度ファイルを作成
-		dstFile, err = c.sftpClient.sftp.Create(remotePath)
-		if err != nil {
-			return nil, fmt.Errorf("再接続後もリモートファイルを作成できません: %v", err)
+	return nil
+}
+
+// verifyUploadChecksum はリモートで sha256sum を実行し、アップロード時に計算したSHA256と比較します
+// 一致しない場合はリモートファイルを削除し、再試行させるためにエラーを返します
+// sha256sum を実行できない場合は警告を出力し、サイズの確認のみで成功として扱います
+func (c *Client) verifyUploadChecksum(sc *sftp.Client, remotePath, expected string) error {
+	output, err := c.ExecuteCommand("sha256sum " + shellQuote(remotePath))
+	if err != nil {
+		log.Printf("警告: リモートのチェックサムを取得できないため、サイズのみで確認します: %s: %v", remotePath, err)
+		return nil
+	}
+
+	fields := strings.Fields(output)
+	if len(fields) == 0 {
+		log.Printf("警告: sha256sum の出力を解析できないため、サイズのみで確認します: %s", remotePath)
+		return nil
+	}
+
+	if actual := strings.ToLower(fields[0]); actual != expected {
+		if err := sc.Remove(remotePath); err != nil {
+			log.Printf("警告: 破損したリモートファイルを削除できませんでした: %s: %v", remotePath, err)
 		}
-	} else if err != nil {
-		return nil, fmt.Errorf("リモートファイルを作成できません: %v", err)
+		return fmt.Errorf("アップロードしたファイルのチェックサムが一致しません: %s (ローカル: %s, リモート: %s)",
+			remotePath, expected, actual)
 	}
 
+	return nil
+}
+
+// shellQuote はリモートのシェルに渡す引数をシングルクォートで囲みます
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// createRemoteFile はリモートファイルを作成します
+func (c *Client) createRemoteFile(pool *SFTPPool, sc *sftp.Client, remotePath string) (*sftp.File, error) {
+	dstFile, err := sc.Create(remotePath)
+	if err != nil {
+		return nil, c.handleSFTPError(pool, err, "リモートファイルを作成できません")
+	}
 	return dstFile, nil
 }
 
 // reconnect はSSHおよびSFTP接続を再確立します
-func (c *Client) reconnect() error {
-	// 既存の接続をクローズ
-	if c.sftpClient != nil && c.sftpClient.sftp != nil {
-		c.sftpClient.sftp.Close()
-	}
-	if c.client != nil {
-		c.client.Close()
+// stale はエラーを検出した時点のプールで、接続の世代を表します。既に別のゴルーチンが再接続済みの場合は何もしません
+// 接続処理（バックオフによる待機を含む）の間は connMu を保持せず、入れ替えの時だけロックします
+func (c *Client) reconnect(stale *SFTPPool) error {
+	c.reconnectMu.Lock()
+	defer c.reconnectMu.Unlock()
+
+	// 待機している間に別のゴルーチンが再接続した場合は、新しい接続をそのまま使う
+	if !c.isCurrentPool(stale) {
+		return nil
 	}
 
-	// 新しいSSHクライアントの作成
-	client, err := NewClient(c.config)
+	// 新しいSSHクライアントの作成（指数バックオフで再試行する）
+	client, err := dialWithBackoff(c.config, newReconnectRetryConfig())
 	if err != nil {
-		return fmt.Errorf("SSH再接続に失敗しました: %v", err)
+		// 次回の ensureConnection で再接続を試みるよう、切断された接続を破棄する
+		c.connMu.Lock()
+		c.closeConnection()
+		c.client = nil
+		c.sftpClient = nil
+		c.connMu.Unlock()
+		return err
 	}
 
-	// 接続情報を更新
+	// 接続情報を入れ替えてから古い接続を閉じる
+	c.connMu.Lock()
+	c.closeConnection()
 	c.client = client.client
 	c.sftpClient = client.sftpClient
+	c.pool = client.pool
+	c.keepAliveDone = client.keepAliveDone
+	c.connMu.Unlock()
 
 	log.Printf("SSH/SFTP接続を再確立しました")
 	return nil
 }
 
+// isCurrentPool は pool が現在の接続のプールかどうかを返します
+func (c *Client) isCurrentPool(pool *SFTPPool) bool {
+	c.connMu.RLock()
+	defer c.connMu.RUnlock()
+	return c.pool == pool
+}
+
+// closeConnection は現在の接続を閉じます。呼び出し元が connMu を保持している必要があります
+func (c *Client) closeConnection() {
+	c.stopKeepAlive()
+	if c.pool != nil {
+		c.pool.Close()
+	}
+	if c.sftpClient != nil && c.sftpClient.sftp != nil {
+		c.sftpClient.sftp.Close()
+	}
+	if c.client != nil {
+		c.client.Close()
+	}
+}
+
+// dialWithBackoff は接続に成功するまで待機時間を伸ばしながら再接続を試みます
+// 最大試行回数に達した場合は ErrReconnectFailed を含むエラーを返します
+func dialWithBackoff(cfg *config.RemoteConfig, retry *retryConfig) (*Client, error) {
+	wait := retry.InitialWait
+
+	for attempt := 1; ; attempt++ {
+		client, err := NewClient(cfg)
+		if err == nil {
+			return client, nil
+		}
+
+		if attempt > retry.MaxRetries {
+			return nil, fmt.Errorf("%w（%d回試行）: %v", ErrReconnectFailed, attempt, err)
+		}
+
+		log.Printf("再接続に失敗しました（試行 %d/%d）: %v - %s後に再試行します",
+			attempt, retry.MaxRetries+1, err, wait)
+		time.Sleep(wait)
+
+		wait = time.Duration(float64(wait) * retry.Factor)
+		if wait > retry.MaxWait {
+			wait = retry.MaxWait
+		}
+	}
+}
+
+// sftpClientOptions は設定に応じたSFTPクライアントのオプションを返します
+// 32KBを超えるパケットサイズはすべてのサーバーが対応しているとは限らないため、警告を出した上で適用します
+func sftpClientOptions(cfg *config.RemoteConfig) []sftp.ClientOption {
+	var options []sftp.ClientOption
+
+	if cfg.SFTPMaxPacketBytes > 32768 {
+		log.Printf("警告: SFTPの最大パケットサイズ %d バイトは32KBを超えています。サーバーが対応していない場合は転送に失敗します", cfg.SFTPMaxPacketBytes)
+		options = append(options, sftp.MaxPacketUnchecked(cfg.SFTPMaxPacketBytes))
+	} else if cfg.SFTPMaxPacketBytes > 0 {
+		options = append(options, sftp.MaxPacket(cfg.SFTPMaxPacketBytes))
+	}
+
+	if cfg.SFTPConcurrentRequests > 0 {
+		options = append(options, sftp.MaxConcurrentRequestsPerFile(cfg.SFTPConcurrentRequests))
+	}
+
+	return options
+}
+
 // newSFTPClient は新しいSFTPクライアントを作成します
-func newSFTPClient(client *ssh.Client) (*SFTPClient, error) {
+func newSFTPClient(client *ssh.Client, options ...sftp.ClientOption) (*SFTPClient, error) {
 	// SFTPクライアントを作成
-	sftpClient, err := sftp.NewClient(client)
+	sftpClient, err := sftp.NewClient(client, options...)
 	if err != nil {
 		return nil, fmt.Errorf("SFTPクライアントの作成に失敗しました: %v", err)
 	}
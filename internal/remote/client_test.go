@@ -0,0 +1,184 @@
+package remote
+
+import (
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestClient はテスト用サーバーに接続したクライアントを作成し、テスト終了時に閉じます
+func newTestClient(t *testing.T, server *testSSHServer) *Client {
+	t.Helper()
+
+	client, err := NewClient(server.remoteConfig())
+	if err != nil {
+		t.Fatalf("NewClient に失敗しました: %v", err)
+	}
+	t.Cleanup(client.Close)
+	return client
+}
+
+// TestReconnectOnce は同じ接続の切断を複数のゴルーチンが同時に検出しても、再接続が1回だけ行われることを確認します
+func TestReconnectOnce(t *testing.T) {
+	server := newTestSSHServer(t)
+	client := newTestClient(t, server)
+	before := server.accepted.Load()
+
+	stale := client.pool
+	server.dropConnections()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := client.reconnect(stale); err != nil {
+				t.Errorf("reconnect に失敗しました: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := server.accepted.Load() - before; got != 1 {
+		t.Errorf("再接続の回数 = %d, 1 を期待しました", got)
+	}
+	if client.isCurrentPool(stale) {
+		t.Error("再接続後もプールが入れ替わっていません")
+	}
+
+	// 新しい接続で操作できること
+	pool, sc, err := client.acquireSFTP()
+	if err != nil {
+		t.Fatalf("再接続後の acquireSFTP に失敗しました: %v", err)
+	}
+	defer pool.Release(sc)
+	if _, err := sc.Getwd(); err != nil {
+		t.Errorf("再接続後のSFTP操作に失敗しました: %v", err)
+	}
+}
+
+// TestReconnectDoesNotBlockHealthyWorkers は再接続の接続処理中も、他のゴルーチンが接続情報を待たずに参照できることを確認します
+func TestReconnectDoesNotBlockHealthyWorkers(t *testing.T) {
+	server := newTestSSHServer(t)
+	client := newTestClient(t, server)
+	server.handshakeDelay.Store(int64(time.Second))
+
+	done := make(chan error, 1)
+	stale := client.pool
+	go func() { done <- client.reconnect(stale) }()
+
+	// 再接続の接続処理が始まるまで待つ
+	deadline := time.Now().Add(5 * time.Second)
+	for server.accepted.Load() < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	start := time.Now()
+	if err := client.ensureConnection(); err != nil {
+		t.Errorf("ensureConnection に失敗しました: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 200*time.Millisecond {
+		t.Errorf("再接続中の ensureConnection に %v かかりました。接続処理の間ロックが保持されています", elapsed)
+	}
+
+	if err := <-done; err != nil {
+		t.Fatalf("reconnect に失敗しました: %v", err)
+	}
+}
+
+// TestKeepAliveSurvivesIdleTimeout はアイドル状態が100ms続くと切断するサーバーに対して、
+// キープアライブを送信している接続は切断されないことを確認します
+func TestKeepAliveSurvivesIdleTimeout(t *testing.T) {
+	const idleTimeout = 100 * time.Millisecond
+
+	t.Run("キープアライブなし", func(t *testing.T) {
+		server := newTestSSHServer(t)
+		server.idleTimeout.Store(int64(idleTimeout))
+		client := newTestClient(t, server)
+
+		closed := make(chan error, 1)
+		go func() { closed <- client.client.Wait() }()
+		select {
+		case <-closed:
+		case <-time.After(2 * time.Second):
+			t.Fatal("アイドル状態の接続がサーバーに切断されませんでした")
+		}
+	})
+
+	t.Run("キープアライブあり", func(t *testing.T) {
+		server := newTestSSHServer(t)
+		server.idleTimeout.Store(int64(idleTimeout))
+		client := newTestClient(t, server)
+		client.keepAliveDone = startKeepAlive(client.client, idleTimeout/5)
+
+		// アイドルによる切断の時間を十分に超えて待機する
+		time.Sleep(4 * idleTimeout)
+
+		if _, err := client.sftpClient.sftp.Getwd(); err != nil {
+			t.Errorf("待機後のSFTP操作に失敗しました: %v", err)
+		}
+		if got := server.accepted.Load(); got != 1 {
+			t.Errorf("接続の回数 = %d, want 1（再接続なし）", got)
+		}
+	})
+}
+
+// BenchmarkSFTPPacketSize はプロセス内のSFTPサーバーとの間で 4MB のファイルを転送し、
+// 最大パケットサイズごとのダウンロードとアップロードのスループット（MB/s）を計測します
+// テスト用のサーバー（pkg/sftp）は1回の読み込みの応答を32KBまでに制限するため、32KBを超えるパケットサイズのダウンロードは
+// 不完全になります（クライアントはサイズの検証で検出して失敗します）。この場合のダウンロードはスキップします
+func BenchmarkSFTPPacketSize(b *testing.B) {
+	const serverMaxRead = 32768
+
+	data := make([]byte, 4<<20)
+	if _, err := rand.Read(data); err != nil {
+		b.Fatal(err)
+	}
+
+	server := newTestSSHServer(b)
+	remoteDir := b.TempDir()
+	remoteFile := filepath.Join(remoteDir, "source.bin")
+	if err := os.WriteFile(remoteFile, data, 0644); err != nil {
+		b.Fatalf("リモートファイルの作成に失敗しました: %v", err)
+	}
+	localDir := b.TempDir()
+	localFile := filepath.Join(localDir, "source.bin")
+	if err := os.WriteFile(localFile, data, 0644); err != nil {
+		b.Fatalf("ローカルファイルの作成に失敗しました: %v", err)
+	}
+
+	for _, packetSize := range []int{8192, 32768, 131072} {
+		cfg := server.remoteConfig()
+		cfg.SFTPMaxPacketBytes = packetSize
+		client, err := NewClient(cfg)
+		if err != nil {
+			b.Fatalf("NewClient に失敗しました: %v", err)
+		}
+
+		b.Run("download/"+strconv.Itoa(packetSize), func(b *testing.B) {
+			if packetSize > serverMaxRead {
+				b.Skipf("テスト用のサーバーは %d バイトを超える読み込みに対応していません", serverMaxRead)
+			}
+			b.SetBytes(int64(len(data)))
+			for i := 0; i < b.N; i++ {
+				if err := client.DownloadFile(remoteFile, filepath.Join(localDir, "downloaded.bin")); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run("upload/"+strconv.Itoa(packetSize), func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			for i := 0; i < b.N; i++ {
+				if err := client.UploadFile(localFile, filepath.Join(remoteDir, "uploaded.bin")); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+
+		client.Close()
+	}
+}
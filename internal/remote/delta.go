@@ -0,0 +1,102 @@
+package remote
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DeltaState はリモートファイルの更新日時を実行間で保持し、差分同期に使用します
+// 前回の実行で処理に成功したファイルのうち、更新日時が変わっていないものをスキップ対象とします
+type DeltaState struct {
+	path string
+
+	mu       sync.Mutex
+	previous map[string]time.Time // 前回の実行で記録した更新日時
+	observed map[string]time.Time // 今回の実行で取得した更新日時
+	next     map[string]time.Time // 次回の実行に引き継ぐ更新日時
+}
+
+// LoadDeltaState は状態ファイルを読み込みます
+// ファイルが存在しない場合は空の状態を返します（初回の実行ではすべてのファイルを処理します）
+func LoadDeltaState(path string) (*DeltaState, error) {
+	state := &DeltaState{
+		path:     path,
+		previous: make(map[string]time.Time),
+		observed: make(map[string]time.Time),
+		next:     make(map[string]time.Time),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("差分同期の状態ファイルの読み込みに失敗しました: %v", err)
+	}
+
+	if err := json.Unmarshal(data, &state.previous); err != nil {
+		return nil, fmt.Errorf("差分同期の状態ファイルの解析に失敗しました: %v", err)
+	}
+
+	return state, nil
+}
+
+// Unchanged は更新日時を記録し、前回の実行から変わっていない場合に true を返します
+// 変わっていないファイルはそのまま次回の実行にも引き継ぎます
+func (d *DeltaState) Unchanged(remotePath string, modTime time.Time) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.observed[remotePath] = modTime
+	previous, ok := d.previous[remotePath]
+	if !ok || !previous.Equal(modTime) {
+		return false
+	}
+
+	d.next[remotePath] = modTime
+	return true
+}
+
+// MarkProcessed はファイルの処理に成功したことを記録します
+// 記録したファイルは次回の実行で、更新されていなければスキップされます
+func (d *DeltaState) MarkProcessed(remotePath string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if modTime, ok := d.observed[remotePath]; ok {
+		d.next[remotePath] = modTime
+	}
+}
+
+// Save は次回の実行に引き継ぐ更新日時を状態ファイルに書き込みます
+// 書き込み途中で中断しても既存の状態ファイルが壊れないよう、一時ファイルに書き込んでから置き換えます
+func (d *DeltaState) Save() error {
+	d.mu.Lock()
+	data, err := json.MarshalIndent(d.next, "", "  ")
+	d.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("差分同期の状態の変換に失敗しました: %v", err)
+	}
+
+	if dir := filepath.Dir(d.path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("状態ファイルのディレクトリの作成に失敗しました: %v", err)
+		}
+	}
+
+	tempPath := d.path + ".tmp"
+	if err := os.WriteFile(tempPath, data, 0644); err != nil {
+		return fmt.Errorf("差分同期の状態ファイルの書き込みに失敗しました: %v", err)
+	}
+	if err := os.Rename(tempPath, d.path); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("差分同期の状態ファイルの書き込みに失敗しました: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package remote
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/223n/image-converter/internal/config"
+	"github.com/223n/image-converter/internal/utils"
+)
+
+// TestDeltaSyncAcrossRuns は2回目の実行で、更新されたファイルだけを処理し、更新されていないファイルをスキップすることを確認します
+// 処理に失敗したファイルは記録されず、次の実行でも処理対象になります
+func TestDeltaSyncAcrossRuns(t *testing.T) {
+	useWebPOnlyConfig(t)
+
+	server := newTestSSHServer(t)
+	root, files := newRemoteImageDir(t, 2)
+	unchanged, changed := files[0], files[1]
+	broken := filepath.Join(root, "broken.png")
+	if err := os.WriteFile(broken, []byte("not an image"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	files = append(files, broken)
+
+	cfg := server.remoteConfig()
+	cfg.RemotePath = root
+	client, err := NewClient(cfg)
+	if err != nil {
+		t.Fatalf("NewClient に失敗しました: %v", err)
+	}
+	defer client.Close()
+
+	statePath := filepath.Join(t.TempDir(), "state", "delta.json")
+
+	// run は Service.Execute と同じ順序で、状態の読み込み・更新されたファイルの処理・状態の保存を行い、処理したファイルを返します
+	run := func() []string {
+		t.Helper()
+
+		state, err := LoadDeltaState(statePath)
+		if err != nil {
+			t.Fatalf("LoadDeltaState に失敗しました: %v", err)
+		}
+		s := &Service{config: cfg, delta: state}
+		targets := s.filterUnchanged(client, files)
+
+		tracker := utils.NewMultiProgressTracker(len(targets), "差分同期")
+		tracker.SetOutput(io.Discard)
+		if err := s.processFileBatch(client, targets, t.TempDir(), tracker, config.NewConversionStats()); err != nil {
+			t.Fatalf("processFileBatch に失敗しました: %v", err)
+		}
+		if err := state.Save(); err != nil {
+			t.Fatalf("Save に失敗しました: %v", err)
+		}
+		return targets
+	}
+
+	// 1回目: 状態ファイルがないため、すべて処理する
+	if got := run(); !reflect.DeepEqual(got, files) {
+		t.Fatalf("1回目に処理したファイル = %v, want %v", got, files)
+	}
+
+	// 2回目: 更新日時が変わったファイルと、前回失敗したファイルだけを処理する
+	modTime := time.Now().Add(time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(changed, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := run(), []string{changed, broken}; !reflect.DeepEqual(got, want) {
+		t.Errorf("2回目に処理したファイル = %v, want %v", got, want)
+	}
+
+	// 3回目: 更新されたファイルも処理済みとして記録されている
+	if got, want := run(), []string{broken}; !reflect.DeepEqual(got, want) {
+		t.Errorf("3回目に処理したファイル = %v, want %v", got, want)
+	}
+
+	state, err := LoadDeltaState(statePath)
+	if err != nil {
+		t.Fatalf("LoadDeltaState に失敗しました: %v", err)
+	}
+	if _, ok := state.previous[unchanged]; !ok {
+		t.Errorf("更新されていないファイルが状態から消えました: %v", state.previous)
+	}
+	if got := state.previous[changed]; !got.Equal(modTime) {
+		t.Errorf("更新されたファイルの記録 = %v, want %v", got, modTime)
+	}
+	if _, ok := state.previous[broken]; ok {
+		t.Error("処理に失敗したファイルが状態に記録されました")
+	}
+}
+
+// TestLoadDeltaStateMalformed は壊れた状態ファイルをエラーにすることを確認します
+func TestLoadDeltaStateMalformed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "delta.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadDeltaState(path); err == nil {
+		t.Error("壊れた状態ファイルでエラーになりませんでした")
+	}
+}
@@ -0,0 +1,50 @@
+package remote
+
+import "time"
+
+// etaWindow は残り時間の推定に使用する直近のバッチ数です
+const etaWindow = 3
+
+// batchSample は1バッチ分の処理ファイル数と所要時間です
+type batchSample struct {
+	files    int
+	duration time.Duration
+}
+
+// batchETA は直近のバッチの処理速度から残り時間を推定します
+// 接続の状態などによる速度の変化に追従するため、全体の平均ではなく直近 etaWindow 件の移動平均を使用します
+type batchETA struct {
+	samples []batchSample
+}
+
+// Add は完了したバッチの処理ファイル数と所要時間を記録します
+func (e *batchETA) Add(files int, duration time.Duration) {
+	if files <= 0 {
+		return
+	}
+	e.samples = append(e.samples, batchSample{files: files, duration: duration})
+	if len(e.samples) > etaWindow {
+		e.samples = e.samples[len(e.samples)-etaWindow:]
+	}
+}
+
+// Remaining は残りのファイル数から推定残り時間を返します
+// 記録がない場合や残りがない場合は0を返します
+func (e *batchETA) Remaining(remainingFiles int) time.Duration {
+	if remainingFiles <= 0 {
+		return 0
+	}
+
+	var files int
+	var total time.Duration
+	for _, sample := range e.samples {
+		files += sample.files
+		total += sample.duration
+	}
+	if files == 0 {
+		return 0
+	}
+
+	perFile := total / time.Duration(files)
+	return perFile * time.Duration(remainingFiles)
+}
@@ -0,0 +1,82 @@
+package remote
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBatchETA(t *testing.T) {
+	tests := []struct {
+		name      string
+		batches   []batchSample
+		remaining int
+		want      time.Duration
+	}{
+		{name: "記録がない", remaining: 10, want: 0},
+		{name: "残りがない", batches: []batchSample{{files: 10, duration: 20 * time.Second}}, remaining: 0, want: 0},
+		{name: "残りが負", batches: []batchSample{{files: 10, duration: 20 * time.Second}}, remaining: -1, want: 0},
+		{name: "1バッチ", batches: []batchSample{{files: 10, duration: 20 * time.Second}}, remaining: 5, want: 10 * time.Second},
+		{
+			// バッチごとの平均ではなく、ファイル数で重み付けした平均になる
+			name: "ファイル数の異なるバッチ",
+			batches: []batchSample{
+				{files: 2, duration: 10 * time.Second},
+				{files: 8, duration: 10 * time.Second},
+			},
+			remaining: 3,
+			want:      6 * time.Second,
+		},
+		{
+			name: "直近3バッチのみを使用する",
+			batches: []batchSample{
+				{files: 10, duration: 100 * time.Second},
+				{files: 10, duration: 10 * time.Second},
+				{files: 10, duration: 10 * time.Second},
+				{files: 10, duration: 10 * time.Second},
+			},
+			remaining: 10,
+			want:      10 * time.Second,
+		},
+		{
+			name: "速度の変化に追従する",
+			batches: []batchSample{
+				{files: 10, duration: 10 * time.Second},
+				{files: 10, duration: 10 * time.Second},
+				{files: 10, duration: 10 * time.Second},
+				{files: 10, duration: 40 * time.Second},
+			},
+			remaining: 10,
+			want:      20 * time.Second,
+		},
+		{
+			// 0件のバッチは記録せず、直近のバッチを押し出さない
+			name: "0件のバッチは無視する",
+			batches: []batchSample{
+				{files: 10, duration: 10 * time.Second},
+				{files: 10, duration: 10 * time.Second},
+				{files: 10, duration: 10 * time.Second},
+				{files: 0, duration: time.Hour},
+				{files: -1, duration: time.Hour},
+			},
+			remaining: 10,
+			want:      10 * time.Second,
+		},
+		{name: "0件のバッチのみ", batches: []batchSample{{files: 0, duration: time.Minute}}, remaining: 10, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var eta batchETA
+			for _, batch := range tt.batches {
+				eta.Add(batch.files, batch.duration)
+			}
+
+			if got := eta.Remaining(tt.remaining); got != tt.want {
+				t.Errorf("Remaining(%d) = %v, want %v", tt.remaining, got, tt.want)
+			}
+			if len(eta.samples) > etaWindow {
+				t.Errorf("記録しているバッチ数 = %d, want %d 以下", len(eta.samples), etaWindow)
+			}
+		})
+	}
+}
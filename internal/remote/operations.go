@@ -1,15 +1,20 @@
 package remote
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"log"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/223n/image-converter/internal/config"
 	"github.com/223n/image-converter/internal/converter"
+	"github.com/223n/image-converter/internal/utils"
 	"github.com/223n/image-converter/pkg/imageutils"
 )
 
@@ -21,6 +26,21 @@ type retryConfig struct {
 	Factor      float64       // リトライ待機時間の増加係数
 }
 
+// ErrReconnectFailed は再接続の試行回数を使い切ったことを表します
+// このエラーはリトライせず、対象ファイルの処理を失敗として扱います
+var ErrReconnectFailed = errors.New("SSH再接続の最大試行回数に達しました")
+
+// newReconnectRetryConfig は再接続用のリトライ設定を返します
+// 回線が不安定な場合にサーバーへ接続要求が集中しないよう、待機時間を指数的に伸ばします
+func newReconnectRetryConfig() *retryConfig {
+	return &retryConfig{
+		MaxRetries:  4,
+		InitialWait: 1 * time.Second,
+		MaxWait:     30 * time.Second,
+		Factor:      2.0,
+	}
+}
+
 // newDefaultRetryConfig はデフォルトのリトライ設定を返します
 func newDefaultRetryConfig() *retryConfig {
 	return &retryConfig{
@@ -44,6 +64,11 @@ func withRetry(fn func() error, config *retryConfig) error {
 			return nil
 		}
 
+		// 再接続できなかった場合はリトライしない
+		if errors.Is(err, ErrReconnectFailed) {
+			return err
+		}
+
 		// 最後の試行の場合はエラーを返す
 		if attempt > config.MaxRetries {
 			return fmt.Errorf("最大リトライ回数(%d)に達しました: %w", config.MaxRetries, err)
@@ -88,7 +113,93 @@ func isConnectionError(err error) bool {
 }
 
 // FindRemoteImages はリモートサーバー上の画像ファイルを検索します
+// remote.list_method が sftp の場合はSFTPで走査し、それ以外（デフォルトの find）は find コマンドを使用します
 func (c *Client) FindRemoteImages(extensions []string) ([]string, error) {
+	if c.config.ListMethod == config.RemoteListMethodSFTP {
+		return c.walkRemoteImages(extensions)
+	}
+	return c.findRemoteImagesWithCommand(extensions)
+}
+
+// walkRemoteImages はSFTPプロトコルでリモートのディレクトリを走査して画像ファイルを検索します
+// シェルを使用しないため、SFTP専用アカウントでも動作します
+func (c *Client) walkRemoteImages(extensions []string) ([]string, error) {
+	if err := c.ensureConnection(); err != nil {
+		return nil, err
+	}
+
+	supported := make(map[string]bool, len(extensions))
+	for _, ext := range extensions {
+		supported[ext] = true
+	}
+
+	return c.ListRemoteFilesRecursive(c.config.RemotePath, supported)
+}
+
+// RemoteModTime はリモートファイルの更新日時を取得します
+func (c *Client) RemoteModTime(remotePath string) (time.Time, error) {
+	pool, sc, err := c.acquireSFTP()
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer pool.Release(sc)
+
+	fi, err := sc.Stat(remotePath)
+	if err != nil {
+		return time.Time{}, c.handleSFTPError(pool, err, "リモートファイルの情報を取得できません")
+	}
+	return fi.ModTime(), nil
+}
+
+// ListRemoteFilesRecursive はSFTPの ReadDir でディレクトリを再帰的に走査し、拡張子が一致するファイルを返します
+// 拡張子はドット付きで指定し、大文字・小文字を区別せずに判定します。結果はパスの昇順に並べ替えて返します
+func (c *Client) ListRemoteFilesRecursive(dir string, extensions map[string]bool) ([]string, error) {
+	pool, sc, err := c.acquireSFTP()
+	if err != nil {
+		return nil, err
+	}
+	defer pool.Release(sc)
+
+	supported := make(map[string]bool, len(extensions))
+	for ext, ok := range extensions {
+		if ok {
+			supported[strings.ToLower(ext)] = true
+		}
+	}
+
+	var result []string
+	stack := []string{dir}
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		entries, err := sc.ReadDir(current)
+		if err != nil {
+			// ルート自体が読めない場合は失敗とし、配下の読めないディレクトリはスキップする
+			if current == dir {
+				return nil, c.handleSFTPError(pool, err, "リモートディレクトリの走査に失敗しました")
+			}
+			log.Printf("警告: リモートディレクトリを読み込めないためスキップします %s: %v", current, err)
+			continue
+		}
+
+		for _, entry := range entries {
+			entryPath := path.Join(current, entry.Name())
+			switch {
+			case entry.IsDir():
+				stack = append(stack, entryPath)
+			case entry.Mode().IsRegular() && supported[strings.ToLower(path.Ext(entry.Name()))]:
+				result = append(result, entryPath)
+			}
+		}
+	}
+
+	sort.Strings(result)
+	return result, nil
+}
+
+// findRemoteImagesWithCommand はリモートで find コマンドを実行して画像ファイルを検索します
+func (c *Client) findRemoteImagesWithCommand(extensions []string) ([]string, error) {
 	// 拡張子をパイプ区切りの文字列に変換
 	var extsFormatted []string
 	for _, ext := range extensions {
@@ -123,6 +234,12 @@ func (c *Client) FindRemoteImages(extensions []string) ([]string, error) {
 
 // ProcessRemoteFile は単一のリモートファイルを処理します
 func (c *Client) ProcessRemoteFile(remoteFile, tempDir string, stats *config.ConversionStats) error {
+	return c.ProcessRemoteFileWithProgress(remoteFile, tempDir, stats, nil)
+}
+
+// ProcessRemoteFileWithProgress は単一のリモートファイルを処理し、ダウンロードとアップロードの転送量を progress に記録します
+// progress が nil の場合は記録しません
+func (c *Client) ProcessRemoteFileWithProgress(remoteFile, tempDir string, stats *config.ConversionStats, progress *utils.FileProgressBar) error {
 	// ベース名とディレクトリを取得
 	baseFileName := filepath.Base(remoteFile)
 	relPath, err := filepath.Rel(c.config.RemotePath, filepath.Dir(remoteFile))
@@ -135,29 +252,48 @@ func (c *Client) ProcessRemoteFile(remoteFile, tempDir string, stats *config.Con
 	localPath := filepath.Join(tempDir, relPath, baseFileName)
 
 	// ファイルをダウンロード
-	if err := c.DownloadFile(remoteFile, localPath); err != nil {
+	if err := c.DownloadFileWithProgress(remoteFile, localPath, progress); err != nil {
 		log.Printf("エラー: ファイルのダウンロードに失敗しました %s: %v", remoteFile, err)
-		stats.DownloadFailed++
+		stats.DownloadFailed.Add(1)
 		return err
 	}
 
+	// 削減量の集計用に元ファイルのサイズを記録
+	var originalSize int64
+	if fi, err := os.Stat(localPath); err == nil {
+		originalSize = fi.Size()
+	}
+
 	// 変換サービスを作成
 	convService := converter.NewService()
 
-	// 画像を変換
-	if err := convService.ConvertImage(localPath); err != nil {
+	// 画像を変換（出力ファイル名は output.filename_template に従う）
+	result, err := convService.ConvertImageResult(localPath)
+	outputs := outputPaths(result)
+	if errors.Is(err, converter.ErrTooManyPixels) {
+		log.Printf("警告: 画素数が上限を超えるためスキップします %s: %v", remoteFile, err)
+		stats.SkippedTooLarge.Add(1)
+		cleanupFiles(localPath, outputs)
+		return nil
+	}
+	if err != nil {
 		log.Printf("エラー: 画像の変換に失敗しました %s: %v", localPath, err)
-		stats.ConvertFailed++
+		stats.ConvertFailed.Add(1)
 		return err
 	}
 
-	stats.TotalProcessed++
+	stats.TotalProcessed.Add(1)
 
 	// 変換結果をアップロード
-	uploadSuccess := c.UploadConvertedFiles(localPath, remoteFile, baseFileName, stats)
+	uploadSuccess := c.uploadResult(result, remoteFile, stats, progress)
+	if uploadSuccess {
+		if smallest := smallestOutputSize(outputs); smallest > 0 {
+			stats.RecordBytes(originalSize, smallest)
+		}
+	}
 
 	// 処理済みファイルを削除して一時ディレクトリの肥大化を防ぐ
-	cleanupFiles(localPath, baseFileName)
+	cleanupFiles(localPath, outputs)
 
 	if !uploadSuccess {
 		return fmt.Errorf("変換結果のアップロードに失敗しました: %s", localPath)
@@ -167,25 +303,55 @@ func (c *Client) ProcessRemoteFile(remoteFile, tempDir string, stats *config.Con
 }
 
 // UploadConvertedFiles は変換されたファイルをアップロードします
-func (c *Client) UploadConvertedFiles(localPath, remoteFile, baseFileName string, stats *config.ConversionStats) bool {
-	ext := filepath.Ext(localPath)
-	baseName := strings.TrimSuffix(baseFileName, ext)
+// 変換後のファイルのパスは、変換前の localPath から output.filename_template に従って求めます
+func (c *Client) UploadConvertedFiles(localPath, remoteFile string, stats *config.ConversionStats) bool {
+	cfg := config.GetConfig()
+	paths := converter.OutputPaths(&cfg, localPath)
+	result := &converter.ConversionResult{
+		OriginalPath: localPath,
+		WebPPath:     paths["webp"],
+		AVIFPath:     paths["avif"],
+		JXLPath:      paths["jxl"],
+	}
+	return c.uploadResult(result, remoteFile, stats, nil)
+}
 
+// uploadResult は変換結果のファイルを元ファイルと同じリモートのディレクトリにアップロードし、転送量を progress に記録します
+func (c *Client) uploadResult(result *converter.ConversionResult, remoteFile string, stats *config.ConversionStats, progress *utils.FileProgressBar) bool {
 	// アップロード成功フラグ
-	webpUploaded := c.uploadWebPFile(localPath, remoteFile, baseName, stats)
-	avifUploaded := c.uploadAVIFFile(localPath, remoteFile, baseName, stats)
+	webpUploaded := c.uploadWebPFile(result.WebPPath, remoteFile, stats, progress)
+	avifUploaded := c.uploadAVIFFile(result.AVIFPath, remoteFile, stats, progress)
+	jxlUploaded := c.uploadJXLFile(result.JXLPath, remoteFile, stats, progress)
+
+	return webpUploaded || avifUploaded || jxlUploaded
+}
 
-	return webpUploaded || avifUploaded
+// remoteOutputPath は変換後のファイルのリモートのパス（元ファイルと同じディレクトリ）を返します
+func remoteOutputPath(outputPath, remoteFile string) string {
+	return filepath.Join(filepath.Dir(remoteFile), filepath.Base(outputPath))
+}
+
+// outputPaths は変換結果に記録された出力ファイルのパスを返します
+func outputPaths(result *converter.ConversionResult) []string {
+	if result == nil {
+		return nil
+	}
+	var paths []string
+	for _, path := range []string{result.WebPPath, result.AVIFPath, result.JXLPath} {
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths
 }
 
 // uploadWebPFile はWebPファイルをアップロードします
-func (c *Client) uploadWebPFile(localPath, remoteFile, baseName string, stats *config.ConversionStats) bool {
-	if !config.IsWebPEnabled() {
+func (c *Client) uploadWebPFile(webpLocalPath, remoteFile string, stats *config.ConversionStats, progress *utils.FileProgressBar) bool {
+	if !config.IsWebPEnabled() || webpLocalPath == "" {
 		return false
 	}
 
-	webpLocalPath := filepath.Join(filepath.Dir(localPath), baseName+".webp")
-	webpRemotePath := filepath.Join(filepath.Dir(remoteFile), baseName+".webp")
+	webpRemotePath := remoteOutputPath(webpLocalPath, remoteFile)
 
 	// ファイルが存在しない場合はスキップ
 	if _, err := os.Stat(webpLocalPath); err != nil {
@@ -196,33 +362,33 @@ func (c *Client) uploadWebPFile(localPath, remoteFile, baseName string, stats *c
 	valid, fileSize := imageutils.IsValidFile(webpLocalPath)
 	if !valid {
 		log.Printf("警告: WebPファイルが無効なためスキップします: %s", webpLocalPath)
-		stats.WebPFailed++
-		stats.SkippedUploads++
+		stats.WebPFailed.Add(1)
+		stats.SkippedUploads.Add(1)
 		return false
 	}
 
 	// アップロード処理
-	if err := c.UploadFile(webpLocalPath, webpRemotePath); err != nil {
+	if err := c.UploadFileWithProgress(webpLocalPath, webpRemotePath, progress); err != nil {
 		log.Printf("エラー: WebPファイルのアップロードに失敗しました %s: %v", webpLocalPath, err)
-		stats.WebPFailed++
+		stats.WebPFailed.Add(1)
 		return false
 	}
 
 	// 成功処理
-	stats.WebPSuccess++
-	stats.UploadedFiles++
+	stats.WebPSuccess.Add(1)
+	stats.UploadedFiles.Add(1)
 	log.Printf("WebPファイルのアップロード成功: %s (サイズ: %d バイト)", webpRemotePath, fileSize)
+	c.uploadChecksumFile(webpLocalPath, webpRemotePath)
 	return true
 }
 
 // uploadAVIFFile はAVIFファイルをアップロードします
-func (c *Client) uploadAVIFFile(localPath, remoteFile, baseName string, stats *config.ConversionStats) bool {
-	if !config.IsAVIFEnabled() {
+func (c *Client) uploadAVIFFile(avifLocalPath, remoteFile string, stats *config.ConversionStats, progress *utils.FileProgressBar) bool {
+	if !config.IsAVIFEnabled() || avifLocalPath == "" {
 		return false
 	}
 
-	avifLocalPath := filepath.Join(filepath.Dir(localPath), baseName+".avif")
-	avifRemotePath := filepath.Join(filepath.Dir(remoteFile), baseName+".avif")
+	avifRemotePath := remoteOutputPath(avifLocalPath, remoteFile)
 
 	// ファイルが存在しない場合はスキップ
 	if _, err := os.Stat(avifLocalPath); err != nil {
@@ -233,47 +399,126 @@ func (c *Client) uploadAVIFFile(localPath, remoteFile, baseName string, stats *c
 	valid, fileSize := imageutils.IsValidFile(avifLocalPath)
 	if !valid {
 		log.Printf("警告: AVIFファイルが無効なためスキップします: %s", avifLocalPath)
-		stats.AVIFFailed++
-		stats.SkippedUploads++
+		stats.AVIFFailed.Add(1)
+		stats.SkippedUploads.Add(1)
 		return false
 	}
 
 	// アップロード処理
-	if err := c.UploadFile(avifLocalPath, avifRemotePath); err != nil {
+	if err := c.UploadFileWithProgress(avifLocalPath, avifRemotePath, progress); err != nil {
 		log.Printf("エラー: AVIFファイルのアップロードに失敗しました %s: %v", avifLocalPath, err)
-		stats.AVIFFailed++
+		stats.AVIFFailed.Add(1)
 		return false
 	}
 
 	// 成功処理
-	stats.AVIFSuccess++
-	stats.UploadedFiles++
+	stats.AVIFSuccess.Add(1)
+	stats.UploadedFiles.Add(1)
 	log.Printf("AVIFファイルのアップロード成功: %s (サイズ: %d バイト)", avifRemotePath, fileSize)
+	c.uploadChecksumFile(avifLocalPath, avifRemotePath)
 	return true
 }
 
-// cleanupFiles は処理済みのファイルを削除します
-func cleanupFiles(localPath, baseName string) {
-	// 元ファイルをすぐに削除
-	os.Remove(localPath)
+// uploadJXLFile はJPEG XLファイルをアップロードします
+func (c *Client) uploadJXLFile(jxlLocalPath, remoteFile string, stats *config.ConversionStats, progress *utils.FileProgressBar) bool {
+	if !config.IsJXLEnabled() || jxlLocalPath == "" {
+		return false
+	}
+
+	jxlRemotePath := remoteOutputPath(jxlLocalPath, remoteFile)
+
+	// ファイルが存在しない場合はスキップ
+	if _, err := os.Stat(jxlLocalPath); err != nil {
+		return false
+	}
+
+	// ファイルの検証（JPEG XLはマジックバイトで確認する）
+	valid, fileSize := imageutils.IsValidFile(jxlLocalPath)
+	if !valid || imageutils.CheckMagicBytes(jxlLocalPath) != nil {
+		log.Printf("警告: JPEG XLファイルが無効なためスキップします: %s", jxlLocalPath)
+		stats.JXLFailed.Add(1)
+		stats.SkippedUploads.Add(1)
+		return false
+	}
+
+	// アップロード処理
+	if err := c.UploadFileWithProgress(jxlLocalPath, jxlRemotePath, progress); err != nil {
+		log.Printf("エラー: JPEG XLファイルのアップロードに失敗しました %s: %v", jxlLocalPath, err)
+		stats.JXLFailed.Add(1)
+		return false
+	}
+
+	// 成功処理
+	stats.JXLSuccess.Add(1)
+	stats.UploadedFiles.Add(1)
+	log.Printf("JPEG XLファイルのアップロード成功: %s (サイズ: %d バイト)", jxlRemotePath, fileSize)
+	c.uploadChecksumFile(jxlLocalPath, jxlRemotePath)
+	return true
+}
+
+// uploadChecksumFile は変換結果のチェックサムファイル（.sha256）を画像と同じ場所にアップロードします
+// チェックサムファイルのアップロードに失敗しても画像のアップロードは成功として扱います
+func (c *Client) uploadChecksumFile(localPath, remotePath string) {
+	if !config.IsChecksumEnabled() {
+		return
+	}
+
+	checksumLocalPath := localPath + ".sha256"
+	if _, err := os.Stat(checksumLocalPath); err != nil {
+		log.Printf("警告: チェックサムファイルが見つかりません: %s", checksumLocalPath)
+		return
+	}
+
+	if err := c.UploadFile(checksumLocalPath, remotePath+".sha256"); err != nil {
+		log.Printf("警告: チェックサムファイルのアップロードに失敗しました %s: %v", checksumLocalPath, err)
+		return
+	}
 
-	dir := filepath.Dir(localPath)
-	webpPath := filepath.Join(dir, baseName+".webp")
-	avifPath := filepath.Join(dir, baseName+".avif")
+	log.Printf("チェックサムファイルのアップロード成功: %s", remotePath+".sha256")
+}
 
-	// ファイルが存在する場合は削除
-	if _, err := os.Stat(webpPath); err == nil {
-		os.Remove(webpPath)
+// smallestOutputSize は変換結果のうち最も小さいファイルのサイズを返します（変換結果がない場合は0）
+func smallestOutputSize(outputs []string) int64 {
+	var smallest int64
+	for _, path := range outputs {
+		fi, err := os.Stat(path)
+		if err != nil || fi.Size() == 0 {
+			continue
+		}
+		if smallest == 0 || fi.Size() < smallest {
+			smallest = fi.Size()
+		}
 	}
+	return smallest
+}
+
+// cleanupFiles は処理済みの元ファイルと変換後のファイル（outputs）を削除します
+// 並行して処理中の他のファイルがダウンロードする可能性があるため、ディレクトリは削除しません（removeEmptyDirs を参照）
+func cleanupFiles(localPath string, outputs []string) {
+	// 元ファイルをすぐに削除
+	os.Remove(localPath)
 
-	if _, err := os.Stat(avifPath); err == nil {
-		os.Remove(avifPath)
+	// 変換後のファイルとチェックサムファイルを削除
+	for _, path := range outputs {
+		os.Remove(path)
+		os.Remove(path + ".sha256")
 	}
+}
 
-	// 明示的にディレクトリが空になったらそのディレクトリも削除
-	files, _ := filepath.Glob(filepath.Join(dir, "*"))
-	if len(files) == 0 {
-		os.Remove(dir)
+// removeEmptyDirs は tempDir の中で空になったディレクトリを削除します（tempDir 自体は残します）
+// バッチのすべてのファイルの処理が終わってから呼び出してください
+func removeEmptyDirs(tempDir string) {
+	var dirs []string
+	filepath.WalkDir(tempDir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && d.IsDir() && path != tempDir {
+			dirs = append(dirs, path)
+		}
+		return nil
+	})
+
+	// 深い階層から順に削除する（空でないディレクトリの削除は失敗するため残る）
+	for i := len(dirs) - 1; i >= 0; i-- {
+		os.Remove(dirs[i])
 	}
 }
 
@@ -285,16 +530,20 @@ func (c *Client) ProcessFileBatch(files []string, tempDir string, stats *config.
 			log.Printf("ファイル処理エラー [%s]: %v", remoteFile, err)
 		}
 	}
+	removeEmptyDirs(tempDir)
 	return nil
 }
 
 // LogIntermediateStats は中間処理結果をログに出力します
 func LogIntermediateStats(stats *config.ConversionStats, processed, total int) {
 	log.Printf("=== 中間処理統計 (%d/%d ファイル) ===", processed, total)
-	log.Printf("処理ファイル数: %d", stats.TotalProcessed)
-	log.Printf("ダウンロード失敗: %d, 変換失敗: %d", stats.DownloadFailed, stats.ConvertFailed)
-	log.Printf("WebP変換成功: %d, 失敗: %d", stats.WebPSuccess, stats.WebPFailed)
-	log.Printf("AVIF変換成功: %d, 失敗: %d", stats.AVIFSuccess, stats.AVIFFailed)
-	log.Printf("アップロード成功: %d, スキップ: %d", stats.UploadedFiles, stats.SkippedUploads)
+	log.Printf("処理ファイル数: %d", stats.TotalProcessedCount())
+	log.Printf("ダウンロード失敗: %d, 変換失敗: %d", stats.DownloadFailedCount(), stats.ConvertFailedCount())
+	log.Printf("WebP変換成功: %d, 失敗: %d", stats.WebPSuccessCount(), stats.WebPFailedCount())
+	log.Printf("AVIF変換成功: %d, 失敗: %d", stats.AVIFSuccessCount(), stats.AVIFFailedCount())
+	if config.IsJXLEnabled() {
+		log.Printf("JPEG XL変換成功: %d, 失敗: %d", stats.JXLSuccessCount(), stats.JXLFailedCount())
+	}
+	log.Printf("アップロード成功: %d, スキップ: %d", stats.UploadedFilesCount(), stats.SkippedUploadsCount())
 	log.Printf("現在の処理時間: %s", time.Since(stats.StartTime))
 }
@@ -0,0 +1,114 @@
+package remote
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/223n/image-converter/internal/config"
+)
+
+// TestFindRemoteImagesWithSFTPListing はシェルを使用できないサーバーでも、list_method: sftp によりSFTPで画像を一覧できることを確認します
+func TestFindRemoteImagesWithSFTPListing(t *testing.T) {
+	server := newTestSSHServer(t) // exec が nil のため、コマンド実行は拒否される
+	root := t.TempDir()
+	for _, name := range []string{"b.jpg", "a.PNG", "sub/c.jpeg", "sub/deep/d.jpg", "notes.txt", "sub/e.gif"} {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := server.remoteConfig()
+	cfg.RemotePath = root
+	cfg.ListMethod = "find"
+	client, err := NewClient(cfg)
+	if err != nil {
+		t.Fatalf("NewClient に失敗しました: %v", err)
+	}
+	defer client.Close()
+
+	extensions := []string{".jpg", ".jpeg", ".png"}
+
+	// find コマンドはシェルがないため失敗する
+	if _, err := client.FindRemoteImages(extensions); err == nil {
+		t.Error("シェルを使用できないサーバーで find による一覧が成功しました")
+	}
+
+	cfg.ListMethod = "sftp"
+	got, err := client.FindRemoteImages(extensions)
+	if err != nil {
+		t.Fatalf("FindRemoteImages に失敗しました: %v", err)
+	}
+	want := []string{
+		filepath.Join(root, "a.PNG"),
+		filepath.Join(root, "b.jpg"),
+		filepath.Join(root, "sub/c.jpeg"),
+		filepath.Join(root, "sub/deep/d.jpg"),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FindRemoteImages = %v, want %v", got, want)
+	}
+}
+
+// TestProcessRemoteFileFilenameTemplate はリモートモードでも output.filename_template に従って変換し、その名前でアップロードすることを確認します
+func TestProcessRemoteFileFilenameTemplate(t *testing.T) {
+	t.Cleanup(func() { config.LoadConfigFromBytes(nil) })
+	data := "conversion:\n  webp:\n    enabled: true\n    quality: 70\n  avif:\n    enabled: false\n  jxl:\n    enabled: false\n" +
+		"output:\n  filename_template: \"{name}_{width}x{height}_q{quality}.{format}\"\n"
+	if err := config.LoadConfigFromBytes([]byte(data)); err != nil {
+		t.Fatalf("設定の読み込みに失敗しました: %v", err)
+	}
+
+	server := newTestSSHServer(t)
+	root := t.TempDir()
+	localPath, pngData := localPNG(t) // 4x4 のPNG
+	remoteFile := filepath.Join(root, "photos", "photo.png")
+	if err := os.MkdirAll(filepath.Dir(remoteFile), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(remoteFile, pngData, 0644); err != nil {
+		t.Fatal(err)
+	}
+	os.Remove(localPath)
+
+	cfg := server.remoteConfig()
+	cfg.RemotePath = root
+	client, err := NewClient(cfg)
+	if err != nil {
+		t.Fatalf("NewClient に失敗しました: %v", err)
+	}
+	defer client.Close()
+
+	tempDir := t.TempDir()
+	stats := config.NewConversionStats()
+	if err := client.ProcessRemoteFile(remoteFile, tempDir, stats); err != nil {
+		t.Fatalf("ProcessRemoteFile に失敗しました: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(root, "photos", "photo_4x4_q70.webp")); err != nil {
+		t.Errorf("テンプレートの名前でアップロードされていません: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "photos", "photo.webp")); !os.IsNotExist(err) {
+		t.Errorf("デフォルトの名前でアップロードされました: %v", err)
+	}
+	if got := stats.WebPSuccess.Load(); got != 1 {
+		t.Errorf("WebPSuccess = %d, want 1", got)
+	}
+
+	// 変換後のファイルは一時ディレクトリから削除される
+	var left []string
+	filepath.Walk(tempDir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			left = append(left, path)
+		}
+		return nil
+	})
+	if len(left) != 0 {
+		t.Errorf("一時ディレクトリにファイルが残っています: %v", left)
+	}
+}
@@ -0,0 +1,126 @@
+package remote
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// ErrPoolClosed はSFTPプールが既に閉じられていることを表します
+// 再接続によりプールが入れ替わった場合にも返されるため、呼び出し元は新しいプールで再試行できます
+var ErrPoolClosed = errors.New("SFTPプールは既に閉じられています")
+
+// SFTPPool は同じSSH接続上に作成した複数のSFTPクライアントを管理します
+// 1つのSFTPクライアントは内部で操作を直列化するため、並列転送ではワーカーごとにクライアントを割り当てます
+type SFTPPool struct {
+	client  *ssh.Client
+	size    int
+	options []sftp.ClientOption
+
+	idle chan *sftp.Client // 使用可能なクライアント
+
+	mu      sync.Mutex
+	clients []*sftp.Client // 作成済みのすべてのクライアント
+	closed  bool
+}
+
+// NewSFTPPool は最大 size 個のSFTPクライアントを持つプールを作成します
+// クライアントは必要になった時点で options を指定して作成します
+func NewSFTPPool(client *ssh.Client, size int, options ...sftp.ClientOption) *SFTPPool {
+	size = max(1, size)
+	return &SFTPPool{
+		client:  client,
+		size:    size,
+		options: options,
+		idle:    make(chan *sftp.Client, size),
+	}
+}
+
+// Acquire はSFTPクライアントを取得します
+// 使用可能なクライアントがなく、上限まで作成済みの場合は返却されるまで待機します
+func (p *SFTPPool) Acquire() (*sftp.Client, error) {
+	// 使用可能なクライアントがあればそれを使う
+	select {
+	case sc, ok := <-p.idle:
+		return p.handOut(sc, ok)
+	default:
+	}
+
+	// 上限に達していなければ新しく作成する
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil, ErrPoolClosed
+	}
+	if len(p.clients) < p.size {
+		sc, err := sftp.NewClient(p.client, p.options...)
+		if err != nil {
+			p.mu.Unlock()
+			return nil, fmt.Errorf("SFTPクライアントの作成に失敗しました: %v", err)
+		}
+		p.clients = append(p.clients, sc)
+		p.mu.Unlock()
+		return sc, nil
+	}
+	p.mu.Unlock()
+
+	// 返却されるまで待機する
+	sc, ok := <-p.idle
+	return p.handOut(sc, ok)
+}
+
+// handOut は待機中のチャネルから受け取ったクライアントを返します
+// チャネルが閉じられている場合や、受け取った時点でプールが閉じられている場合は ErrPoolClosed を返します
+func (p *SFTPPool) handOut(sc *sftp.Client, ok bool) (*sftp.Client, error) {
+	if !ok {
+		return nil, ErrPoolClosed
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil, ErrPoolClosed
+	}
+	return sc, nil
+}
+
+// Release は取得したSFTPクライアントをプールに返却します
+// プールが閉じられている場合はクライアントを閉じます
+func (p *SFTPPool) Release(sc *sftp.Client) {
+	if sc == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		sc.Close()
+		return
+	}
+	p.idle <- sc
+}
+
+// Close はプールが作成したすべてのSFTPクライアントを閉じます
+// 使用中のクライアントも閉じられるため、実行中の転送はエラーになります
+func (p *SFTPPool) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return
+	}
+	p.closed = true
+
+	for _, sc := range p.clients {
+		sc.Close()
+	}
+
+	// 待機中のクライアントを取り除き、閉じた後に取得されないようにする
+	close(p.idle)
+	for range p.idle {
+	}
+}
@@ -0,0 +1,106 @@
+package remote
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestSFTPPoolConcurrentAcquire は上限2のプールを複数のワーカーで取り合っても、同時に使用されるクライアントが上限を超えないことを確認します
+func TestSFTPPoolConcurrentAcquire(t *testing.T) {
+	server := newTestSSHServer(t)
+	pool := NewSFTPPool(server.dial(), 2)
+	defer pool.Close()
+
+	const workers = 8
+	var inUse, maxInUse atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 5; j++ {
+				sc, err := pool.Acquire()
+				if err != nil {
+					t.Errorf("Acquire に失敗しました: %v", err)
+					return
+				}
+				n := inUse.Add(1)
+				for {
+					current := maxInUse.Load()
+					if n <= current || maxInUse.CompareAndSwap(current, n) {
+						break
+					}
+				}
+				if _, err := sc.Getwd(); err != nil {
+					t.Errorf("SFTPの操作に失敗しました: %v", err)
+				}
+				inUse.Add(-1)
+				pool.Release(sc)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := maxInUse.Load(); got > 2 {
+		t.Errorf("同時に使用されたクライアント数 = %d, 上限 2 を超えています", got)
+	}
+	if got := len(pool.clients); got > 2 {
+		t.Errorf("作成されたクライアント数 = %d, 上限 2 を超えています", got)
+	}
+}
+
+// TestSFTPPoolCloseDuringUse は使用中にプールを閉じた場合、待機中・以降の Acquire が ErrPoolClosed を返し、nil のクライアントを返さないことを確認します
+func TestSFTPPoolCloseDuringUse(t *testing.T) {
+	server := newTestSSHServer(t)
+	pool := NewSFTPPool(server.dial(), 2)
+
+	const workers = 6
+	started := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; ; j++ {
+				sc, err := pool.Acquire()
+				if err != nil {
+					if !errors.Is(err, ErrPoolClosed) {
+						t.Errorf("Acquire のエラー = %v, ErrPoolClosed を期待しました", err)
+					}
+					return
+				}
+				if sc == nil {
+					t.Error("Acquire がエラーなしで nil のクライアントを返しました")
+					return
+				}
+				if j == 0 {
+					started <- struct{}{}
+				}
+				// 閉じられたクライアントの操作はエラーになるが、パニックしないこと
+				sc.Getwd()
+				pool.Release(sc)
+			}
+		}()
+	}
+
+	// すべてのワーカーが少なくとも1回取得してから閉じる
+	for i := 0; i < workers; i++ {
+		select {
+		case <-started:
+		case <-time.After(5 * time.Second):
+			t.Fatal("ワーカーがクライアントを取得できませんでした")
+		}
+	}
+	pool.Close()
+	wg.Wait()
+
+	// 閉じた後は待機中のクライアントも取得できない
+	for i := 0; i < 3; i++ {
+		if sc, err := pool.Acquire(); !errors.Is(err, ErrPoolClosed) || sc != nil {
+			t.Errorf("Close 後の Acquire = (%v, %v), (nil, ErrPoolClosed) を期待しました", sc, err)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package remote
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/223n/image-converter/internal/config"
+)
+
+// partialSuffix は途中までダウンロードしたファイルの拡張子です
+const partialSuffix = ".part"
+
+// partialMarker は途中までダウンロードしたファイルの取得元の情報です
+// 再開前に取得元のサイズと更新日時を比較し、別の内容に追記しないようにします
+type partialMarker struct {
+	RemotePath string    `json:"remote_path"`
+	Size       int64     `json:"size"`
+	ModTime    time.Time `json:"mod_time"`
+}
+
+// partialDirectory は途中までのダウンロードを保存するディレクトリを返します
+// remote.partial_dir が未設定の場合は、ユーザーのキャッシュディレクトリ配下に接続先ごとのディレクトリを使用します
+func partialDirectory(cfg *config.RemoteConfig) string {
+	if cfg.PartialDir != "" {
+		return expandPath(cfg.PartialDir)
+	}
+
+	base, err := os.UserCacheDir()
+	if err != nil {
+		base = os.TempDir()
+	}
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s@%s:%d", cfg.User, cfg.Host, cfg.Port)))
+	return filepath.Join(base, "image-converter", "partial", hex.EncodeToString(sum[:8]))
+}
+
+// partialPaths はリモートファイルに対応する途中のファイルと取得元情報のファイルのパスを返します
+func (c *Client) partialPaths(remotePath string) (string, string) {
+	sum := sha256.Sum256([]byte(remotePath))
+	base := filepath.Join(partialDirectory(c.config), hex.EncodeToString(sum[:16])+filepath.Ext(remotePath))
+	return base + partialSuffix, base + partialSuffix + ".json"
+}
+
+// preparePartial はダウンロード先の途中のファイルのパスと、再開する位置（取得済みのバイト数）を返します
+// 取得元のサイズ・更新日時が記録と一致しない場合や、途中のファイルがリモートより大きい場合は破棄して最初から取得します
+func (c *Client) preparePartial(remotePath string, remoteInfo os.FileInfo) (string, int64, error) {
+	partialPath, markerPath := c.partialPaths(remotePath)
+	if err := os.MkdirAll(filepath.Dir(partialPath), 0755); err != nil {
+		return "", 0, fmt.Errorf("再開用ディレクトリの作成に失敗しました: %v", err)
+	}
+
+	// SFTPの更新日時は秒単位のため、秒未満は比較しない
+	current := partialMarker{RemotePath: remotePath, Size: remoteInfo.Size(), ModTime: remoteInfo.ModTime().UTC().Truncate(time.Second)}
+
+	fi, err := os.Stat(partialPath)
+	if err == nil && fi.Size() > 0 {
+		if fi.Size() <= current.Size && readPartialMarker(markerPath) == current {
+			return partialPath, fi.Size(), nil
+		}
+		log.Printf("取得元が変更されているため、途中までのダウンロードを破棄します: %s", remotePath)
+	}
+
+	// 最初から取得する。取得元の情報を先に記録し、中断された場合に次回の実行で再開できるようにする
+	os.Remove(partialPath)
+	data, err := json.Marshal(current)
+	if err != nil {
+		return "", 0, fmt.Errorf("再開用の情報の作成に失敗しました: %v", err)
+	}
+	if err := os.WriteFile(markerPath, data, 0644); err != nil {
+		return "", 0, fmt.Errorf("再開用の情報の保存に失敗しました: %v", err)
+	}
+	return partialPath, 0, nil
+}
+
+// readPartialMarker は取得元情報のファイルを読み込みます。読み込めない場合はゼロ値を返します
+func readPartialMarker(path string) partialMarker {
+	var marker partialMarker
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return partialMarker{}
+	}
+	if err := json.Unmarshal(data, &marker); err != nil {
+		return partialMarker{}
+	}
+	marker.ModTime = marker.ModTime.UTC()
+	return marker
+}
+
+// discardPartial は途中のファイルと取得元情報を削除します
+func (c *Client) discardPartial(remotePath string) {
+	partialPath, markerPath := c.partialPaths(remotePath)
+	os.Remove(partialPath)
+	os.Remove(markerPath)
+}
+
+// completePartial は取得を終えたファイルを localPath に移動し、取得元情報を削除します
+// 別のファイルシステムで移動できない場合はコピーします
+func (c *Client) completePartial(remotePath, localPath string) error {
+	partialPath, markerPath := c.partialPaths(remotePath)
+
+	if err := os.Rename(partialPath, localPath); err != nil {
+		if err := copyFile(partialPath, localPath); err != nil {
+			return fmt.Errorf("ダウンロードしたファイルの移動に失敗しました: %v", err)
+		}
+		os.Remove(partialPath)
+	}
+	os.Remove(markerPath)
+	return nil
+}
+
+// copyFile はファイルをコピーします
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,136 @@
+package remote
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newResumeTestClient は再開用ディレクトリを一時ディレクトリにしたクライアントと、1MBのリモートファイルを用意します
+func newResumeTestClient(t *testing.T) (*Client, string, []byte) {
+	t.Helper()
+
+	server := newTestSSHServer(t)
+	cfg := server.remoteConfig()
+	cfg.ResumeDownloads = true
+	cfg.PartialDir = t.TempDir()
+
+	client, err := NewClient(cfg)
+	if err != nil {
+		t.Fatalf("NewClient に失敗しました: %v", err)
+	}
+	t.Cleanup(client.Close)
+
+	content := make([]byte, 1<<20)
+	for i := range content {
+		content[i] = byte(i * 7)
+	}
+	remotePath := filepath.Join(t.TempDir(), "photo.jpg")
+	if err := os.WriteFile(remotePath, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return client, remotePath, content
+}
+
+// interruptHalfway は前回の実行が半分まで取得して中断された状態を再現します
+// 取得元の情報を記録してから、先頭の半分だけを途中のファイルに書き込みます
+func interruptHalfway(t *testing.T, client *Client, remotePath string, content []byte) {
+	t.Helper()
+
+	info, err := os.Stat(remotePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	partialPath, offset, err := client.preparePartial(remotePath, info)
+	if err != nil || offset != 0 {
+		t.Fatalf("preparePartial = (%d, %v), 最初からの取得を期待しました", offset, err)
+	}
+	if err := os.WriteFile(partialPath, content[:len(content)/2], 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestDownloadResumesAfterInterruption は前回の実行で半分まで取得したファイルを、次の実行で続きから取得することを確認します
+func TestDownloadResumesAfterInterruption(t *testing.T) {
+	client, remotePath, content := newResumeTestClient(t)
+	interruptHalfway(t, client, remotePath, content)
+
+	// 取得済みの前半を再取得していないことを確認するため、サイズと更新日時を保ったまま前半だけを書き換える
+	info, _ := os.Stat(remotePath)
+	changed := bytes.Repeat([]byte{0xFF}, len(content)/2)
+	modified := append(append([]byte{}, changed...), content[len(content)/2:]...)
+	if err := os.WriteFile(remotePath, modified, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(remotePath, info.ModTime(), info.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+
+	localPath := filepath.Join(t.TempDir(), "photo.jpg")
+	if err := client.DownloadFile(remotePath, localPath); err != nil {
+		t.Fatalf("DownloadFile に失敗しました: %v", err)
+	}
+
+	got, err := os.ReadFile(localPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Error("再開したダウンロードの内容が、取得済みの前半と残りの後半の組み合わせと一致しません")
+	}
+
+	// 完了後は途中のファイルと取得元情報が残らない
+	partialPath, markerPath := client.partialPaths(remotePath)
+	for _, path := range []string{partialPath, markerPath} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("完了後も %s が残っています", path)
+		}
+	}
+}
+
+// TestDownloadRestartsWhenRemoteChanged は取得元の更新日時が変わっている場合、途中のファイルに追記せず最初から取得することを確認します
+func TestDownloadRestartsWhenRemoteChanged(t *testing.T) {
+	client, remotePath, content := newResumeTestClient(t)
+	interruptHalfway(t, client, remotePath, content)
+
+	// 同じサイズの別の内容に差し替える
+	replaced := bytes.Repeat([]byte{0x42}, len(content))
+	if err := os.WriteFile(remotePath, replaced, 0644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(remotePath, later, later); err != nil {
+		t.Fatal(err)
+	}
+
+	localPath := filepath.Join(t.TempDir(), "photo.jpg")
+	if err := client.DownloadFile(remotePath, localPath); err != nil {
+		t.Fatalf("DownloadFile に失敗しました: %v", err)
+	}
+	if got, _ := os.ReadFile(localPath); !bytes.Equal(got, replaced) {
+		t.Error("取得元が変更されたのに、古い途中のファイルに追記されています")
+	}
+}
+
+// TestDownloadRestartsWithoutMarker は取得元情報のない途中のファイル（別のファイルの残骸など）には追記しないことを確認します
+func TestDownloadRestartsWithoutMarker(t *testing.T) {
+	client, remotePath, content := newResumeTestClient(t)
+
+	partialPath, _ := client.partialPaths(remotePath)
+	if err := os.MkdirAll(filepath.Dir(partialPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(partialPath, []byte("unrelated"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	localPath := filepath.Join(t.TempDir(), "photo.jpg")
+	if err := client.DownloadFile(remotePath, localPath); err != nil {
+		t.Fatalf("DownloadFile に失敗しました: %v", err)
+	}
+	if got, _ := os.ReadFile(localPath); !bytes.Equal(got, content) {
+		t.Error("取得元情報のない途中のファイルに追記されています")
+	}
+}
@@ -6,21 +6,31 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"sync"
 	"time"
 
 	"github.com/223n/image-converter/internal/config"
+	"github.com/223n/image-converter/internal/converter"
+	"github.com/223n/image-converter/internal/notify"
 	"github.com/223n/image-converter/internal/utils"
 )
 
 // Service はリモート変換サービスを表します
 type Service struct {
 	config *config.RemoteConfig
+
+	// 差分同期の状態（無効な場合はnil）
+	delta *DeltaState
+
+	// sleep は接続を安定させるための待機に使用します（テストでは待機せずに記録する関数に差し替えます）
+	sleep func(time.Duration)
 }
 
 // NewService は新しいリモート変換サービスを作成します
 func NewService() *Service {
 	return &Service{
 		config: config.GetRemoteConfig(),
+		sleep:  time.Sleep,
 	}
 }
 
@@ -53,6 +63,35 @@ func (s *Service) Execute() error {
 		return err
 	}
 
+	// この環境でデコードできない形式のファイルを除外
+	cfg := config.GetConfig()
+	imageFiles, skippedUnsupported, err := converter.FilterUnsupportedInputs(imageFiles, &cfg)
+	if err != nil {
+		return err
+	}
+	totalFiles = len(imageFiles)
+
+	// 差分同期: 前回から更新されていないファイルを除外
+	if s.config.DeltaSync.Enabled {
+		if s.delta, err = LoadDeltaState(s.config.DeltaSync.StateFile); err != nil {
+			return err
+		}
+		imageFiles = s.filterUnchanged(client, imageFiles)
+		totalFiles = len(imageFiles)
+	}
+
+	// ドライランの変換予定をファイルに書き込む
+	if config.IsDryRun() && cfg.Mode.DryRunOutput != "" {
+		plans := make([]converter.PlannedConversion, 0, len(imageFiles))
+		for _, remoteFile := range imageFiles {
+			plans = append(plans, converter.PlanConversion(&cfg, remoteFile))
+		}
+		if err := converter.WriteDryRunPlan(cfg.Mode.DryRunOutput, plans); err != nil {
+			return err
+		}
+		log.Printf("ドライランの変換予定を書き込みました: %s", cfg.Mode.DryRunOutput)
+	}
+
 	// 一時ディレクトリの準備
 	tempDir, err := s.prepareTempDirectory()
 	if err != nil {
@@ -62,6 +101,7 @@ func (s *Service) Execute() error {
 
 	// 統計情報の初期化
 	stats := config.NewConversionStats()
+	stats.SkippedUnsupported.Store(int64(skippedUnsupported))
 
 	// バッチ処理
 	if err := s.processBatches(client, imageFiles, totalFiles, tempDir, stats); err != nil {
@@ -71,6 +111,16 @@ func (s *Service) Execute() error {
 	// 結果の出力
 	s.logConversionResults(stats, totalFiles, logFileName)
 
+	// 差分同期の状態を保存（ドライランでは保存しない）
+	if s.delta != nil && !config.IsDryRun() {
+		if err := s.delta.Save(); err != nil {
+			log.Printf("警告: %v", err)
+		}
+	}
+
+	// 完了通知
+	notify.NotifyCompletion(&cfg, notify.NewCompletionPayload("remote", totalFiles, stats))
+
 	return nil
 }
 
@@ -115,7 +165,7 @@ func (s *Service) setupLogging() (string, *os.File) {
 		return logFileName, nil
 	}
 
-	log.SetOutput(logFile)
+	utils.RedirectLogOutput(logFile)
 	return logFileName, logFile
 }
 
@@ -134,7 +184,30 @@ func (s *Service) logFatalError(message string, err error) {
 
 // findRemoteImages はリモートサーバー上の画像ファイルを検索します
 func (s *Service) findRemoteImages(client *Client) ([]string, int, error) {
+	// 件数が分かるまでは一覧の取得中であることをスピナーで表示する
+	spinner := utils.NewProgressBar(0, "リモート画像の検索")
+	spinner.SetIndeterminate(true)
+	stopSpinner := make(chan struct{})
+	spinnerDone := make(chan struct{})
+	go func() {
+		defer close(spinnerDone)
+		ticker := time.NewTicker(200 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				spinner.Tick()
+			case <-stopSpinner:
+				return
+			}
+		}
+	}()
+
 	imageFiles, err := client.FindRemoteImages(config.GetSupportedExtensions())
+	close(stopSpinner)
+	<-spinnerDone
+	spinner.IncrementBy(len(imageFiles))
+	spinner.Complete()
 	if err != nil {
 		s.logFatalError("リモート画像の検索に失敗しました", err)
 		return nil, 0, fmt.Errorf("リモート画像の検索に失敗しました: %w", err)
@@ -145,11 +218,32 @@ func (s *Service) findRemoteImages(client *Client) ([]string, int, error) {
 
 	// 一時停止して接続を確保
 	log.Printf("処理を開始する前に5秒間待機します...")
-	time.Sleep(5 * time.Second)
+	s.sleep(5 * time.Second)
 
 	return imageFiles, totalFiles, nil
 }
 
+// filterUnchanged は前回の実行から更新日時が変わっていないファイルを除外します
+// 更新日時を取得できないファイルは変更ありとして処理対象に残します
+func (s *Service) filterUnchanged(client *Client, imageFiles []string) []string {
+	var changed []string
+	for _, remoteFile := range imageFiles {
+		modTime, err := client.RemoteModTime(remoteFile)
+		if err != nil {
+			log.Printf("警告: 更新日時を取得できないため処理対象とします %s: %v", remoteFile, err)
+			changed = append(changed, remoteFile)
+			continue
+		}
+		if s.delta.Unchanged(remoteFile, modTime) {
+			continue
+		}
+		changed = append(changed, remoteFile)
+	}
+
+	log.Printf("差分同期: %d個のファイルのうち、更新された %d個を処理します", len(imageFiles), len(changed))
+	return changed
+}
+
 // prepareTempDirectory は一時ディレクトリを作成します
 func (s *Service) prepareTempDirectory() (string, error) {
 	tempDir, err := os.MkdirTemp("", "remote-images-")
@@ -165,10 +259,24 @@ func (s *Service) processBatches(client *Client, imageFiles []string, totalFiles
 	// 進捗トラッカーを作成
 	tracker := utils.NewMultiProgressTracker(totalFiles, "リモート変換")
 
-	// バッチサイズを設定（メモリ使用量削減のため小さいサイズに変更）
-	const batchSize = 10
+	// 外部の監視用の進捗の出力（開けない場合も変換は続ける）
+	closeStream, err := tracker.AttachEventStream(config.GetConfig().Reporting.ProgressStream)
+	if err != nil {
+		log.Printf("警告: %v", err)
+	}
+	defer closeStream()
+
+	// メモリ使用量削減のため、設定されたファイル数ごとに処理する
+	batchSize := max(1, s.config.BatchSize)
+	batchPause := time.Duration(s.config.BatchPauseSeconds) * time.Second
 	log.Printf("バッチ処理を使用します: %d個のファイルごとに処理", batchSize)
 
+	// 直近のバッチの処理速度から残り時間を推定する
+	var eta batchETA
+
+	// ディレクトリごとにまとめて処理し、アップロード先のディレクトリ作成をまとめる
+	imageFiles = groupByDirectory(imageFiles)
+
 	// ファイルをバッチごとに処理
 	for i := 0; i < len(imageFiles); i += batchSize {
 		end := i + batchSize
@@ -179,15 +287,20 @@ func (s *Service) processBatches(client *Client, imageFiles []string, totalFiles
 		log.Printf("バッチ処理: %d - %d / %d ファイル", i+1, end, totalFiles)
 
 		// 各バッチの間で休止してSSH接続を安定させる
-		if i > 0 {
-			log.Printf("バッチ間休止: 5秒間待機...")
-			time.Sleep(5 * time.Second)
+		if i > 0 && batchPause > 0 {
+			log.Printf("バッチ間休止: %s待機...", batchPause)
+			s.sleep(batchPause)
 		}
 
 		// このバッチのファイルを処理
+		batchStart := time.Now()
 		if err := s.processFileBatch(client, imageFiles[i:end], tempDir, tracker, stats); err != nil {
 			return err
 		}
+		eta.Add(end-i, time.Since(batchStart))
+		if remaining := len(imageFiles) - end; remaining > 0 {
+			log.Printf("推定残り時間: %s (残り %d ファイル)", eta.Remaining(remaining).Round(time.Second), remaining)
+		}
 
 		// 中間統計情報をログに出力
 		LogIntermediateStats(stats, end, totalFiles)
@@ -202,6 +315,28 @@ func (s *Service) processBatches(client *Client, imageFiles []string, totalFiles
 	return nil
 }
 
+// groupByDirectory はファイルをディレクトリごとにまとめた順序に並べ替えます
+// ディレクトリは最初に現れた順、同じディレクトリ内のファイルは元の順序を保ちます
+func groupByDirectory(files []string) []string {
+	var dirs []string
+	groups := make(map[string][]string)
+	for _, file := range files {
+		dir := filepath.Dir(file)
+		if _, ok := groups[dir]; !ok {
+			dirs = append(dirs, dir)
+		}
+		groups[dir] = append(groups[dir], file)
+	}
+
+	grouped := make([]string, 0, len(files))
+	for _, dir := range dirs {
+		grouped = append(grouped, groups[dir]...)
+	}
+
+	log.Printf("%d個のディレクトリごとにまとめて処理します", len(dirs))
+	return grouped
+}
+
 // performMemoryManagement はメモリ使用状況の出力とガベージコレクションを実行します
 func (s *Service) performMemoryManagement() {
 	// 明示的にガベージコレクションを呼び出す
@@ -214,37 +349,73 @@ func (s *Service) performMemoryManagement() {
 }
 
 // processFileBatch はファイルのバッチを処理します
+// remote.concurrent_transfers が2以上の場合は、その数までのファイルを同時に処理します
 func (s *Service) processFileBatch(client *Client, files []string, tempDir string, tracker *utils.MultiProgressTracker, stats *config.ConversionStats) error {
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, max(1, s.config.ConcurrentTransfers))
+
 	for _, remoteFile := range files {
-		if err := s.processFile(client, remoteFile, tempDir, tracker, stats); err != nil {
-			// エラーがあっても続行
-			log.Printf("ファイル処理エラー [%s]: %v", remoteFile, err)
-		}
+		wg.Add(1)
+		semaphore <- struct{}{}
+
+		go func(remoteFile string) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			// ワーカー間のロック競合を避けるため、ファイルごとに集計してから全体に加算する
+			fileStats := config.NewConversionStats()
+			defer stats.Merge(fileStats)
+
+			if err := s.processFile(client, remoteFile, tempDir, tracker, fileStats); err != nil {
+				// エラーがあっても続行
+				log.Printf("ファイル処理エラー [%s]: %v", remoteFile, err)
+			}
+		}(remoteFile)
 	}
+
+	wg.Wait()
+
+	// 処理中の他のファイルと競合しないよう、空になったディレクトリはバッチの完了後に削除する
+	removeEmptyDirs(tempDir)
 	return nil
 }
 
 // processFile は単一のリモートファイルを処理します
 func (s *Service) processFile(client *Client, remoteFile, tempDir string, tracker *utils.MultiProgressTracker, stats *config.ConversionStats) error {
-	err := client.ProcessRemoteFile(remoteFile, tempDir, stats)
+	// ダウンロードとアップロードの転送状況を全体の進捗バーに表示する
+	progress := tracker.StartFile(remoteFile, 0)
+	err := client.ProcessRemoteFileWithProgress(remoteFile, tempDir, stats, progress)
 
 	if err != nil {
-		tracker.IncrementFailed()
+		progress.Fail()
 		return err
 	}
 
-	tracker.IncrementSuccess()
+	if s.delta != nil {
+		s.delta.MarkProcessed(remoteFile)
+	}
+	progress.Complete()
 	return nil
 }
 
 // logConversionResults はリモート変換結果をログに出力します
 func (s *Service) logConversionResults(stats *config.ConversionStats, _ int, logFileName string) {
 	log.Println("=== 変換処理結果 ===")
-	log.Printf("処理ファイル数: %d", stats.TotalProcessed)
-	log.Printf("ダウンロード失敗: %d, 変換失敗: %d", stats.DownloadFailed, stats.ConvertFailed)
-	log.Printf("WebP変換成功: %d, 失敗: %d", stats.WebPSuccess, stats.WebPFailed)
-	log.Printf("AVIF変換成功: %d, 失敗: %d", stats.AVIFSuccess, stats.AVIFFailed)
-	log.Printf("アップロード成功: %d, スキップ: %d", stats.UploadedFiles, stats.SkippedUploads)
+	log.Printf("処理ファイル数: %d", stats.TotalProcessedCount())
+	log.Printf("ダウンロード失敗: %d, 変換失敗: %d", stats.DownloadFailedCount(), stats.ConvertFailedCount())
+	log.Printf("WebP変換成功: %d, 失敗: %d", stats.WebPSuccessCount(), stats.WebPFailedCount())
+	log.Printf("AVIF変換成功: %d, 失敗: %d", stats.AVIFSuccessCount(), stats.AVIFFailedCount())
+	if config.IsJXLEnabled() {
+		log.Printf("JPEG XL変換成功: %d, 失敗: %d", stats.JXLSuccessCount(), stats.JXLFailedCount())
+	}
+	log.Printf("アップロード成功: %d, スキップ: %d", stats.UploadedFilesCount(), stats.SkippedUploadsCount())
+	if stats.SkippedTooLargeCount() > 0 {
+		log.Printf("画素数が上限を超えるためスキップ: %d", stats.SkippedTooLargeCount())
+	}
+	if stats.SkippedUnsupportedCount() > 0 {
+		log.Printf("未対応の形式でスキップ: %d", stats.SkippedUnsupportedCount())
+	}
+	log.Printf("削減サイズ: %d バイト", stats.BytesSaved())
 	log.Printf("処理時間: %s", time.Since(stats.StartTime))
 	log.Printf("=== 画像変換処理終了: %s ===", time.Now().Format("2006-01-02 15:04:05"))
 
@@ -0,0 +1,203 @@
+package remote
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/223n/image-converter/internal/config"
+	"github.com/223n/image-converter/internal/utils"
+)
+
+// newRemoteImageDir はテスト用SSHサーバーで公開する、count 個のPNGを含むリモートディレクトリを作成します
+func newRemoteImageDir(tb testing.TB, count int) (string, []string) {
+	tb.Helper()
+
+	img := image.NewNRGBA(image.Rect(0, 0, 64, 48))
+	for y := 0; y < 48; y++ {
+		for x := 0; x < 64; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{R: uint8(x * 4), G: uint8(y * 5), B: 128, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		tb.Fatalf("PNGのエンコードに失敗しました: %v", err)
+	}
+
+	root := tb.TempDir()
+	files := make([]string, count)
+	for i := range files {
+		files[i] = filepath.Join(root, "photo"+strconv.Itoa(i)+".png")
+		if err := os.WriteFile(files[i], buf.Bytes(), 0644); err != nil {
+			tb.Fatalf("リモートファイルの作成に失敗しました: %v", err)
+		}
+	}
+	return root, files
+}
+
+// useWebPOnlyConfig はWebPのみを出力する設定を読み込み、テストの終了時に元に戻します
+func useWebPOnlyConfig(tb testing.TB) {
+	tb.Helper()
+
+	tb.Cleanup(func() { config.LoadConfigFromBytes(nil) })
+	data := "conversion:\n  webp:\n    enabled: true\n  avif:\n    enabled: false\n  jxl:\n    enabled: false\n"
+	if err := config.LoadConfigFromBytes([]byte(data)); err != nil {
+		tb.Fatalf("設定の読み込みに失敗しました: %v", err)
+	}
+}
+
+// TestProcessBatchesPause はバッチの間にだけ設定された時間休止し、休止時間が0の場合は待機しないことを確認します
+func TestProcessBatchesPause(t *testing.T) {
+	useWebPOnlyConfig(t)
+	server := newTestSSHServer(t)
+	root, files := newRemoteImageDir(t, 3)
+
+	tests := []struct {
+		name         string
+		batchSize    int
+		pauseSeconds int
+		want         []time.Duration
+	}{
+		{name: "1ファイルずつ", batchSize: 1, pauseSeconds: 2, want: []time.Duration{2 * time.Second, 2 * time.Second}},
+		{name: "最後のバッチが端数", batchSize: 2, pauseSeconds: 5, want: []time.Duration{5 * time.Second}},
+		{name: "1つのバッチに収まる", batchSize: 10, pauseSeconds: 5, want: nil},
+		{name: "休止しない", batchSize: 1, pauseSeconds: 0, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := server.remoteConfig()
+			cfg.RemotePath = root
+			cfg.BatchSize = tt.batchSize
+			cfg.BatchPauseSeconds = tt.pauseSeconds
+			client, err := NewClient(cfg)
+			if err != nil {
+				t.Fatalf("NewClient に失敗しました: %v", err)
+			}
+			defer client.Close()
+
+			var slept []time.Duration
+			s := &Service{config: cfg, sleep: func(d time.Duration) { slept = append(slept, d) }}
+			stats := config.NewConversionStats()
+			if err := s.processBatches(client, files, len(files), t.TempDir(), stats); err != nil {
+				t.Fatalf("processBatches に失敗しました: %v", err)
+			}
+
+			if !reflect.DeepEqual(slept, tt.want) {
+				t.Errorf("休止 = %v, want %v", slept, tt.want)
+			}
+			if got := stats.WebPSuccess.Load(); got != int64(len(files)) {
+				t.Errorf("WebPSuccess = %d, want %d", got, len(files))
+			}
+		})
+	}
+}
+
+// TestProcessFileBatchTempDir は複数のファイルを並行して処理しても、他のファイルの処理中に
+// 一時ディレクトリが削除されず（ダウンロードを再試行せず）、バッチの完了後に空のディレクトリが残らないことを確認します
+func TestProcessFileBatchTempDir(t *testing.T) {
+	useWebPOnlyConfig(t)
+	server := newTestSSHServer(t)
+	root, files := newRemoteImageDir(t, 8)
+
+	// 半分のファイルはサブディレクトリに置く
+	for i := 0; i < len(files); i += 2 {
+		moved := filepath.Join(root, "dir"+strconv.Itoa(i%4), filepath.Base(files[i]))
+		if err := os.MkdirAll(filepath.Dir(moved), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Rename(files[i], moved); err != nil {
+			t.Fatal(err)
+		}
+		files[i] = moved
+	}
+
+	cfg := server.remoteConfig()
+	cfg.RemotePath = root
+	cfg.ConcurrentTransfers = 4
+	client, err := NewClient(cfg)
+	if err != nil {
+		t.Fatalf("NewClient に失敗しました: %v", err)
+	}
+	defer client.Close()
+
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	tempDir := t.TempDir()
+	tracker := utils.NewMultiProgressTracker(len(files), "一時ディレクトリ")
+	tracker.SetOutput(io.Discard)
+	stats := config.NewConversionStats()
+	s := &Service{config: cfg}
+	if err := s.processFileBatch(client, files, tempDir, tracker, stats); err != nil {
+		t.Fatalf("processFileBatch に失敗しました: %v", err)
+	}
+
+	if got := stats.WebPSuccess.Load(); got != int64(len(files)) {
+		t.Errorf("WebPSuccess = %d, want %d", got, len(files))
+	}
+	if strings.Contains(logs.String(), "再試行") {
+		t.Errorf("処理中に再試行しました:\n%s", logs.String())
+	}
+	entries, err := os.ReadDir(tempDir)
+	if err != nil {
+		t.Fatalf("一時ディレクトリが削除されました: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("一時ディレクトリに残っています: %v", entries)
+	}
+}
+
+// BenchmarkProcessFileBatch は100ファイルのリモートディレクトリのダウンロード・変換・アップロードを、
+// 逐次処理（同時転送数1）と4ファイルの並行処理で比較します
+func BenchmarkProcessFileBatch(b *testing.B) {
+	useWebPOnlyConfig(b)
+
+	server := newTestSSHServer(b)
+	root, files := newRemoteImageDir(b, 100)
+
+	for _, bc := range []struct {
+		name        string
+		concurrency int
+	}{
+		{name: "serial", concurrency: 1},
+		{name: "parallel-4", concurrency: 4},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			cfg := server.remoteConfig()
+			cfg.RemotePath = root
+			cfg.ConcurrentTransfers = bc.concurrency
+			client, err := NewClient(cfg)
+			if err != nil {
+				b.Fatalf("NewClient に失敗しました: %v", err)
+			}
+			defer client.Close()
+
+			s := &Service{config: cfg}
+			tempDir := b.TempDir()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				tracker := utils.NewMultiProgressTracker(len(files), "ベンチマーク")
+				tracker.SetOutput(io.Discard)
+				stats := config.NewConversionStats()
+				if err := s.processFileBatch(client, files, tempDir, tracker, stats); err != nil {
+					b.Fatalf("processFileBatch に失敗しました: %v", err)
+				}
+				if got := stats.WebPSuccess.Load(); got != int64(len(files)) {
+					b.Fatalf("WebPSuccess = %d, want %d", got, len(files))
+				}
+			}
+		})
+	}
+}
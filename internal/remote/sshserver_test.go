@@ -0,0 +1,249 @@
+package remote
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/pem"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/223n/image-converter/internal/config"
+)
+
+// testSSHServer はテスト用にプロセス内で起動するSSH/SFTPサーバーです
+// SFTPはローカルのファイルシステムをそのまま公開するため、リモートパスには一時ディレクトリの絶対パスを使用します
+type testSSHServer struct {
+	t        testing.TB
+	listener net.Listener
+	config   *ssh.ServerConfig
+	keyPath  string
+
+	// exec はコマンド実行要求を処理します。nil の場合はシェルを使用できないサーバーとしてコマンド実行を拒否します
+	exec func(command string) (output string, status uint32)
+
+	// sftpHandlers を指定した場合は、ローカルのファイルシステムの代わりにこのハンドラーでSFTPの要求を処理します
+	sftpHandlers *sftp.Handlers
+
+	// handshakeDelay はハンドシェイク前に待機する時間です（接続に時間がかかるサーバーの再現に使用します）
+	handshakeDelay atomic.Int64
+
+	// idleTimeout を設定した場合は、クライアントからの受信がこの時間ない接続を切断します（ファイアウォールによるアイドル接続の切断の再現に使用します）
+	idleTimeout atomic.Int64
+
+	// accepted は受け付けた接続の数です
+	accepted atomic.Int32
+
+	mu    sync.Mutex
+	conns []net.Conn
+}
+
+// newTestSSHServer はテスト用のSSHサーバーを起動し、テスト（ベンチマーク）終了時に停止します
+func newTestSSHServer(t testing.TB) *testSSHServer {
+	t.Helper()
+
+	hostKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("ホスト鍵の生成に失敗しました: %v", err)
+	}
+	hostSigner, err := ssh.NewSignerFromKey(hostKey)
+	if err != nil {
+		t.Fatalf("ホスト鍵の読み込みに失敗しました: %v", err)
+	}
+
+	// クライアントの秘密鍵は key_path で読み込めるようPEM形式で保存する
+	clientKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("クライアント鍵の生成に失敗しました: %v", err)
+	}
+	der, err := x509.MarshalECPrivateKey(clientKey)
+	if err != nil {
+		t.Fatalf("クライアント鍵の変換に失敗しました: %v", err)
+	}
+	keyPath := filepath.Join(t.TempDir(), "id_ecdsa")
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		t.Fatalf("クライアント鍵の保存に失敗しました: %v", err)
+	}
+	clientPub, err := ssh.NewPublicKey(&clientKey.PublicKey)
+	if err != nil {
+		t.Fatalf("公開鍵の作成に失敗しました: %v", err)
+	}
+
+	serverConfig := &ssh.ServerConfig{
+		PublicKeyCallback: func(_ ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if string(key.Marshal()) == string(clientPub.Marshal()) {
+				return nil, nil
+			}
+			return nil, os.ErrPermission
+		},
+	}
+	serverConfig.AddHostKey(hostSigner)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("待ち受けを開始できません: %v", err)
+	}
+
+	s := &testSSHServer{t: t, listener: listener, config: serverConfig, keyPath: keyPath}
+	go s.serve()
+	t.Cleanup(s.close)
+	return s
+}
+
+// remoteConfig はこのサーバーに接続するリモート設定を返します
+func (s *testSSHServer) remoteConfig() *config.RemoteConfig {
+	addr := s.listener.Addr().(*net.TCPAddr)
+	return &config.RemoteConfig{
+		Enabled:             true,
+		Host:                addr.IP.String(),
+		Port:                addr.Port,
+		User:                "test",
+		KeyPath:             s.keyPath,
+		Timeout:             5,
+		ConcurrentTransfers: 2,
+	}
+}
+
+// dial はこのサーバーにSSH接続します
+func (s *testSSHServer) dial() *ssh.Client {
+	s.t.Helper()
+
+	cfg := s.remoteConfig()
+	clientConfig, err := createSSHClientConfig(cfg)
+	if err != nil {
+		s.t.Fatalf("SSHクライアント設定の作成に失敗しました: %v", err)
+	}
+	client, err := ssh.Dial("tcp", s.listener.Addr().String(), clientConfig)
+	if err != nil {
+		s.t.Fatalf("SSH接続に失敗しました: %v", err)
+	}
+	s.t.Cleanup(func() { client.Close() })
+	return client
+}
+
+// dropConnections は確立済みのすべての接続を切断します（回線断の再現に使用します）
+func (s *testSSHServer) dropConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, conn := range s.conns {
+		conn.Close()
+	}
+	s.conns = nil
+}
+
+// close はサーバーを停止します
+func (s *testSSHServer) close() {
+	s.listener.Close()
+	s.dropConnections()
+}
+
+// serve は接続を受け付けます
+func (s *testSSHServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.accepted.Add(1)
+		s.mu.Lock()
+		s.conns = append(s.conns, conn)
+		s.mu.Unlock()
+		go s.handleConn(conn)
+	}
+}
+
+// handleConn はSSHのハンドシェイクを行い、セッションチャネルを処理します
+func (s *testSSHServer) handleConn(conn net.Conn) {
+	time.Sleep(time.Duration(s.handshakeDelay.Load()))
+	if timeout := time.Duration(s.idleTimeout.Load()); timeout > 0 {
+		conn = &idleConn{Conn: conn, timeout: timeout}
+	}
+	_, channels, requests, err := ssh.NewServerConn(conn, s.config)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	go ssh.DiscardRequests(requests)
+
+	for newChannel := range channels {
+		if newChannel.ChannelType() != "session" {
+			newChannel.Reject(ssh.UnknownChannelType, "unsupported channel type")
+			continue
+		}
+		channel, chanRequests, err := newChannel.Accept()
+		if err != nil {
+			continue
+		}
+		go s.handleSession(channel, chanRequests)
+	}
+}
+
+// idleConn は受信のたびに読み込みの期限を延長し、timeout の間受信がない場合に読み込みを失敗させる接続です
+type idleConn struct {
+	net.Conn
+	timeout time.Duration
+}
+
+// Read は読み込みの期限を延長してから読み込みます
+func (c *idleConn) Read(p []byte) (int, error) {
+	if err := c.Conn.SetReadDeadline(time.Now().Add(c.timeout)); err != nil {
+		return 0, err
+	}
+	return c.Conn.Read(p)
+}
+
+// handleSession は sftp サブシステムとコマンド実行の要求を処理します
+func (s *testSSHServer) handleSession(channel ssh.Channel, requests <-chan *ssh.Request) {
+	defer channel.Close()
+
+	for req := range requests {
+		switch {
+		case req.Type == "subsystem" && payloadString(req.Payload) == "sftp":
+			req.Reply(true, nil)
+			if s.sftpHandlers != nil {
+				server := sftp.NewRequestServer(channel, *s.sftpHandlers)
+				server.Serve()
+				server.Close()
+				return
+			}
+			server, err := sftp.NewServer(channel)
+			if err != nil {
+				return
+			}
+			server.Serve()
+			server.Close()
+			return
+		case req.Type == "exec" && s.exec != nil:
+			req.Reply(true, nil)
+			output, status := s.exec(payloadString(req.Payload))
+			channel.Write([]byte(output))
+			channel.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{status}))
+			return
+		default:
+			req.Reply(false, nil)
+		}
+	}
+}
+
+// payloadString はSSHの要求に含まれる文字列（長さ付き）を取り出します
+func payloadString(payload []byte) string {
+	if len(payload) < 4 {
+		return ""
+	}
+	n := binary.BigEndian.Uint32(payload)
+	if int(n) > len(payload)-4 {
+		return ""
+	}
+	return string(payload[4 : 4+n])
+}
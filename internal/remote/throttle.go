@@ -0,0 +1,81 @@
+package remote
+
+import (
+	"context"
+	"io"
+
+	"golang.org/x/time/rate"
+)
+
+// throttleMinChunk は帯域制限時に一度に読み書きする最小バイト数です
+const throttleMinChunk = 1024
+
+// newBandwidthLimiter は転送速度を kbps（KB/秒）に制限する rate.Limiter を作成します
+// Client の全転送（ダウンロード・アップロード、並列転送）で共有し、合計の転送速度を制限します
+// 待機を細かく分けるため、バースト（1回に読み書きする上限）は約0.1秒分とします
+// kbps が0以下の場合は制限しないため nil を返します
+func newBandwidthLimiter(kbps int) *rate.Limiter {
+	if kbps <= 0 {
+		return nil
+	}
+	bytesPerSec := kbps * 1024
+	return rate.NewLimiter(rate.Limit(bytesPerSec), max(throttleMinChunk, bytesPerSec/10))
+}
+
+// throttledReader は読み込み速度を制限する io.Reader です
+type throttledReader struct {
+	r       io.Reader
+	limiter *rate.Limiter
+}
+
+// newThrottledReader は読み込み速度を limiter で制限した Reader を返します
+// limiter が nil の場合は r をそのまま返します
+func newThrottledReader(r io.Reader, limiter *rate.Limiter) io.Reader {
+	if limiter == nil {
+		return r
+	}
+	return &throttledReader{r: r, limiter: limiter}
+}
+
+// Read は制限速度に合わせて待機しながら読み込みます
+func (tr *throttledReader) Read(p []byte) (int, error) {
+	if len(p) > tr.limiter.Burst() {
+		p = p[:tr.limiter.Burst()]
+	}
+	n, err := tr.r.Read(p)
+	if n > 0 {
+		// n はバースト以下のため、WaitN はキャンセルされない限り失敗しない
+		tr.limiter.WaitN(context.Background(), n)
+	}
+	return n, err
+}
+
+// throttledWriter は書き込み速度を制限する io.Writer です
+type throttledWriter struct {
+	w       io.Writer
+	limiter *rate.Limiter
+}
+
+// newThrottledWriter は書き込み速度を limiter で制限した Writer を返します
+// limiter が nil の場合は w をそのまま返します
+func newThrottledWriter(w io.Writer, limiter *rate.Limiter) io.Writer {
+	if limiter == nil {
+		return w
+	}
+	return &throttledWriter{w: w, limiter: limiter}
+}
+
+// Write は制限速度に合わせて分割・待機しながら書き込みます
+func (tw *throttledWriter) Write(p []byte) (int, error) {
+	written := 0
+	for written < len(p) {
+		chunk := p[written:min(len(p), written+tw.limiter.Burst())]
+		tw.limiter.WaitN(context.Background(), len(chunk))
+		n, err := tw.w.Write(chunk)
+		written += n
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
@@ -0,0 +1,85 @@
+package remote
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestThrottledTransfersShareLimiter は同じ limiter を使う読み込みと書き込みの合計が制限速度に収まることを確認します
+func TestThrottledTransfersShareLimiter(t *testing.T) {
+	const kbps = 100
+	const size = 20 * 1024
+	limiter := newBandwidthLimiter(kbps)
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if _, err := newThrottledWriter(io.Discard, limiter).Write(make([]byte, size)); err != nil {
+				t.Errorf("書き込みに失敗しました: %v", err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if _, err := io.Copy(io.Discard, newThrottledReader(bytes.NewReader(make([]byte, size)), limiter)); err != nil {
+				t.Errorf("読み込みに失敗しました: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// 合計 80KB のうち、最初のバースト（約10KB）を除いた分は 100KB/秒 で待機する
+	total := 4 * size
+	want := time.Duration(float64(total-limiter.Burst()) / float64(kbps*1024) * float64(time.Second))
+	if elapsed := time.Since(start); elapsed < want*9/10 {
+		t.Errorf("転送時間 = %v, 共有した制限では %v 以上を期待しました", elapsed, want)
+	}
+}
+
+// TestNewBandwidthLimiterUnlimited は制限しない場合に元の Reader・Writer をそのまま使用することを確認します
+func TestNewBandwidthLimiterUnlimited(t *testing.T) {
+	limiter := newBandwidthLimiter(0)
+	if limiter != nil {
+		t.Fatalf("newBandwidthLimiter(0) = %v, want nil", limiter)
+	}
+
+	r := bytes.NewReader(nil)
+	if got := newThrottledReader(r, limiter); got != io.Reader(r) {
+		t.Errorf("newThrottledReader = %T, want 元の Reader", got)
+	}
+	var w bytes.Buffer
+	if got := newThrottledWriter(&w, limiter); got != io.Writer(&w) {
+		t.Errorf("newThrottledWriter = %T, want 元の Writer", got)
+	}
+}
+
+// BenchmarkThrottledWriter は帯域制限ごとの書き込み速度を計測します
+// 1回の書き込みは 16KB で、制限した場合は MB/s が制限速度に近い値になります
+func BenchmarkThrottledWriter(b *testing.B) {
+	data := make([]byte, 16*1024)
+
+	for _, bc := range []struct {
+		name string
+		kbps int
+	}{
+		{name: "100KBps", kbps: 100},
+		{name: "1MBps", kbps: 1024},
+		{name: "unlimited", kbps: 0},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			w := newThrottledWriter(io.Discard, newBandwidthLimiter(bc.kbps))
+			b.SetBytes(int64(len(data)))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := w.Write(data); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package remote
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/pkg/sftp"
+)
+
+// localPNG はアップロード元の有効なPNGファイルを作成し、そのパスと内容を返します
+// アップロード前に画像として検証されるため、アップロードのテストでは有効な画像を使用します
+func localPNG(t *testing.T) (string, []byte) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 4, 4))); err != nil {
+		t.Fatalf("PNGのエンコードに失敗しました: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "photo.png")
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("ローカルファイルの作成に失敗しました: %v", err)
+	}
+	return path, buf.Bytes()
+}
+
+// truncatingWriter は最初の remaining 回のアップロードを、半分のサイズで書き込む FileWriter です
+type truncatingWriter struct {
+	sftp.FileWriter
+	remaining atomic.Int32
+	calls     atomic.Int32
+}
+
+// Filewrite はアップロード先のファイルを開きます
+func (w *truncatingWriter) Filewrite(r *sftp.Request) (io.WriterAt, error) {
+	w.calls.Add(1)
+	dst, err := w.FileWriter.Filewrite(r)
+	if err != nil || w.remaining.Add(-1) < 0 {
+		return dst, err
+	}
+	return &halfWriter{dst: dst}, nil
+}
+
+// halfWriter は書き込まれた内容を保持し、閉じる時に前半だけを書き込みます
+type halfWriter struct {
+	dst io.WriterAt
+	mu  sync.Mutex
+	buf []byte
+}
+
+// WriteAt は書き込まれた内容を保持します
+func (w *halfWriter) WriteAt(p []byte, off int64) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if end := int(off) + len(p); end > len(w.buf) {
+		w.buf = append(w.buf, make([]byte, end-len(w.buf))...)
+	}
+	copy(w.buf[off:], p)
+	return len(p), nil
+}
+
+// Close は保持した内容の前半だけを書き込みます
+func (w *halfWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, err := w.dst.WriteAt(w.buf[:len(w.buf)/2], 0); err != nil {
+		return err
+	}
+	if closer, ok := w.dst.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// newTruncatingServer は最初の truncated 回のアップロードを半分のサイズで書き込む、メモリ上のSFTPサーバーを起動します
+func newTruncatingServer(t *testing.T, truncated int32) (*testSSHServer, *truncatingWriter) {
+	t.Helper()
+
+	handlers := sftp.InMemHandler()
+	writer := &truncatingWriter{FileWriter: handlers.FilePut}
+	writer.remaining.Store(truncated)
+	handlers.FilePut = writer
+
+	server := newTestSSHServer(t)
+	server.sftpHandlers = &handlers
+	return server, writer
+}
+
+// remoteSize はリモートファイルのサイズを返します
+func remoteSize(t *testing.T, client *Client, remotePath string) int64 {
+	t.Helper()
+
+	pool, sc, err := client.acquireSFTP()
+	if err != nil {
+		t.Fatalf("acquireSFTP に失敗しました: %v", err)
+	}
+	defer pool.Release(sc)
+
+	info, err := sc.Stat(remotePath)
+	if err != nil {
+		t.Fatalf("リモートファイルの情報を取得できません: %v", err)
+	}
+	return info.Size()
+}
+
+// TestUploadFileRetriesTruncatedUpload はサーバーが半分のサイズしか書き込まなかった場合に、サイズの不一致を検出して再試行することを確認します
+func TestUploadFileRetriesTruncatedUpload(t *testing.T) {
+	server, writer := newTruncatingServer(t, 1)
+	client := newTestClient(t, server)
+	client.config.VerifyUploads = true
+
+	localPath, data := localPNG(t)
+	if err := client.UploadFile(localPath, "/uploads/photo.png"); err != nil {
+		t.Fatalf("UploadFile に失敗しました: %v", err)
+	}
+
+	if got := writer.calls.Load(); got != 2 {
+		t.Errorf("アップロードの回数 = %d, want 2", got)
+	}
+	if got := remoteSize(t, client, "/uploads/photo.png"); got != int64(len(data)) {
+		t.Errorf("リモートファイルのサイズ = %d, want %d", got, len(data))
+	}
+}
+
+// TestUploadFileWithoutVerification は verify_uploads が無効な場合に、サイズを確認しないことを確認します
+func TestUploadFileWithoutVerification(t *testing.T) {
+	server, writer := newTruncatingServer(t, 1)
+	client := newTestClient(t, server)
+	client.config.VerifyUploads = false
+
+	localPath, data := localPNG(t)
+	if err := client.UploadFile(localPath, "/uploads/photo.png"); err != nil {
+		t.Fatalf("UploadFile に失敗しました: %v", err)
+	}
+
+	if got := writer.calls.Load(); got != 1 {
+		t.Errorf("アップロードの回数 = %d, want 1", got)
+	}
+	if got := remoteSize(t, client, "/uploads/photo.png"); got != int64(len(data)/2) {
+		t.Errorf("リモートファイルのサイズ = %d, want %d", got, len(data)/2)
+	}
+}
+
+func TestVerifyUpload(t *testing.T) {
+	tests := []struct {
+		name         string
+		expectedSize int64
+		wantErr      bool
+	}{
+		{name: "サイズが一致する", expectedSize: 19},
+		{name: "リモートが小さい", expectedSize: 38, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestSSHServer(t)
+			client := newTestClient(t, server)
+
+			remotePath, _ := uploadedFile(t)
+			pool, sc, err := client.acquireSFTP()
+			if err != nil {
+				t.Fatalf("acquireSFTP に失敗しました: %v", err)
+			}
+			defer pool.Release(sc)
+
+			err = client.verifyUpload(pool, sc, remotePath, tt.expectedSize)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("verifyUpload のエラー = %v, エラーの有無 %v を期待しました", err, tt.wantErr)
+			}
+			// 一致しない場合は不完全なリモートファイルを削除する
+			_, statErr := os.Stat(remotePath)
+			if removed := os.IsNotExist(statErr); removed != tt.wantErr {
+				t.Errorf("リモートファイルの削除 = %v, want %v", removed, tt.wantErr)
+			}
+		})
+	}
+}
@@ -7,6 +7,9 @@ package server
 import (
 	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/223n/image-converter/internal/config"
 )
@@ -50,19 +53,54 @@ func (s *Service) Start() error {
 	// いずれかのサーバーが起動している場合
 	if config.IsFTPEnabled() || config.IsSSHEnabled() {
 		fmt.Println("サーバーが稼働中です。Ctrl+Cで終了してください。")
-		// 無限待機
-		select {}
+		// シグナルを受けるまで待機
+		return s.waitForSignals()
 	}
 
 	return nil
 }
 
-// Stop はすべてのサーバーを停止します
+// waitForSignals はシグナルを待機し、SIGHUPで設定を再読み込み、SIGINT/SIGTERMでサーバーを停止します
+func (s *Service) waitForSignals() error {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	for sig := range signals {
+		if sig == syscall.SIGHUP {
+			s.reloadConfig()
+			continue
+		}
+
+		log.Printf("シグナル %v を受信しました。サーバーを停止します", sig)
+		return s.Stop()
+	}
+
+	return nil
+}
+
+// reloadConfig は設定ファイルを再読み込みします
+// 失敗した場合は現在の設定のまま稼働を続けます
+func (s *Service) reloadConfig() {
+	log.Printf("SIGHUPを受信しました。設定ファイルを再読み込みします")
+
+	if err := config.ReloadConfig(); err != nil {
+		log.Printf("設定ファイルの再読み込みに失敗しました（現在の設定を維持します）: %v", err)
+		return
+	}
+
+	cfg := config.GetConfig()
+	log.Printf("設定ファイルを再読み込みしました（WebP品質: %d, AVIF品質: %d, AVIF速度: %d）",
+		cfg.Conversion.WebP.Quality, cfg.Conversion.AVIF.Quality, cfg.Conversion.AVIF.Speed)
+}
+
+// Stop は起動しているすべてのサーバーを停止します
+// SIGHUPで設定が再読み込みされていても、起動時の設定ではなく実際に起動したサーバーを停止します
 func (s *Service) Stop() error {
 	var ftpErr, sshErr error
 
 	// FTPサーバーの停止
-	if config.IsFTPEnabled() {
+	if s.ftpService.IsRunning() {
 		ftpErr = s.ftpService.Stop()
 		if ftpErr != nil {
 			log.Printf("FTPサーバーの停止に失敗しました: %v", ftpErr)
@@ -70,7 +108,7 @@ func (s *Service) Stop() error {
 	}
 
 	// SSHサーバーの停止
-	if config.IsSSHEnabled() {
+	if s.sshService.IsRunning() {
 		sshErr = s.sshService.Stop()
 		if sshErr != nil {
 			log.Printf("SSHサーバーの停止に失敗しました: %v", sshErr)
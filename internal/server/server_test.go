@@ -0,0 +1,90 @@
+package server
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/223n/image-converter/internal/config"
+)
+
+// startDummyProcess はサーバーの代わりに停止されるまで待機するプロセスを起動します
+func startDummyProcess(t *testing.T) *exec.Cmd {
+	t.Helper()
+
+	cmd := exec.Command("sleep", "60")
+	if err := cmd.Start(); err != nil {
+		t.Skipf("sleep コマンドを起動できません: %v", err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+	})
+	return cmd
+}
+
+// waitExited はプロセスが終了するまで待機し、時間内に終了したかどうかを返します
+func waitExited(cmd *exec.Cmd) bool {
+	done := make(chan struct{})
+	go func() {
+		cmd.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(5 * time.Second):
+		return false
+	}
+}
+
+// TestStopAfterReload はSIGHUPでサーバーを無効にする設定へ再読み込みした後も、
+// 起動済みのサーバーを停止することを確認します
+func TestStopAfterReload(t *testing.T) {
+	t.Cleanup(func() { config.LoadConfigFromBytes(nil) })
+
+	path := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(path, []byte("ftp:\n  enabled: true\nssh:\n  enabled: true\n"), 0644); err != nil {
+		t.Fatalf("設定ファイルの作成に失敗しました: %v", err)
+	}
+	if err := config.LoadConfig(path); err != nil {
+		t.Fatalf("設定ファイルの読み込みに失敗しました: %v", err)
+	}
+
+	// pure-ftpd と sshd の代わりにダミーのプロセスを起動済みとして扱う
+	s := NewService()
+	ftpCmd := startDummyProcess(t)
+	s.ftpService.cmd, s.ftpService.running = ftpCmd, true
+	sshCmd := startDummyProcess(t)
+	s.sshService.cmd, s.sshService.running = sshCmd, true
+
+	if err := os.WriteFile(path, []byte("ftp:\n  enabled: false\nssh:\n  enabled: false\n"), 0644); err != nil {
+		t.Fatalf("設定ファイルの更新に失敗しました: %v", err)
+	}
+	s.reloadConfig()
+	if config.IsFTPEnabled() || config.IsSSHEnabled() {
+		t.Fatal("再読み込み後もサーバーが有効になっています")
+	}
+
+	if err := s.Stop(); err != nil {
+		t.Fatalf("Stop に失敗しました: %v", err)
+	}
+	if s.ftpService.IsRunning() || s.sshService.IsRunning() {
+		t.Errorf("停止後の状態 FTP=%v SSH=%v, どちらも停止を期待しました",
+			s.ftpService.IsRunning(), s.sshService.IsRunning())
+	}
+	if !waitExited(ftpCmd) {
+		t.Error("FTPサーバーのプロセスが終了していません")
+	}
+	if !waitExited(sshCmd) {
+		t.Error("SSHサーバーのプロセスが終了していません")
+	}
+
+	t.Run("起動していないサーバーは停止しない", func(t *testing.T) {
+		if err := NewService().Stop(); err != nil {
+			t.Errorf("Stop = %v, want nil", err)
+		}
+	})
+}
@@ -0,0 +1,129 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/223n/image-converter/internal/config"
+)
+
+// LogEntry は付加情報（フィールド）を持つ1件のログを表します
+type LogEntry struct {
+	Level   LogLevel
+	Fields  map[string]interface{}
+	Message string
+}
+
+// Text は "[INFO] メッセージ key=value ..." 形式の文字列を返します
+// フィールドはキーの昇順に並べ、空白や引用符を含む値は引用符で囲みます
+func (e LogEntry) Text() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "[%s] %s", e.Level.String(), e.Message)
+	for _, key := range sortedKeys(e.Fields) {
+		fmt.Fprintf(&sb, " %s=%s", key, formatFieldValue(e.Fields[key]))
+	}
+	return sb.String()
+}
+
+// JSON は時刻・レベル・メッセージとフィールドを1つのJSONオブジェクトにして返します
+// フィールドのキーが time, level, message と重複する場合はフィールド側を無視します
+func (e LogEntry) JSON(timestamp time.Time) ([]byte, error) {
+	obj := make(map[string]interface{}, len(e.Fields)+3)
+	for key, value := range e.Fields {
+		if err, ok := value.(error); ok {
+			value = err.Error()
+		}
+		obj[key] = value
+	}
+	obj["time"] = timestamp.Format(time.RFC3339)
+	obj["level"] = e.Level.String()
+	obj["message"] = e.Message
+	return json.Marshal(obj)
+}
+
+// writeLogEntry はログの出力形式に応じてエントリーを出力します
+// JSON形式の場合は標準ロガーの日時やファイル名の接頭辞を付けずに出力します
+func writeLogEntry(format string, entry LogEntry) {
+	if format != config.LogFormatJSON {
+		log.Print(entry.Text())
+		return
+	}
+
+	data, err := entry.JSON(time.Now())
+	if err != nil {
+		// JSONにできない値が含まれる場合はテキスト形式で出力する
+		log.Print(entry.Text())
+		return
+	}
+	io.WriteString(log.Writer(), string(data)+"\n")
+}
+
+// sortedKeys はフィールドのキーを昇順で返します
+func sortedKeys(fields map[string]interface{}) []string {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// formatFieldValue はテキスト形式で出力するフィールドの値を文字列にします
+func formatFieldValue(value interface{}) string {
+	s := fmt.Sprint(value)
+	if s == "" || strings.ContainsAny(s, " \t\n\"=") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// FieldLogger は共通のフィールドを付けてログを出力します
+type FieldLogger struct {
+	manager *LogManager
+	fields  map[string]interface{}
+}
+
+// WithFields は指定したフィールドを付けてログを出力する FieldLogger を返します
+// フィールドはコピーして保持するため、呼び出し後に fields を変更しても影響しません
+func (lm *LogManager) WithFields(fields map[string]interface{}) *FieldLogger {
+	copied := make(map[string]interface{}, len(fields))
+	for key, value := range fields {
+		copied[key] = value
+	}
+	return &FieldLogger{manager: lm, fields: copied}
+}
+
+// Debug はデバッグメッセージをフィールド付きでログに出力します
+func (fl *FieldLogger) Debug(msg string) {
+	fl.log(LogLevelDebug, msg)
+}
+
+// Info は情報メッセージをフィールド付きでログに出力します
+func (fl *FieldLogger) Info(msg string) {
+	fl.log(LogLevelInfo, msg)
+}
+
+// Warning は警告メッセージをフィールド付きでログに出力します
+func (fl *FieldLogger) Warning(msg string) {
+	fl.log(LogLevelWarn, msg)
+}
+
+// Error はエラーメッセージをフィールド付きでログに出力します
+func (fl *FieldLogger) Error(msg string) {
+	fl.log(LogLevelError, msg)
+}
+
+// log は設定されたレベル以上の場合のみフィールド付きでログを出力します
+func (fl *FieldLogger) log(level LogLevel, msg string) {
+	// Info などの呼び出し元のモジュールのレベルを使用する
+	if level < fl.manager.levelFor(2) {
+		return
+	}
+	writeLogEntry(fl.manager.format, LogEntry{Level: level, Fields: fl.fields, Message: msg})
+}
@@ -0,0 +1,145 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/223n/image-converter/internal/config"
+)
+
+// captureLog はテストの間、標準ロガーの出力を日時などの接頭辞なしでバッファに切り替えます
+func captureLog(t *testing.T) *bytes.Buffer {
+	t.Helper()
+
+	var buf bytes.Buffer
+	flags := log.Flags()
+	log.SetOutput(&buf)
+	log.SetFlags(0)
+	t.Cleanup(func() {
+		log.SetOutput(os.Stderr)
+		log.SetFlags(flags)
+	})
+	return &buf
+}
+
+// fileProcessedEntry は local.FileProcessor が出力するものと同じフィールドを持つログです
+func fileProcessedEntry() LogEntry {
+	return LogEntry{
+		Level: LogLevelInfo,
+		Fields: map[string]interface{}{
+			"file":              "/images/my photo.png",
+			"format":            "webp,avif",
+			"duration_ms":       int64(12),
+			"output_size_bytes": int64(2048),
+		},
+		Message: "ファイル処理完了",
+	}
+}
+
+func TestLogEntryText(t *testing.T) {
+	want := `[INFO] ファイル処理完了 duration_ms=12 file="/images/my photo.png" format=webp,avif output_size_bytes=2048`
+	if got := fileProcessedEntry().Text(); got != want {
+		t.Errorf("Text =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestLogEntryJSON(t *testing.T) {
+	timestamp := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	data, err := fileProcessedEntry().JSON(timestamp)
+	if err != nil {
+		t.Fatalf("JSON に失敗しました: %v", err)
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("出力がJSONではありません: %s: %v", data, err)
+	}
+	want := map[string]interface{}{
+		"time":              "2024-01-02T03:04:05Z",
+		"level":             "INFO",
+		"message":           "ファイル処理完了",
+		"file":              "/images/my photo.png",
+		"format":            "webp,avif",
+		"duration_ms":       float64(12),
+		"output_size_bytes": float64(2048),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("JSON = %v, want %v", got, want)
+	}
+}
+
+// TestLogEntryJSONReservedKeys はフィールドのキーが time, level, message と重複しても上書きされないことを確認します
+func TestLogEntryJSONReservedKeys(t *testing.T) {
+	entry := LogEntry{Level: LogLevelWarn, Fields: map[string]interface{}{"level": "DEBUG", "message": "x"}, Message: "本文"}
+	data, err := entry.JSON(time.Now())
+	if err != nil {
+		t.Fatalf("JSON に失敗しました: %v", err)
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("出力がJSONではありません: %v", err)
+	}
+	if got["level"] != "WARN" || got["message"] != "本文" {
+		t.Errorf("予約されたキーが上書きされました: %v", got)
+	}
+}
+
+// TestFieldLoggerFormats は同じログをテキスト形式とJSON形式で出力し、同じ内容になることを確認します
+func TestFieldLoggerFormats(t *testing.T) {
+	entry := fileProcessedEntry()
+
+	t.Run("テキスト形式", func(t *testing.T) {
+		buf := captureLog(t)
+		lm := &LogManager{level: LogLevelInfo, format: config.LogFormatText}
+		lm.WithFields(entry.Fields).Info(entry.Message)
+
+		if got := strings.TrimSpace(buf.String()); got != entry.Text() {
+			t.Errorf("出力 = %q, want %q", got, entry.Text())
+		}
+	})
+
+	t.Run("JSON形式", func(t *testing.T) {
+		buf := captureLog(t)
+		lm := &LogManager{level: LogLevelInfo, format: config.LogFormatJSON}
+		lm.WithFields(entry.Fields).Info(entry.Message)
+
+		var got map[string]interface{}
+		if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+			t.Fatalf("出力がJSONではありません: %q: %v", buf.String(), err)
+		}
+		if got["message"] != entry.Message || got["level"] != "INFO" || got["file"] != entry.Fields["file"] ||
+			got["format"] != entry.Fields["format"] || got["duration_ms"] != float64(12) || got["output_size_bytes"] != float64(2048) {
+			t.Errorf("出力 = %v", got)
+		}
+	})
+
+	t.Run("レベル未満は出力しない", func(t *testing.T) {
+		buf := captureLog(t)
+		lm := &LogManager{level: LogLevelWarn, format: config.LogFormatText}
+		lm.WithFields(entry.Fields).Info(entry.Message)
+
+		if buf.Len() != 0 {
+			t.Errorf("レベル未満のログが出力されました: %q", buf.String())
+		}
+	})
+}
+
+// TestWithFieldsCopiesFields は WithFields の呼び出し後にフィールドを変更しても、出力に影響しないことを確認します
+func TestWithFieldsCopiesFields(t *testing.T) {
+	buf := captureLog(t)
+	fields := map[string]interface{}{"file": "a.png"}
+	logger := (&LogManager{level: LogLevelInfo}).WithFields(fields)
+	fields["file"] = "b.png"
+
+	logger.Info("完了")
+	if got := strings.TrimSpace(buf.String()); got != "[INFO] 完了 file=a.png" {
+		t.Errorf("出力 = %q, want %q", got, "[INFO] 完了 file=a.png")
+	}
+}
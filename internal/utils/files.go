@@ -3,6 +3,7 @@ package utils
 import (
 	"fmt"
 	"image"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
@@ -138,6 +139,57 @@ func CleanupFiles(filePaths ...string) {
 	}
 }
 
+// MoveFile はファイルを移動します。移動先のディレクトリは必要に応じて作成します
+// 異なるファイルシステム間の移動ではコピー後に元ファイルを削除します
+func MoveFile(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return fmt.Errorf("移動先ディレクトリの作成に失敗しました: %v", err)
+	}
+
+	// 同一ファイルシステム内であればリネームで移動
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	// リネームできない場合はコピーしてから削除
+	if err := copyFile(src, dst); err != nil {
+		return fmt.Errorf("ファイルのコピーに失敗しました: %v", err)
+	}
+
+	if err := os.Remove(src); err != nil {
+		return fmt.Errorf("移動元ファイルの削除に失敗しました: %v", err)
+	}
+
+	return nil
+}
+
+// copyFile はファイルの内容と権限をコピーします
+func copyFile(src, dst string) error {
+	srcFile, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer srcFile.Close()
+
+	info, err := srcFile.Stat()
+	if err != nil {
+		return err
+	}
+
+	dstFile, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(dstFile, srcFile); err != nil {
+		dstFile.Close()
+		os.Remove(dst)
+		return err
+	}
+
+	return dstFile.Close()
+}
+
 // GetFileSize はファイルのサイズをバイト単位で返します
 func GetFileSize(path string) (int64, error) {
 	fileInfo, err := os.Stat(path)
@@ -1,11 +1,16 @@
 package utils
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"path"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/223n/image-converter/internal/config"
@@ -47,15 +52,53 @@ func (l LogLevel) String() string {
 
 // LogManager はログ管理機能を提供します
 type LogManager struct {
-	level LogLevel
+	level  LogLevel
+	format string // text または json
+
+	// ファイルごとの情報ログ（LogFileInfo）を出力するかどうか
+	perFile bool
+
+	// モジュール（パッケージ名）ごとのレベル。該当するモジュールでは level の代わりに使用します
+	moduleMu     sync.RWMutex
+	moduleLevels map[string]LogLevel
 }
 
 // NewLogManager は新しいLogManagerインスタンスを作成します
 func NewLogManager() *LogManager {
 	cfg := config.GetConfig()
 	return &LogManager{
-		level: stringToLogLevel(cfg.Logging.Level),
+		level:        stringToLogLevel(cfg.Logging.Level),
+		format:       cfg.Logging.Format,
+		perFile:      cfg.Logging.PerFile,
+		moduleLevels: parseModuleLevels(cfg.Logging.ModuleLevels),
+	}
+}
+
+// SetModuleLevel はモジュール（パッケージ名。例: remote, converter）のログレベルを設定します
+func (lm *LogManager) SetModuleLevel(module string, level LogLevel) {
+	lm.moduleMu.Lock()
+	defer lm.moduleMu.Unlock()
+
+	if lm.moduleLevels == nil {
+		lm.moduleLevels = make(map[string]LogLevel)
+	}
+	lm.moduleLevels[module] = level
+}
+
+// levelFor は呼び出し元のモジュールに適用されるログレベルを返します
+// skip は levelFor の呼び出し元から数えた、ログを出力したコードまでのスタックの深さです
+func (lm *LogManager) levelFor(skip int) LogLevel {
+	lm.moduleMu.RLock()
+	defer lm.moduleMu.RUnlock()
+
+	// モジュールごとの設定がなければ呼び出し元を調べずに済ませる
+	if len(lm.moduleLevels) == 0 {
+		return lm.level
 	}
+	if level, ok := lm.moduleLevels[callerModule(skip+1)]; ok {
+		return level
+	}
+	return lm.level
 }
 
 // LogInfo は情報メッセージをログに出力します
@@ -63,6 +106,15 @@ func (lm *LogManager) LogInfo(format string, args ...interface{}) {
 	lm.logWithLevel(LogLevelInfo, format, args...)
 }
 
+// LogFileInfo は変換成功など、1ファイルごとに出力される情報メッセージをログに出力します
+// logging.per_file が無効な場合（-summary-only）は出力せず、集計結果などの LogInfo だけを残します
+func (lm *LogManager) LogFileInfo(format string, args ...interface{}) {
+	if !lm.perFile {
+		return
+	}
+	lm.logWithLevel(LogLevelInfo, format, args...)
+}
+
 // LogWarning は警告メッセージをログに出力します
 func (lm *LogManager) LogWarning(format string, args ...interface{}) {
 	lm.logWithLevel(LogLevelWarn, format, args...)
@@ -80,16 +132,31 @@ func (lm *LogManager) LogDebug(format string, args ...interface{}) {
 
 // logWithLevel は指定されたレベルでメッセージをログに出力します
 func (lm *LogManager) logWithLevel(level LogLevel, format string, args ...interface{}) {
-	// 設定されたレベル以上の場合のみログを出力
-	if level >= lm.level {
+	// 設定されたレベル以上の場合のみログを出力（LogInfo などの呼び出し元のモジュールのレベルを使用）
+	if level >= lm.levelFor(2) {
 		message := fmt.Sprintf(format, args...)
-		log.Printf("[%s] %s", level.String(), message)
+		writeLogEntry(lm.format, LogEntry{Level: level, Message: message})
+	}
+}
+
+// syslogLevel はログの行に含まれるレベル表記（[INFO] や JSON形式の "level":"INFO" など）からレベルを判定します
+// レベル表記がない場合は LogLevelInfo を返します
+func syslogLevel(line []byte) LogLevel {
+	for _, level := range []LogLevel{LogLevelDebug, LogLevelWarn, LogLevelError, LogLevelFatal} {
+		if bytes.Contains(line, []byte("["+level.String()+"]")) ||
+			bytes.Contains(line, []byte(`"level":"`+level.String()+`"`)) {
+			return level
+		}
 	}
+	return LogLevelInfo
 }
 
 // logFile はログファイルへの参照を保持します
 var logFile *os.File
 
+// syslogOutput はsyslogへの出力先を保持します（無効な場合はnil）
+var syslogOutput io.WriteCloser
+
 // SetupLogger はロガーを設定します
 func SetupLogger(logFileName string) {
 	// 基本的なログ設定
@@ -102,6 +169,11 @@ func SetupLogger(logFileName string) {
 		logsDir = cfg.Logging.Directory
 	}
 
+	// syslogへの出力はログファイルの作成に失敗した場合も追加する
+	if cfg.Logging.Syslog.Enabled {
+		defer attachSyslog(cfg.Logging.Syslog.Network, cfg.Logging.Syslog.Address, cfg.Logging.Syslog.Tag)
+	}
+
 	// ログディレクトリを作成
 	if err := os.MkdirAll(logsDir, 0755); err != nil {
 		log.Printf("警告: ログディレクトリの作成に失敗しました: %v - 標準出力にログを出力します", err)
@@ -131,12 +203,38 @@ func SetupLogger(logFileName string) {
 	fmt.Printf("ログファイル: %s\n", outputLogFile)
 }
 
+// attachSyslog は現在のログ出力先に加えてsyslogにも出力するように設定します
+// 接続できない場合は警告を出力し、syslogへの出力は行いません
+func attachSyslog(network, address, tag string) {
+	w, err := openSyslog(network, address, tag)
+	if err != nil {
+		log.Printf("警告: %v", err)
+		return
+	}
+
+	syslogOutput = w
+	log.SetOutput(io.MultiWriter(log.Writer(), w))
+}
+
+// RedirectLogOutput はログの出力先を w に切り替えます
+// syslogへの出力が有効な場合は、切り替え後も引き続きsyslogにも出力します
+func RedirectLogOutput(w io.Writer) {
+	if syslogOutput != nil {
+		w = io.MultiWriter(w, syslogOutput)
+	}
+	log.SetOutput(w)
+}
+
 // CloseLogger はロガーのリソースを解放します
 func CloseLogger() {
 	if logFile != nil {
 		logFile.Close()
 		logFile = nil
 	}
+	if syslogOutput != nil {
+		syslogOutput.Close()
+		syslogOutput = nil
+	}
 }
 
 // GetLogFileName は日時を含むログファイル名を生成します
@@ -187,12 +285,38 @@ func LogFatal(format string, args ...interface{}) {
 func logWithLevel(level LogLevel, format string, args ...interface{}) {
 	cfg := config.GetConfig()
 	configLevel := stringToLogLevel(cfg.Logging.Level)
+	if len(cfg.Logging.ModuleLevels) > 0 {
+		// LogInfo などの呼び出し元のモジュールにレベルが設定されていればそれを使用する
+		if moduleLevel, ok := cfg.Logging.ModuleLevels[callerModule(2)]; ok {
+			configLevel = stringToLogLevel(moduleLevel)
+		}
+	}
 
 	// 設定されたレベル以上の場合のみログを出力
 	if level >= configLevel {
 		message := fmt.Sprintf(format, args...)
-		log.Printf("[%s] %s", level.String(), message)
+		writeLogEntry(cfg.Logging.Format, LogEntry{Level: level, Message: message})
+	}
+}
+
+// parseModuleLevels は設定ファイルのモジュールごとのレベルを LogLevel に変換します
+func parseModuleLevels(levels map[string]string) map[string]LogLevel {
+	parsed := make(map[string]LogLevel, len(levels))
+	for module, level := range levels {
+		parsed[module] = stringToLogLevel(level)
+	}
+	return parsed
+}
+
+// callerModule は呼び出し元のファイルが属するパッケージのディレクトリ名を返します
+// skip は callerModule の呼び出し元から数えたスタックの深さです（取得できない場合は空文字列）
+func callerModule(skip int) string {
+	_, file, _, ok := runtime.Caller(skip + 1)
+	if !ok {
+		return ""
 	}
+	// runtime.Caller のパスはOSに関係なく / 区切り
+	return path.Base(path.Dir(file))
 }
 
 // stringToLogLevel は文字列をLogLevelに変換します
@@ -0,0 +1,102 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/223n/image-converter/internal/config"
+)
+
+// このファイルのログは呼び出し元のモジュール（パッケージのディレクトリ名）が "utils" になります
+const testModule = "utils"
+
+func TestCallerModule(t *testing.T) {
+	if got := callerModule(0); got != testModule {
+		t.Errorf("callerModule = %q, want %q", got, testModule)
+	}
+}
+
+// TestSetModuleLevel はモジュールのレベルを WARN にすると、そのモジュールのDEBUGログが出力されないことを確認します
+func TestSetModuleLevel(t *testing.T) {
+	tests := []struct {
+		name        string
+		module      string
+		level       LogLevel
+		wantDebug   bool
+		wantWarning bool
+	}{
+		{name: "モジュールのレベルで抑制する", module: testModule, level: LogLevelWarn, wantDebug: false, wantWarning: true},
+		{name: "他のモジュールの設定は影響しない", module: "remote", level: LogLevelError, wantDebug: true, wantWarning: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := captureLog(t)
+			lm := &LogManager{level: LogLevelDebug}
+			lm.SetModuleLevel(tt.module, tt.level)
+
+			lm.LogDebug("デバッグ")
+			lm.WithFields(map[string]interface{}{"k": 1}).Debug("フィールド付きデバッグ")
+			lm.LogWarning("警告")
+
+			out := buf.String()
+			if got := strings.Contains(out, "[DEBUG]"); got != tt.wantDebug {
+				t.Errorf("DEBUGの出力 = %v, want %v: %q", got, tt.wantDebug, out)
+			}
+			if got := strings.Contains(out, "[WARN] 警告"); got != tt.wantWarning {
+				t.Errorf("WARNの出力 = %v, want %v: %q", got, tt.wantWarning, out)
+			}
+		})
+	}
+}
+
+// TestModuleLevelRaisesVerbosity はグローバルなレベルより詳細なレベルをモジュールに設定できることを確認します
+func TestModuleLevelRaisesVerbosity(t *testing.T) {
+	buf := captureLog(t)
+	lm := &LogManager{level: LogLevelWarn}
+	lm.SetModuleLevel(testModule, LogLevelDebug)
+
+	lm.LogDebug("デバッグ")
+	if !strings.Contains(buf.String(), "[DEBUG] デバッグ") {
+		t.Errorf("モジュールのDEBUGログが出力されませんでした: %q", buf.String())
+	}
+}
+
+// TestModuleLevelsFromConfig は logging.module_levels が LogManager とパッケージ関数の両方に反映されることを確認します
+func TestModuleLevelsFromConfig(t *testing.T) {
+	t.Cleanup(func() { config.LoadConfigFromBytes(nil) })
+	data := "logging:\n  level: debug\n  module_levels:\n    utils: warn\n    converter: debug\n"
+	if err := config.LoadConfigFromBytes([]byte(data)); err != nil {
+		t.Fatalf("設定の読み込みに失敗しました: %v", err)
+	}
+
+	buf := captureLog(t)
+	NewLogManager().LogDebug("LogManager のデバッグ")
+	LogDebug("パッケージ関数のデバッグ")
+	LogWarn("パッケージ関数の警告")
+
+	out := buf.String()
+	if strings.Contains(out, "[DEBUG]") {
+		t.Errorf("抑制されるべきDEBUGログが出力されました: %q", out)
+	}
+	if !strings.Contains(out, "[WARN] パッケージ関数の警告") {
+		t.Errorf("WARNログが出力されませんでした: %q", out)
+	}
+}
+
+func TestStringToLogLevel(t *testing.T) {
+	tests := map[string]LogLevel{
+		"debug":   LogLevelDebug,
+		"INFO":    LogLevelInfo,
+		"warn":    LogLevelWarn,
+		"Warning": LogLevelWarn,
+		"err":     LogLevelError,
+		"fatal":   LogLevelFatal,
+		"unknown": LogLevelInfo,
+	}
+	for input, want := range tests {
+		if got := stringToLogLevel(input); got != want {
+			t.Errorf("stringToLogLevel(%q) = %v, want %v", input, got, want)
+		}
+	}
+}
@@ -5,11 +5,27 @@ package utils
 
 import (
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
 )
 
+// Clock は進捗バーが経過時間の計算に使用する現在時刻の取得元です
+type Clock interface {
+	Now() time.Time
+}
+
+// systemClock はシステムの現在時刻を返す Clock です
+type systemClock struct{}
+
+// Now はシステムの現在時刻を返します
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
 // ProgressBar はコンソールに進捗バーを表示するための構造体です
 type ProgressBar struct {
 	total       int
@@ -20,8 +36,20 @@ type ProgressBar struct {
 	startTime   time.Time
 	lastUpdate  time.Time
 	isDone      bool
+	out         io.Writer // 出力先（デフォルトは標準出力）
+	clock       Clock     // 現在時刻の取得元（デフォルトはシステム時刻）
+
+	// 件数が不明な場合はバーの代わりにスピナーを表示する
+	indeterminate bool
+	spinnerIndex  int
+
+	// 進捗の後ろに " / " で区切って表示する補足（処理中のファイルの進捗など）
+	detail string
 }
 
+// spinnerFrames は件数が不明な場合に順に表示するスピナーの文字です
+var spinnerFrames = []rune{'|', '/', '-', '\\'}
+
 // NewProgressBar は新しい進捗バーを作成します
 func NewProgressBar(total int, description string) *ProgressBar {
 	return &ProgressBar{
@@ -31,7 +59,86 @@ func NewProgressBar(total int, description string) *ProgressBar {
 		description: description,
 		startTime:   time.Now(),
 		lastUpdate:  time.Now(),
+		out:         os.Stdout,
+		clock:       systemClock{},
+	}
+}
+
+// SetClock は現在時刻の取得元を変更し、開始時刻を新しい取得元の時刻で設定し直します
+func (p *ProgressBar) SetClock(clock Clock) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.clock = clock
+	p.startTime = clock.Now()
+	p.lastUpdate = p.startTime
+}
+
+// SetIndeterminate は件数が不明な状態（スピナー表示）にするかどうかを設定します
+// 合計が0の場合は設定に関わらずスピナーを表示します
+func (p *ProgressBar) SetIndeterminate(indeterminate bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.indeterminate = indeterminate
+}
+
+// isIndeterminate はスピナーを表示するかどうかを返します（呼び出し元でロックを取得してください）
+func (p *ProgressBar) isIndeterminate() bool {
+	return p.indeterminate || p.total <= 0
+}
+
+// SetDetail は進捗の後ろに表示する補足を設定し、表示を更新します（空の場合は表示しません）
+// 表示の更新頻度は Increment と同じく100msに1回までに制限します
+func (p *ProgressBar) SetDetail(detail string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.detail = detail
+	if p.isDone {
+		return
+	}
+
+	now := p.clock.Now()
+	if now.Sub(p.lastUpdate) < 100*time.Millisecond {
+		return
+	}
+	p.lastUpdate = now
+	p.printProgress()
+}
+
+// Tick は件数を増やさずにスピナーを1つ進めます
+// 一覧の取得など、進み具合を件数で表せない処理の実行中に定期的に呼び出します
+func (p *ProgressBar) Tick() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.isDone {
+		return
 	}
+	p.spinnerIndex++
+	p.printProgress()
+}
+
+// CurrentRate は開始からの1秒あたりの処理件数を返します（経過時間が0の場合は0）
+func (p *ProgressBar) CurrentRate() float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.rate()
+}
+
+// rate は開始からの1秒あたりの処理件数を返します（呼び出し元でロックを取得してください）
+func (p *ProgressBar) rate() float64 {
+	elapsed := p.clock.Now().Sub(p.startTime).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(p.current) / elapsed
+}
+
+// SetOutput は進捗バーの出力先を変更します
+func (p *ProgressBar) SetOutput(w io.Writer) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.out = w
 }
 
 // Increment は進捗バーを指定されたステップ数だけ増加させます
@@ -40,7 +147,8 @@ func (p *ProgressBar) Increment() {
 	defer p.mu.Unlock()
 
 	p.current++
-	now := time.Now()
+	p.spinnerIndex++
+	now := p.clock.Now()
 
 	// 更新頻度を制限（100msに1回まで）
 	if now.Sub(p.lastUpdate) < 100*time.Millisecond && p.current < p.total {
@@ -57,11 +165,12 @@ func (p *ProgressBar) IncrementBy(steps int) {
 	defer p.mu.Unlock()
 
 	p.current += steps
-	if p.current > p.total {
+	p.spinnerIndex++
+	if p.current > p.total && !p.isIndeterminate() {
 		p.current = p.total
 	}
 
-	now := time.Now()
+	now := p.clock.Now()
 
 	// 更新頻度を制限（100msに1回まで）
 	if now.Sub(p.lastUpdate) < 100*time.Millisecond && p.current < p.total {
@@ -88,17 +197,25 @@ func (p *ProgressBar) Complete() {
 		return
 	}
 
-	p.current = p.total
+	// スピナー表示の場合は件数が不明なため、処理した件数をそのまま表示する
+	if !p.isIndeterminate() {
+		p.current = p.total
+	}
 	p.printProgress()
-	fmt.Println() // 進捗バーの下に改行を追加
+	fmt.Fprintln(p.out) // 進捗バーの下に改行を追加
 
-	duration := time.Since(p.startTime)
-	fmt.Printf("%s: 完了 (所要時間: %s)\n", p.description, FormatDuration(duration))
+	duration := p.clock.Now().Sub(p.startTime)
+	fmt.Fprintf(p.out, "%s: 完了 (所要時間: %s)\n", p.description, FormatDuration(duration))
 	p.isDone = true
 }
 
 // printProgress は現在の進捗状況を表示します
 func (p *ProgressBar) printProgress() {
+	if p.isIndeterminate() {
+		p.printSpinner()
+		return
+	}
+
 	percent := float64(p.current) / float64(p.total)
 	if percent > 1.0 {
 		percent = 1.0
@@ -111,7 +228,7 @@ func (p *ProgressBar) printProgress() {
 	}
 
 	// 経過時間と推定残り時間を計算
-	elapsed := time.Since(p.startTime)
+	elapsed := p.clock.Now().Sub(p.startTime)
 	var eta time.Duration
 	if percent > 0 {
 		eta = time.Duration(float64(elapsed) / percent * (1 - percent))
@@ -121,9 +238,25 @@ func (p *ProgressBar) printProgress() {
 	bar := strings.Repeat("█", filled) + strings.Repeat("░", p.width-filled)
 
 	// ステータス行を出力（\rで行頭に戻る）
-	fmt.Printf("\r%s: [%s] %3.0f%% (%d/%d) 経過: %s 残り: %s",
+	fmt.Fprintf(p.out, "\r%s: [%s] %3.0f%% (%d/%d) 経過: %s 残り: %s",
 		p.description, bar, percent*100, p.current, p.total,
 		FormatDuration(elapsed), FormatDuration(eta))
+
+	// 処理速度（件数が増えるまでは表示しない）
+	if rate := p.rate(); rate > 0 {
+		fmt.Fprintf(p.out, " (%.1f files/sec)", rate)
+	}
+	if p.detail != "" {
+		fmt.Fprintf(p.out, " / %s", p.detail)
+	}
+}
+
+// printSpinner は件数が不明な場合の進捗状況をスピナーで表示します
+func (p *ProgressBar) printSpinner() {
+	frame := spinnerFrames[p.spinnerIndex%len(spinnerFrames)]
+	elapsed := p.clock.Now().Sub(p.startTime)
+
+	fmt.Fprintf(p.out, "\r%s: %c (%d) 経過: %s", p.description, frame, p.current, FormatDuration(elapsed))
 }
 
 // FormatDuration は時間を見やすい形式にフォーマットします
@@ -143,13 +276,18 @@ func FormatDuration(d time.Duration) string {
 
 // MultiProgressTracker は複数の処理の進捗を追跡する構造体です
 type MultiProgressTracker struct {
-	totalFiles  int
-	processed   int
-	succeeded   int
-	failed      int
-	skipped     int
-	progressBar *ProgressBar
-	mu          sync.Mutex
+	totalFiles    int
+	processed     int
+	succeeded     int
+	failed        int
+	skipped       int
+	progressBar   *ProgressBar
+	cancelled     bool             // Cancel で中断された場合は以降の進捗と完了を表示しない
+	currentFile   *FileProgressBar // 進捗バーに転送状況を表示しているファイル
+	events        io.Writer        // JSON Lines形式の進捗の出力先（nilの場合は出力しない）
+	lastEvent     time.Time        // 最後に進捗を出力した時刻
+	lastEventData *ProgressEvent   // 最後に出力した進捗
+	mu            sync.Mutex
 }
 
 // NewMultiProgressTracker は新しい進捗トラッカーを作成します
@@ -160,6 +298,13 @@ func NewMultiProgressTracker(totalFiles int, description string) *MultiProgressT
 	}
 }
 
+// SetOutput は進捗バーと処理結果の出力先を変更します
+func (m *MultiProgressTracker) SetOutput(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.progressBar.SetOutput(w)
+}
+
 // IncrementSuccess は成功したファイルの数を増やします
 func (m *MultiProgressTracker) IncrementSuccess() {
 	m.mu.Lock()
@@ -167,7 +312,7 @@ func (m *MultiProgressTracker) IncrementSuccess() {
 
 	m.processed++
 	m.succeeded++
-	m.progressBar.Increment()
+	m.advance()
 }
 
 // IncrementFailed は失敗したファイルの数を増やします
@@ -177,7 +322,7 @@ func (m *MultiProgressTracker) IncrementFailed() {
 
 	m.processed++
 	m.failed++
-	m.progressBar.Increment()
+	m.advance()
 }
 
 // IncrementSkipped はスキップされたファイルの数を増やします
@@ -187,17 +332,57 @@ func (m *MultiProgressTracker) IncrementSkipped() {
 
 	m.processed++
 	m.skipped++
+	m.advance()
+}
+
+// advance は進捗バーを1つ進めます（中断後は表示しません。呼び出し元でロックを取得してください）
+func (m *MultiProgressTracker) advance() {
+	if m.cancelled {
+		return
+	}
 	m.progressBar.Increment()
+	m.writeEvent(false)
+}
+
+// Cancel は処理を中断し、理由とその時点までの統計情報を表示します
+// 中断後も件数の集計は続けますが、進捗と Complete による完了は表示しません
+func (m *MultiProgressTracker) Cancel(reason string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.cancelled {
+		return
+	}
+	m.cancelled = true
+	m.writeEvent(true)
+
+	fmt.Fprintf(m.progressBar.out, "\n中断しました: %s\n", reason)
+	fmt.Fprintf(m.progressBar.out, "処理結果（中断時点）: 成功: %d, 失敗: %d, スキップ: %d, 合計: %d\n",
+		m.succeeded, m.failed, m.skipped, m.totalFiles)
+}
+
+// Cancelled は Cancel で中断されたかどうかを返します
+func (m *MultiProgressTracker) Cancelled() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.cancelled
 }
 
 // Complete は処理を完了し、最終的な統計情報を表示します
+// Cancel で中断された場合は何も表示しません
 func (m *MultiProgressTracker) Complete() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	if m.cancelled {
+		return
+	}
+
 	m.progressBar.Complete()
-	fmt.Printf("処理結果: 成功: %d, 失敗: %d, スキップ: %d, 合計: %d\n",
+	m.writeEvent(true)
+	fmt.Fprintf(m.progressBar.out, "処理結果: 成功: %d, 失敗: %d, スキップ: %d, 合計: %d\n",
 		m.succeeded, m.failed, m.skipped, m.totalFiles)
+	fmt.Fprintf(m.progressBar.out, "平均処理速度: %.1f files/sec\n", m.progressBar.CurrentRate())
 }
 
 // GetStats は現在の統計情報を返します
@@ -206,3 +391,130 @@ func (m *MultiProgressTracker) GetStats() (int, int, int, int) {
 	defer m.mu.Unlock()
 	return m.processed, m.succeeded, m.failed, m.skipped
 }
+
+// FileProgressBar は MultiProgressTracker の中で1ファイル分の転送バイト数を追跡します
+// io.Writer を実装しているため、io.TeeReader や io.MultiWriter と組み合わせて転送量を記録できます
+// nil の場合も呼び出すことができ、その場合は何も記録しません
+type FileProgressBar struct {
+	parent   *MultiProgressTracker
+	filename string
+
+	mu      sync.Mutex
+	total   int64
+	written int64
+	done    bool
+}
+
+// StartFile は1ファイル分の転送状況を追跡する FileProgressBar を作成します
+// 全体の進捗バーには、最後に転送量が更新されたファイルの進捗が表示されます
+// totalBytes が事前に分からない場合は0を指定し、AddTotal で後から加算してください
+func (m *MultiProgressTracker) StartFile(filename string, totalBytes int64) *FileProgressBar {
+	return &FileProgressBar{
+		parent:   m,
+		filename: filename,
+		total:    totalBytes,
+	}
+}
+
+// Write は転送したバイト数を記録し、全体の進捗バーの表示を更新します
+func (f *FileProgressBar) Write(p []byte) (int, error) {
+	if f == nil {
+		return len(p), nil
+	}
+
+	f.mu.Lock()
+	f.written += int64(len(p))
+	done := f.done
+	f.mu.Unlock()
+
+	if !done {
+		f.parent.showFile(f)
+	}
+	return len(p), nil
+}
+
+// AddTotal は転送予定のバイト数を加算します（ダウンロードとアップロードを1つのバーで追跡する場合など）
+func (f *FileProgressBar) AddTotal(n int64) {
+	if f == nil {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.total += n
+}
+
+// Percent は転送済みの割合を0〜100で返します（転送予定のバイト数が0の場合は0）
+func (f *FileProgressBar) Percent() float64 {
+	if f == nil {
+		return 0
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.total <= 0 {
+		return 0
+	}
+	// リトライで同じ内容を再送した場合も100%を超えないようにする
+	return min(100, float64(f.written)/float64(f.total)*100)
+}
+
+// Complete はファイルの処理が成功したものとして全体の処理済み件数を増やします
+// 2回目以降の呼び出しは無視します
+func (f *FileProgressBar) Complete() {
+	if f.finish() {
+		f.parent.IncrementSuccess()
+	}
+}
+
+// Fail はファイルの処理が失敗したものとして全体の処理済み件数を増やします
+// 2回目以降の呼び出しは無視します
+func (f *FileProgressBar) Fail() {
+	if f.finish() {
+		f.parent.IncrementFailed()
+	}
+}
+
+// finish はファイルの追跡を終了し、全体の進捗バーから表示を消します
+// 既に終了している場合は false を返します
+func (f *FileProgressBar) finish() bool {
+	if f == nil {
+		return false
+	}
+
+	f.mu.Lock()
+	if f.done {
+		f.mu.Unlock()
+		return false
+	}
+	f.done = true
+	f.mu.Unlock()
+
+	f.parent.clearFile(f)
+	return true
+}
+
+// showFile は全体の進捗バーにファイルの転送状況を表示します
+func (m *MultiProgressTracker) showFile(f *FileProgressBar) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.cancelled {
+		return
+	}
+	m.currentFile = f
+	m.progressBar.SetDetail(fmt.Sprintf("%s %3.0f%%", filepath.Base(f.filename), f.Percent()))
+}
+
+// clearFile は全体の進捗バーに表示しているファイルが f の場合に表示を消します
+func (m *MultiProgressTracker) clearFile(f *FileProgressBar) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.currentFile != f {
+		return
+	}
+	m.currentFile = nil
+	m.progressBar.mu.Lock()
+	m.progressBar.detail = ""
+	m.progressBar.mu.Unlock()
+}
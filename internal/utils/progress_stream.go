@@ -0,0 +1,126 @@
+/*
+Package utils の一部として、外部の監視用にJSON Lines形式の進捗を出力する機能を提供します。
+*/
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/223n/image-converter/internal/config"
+)
+
+// ProgressEvent は進捗の出力先に1行ずつ書き込む進捗の状態です
+type ProgressEvent struct {
+	Done       int     `json:"done"`
+	Total      int     `json:"total"`
+	Succeeded  int     `json:"succeeded"`
+	Failed     int     `json:"failed"`
+	Skipped    int     `json:"skipped"`
+	ETASeconds float64 `json:"eta_seconds"` // 推定残り時間（推定できない場合は0）
+}
+
+// OpenProgressStream は進捗の出力先を開きます
+// target はファイルのパス、または fd:N 形式のファイルディスクリプタ番号です
+// 標準出力・標準エラー出力（fd:1, fd:2）を指定した場合、返された出力先を閉じてもそれらは閉じません
+func OpenProgressStream(target string) (io.WriteCloser, error) {
+	fd, err := config.ParseProgressStream(target)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case fd == 1:
+		return nopWriteCloser{os.Stdout}, nil
+	case fd == 2:
+		return nopWriteCloser{os.Stderr}, nil
+	case fd >= 0:
+		return os.NewFile(uintptr(fd), target), nil
+	}
+
+	file, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("進捗の出力先を開けません: %v", err)
+	}
+	return file, nil
+}
+
+// nopWriteCloser は閉じても何もしない io.WriteCloser です
+type nopWriteCloser struct {
+	io.Writer
+}
+
+// Close は何もしません
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+// SetEventStream は進捗が更新されるたびにJSON Lines形式の進捗を書き込む出力先を設定します
+// 進捗バーと同じく書き込みは100msに1回までに制限しますが、最後の1件と完了時は必ず書き込みます
+func (m *MultiProgressTracker) SetEventStream(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.events = w
+}
+
+// AttachEventStream は target（ファイルのパスまたは fd:N）を開いて進捗の出力先に設定し、出力先を閉じる関数を返します
+// target が空の場合や開けなかった場合も、呼び出し側で defer できるよう何もしない関数を返します
+func (m *MultiProgressTracker) AttachEventStream(target string) (func(), error) {
+	if target == "" {
+		return func() {}, nil
+	}
+
+	stream, err := OpenProgressStream(target)
+	if err != nil {
+		return func() {}, err
+	}
+	m.SetEventStream(stream)
+
+	return func() {
+		m.SetEventStream(nil)
+		stream.Close()
+	}, nil
+}
+
+// writeEvent は進捗の出力先に現在の進捗を書き込みます（呼び出し元でロックを取得してください）
+// force が false の場合、前回の書き込みから100ms経っていなければ書き込みません
+func (m *MultiProgressTracker) writeEvent(force bool) {
+	if m.events == nil {
+		return
+	}
+
+	now := m.progressBar.clock.Now()
+	if !force && m.processed < m.totalFiles && now.Sub(m.lastEvent) < 100*time.Millisecond {
+		return
+	}
+
+	event := ProgressEvent{
+		Done:      m.processed,
+		Total:     m.totalFiles,
+		Succeeded: m.succeeded,
+		Failed:    m.failed,
+		Skipped:   m.skipped,
+	}
+	if m.processed > 0 && m.processed < m.totalFiles {
+		elapsed := now.Sub(m.progressBar.startTime)
+		eta := time.Duration(float64(elapsed) / float64(m.processed) * float64(m.totalFiles-m.processed))
+		event.ETASeconds = eta.Round(time.Second).Seconds()
+	}
+
+	// 完了時など、直前に書き込んだ内容と同じ場合は書き込まない
+	if m.lastEventData != nil && *m.lastEventData == event {
+		return
+	}
+	m.lastEvent = now
+	m.lastEventData = &event
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	// 監視用の出力のため、書き込みに失敗しても処理は続ける
+	m.events.Write(append(data, '\n'))
+}
@@ -0,0 +1,157 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fakeClock はテストで進める現在時刻を返す Clock です
+type fakeClock struct {
+	now time.Time
+}
+
+// Now は現在の時刻を返します
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+// advance は時刻を d だけ進めます
+func (c *fakeClock) advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+// newStreamTracker は進捗の出力先を buf に設定した進捗トラッカーを作成します
+func newStreamTracker(total int) (*MultiProgressTracker, *fakeClock, *bytes.Buffer) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	m := NewMultiProgressTracker(total, "テスト")
+	m.SetOutput(io.Discard)
+	m.progressBar.SetClock(clock)
+
+	var buf bytes.Buffer
+	m.SetEventStream(&buf)
+	return m, clock, &buf
+}
+
+// readEvents は buf に書き込まれたJSON Lines形式の進捗を読み込みます
+func readEvents(t *testing.T, r io.Reader) []ProgressEvent {
+	t.Helper()
+
+	var events []ProgressEvent
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		var event ProgressEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("JSONとして読み込めない行があります: %q: %v", scanner.Text(), err)
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestProgressStreamEvents(t *testing.T) {
+	m, clock, buf := newStreamTracker(5)
+
+	m.IncrementSuccess() // 最初の進捗は書き込む
+	m.IncrementSuccess() // 100ms経っていないため書き込まない
+	clock.advance(3 * time.Second)
+	m.IncrementFailed() // 3秒で3件のため、残り2件は2秒と推定する
+	clock.advance(50 * time.Millisecond)
+	m.IncrementSkipped() // 100ms経っていないため書き込まない
+	m.IncrementSkipped() // 最後の1件は必ず書き込む
+	m.Complete()         // 直前と同じ内容のため書き込まない
+
+	want := []ProgressEvent{
+		{Done: 1, Total: 5, Succeeded: 1},
+		{Done: 3, Total: 5, Succeeded: 2, Failed: 1, ETASeconds: 2},
+		{Done: 5, Total: 5, Succeeded: 2, Failed: 1, Skipped: 2},
+	}
+	got := readEvents(t, buf)
+	if len(got) != len(want) {
+		t.Fatalf("進捗の件数 = %d, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("進捗[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestProgressStreamKeys(t *testing.T) {
+	m, _, buf := newStreamTracker(2)
+	m.IncrementSuccess()
+
+	var event map[string]interface{}
+	if err := json.Unmarshal(bytes.TrimSpace(buf.Bytes()), &event); err != nil {
+		t.Fatalf("JSONとして読み込めません: %v", err)
+	}
+	for _, key := range []string{"done", "total", "succeeded", "failed", "skipped", "eta_seconds"} {
+		if _, ok := event[key]; !ok {
+			t.Errorf("キー %q がありません: %v", key, event)
+		}
+	}
+}
+
+func TestProgressStreamCancel(t *testing.T) {
+	m, _, buf := newStreamTracker(4)
+	m.IncrementSuccess()
+	m.IncrementFailed()  // 100ms経っていないため書き込まない
+	m.Cancel("テスト")      // 中断時は必ず書き込む
+	m.IncrementSuccess() // 中断後は書き込まない
+	m.Complete()
+
+	got := readEvents(t, buf)
+	if len(got) != 2 {
+		t.Fatalf("進捗の件数 = %d, want 2: %+v", len(got), got)
+	}
+	if want := (ProgressEvent{Done: 2, Total: 4, Succeeded: 1, Failed: 1}); got[1] != want {
+		t.Errorf("中断時の進捗 = %+v, want %+v", got[1], want)
+	}
+}
+
+func TestAttachEventStream(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "progress.jsonl")
+
+	m := NewMultiProgressTracker(1, "テスト")
+	m.SetOutput(io.Discard)
+	detach, err := m.AttachEventStream(path)
+	if err != nil {
+		t.Fatalf("AttachEventStream に失敗しました: %v", err)
+	}
+	m.IncrementSuccess()
+	m.Complete()
+	detach()
+
+	// 出力先を外した後は書き込まない
+	m.IncrementSuccess()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("進捗の出力先を読み込めません: %v", err)
+	}
+	got := readEvents(t, bytes.NewReader(data))
+	if want := (ProgressEvent{Done: 1, Total: 1, Succeeded: 1}); len(got) != 1 || got[0] != want {
+		t.Errorf("進捗 = %+v, want [%+v]", got, want)
+	}
+}
+
+func TestAttachEventStreamTargets(t *testing.T) {
+	m := NewMultiProgressTracker(1, "テスト")
+
+	detach, err := m.AttachEventStream("")
+	if err != nil {
+		t.Errorf("空の出力先でエラーになりました: %v", err)
+	}
+	detach()
+
+	detach, err = m.AttachEventStream("fd:abc")
+	if err == nil {
+		t.Error("不正なファイルディスクリプタの指定でエラーになりませんでした")
+	}
+	detach()
+}
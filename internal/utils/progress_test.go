@@ -0,0 +1,287 @@
+package utils
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newBufferedProgressBar は出力先を buf に、時刻を fakeClock に設定した進捗バーを作成します
+func newBufferedProgressBar(total int, description string) (*ProgressBar, *fakeClock, *bytes.Buffer) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	p := NewProgressBar(total, description)
+	p.SetClock(clock)
+
+	var buf bytes.Buffer
+	p.SetOutput(&buf)
+	return p, clock, &buf
+}
+
+func TestProgressBarOutput(t *testing.T) {
+	p, clock, buf := newBufferedProgressBar(5, "変換")
+
+	clock.advance(2 * time.Second)
+	p.Increment()
+	p.Increment() // 100ms経っていないため表示しない
+	clock.advance(2 * time.Second)
+	p.SetDetail("photo.png 50%")
+
+	lines := strings.Split(strings.TrimPrefix(buf.String(), "\r"), "\r")
+	patterns := []string{
+		`^変換: \[█{10}░{40}\]  20% \(1/5\) 経過: 00:02 残り: 00:08 \(0\.5 files/sec\)$`,
+		`^変換: \[█{20}░{30}\]  40% \(2/5\) 経過: 00:04 残り: 00:06 \(0\.5 files/sec\) / photo\.png 50%$`,
+	}
+	if len(lines) != len(patterns) {
+		t.Fatalf("表示の回数 = %d, want %d: %q", len(lines), len(patterns), buf.String())
+	}
+	for i, pattern := range patterns {
+		if !regexp.MustCompile(pattern).MatchString(lines[i]) {
+			t.Errorf("表示[%d] = %q, want %s", i, lines[i], pattern)
+		}
+	}
+}
+
+func TestProgressBarComplete(t *testing.T) {
+	p, clock, buf := newBufferedProgressBar(3, "アップロード")
+
+	clock.advance(65 * time.Second)
+	p.Complete()
+	p.Complete() // 完了後は表示しない
+
+	pattern := `^\rアップロード: \[█{50}\] 100% \(3/3\) 経過: 01:05 残り: 00:00 \(0\.0 files/sec\)\nアップロード: 完了 \(所要時間: 01:05\)\n$`
+	if !regexp.MustCompile(pattern).MatchString(buf.String()) {
+		t.Errorf("出力 = %q, want %s", buf.String(), pattern)
+	}
+}
+
+func TestProgressBarSpinner(t *testing.T) {
+	p, clock, buf := newBufferedProgressBar(0, "一覧の取得")
+
+	clock.advance(3 * time.Second)
+	p.Tick()
+	p.Tick()
+
+	want := "\r一覧の取得: / (0) 経過: 00:03\r一覧の取得: - (0) 経過: 00:03"
+	if got := buf.String(); got != want {
+		t.Errorf("出力 = %q, want %q", got, want)
+	}
+}
+
+// TestProgressBarCurrentRate は開始からの経過時間と処理件数から処理速度を求めることを確認します
+func TestProgressBarCurrentRate(t *testing.T) {
+	tests := []struct {
+		name      string
+		processed int
+		elapsed   time.Duration
+		want      float64
+	}{
+		{name: "経過時間が0", processed: 3, elapsed: 0, want: 0},
+		{name: "処理件数が0", processed: 0, elapsed: 10 * time.Second, want: 0},
+		{name: "1秒あたり2件", processed: 8, elapsed: 4 * time.Second, want: 2},
+		{name: "1秒に満たない", processed: 1, elapsed: 500 * time.Millisecond, want: 2},
+		{name: "1件に満たない", processed: 3, elapsed: 12 * time.Second, want: 0.25},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, clock, _ := newBufferedProgressBar(10, "変換")
+			for i := 0; i < tt.processed; i++ {
+				p.Increment()
+			}
+			clock.advance(tt.elapsed)
+
+			if got := p.CurrentRate(); got != tt.want {
+				t.Errorf("CurrentRate = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMultiProgressTrackerOutput(t *testing.T) {
+	m := NewMultiProgressTracker(3, "変換処理")
+	var buf bytes.Buffer
+	m.SetOutput(&buf)
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	m.progressBar.SetClock(clock)
+
+	clock.advance(time.Second)
+	m.IncrementSuccess()
+	m.IncrementFailed()
+	clock.advance(time.Second)
+	m.IncrementSkipped()
+	m.Complete()
+
+	// 3件を2秒で処理したため、途中の表示と完了時の平均は 1.5 files/sec になる
+	if !strings.Contains(buf.String(), "(3/3) 経過: 00:02 残り: 00:00 (1.5 files/sec)") {
+		t.Errorf("進捗の表示に処理速度がありません: %q", buf.String())
+	}
+	if !strings.HasSuffix(buf.String(), "処理結果: 成功: 1, 失敗: 1, スキップ: 1, 合計: 3\n平均処理速度: 1.5 files/sec\n") {
+		t.Errorf("出力 = %q", buf.String())
+	}
+}
+
+func TestMultiProgressTrackerCancelOutput(t *testing.T) {
+	m := NewMultiProgressTracker(3, "変換処理")
+	var buf bytes.Buffer
+	m.SetOutput(&buf)
+
+	m.IncrementSuccess()
+	m.Cancel("シグナル interrupt を受信しました")
+
+	if !strings.HasSuffix(buf.String(), "\n中断しました: シグナル interrupt を受信しました\n処理結果（中断時点）: 成功: 1, 失敗: 0, スキップ: 0, 合計: 3\n") {
+		t.Errorf("出力 = %q", buf.String())
+	}
+}
+
+// TestMultiProgressTrackerCancel は中断後の件数は集計を続けるが、進捗と完了を表示し直さないことを確認します
+func TestMultiProgressTrackerCancel(t *testing.T) {
+	m := NewMultiProgressTracker(4, "変換処理")
+	var buf bytes.Buffer
+	m.SetOutput(&buf)
+
+	m.IncrementSuccess()
+	m.Cancel("実行時間の上限に達しました")
+	cancelled := buf.String()
+
+	m.IncrementSuccess()
+	m.IncrementFailed()
+	m.IncrementSkipped()
+	m.Complete()
+	m.Cancel("2回目の中断")
+
+	if got := buf.String(); got != cancelled {
+		t.Errorf("中断後に表示されました: %q", strings.TrimPrefix(got, cancelled))
+	}
+	if strings.Count(cancelled, "中断しました") != 1 || strings.Contains(cancelled, "処理結果: ") {
+		t.Errorf("中断時の出力 = %q", cancelled)
+	}
+	if !m.Cancelled() {
+		t.Error("Cancelled = false, want true")
+	}
+
+	processed, succeeded, failed, skipped := m.GetStats()
+	if processed != 4 || succeeded != 2 || failed != 1 || skipped != 1 {
+		t.Errorf("GetStats = (%d, %d, %d, %d), want (4, 2, 1, 1)", processed, succeeded, failed, skipped)
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{d: 0, want: "00:00"},
+		{d: 1499 * time.Millisecond, want: "00:01"},
+		{d: 59*time.Minute + 59*time.Second, want: "59:59"},
+		{d: 2*time.Hour + 3*time.Minute + 4*time.Second, want: "02:03:04"},
+	}
+	for _, tt := range tests {
+		if got := FormatDuration(tt.d); got != tt.want {
+			t.Errorf("FormatDuration(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
+
+// newBufferedTracker は出力先を buf に、時刻を fakeClock に設定した進捗トラッカーを作成します
+func newBufferedTracker(total int) (*MultiProgressTracker, *fakeClock, *bytes.Buffer) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	m := NewMultiProgressTracker(total, "転送")
+	m.progressBar.SetClock(clock)
+
+	var buf bytes.Buffer
+	m.SetOutput(&buf)
+	return m, clock, &buf
+}
+
+// TestFileProgressBarComplete はファイルの転送を完了すると全体の処理済み件数が増え、
+// 転送中は全体の進捗バーにファイルの割合が表示されることを確認します
+func TestFileProgressBarComplete(t *testing.T) {
+	m, clock, buf := newBufferedTracker(2)
+
+	f := m.StartFile("/remote/images/photo.png", 200)
+	clock.advance(time.Second)
+	if _, err := io.Copy(io.Discard, io.TeeReader(strings.NewReader(strings.Repeat("x", 100)), f)); err != nil {
+		t.Fatalf("転送に失敗しました: %v", err)
+	}
+	if got := f.Percent(); got != 50 {
+		t.Errorf("Percent = %v, want 50", got)
+	}
+	if out := buf.String(); !strings.Contains(out, "(0/2)") || !strings.HasSuffix(out, " / photo.png  50%") {
+		t.Errorf("転送中の表示 = %q", buf.String())
+	}
+
+	clock.advance(time.Second)
+	f.Complete()
+	f.Complete() // 2回目は数えない
+	f.Fail()     // 完了後の失敗も数えない
+
+	processed, succeeded, failed, _ := m.GetStats()
+	if processed != 1 || succeeded != 1 || failed != 0 {
+		t.Errorf("GetStats = (%d, %d, %d), want (1, 1, 0)", processed, succeeded, failed)
+	}
+	last := buf.String()[strings.LastIndex(buf.String(), "\r"):]
+	if !strings.Contains(last, "(1/2)") || strings.Contains(last, "photo.png") {
+		t.Errorf("完了後の表示 = %q, ファイルの割合を消して 1/2 を期待しました", last)
+	}
+
+	// 完了後の書き込みは表示しない
+	before := buf.String()
+	f.Write([]byte("late"))
+	if buf.String() != before {
+		t.Errorf("完了後に表示されました: %q", strings.TrimPrefix(buf.String(), before))
+	}
+}
+
+func TestFileProgressBarFail(t *testing.T) {
+	m, _, _ := newBufferedTracker(3)
+
+	m.StartFile("a.png", 10).Fail()
+	ok := m.StartFile("b.png", 10)
+	ok.Write(make([]byte, 10))
+	ok.Complete()
+
+	processed, succeeded, failed, _ := m.GetStats()
+	if processed != 2 || succeeded != 1 || failed != 1 {
+		t.Errorf("GetStats = (%d, %d, %d), want (2, 1, 1)", processed, succeeded, failed)
+	}
+}
+
+func TestFileProgressBarPercent(t *testing.T) {
+	m, _, _ := newBufferedTracker(1)
+
+	unknown := m.StartFile("unknown.png", 0)
+	unknown.Write(make([]byte, 10))
+	if got := unknown.Percent(); got != 0 {
+		t.Errorf("転送予定が不明な場合の Percent = %v, want 0", got)
+	}
+
+	// ダウンロードとアップロードを1つのバーで追跡する
+	f := m.StartFile("photo.png", 100)
+	f.AddTotal(100)
+	f.Write(make([]byte, 150))
+	if got := f.Percent(); got != 75 {
+		t.Errorf("Percent = %v, want 75", got)
+	}
+	// リトライで再送しても100%を超えない
+	f.Write(make([]byte, 100))
+	if got := f.Percent(); got != 100 {
+		t.Errorf("再送後の Percent = %v, want 100", got)
+	}
+
+	// nil の場合は何も記録しない
+	var none *FileProgressBar
+	if n, err := none.Write([]byte("abc")); n != 3 || err != nil {
+		t.Errorf("nil の Write = %d, %v", n, err)
+	}
+	none.AddTotal(1)
+	none.Complete()
+	if got := none.Percent(); got != 0 {
+		t.Errorf("nil の Percent = %v, want 0", got)
+	}
+	if processed, _, _, _ := m.GetStats(); processed != 0 {
+		t.Errorf("nil の Complete で処理済み件数が増えました: %d", processed)
+	}
+}
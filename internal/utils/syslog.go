@@ -0,0 +1,53 @@
+//go:build !windows
+
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/syslog"
+)
+
+// syslogWriter はログの行をレベルに応じた優先度でsyslogに送信します
+type syslogWriter struct {
+	w *syslog.Writer
+}
+
+// openSyslog はsyslogに接続し、ログ出力先として使用する Writer を返します
+// network が空の場合はローカルのsyslogデーモンに接続します
+func openSyslog(network, address, tag string) (io.WriteCloser, error) {
+	w, err := syslog.Dial(network, address, syslog.LOG_INFO|syslog.LOG_USER, tag)
+	if err != nil {
+		return nil, fmt.Errorf("syslogへの接続に失敗しました: %v", err)
+	}
+	return &syslogWriter{w: w}, nil
+}
+
+// Write はログの行に含まれるレベル（[INFO] など）に対応する優先度で送信します
+func (s *syslogWriter) Write(p []byte) (int, error) {
+	message := string(bytes.TrimRight(p, "\n"))
+
+	var err error
+	switch syslogLevel(p) {
+	case LogLevelDebug:
+		err = s.w.Debug(message)
+	case LogLevelWarn:
+		err = s.w.Warning(message)
+	case LogLevelError:
+		err = s.w.Err(message)
+	case LogLevelFatal:
+		err = s.w.Crit(message)
+	default:
+		err = s.w.Info(message)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close はsyslogとの接続を閉じます
+func (s *syslogWriter) Close() error {
+	return s.w.Close()
+}
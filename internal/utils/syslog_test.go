@@ -0,0 +1,128 @@
+//go:build !windows
+
+package utils
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newSyslogReceiver はsyslogのUDPパケットを受信するソケットを 127.0.0.1 上に作成します
+func newSyslogReceiver(t *testing.T) net.PacketConn {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("UDPソケットの作成に失敗しました: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// receiveSyslog はパケットを1つ受信して返します
+func receiveSyslog(t *testing.T, conn net.PacketConn) string {
+	t.Helper()
+
+	buf := make([]byte, 4096)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("syslogのパケットを受信できませんでした: %v", err)
+	}
+	return string(buf[:n])
+}
+
+func TestSyslogLevel(t *testing.T) {
+	tests := []struct {
+		line string
+		want LogLevel
+	}{
+		{line: "[DEBUG] デバッグ", want: LogLevelDebug},
+		{line: "[INFO] 情報", want: LogLevelInfo},
+		{line: "[WARN] 警告", want: LogLevelWarn},
+		{line: "[ERROR] エラー", want: LogLevelError},
+		{line: "[FATAL] 致命的", want: LogLevelFatal},
+		{line: `{"level":"WARN","message":"警告"}`, want: LogLevelWarn},
+		{line: `{"level":"ERROR","message":"エラー"}`, want: LogLevelError},
+		{line: "レベルのないログ", want: LogLevelInfo},
+		{line: `{"message":"レベルのないJSON"}`, want: LogLevelInfo},
+	}
+
+	for _, tt := range tests {
+		if got := syslogLevel([]byte(tt.line)); got != tt.want {
+			t.Errorf("syslogLevel(%q) = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+}
+
+// TestSyslogPriority はログのレベルに応じた優先度（facility は user）でsyslogに送信することを確認します
+func TestSyslogPriority(t *testing.T) {
+	conn := newSyslogReceiver(t)
+	w, err := openSyslog("udp", conn.LocalAddr().String(), "image-converter-test")
+	if err != nil {
+		t.Fatalf("openSyslog に失敗しました: %v", err)
+	}
+	defer w.Close()
+
+	// 優先度は facility（user = 1）* 8 + severity
+	tests := []struct {
+		line     string
+		priority string
+	}{
+		{line: "[DEBUG] デバッグ\n", priority: "<15>"},
+		{line: "[INFO] 情報\n", priority: "<14>"},
+		{line: "[WARN] 警告\n", priority: "<12>"},
+		{line: "[ERROR] エラー\n", priority: "<11>"},
+		{line: "[FATAL] 致命的\n", priority: "<10>"},
+		{line: `{"level":"WARN","message":"JSON形式の警告"}` + "\n", priority: "<12>"},
+		{line: "レベルのないログ\n", priority: "<14>"},
+	}
+
+	for _, tt := range tests {
+		n, err := w.Write([]byte(tt.line))
+		if err != nil || n != len(tt.line) {
+			t.Fatalf("Write(%q) = %d, %v", tt.line, n, err)
+		}
+
+		packet := receiveSyslog(t, conn)
+		if !strings.HasPrefix(packet, tt.priority) {
+			t.Errorf("%q の優先度: %q, want %s", tt.line, packet, tt.priority)
+		}
+		if !strings.Contains(packet, "image-converter-test[") {
+			t.Errorf("%q のタグがありません: %q", tt.line, packet)
+		}
+		message := strings.TrimSuffix(tt.line, "\n")
+		if !strings.HasSuffix(strings.TrimSuffix(packet, "\n"), ": "+message) {
+			t.Errorf("%q のメッセージ: %q", tt.line, packet)
+		}
+	}
+}
+
+// TestRedirectLogOutputKeepsSyslog はログの出力先を切り替えても、syslogへの出力が続くことを確認します
+func TestRedirectLogOutputKeepsSyslog(t *testing.T) {
+	buf := captureLog(t)
+	conn := newSyslogReceiver(t)
+
+	attachSyslog("udp", conn.LocalAddr().String(), "image-converter-test")
+	t.Cleanup(CloseLogger)
+	if syslogOutput == nil {
+		t.Fatalf("syslogに接続されませんでした: %q", buf.String())
+	}
+
+	(&LogManager{level: LogLevelDebug}).LogError("切り替え前")
+	if packet := receiveSyslog(t, conn); !strings.HasPrefix(packet, "<11>") || !strings.Contains(packet, "[ERROR] 切り替え前") {
+		t.Errorf("切り替え前のパケット = %q", packet)
+	}
+
+	var redirected strings.Builder
+	RedirectLogOutput(&redirected)
+	(&LogManager{level: LogLevelDebug}).LogWarning("切り替え後")
+	if packet := receiveSyslog(t, conn); !strings.HasPrefix(packet, "<12>") || !strings.Contains(packet, "[WARN] 切り替え後") {
+		t.Errorf("切り替え後のパケット = %q", packet)
+	}
+	if !strings.Contains(redirected.String(), "[WARN] 切り替え後") {
+		t.Errorf("切り替え先の出力 = %q", redirected.String())
+	}
+}
@@ -0,0 +1,11 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+)
+
+// openSyslog はWindowsではsyslogを使用できないため、常にエラーを返します
+func openSyslog(network, address, tag string) (io.WriteCloser, error) {
+	return nil, fmt.Errorf("syslogへの出力はWindowsでは使用できません")
+}
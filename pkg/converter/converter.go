@@ -0,0 +1,111 @@
+/*
+Package converter は画像変換をライブラリとして組み込むための変換器を提供します。
+
+変換器は変換設定を個別に保持するため、異なる設定の Converter を同じプロセスで同時に使用できます。
+*/
+package converter
+
+import (
+	"fmt"
+	"image"
+	"io"
+	"log"
+
+	"github.com/223n/image-converter/internal/config"
+	internalconverter "github.com/223n/image-converter/internal/converter"
+	"github.com/223n/image-converter/internal/utils"
+	"github.com/223n/image-converter/pkg/imageutils"
+)
+
+// Options は Converter の変換設定です（設定ファイルの conversion と同じ項目）
+type Options = config.ConversionConfig
+
+// ConversionResult は画像1枚の変換結果です
+type ConversionResult = internalconverter.ConversionResult
+
+var (
+	// ErrUnsupportedFormat は対応していない形式の画像を変換しようとした場合のエラーです
+	ErrUnsupportedFormat = internalconverter.ErrUnsupportedFormat
+	// ErrDecodeFailed は画像のデコードに失敗した場合のエラーです
+	ErrDecodeFailed = internalconverter.ErrDecodeFailed
+	// ErrEncodeFailed は画像のエンコードに失敗した場合のエラーです
+	ErrEncodeFailed = internalconverter.ErrEncodeFailed
+	// ErrTooManyPixels は画素数が Options.MaxDecodePixels を超える画像を変換しようとした場合のエラーです
+	ErrTooManyPixels = internalconverter.ErrTooManyPixels
+)
+
+// DefaultOptions はデフォルトの変換設定を返します
+func DefaultOptions() Options {
+	return config.DefaultConfig().Conversion
+}
+
+// Converter は変換設定を個別に保持する画像変換器です
+// 画質・ビット深度・色差サブサンプリングなどのエンコード設定はグローバルな設定を参照しません
+// 外部コマンドのスレッド数（external_threads）はプロセス全体の設定を使用します
+type Converter struct {
+	opts Options
+	ic   *internalconverter.ImageConverter
+}
+
+// NewConverter は opts の複製を保持する変換器を作成します
+// 範囲外の値は設定ファイルの読み込み時と同じく調整し、調整内容を警告として出力します
+func NewConverter(opts Options) *Converter {
+	cfg := config.DefaultConfig()
+	cfg.Conversion = opts
+	cfg.Conversion.StripEXIFTags = append([]string(nil), opts.StripEXIFTags...)
+	for _, adjustment := range config.NormalizeConfig(&cfg) {
+		log.Printf("警告: 変換設定の値を調整しました: %s", adjustment)
+	}
+
+	return &Converter{
+		opts: cfg.Conversion,
+		ic:   internalconverter.NewImageConverter(&cfg, utils.NewLogManager()),
+	}
+}
+
+// Options は変換器の変換設定（調整後の値）を返します
+func (c *Converter) Options() Options {
+	opts := c.opts
+	opts.StripEXIFTags = append([]string(nil), c.opts.StripEXIFTags...)
+	return opts
+}
+
+// ConvertFile は画像ファイルを変換し、元画像と同じディレクトリに出力します
+func (c *Converter) ConvertFile(path string) (*ConversionResult, error) {
+	return c.ic.Convert(path)
+}
+
+// ConvertImage はデコード済みの画像を変換します
+// name は出力パスの決定に使用する元画像のパスです（例: out/photo.jpg の場合は out/photo.webp に出力します）
+// name のファイルが存在しない場合、EXIFなどのメタデータは引き継ぎません
+// name のファイルにEXIFの Orientation がある場合、auto_orient が有効であれば img をその向きに補正します
+func (c *Converter) ConvertImage(img image.Image, name string) (*ConversionResult, error) {
+	if img == nil {
+		return nil, fmt.Errorf("変換する画像がありません")
+	}
+
+	outputImg, err := c.ic.Transform(img, imageutils.ReadOrientation(name))
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ConversionResult{OriginalPath: name}
+	if err := c.ic.Encode(outputImg, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ConvertStream は r の画像データをデコードし、WebPを webpW、AVIFを avifW に書き込みます
+// ext は入力データの拡張子（例: ".jpg"）です。書き込み先が nil の形式、または設定で無効な形式は出力しません
+// 入力データのEXIFは読み取らないため、画像の向きは補正しません
+func (c *Converter) ConvertStream(r io.Reader, ext string, webpW, avifW io.Writer) error {
+	img, err := c.ic.DecodeStream(r, ext)
+	if err != nil {
+		return err
+	}
+	if img, err = c.ic.Transform(img, 1); err != nil {
+		return err
+	}
+	return c.ic.EncodeStreams(img, webpW, avifW)
+}
@@ -0,0 +1,126 @@
+package converter
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"sync"
+	"testing"
+
+	"github.com/223n/image-converter/internal/config"
+)
+
+// noisyPNG は画質によって出力サイズが変わるよう、細部の多いPNG画像を返します
+func noisyPNG(t *testing.T) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, 96, 96))
+	seed := uint32(1)
+	for y := 0; y < 96; y++ {
+		for x := 0; x < 96; x++ {
+			seed = seed*1664525 + 1013904223
+			img.Set(x, y, color.RGBA{uint8(seed >> 24), uint8(seed >> 16), uint8(x * 2), 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("PNGのエンコードに失敗しました: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// webpOnly は指定した画質でWebPのみを出力する変換設定を返します
+func webpOnly(quality int) Options {
+	opts := DefaultOptions()
+	opts.WebP.Enabled = true
+	opts.WebP.Quality = quality
+	opts.AVIF.Enabled = false
+	opts.JXL.Enabled = false
+	opts.AdaptiveQuality = false
+	return opts
+}
+
+// TestConvertersAreIsolated は画質の異なる2つの変換器を同時に使用しても、互いの設定やグローバルな設定の影響を受けないことを確認します
+func TestConvertersAreIsolated(t *testing.T) {
+	input := noisyPNG(t)
+	low := NewConverter(webpOnly(10))
+	high := NewConverter(webpOnly(95))
+
+	convert := func(c *Converter) []byte {
+		var out bytes.Buffer
+		if err := c.ConvertStream(bytes.NewReader(input), ".png", &out, nil); err != nil {
+			t.Errorf("ConvertStream に失敗しました: %v", err)
+			return nil
+		}
+		return out.Bytes()
+	}
+	wantLow, wantHigh := convert(low), convert(high)
+	if len(wantLow) == 0 || len(wantHigh) == 0 {
+		t.Fatal("WebPが出力されませんでした")
+	}
+	if len(wantLow) >= len(wantHigh) {
+		t.Fatalf("画質10の出力 (%d バイト) が画質95の出力 (%d バイト) より小さくありません", len(wantLow), len(wantHigh))
+	}
+
+	const iterations = 10
+	var wg sync.WaitGroup
+	for i := 0; i < iterations; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if got := convert(low); !bytes.Equal(got, wantLow) {
+				t.Errorf("画質10の変換器の出力が変わりました (%d バイト, want %d バイト)", len(got), len(wantLow))
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if got := convert(high); !bytes.Equal(got, wantHigh) {
+				t.Errorf("画質95の変換器の出力が変わりました (%d バイト, want %d バイト)", len(got), len(wantHigh))
+			}
+		}()
+	}
+
+	// 変換中にグローバルな設定を変更しても変換器の出力は変わらない
+	t.Cleanup(func() { config.LoadConfigFromBytes(nil) })
+	if err := config.LoadConfigFromBytes([]byte("conversion:\n  webp:\n    quality: 50\n    compression_level: 1\n")); err != nil {
+		t.Fatalf("設定の読み込みに失敗しました: %v", err)
+	}
+	wg.Wait()
+}
+
+// TestNewConverterCopiesOptions は作成後に元の設定を変更しても変換器の設定が変わらないことを確認します
+func TestNewConverterCopiesOptions(t *testing.T) {
+	opts := webpOnly(60)
+	opts.StripEXIFTags = []string{"GPS"}
+	c := NewConverter(opts)
+
+	opts.WebP.Quality = 10
+	opts.StripEXIFTags[0] = "Make"
+
+	got := c.Options()
+	if got.WebP.Quality != 60 {
+		t.Errorf("WebP.Quality = %d, want 60", got.WebP.Quality)
+	}
+	if len(got.StripEXIFTags) != 1 || got.StripEXIFTags[0] != "GPS" {
+		t.Errorf("StripEXIFTags = %v, want [GPS]", got.StripEXIFTags)
+	}
+}
+
+// TestConvertStreamTooManyPixels は画素数が上限を超える画像をデコードせずに ErrTooManyPixels を返すことを確認します
+func TestConvertStreamTooManyPixels(t *testing.T) {
+	opts := webpOnly(75)
+	opts.MaxDecodePixels = 100
+	c := NewConverter(opts)
+
+	var out bytes.Buffer
+	err := c.ConvertStream(bytes.NewReader(noisyPNG(t)), ".png", &out, nil)
+	if !errors.Is(err, ErrTooManyPixels) {
+		t.Fatalf("ConvertStream のエラー = %v, ErrTooManyPixels を期待しました", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("上限を超える画像が出力されました (%d バイト)", out.Len())
+	}
+}
@@ -0,0 +1,22 @@
+package imageutils
+
+import "image"
+
+// HasAlpha は画像に完全に不透明でないピクセル（透過）が含まれるかどうかを判定します
+// 標準の画像型は Opaque メソッドで判定し、それ以外の画像はすべてのピクセルを走査します
+// アルファチャンネルを持つ形式でも、すべてのピクセルが不透明な場合はfalseを返します
+func HasAlpha(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return !o.Opaque()
+	}
+
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if _, _, _, a := img.At(x, y).RGBA(); a != 0xffff {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,156 @@
+package imageutils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image/gif"
+	"io"
+	"os"
+)
+
+// IsAnimated は画像ファイルが複数フレームを持つアニメーション画像かどうかを判定します
+// 形式は拡張子ではなくマジックバイトで判定し、GIF・WebP・AVIF以外の形式は常にfalseを返します
+//   - GIF: 全フレームをデコードし、2フレーム以上あればアニメーションとみなします
+//   - WebP: RIFFコンテナにANIMチャンクがあればアニメーションとみなします
+//   - AVIF: ftypにイメージシーケンスのブランド（avis）があるか、mdatボックスが複数あればアニメーションとみなします
+func IsAnimated(path string) (bool, error) {
+	format, err := DetectFormat(path)
+	if err != nil {
+		return false, err
+	}
+
+	switch format {
+	case "gif":
+		return isAnimatedGIF(path)
+	case "webp":
+		return isAnimatedWebP(path)
+	case "avif":
+		return isAnimatedAVIF(path)
+	default:
+		return false, nil
+	}
+}
+
+// isAnimatedGIF はGIFのフレーム数からアニメーションかどうかを判定します
+func isAnimatedGIF(path string) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, fmt.Errorf("ファイルを開けません: %v", err)
+	}
+	defer file.Close()
+
+	g, err := gif.DecodeAll(file)
+	if err != nil {
+		return false, fmt.Errorf("GIFのデコードに失敗しました: %v", err)
+	}
+
+	return len(g.Image) > 1, nil
+}
+
+// isAnimatedWebP はRIFFコンテナのチャンクを走査し、ANIMチャンクの有無を判定します
+func isAnimatedWebP(path string) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, fmt.Errorf("ファイルを開けません: %v", err)
+	}
+	defer file.Close()
+
+	// RIFFヘッダー（"RIFF" + サイズ + "WEBP"）を読み飛ばす
+	if _, err := file.Seek(12, io.SeekStart); err != nil {
+		return false, fmt.Errorf("WebPヘッダーの読み込みに失敗しました: %v", err)
+	}
+
+	header := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(file, header); err != nil {
+			// チャンクの終端に達した
+			return false, nil
+		}
+
+		if string(header[0:4]) == "ANIM" {
+			return true, nil
+		}
+
+		// チャンクのデータは偶数バイトに揃えられる
+		size := int64(binary.LittleEndian.Uint32(header[4:8]))
+		if _, err := file.Seek(size+size%2, io.SeekCurrent); err != nil {
+			return false, fmt.Errorf("WebPチャンクの読み込みに失敗しました: %v", err)
+		}
+	}
+}
+
+// isAnimatedAVIF はISOBMFFの最上位ボックスを走査し、イメージシーケンスかどうかを判定します
+func isAnimatedAVIF(path string) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, fmt.Errorf("ファイルを開けません: %v", err)
+	}
+	defer file.Close()
+
+	mdatCount := 0
+	header := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(file, header); err != nil {
+			// ボックスの終端に達した
+			return mdatCount > 1, nil
+		}
+
+		boxType := string(header[4:8])
+		size := int64(binary.BigEndian.Uint32(header[0:4]))
+		headerSize := int64(8)
+
+		switch size {
+		case 0:
+			// ファイル末尾まで続くボックス（最後のボックス）
+			if boxType == "mdat" {
+				mdatCount++
+			}
+			return mdatCount > 1, nil
+		case 1:
+			// 64ビットのサイズが続く
+			large := make([]byte, 8)
+			if _, err := io.ReadFull(file, large); err != nil {
+				return false, fmt.Errorf("AVIFボックスの読み込みに失敗しました: %v", err)
+			}
+			size = int64(binary.BigEndian.Uint64(large))
+			headerSize += 8
+		}
+
+		if size < headerSize {
+			return false, fmt.Errorf("AVIFボックスのサイズが不正です: %s (%d バイト)", boxType, size)
+		}
+
+		switch boxType {
+		case "ftyp":
+			brands := make([]byte, size-headerSize)
+			if _, err := io.ReadFull(file, brands); err != nil {
+				return false, fmt.Errorf("AVIFのftypボックスの読み込みに失敗しました: %v", err)
+			}
+			if hasISOBMFFBrand(brands, "avis") {
+				return true, nil
+			}
+			continue
+		case "mdat":
+			mdatCount++
+		}
+
+		if _, err := file.Seek(size-headerSize, io.SeekCurrent); err != nil {
+			return false, fmt.Errorf("AVIFボックスの読み込みに失敗しました: %v", err)
+		}
+	}
+}
+
+// hasISOBMFFBrand はftypボックスの内容（メジャーブランド・バージョン・互換ブランド）に brand が含まれるかを判定します
+func hasISOBMFFBrand(ftyp []byte, brand string) bool {
+	if len(ftyp) >= 4 && bytes.Equal(ftyp[0:4], []byte(brand)) {
+		return true
+	}
+	// 互換ブランドはマイナーバージョン（4バイト）の後に並ぶ
+	for i := 8; i+4 <= len(ftyp); i += 4 {
+		if bytes.Equal(ftyp[i:i+4], []byte(brand)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package imageutils
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"testing"
+)
+
+// gifWithFrames は frames 枚のフレームを持つGIFを返します
+func gifWithFrames(t *testing.T, frames int) []byte {
+	t.Helper()
+
+	palette := color.Palette{color.Black, color.White}
+	g := &gif.GIF{}
+	for i := 0; i < frames; i++ {
+		frame := image.NewPaletted(image.Rect(0, 0, 8, 6), palette)
+		for p := range frame.Pix {
+			frame.Pix[p] = uint8(i % len(palette))
+		}
+		g.Image = append(g.Image, frame)
+		g.Delay = append(g.Delay, 10)
+	}
+
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, g); err != nil {
+		t.Fatalf("GIFのエンコードに失敗しました: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestIsAnimated(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		data    []byte
+		want    bool
+		wantErr bool
+	}{
+		{name: "3フレームのGIF", file: "animated.gif", data: gifWithFrames(t, 3), want: true},
+		{name: "1フレームのGIF", file: "static.gif", data: gifWithFrames(t, 1), want: false},
+		{name: "拡張子のないGIF", file: "animated", data: gifWithFrames(t, 3), want: true},
+		{name: "アニメーションに対応しない形式", file: "image.png", data: encodedImage(t, "png"), want: false},
+		{name: "壊れたGIF", file: "broken.gif", data: []byte("GIF89a\x08\x00\x06\x00"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := IsAnimated(writeTempFile(t, tt.file, tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("IsAnimated のエラー = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("IsAnimated = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("ファイルがない", func(t *testing.T) {
+		if _, err := IsAnimated("missing.gif"); err == nil {
+			t.Error("存在しないファイルでエラーになりませんでした")
+		}
+	})
+}
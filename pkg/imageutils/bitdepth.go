@@ -0,0 +1,72 @@
+package imageutils
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// bayerMatrix は4x4の順序ディザリングに使用する閾値行列です
+var bayerMatrix = [4][4]float64{
+	{0, 8, 2, 10},
+	{12, 4, 14, 6},
+	{3, 11, 1, 9},
+	{15, 7, 13, 5},
+}
+
+// SourceBitDepth は画像の1チャンネルあたりのビット深度（8または16）を返します
+// 16ビットのPNGなど、16ビットの色モデルを持つ画像の場合は16を返します
+func SourceBitDepth(img image.Image) int {
+	switch img.(type) {
+	case *image.RGBA64, *image.NRGBA64, *image.Gray16:
+		return 16
+	default:
+		return 8
+	}
+}
+
+// ReduceBitDepth は画像を指定したビット深度に順序ディザリングで減色します
+// bits が8の場合は *image.NRGBA を、それ以外は値を bits ビットに丸めた *image.NRGBA64 を返します
+// 単純な切り捨てで生じるグラデーションの縞（バンディング）を抑えるために使用します
+func ReduceBitDepth(img image.Image, bits int) image.Image {
+	bits = max(1, min(16, bits))
+	levels := float64(int(1)<<bits - 1)
+	bounds := img.Bounds()
+
+	// 位置ごとの閾値（0〜1）を加えてから切り捨てることで、丸め誤差を空間的に分散させる
+	quantize := func(v uint16, x, y int) uint16 {
+		threshold := (bayerMatrix[y&3][x&3] + 0.5) / 16
+		q := math.Min(math.Floor(float64(v)*levels/0xffff+threshold), levels)
+		return uint16(math.Round(q * 0xffff / levels))
+	}
+
+	if bits == 8 {
+		out := image.NewNRGBA(bounds)
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				c := color.NRGBA64Model.Convert(img.At(x, y)).(color.NRGBA64)
+				out.SetNRGBA(x, y, color.NRGBA{
+					R: uint8(quantize(c.R, x, y) >> 8),
+					G: uint8(quantize(c.G, x, y) >> 8),
+					B: uint8(quantize(c.B, x, y) >> 8),
+					A: uint8(quantize(c.A, x, y) >> 8),
+				})
+			}
+		}
+		return out
+	}
+
+	out := image.NewNRGBA64(bounds)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBA64Model.Convert(img.At(x, y)).(color.NRGBA64)
+			out.SetNRGBA64(x, y, color.NRGBA64{
+				R: quantize(c.R, x, y),
+				G: quantize(c.G, x, y),
+				B: quantize(c.B, x, y),
+				A: quantize(c.A, x, y),
+			})
+		}
+	}
+	return out
+}
@@ -0,0 +1,91 @@
+package imageutils
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// ImageComparison は2つの画像をピクセル単位で比較した結果です
+// 誤差は各チャンネル（R, G, B, A）を0〜255の尺度に揃えて計算します
+type ImageComparison struct {
+	DimensionsMatch   bool    // 幅と高さが一致するかどうか
+	MeanAbsoluteError float64 // チャンネルごとの差の絶対値の平均（0で完全一致）
+	MaxPixelDelta     int     // チャンネルごとの差の絶対値の最大値（0〜255）
+	DifferentPixels   int     // いずれかのチャンネルが異なるピクセル数
+	TotalPixels       int     // 比較したピクセル数
+}
+
+// CompareImages は変換前後の画像をピクセル単位で比較し、誤差を返します
+// 寸法が異なる場合はリサンプリングせず、DimensionsMatch を false にしてエラーを返します
+// 画像の原点（Bounds().Min）が異なる場合は、それぞれの左上を揃えて比較します
+func CompareImages(a, b image.Image) (ImageComparison, error) {
+	ab, bb := a.Bounds(), b.Bounds()
+	if ab.Dx() != bb.Dx() || ab.Dy() != bb.Dy() {
+		return ImageComparison{}, fmt.Errorf("画像の寸法が一致しません: %s と %s",
+			FormatImageDimensions(ab.Dx(), ab.Dy()), FormatImageDimensions(bb.Dx(), bb.Dy()))
+	}
+
+	result := ImageComparison{
+		DimensionsMatch: true,
+		TotalPixels:     ab.Dx() * ab.Dy(),
+	}
+	if result.TotalPixels == 0 {
+		return result, nil
+	}
+
+	var sum int64
+	for y := 0; y < ab.Dy(); y++ {
+		for x := 0; x < ab.Dx(); x++ {
+			ca := color.NRGBAModel.Convert(a.At(ab.Min.X+x, ab.Min.Y+y)).(color.NRGBA)
+			cb := color.NRGBAModel.Convert(b.At(bb.Min.X+x, bb.Min.Y+y)).(color.NRGBA)
+
+			differs := false
+			for _, d := range [4]int{
+				absDiff(ca.R, cb.R), absDiff(ca.G, cb.G), absDiff(ca.B, cb.B), absDiff(ca.A, cb.A),
+			} {
+				sum += int64(d)
+				result.MaxPixelDelta = max(result.MaxPixelDelta, d)
+				differs = differs || d != 0
+			}
+			if differs {
+				result.DifferentPixels++
+			}
+		}
+	}
+
+	result.MeanAbsoluteError = float64(sum) / float64(result.TotalPixels*4)
+	return result, nil
+}
+
+// ImagesEqual は2つの画像がピクセル単位で完全に一致するかどうかと、一致しないピクセル数を返します
+// 可逆圧縮の出力の検証に使用します。比較は color.Color の RGBA 値（アルファ乗算済みの16ビット値）で行うため、
+// 完全に透明なピクセルの色の違いは無視されます
+// 範囲（Bounds）が異なる場合は比較せずに false, 0 を返します
+func ImagesEqual(a, b image.Image) (equal bool, differingPixels int64) {
+	bounds := a.Bounds()
+	if bounds != b.Bounds() {
+		return false, 0
+	}
+
+	var differing int64
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			ar, ag, ab, aa := a.At(x, y).RGBA()
+			br, bg, bb, ba := b.At(x, y).RGBA()
+			if ar != br || ag != bg || ab != bb || aa != ba {
+				differing++
+			}
+		}
+	}
+
+	return differing == 0, differing
+}
+
+// absDiff は2つのチャンネル値の差の絶対値を返します
+func absDiff(a, b uint8) int {
+	if a > b {
+		return int(a - b)
+	}
+	return int(b - a)
+}
@@ -0,0 +1,149 @@
+package imageutils
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// solidImage は全体を c で塗りつぶした画像を返します
+func solidImage(width, height int, c color.Color) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+// gradientImage は位置によって色の変わる画像を返します
+func gradientImage(width, height int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.NRGBA{uint8(x * 16), uint8(y * 16), uint8(x + y), 255})
+		}
+	}
+	return img
+}
+
+func TestImagesEqual(t *testing.T) {
+	base := gradientImage(8, 6)
+
+	onePixelOff := gradientImage(8, 6)
+	onePixelOff.Set(3, 2, color.NRGBA{255, 0, 0, 255})
+
+	tests := []struct {
+		name          string
+		a, b          image.Image
+		wantEqual     bool
+		wantDiffering int64
+	}{
+		{name: "同一の画像", a: base, b: gradientImage(8, 6), wantEqual: true, wantDiffering: 0},
+		{name: "1ピクセルだけ異なる", a: base, b: onePixelOff, wantEqual: false, wantDiffering: 1},
+		{name: "すべて異なる", a: solidImage(8, 6, color.White), b: solidImage(8, 6, color.Black), wantEqual: false, wantDiffering: 8 * 6},
+		{name: "寸法が異なる", a: base, b: gradientImage(6, 8), wantEqual: false, wantDiffering: 0},
+		{name: "空の画像", a: image.NewNRGBA(image.Rectangle{}), b: image.NewNRGBA(image.Rectangle{}), wantEqual: true, wantDiffering: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			equal, differing := ImagesEqual(tt.a, tt.b)
+			if equal != tt.wantEqual || differing != tt.wantDiffering {
+				t.Errorf("ImagesEqual = (%v, %d), want (%v, %d)", equal, differing, tt.wantEqual, tt.wantDiffering)
+			}
+		})
+	}
+}
+
+// TestImagesEqualColorModels は色のモデルが異なっても、同じ色であれば一致とみなすことを確認します
+func TestImagesEqualColorModels(t *testing.T) {
+	nrgba := solidImage(4, 4, color.NRGBA{10, 20, 30, 255})
+	rgba := image.NewRGBA(nrgba.Bounds())
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			rgba.Set(x, y, color.RGBA{10, 20, 30, 255})
+		}
+	}
+
+	if equal, differing := ImagesEqual(nrgba, rgba); !equal || differing != 0 {
+		t.Errorf("ImagesEqual = (%v, %d), want (true, 0)", equal, differing)
+	}
+}
+
+func TestCompareImages(t *testing.T) {
+	base := solidImage(4, 5, color.NRGBA{100, 100, 100, 255})
+
+	onePixelOff := solidImage(4, 5, color.NRGBA{100, 100, 100, 255})
+	onePixelOff.Set(1, 1, color.NRGBA{110, 90, 100, 255})
+
+	offset := image.NewNRGBA(image.Rect(10, 20, 14, 25))
+	for y := 20; y < 25; y++ {
+		for x := 10; x < 14; x++ {
+			offset.Set(x, y, color.NRGBA{100, 100, 100, 255})
+		}
+	}
+
+	tests := []struct {
+		name string
+		a, b image.Image
+		want ImageComparison
+	}{
+		{
+			name: "同一の画像",
+			a:    base, b: solidImage(4, 5, color.NRGBA{100, 100, 100, 255}),
+			want: ImageComparison{DimensionsMatch: true, TotalPixels: 20},
+		},
+		{
+			// 2チャンネルが10ずつ異なる: 20 / (20ピクセル * 4チャンネル)
+			name: "1ピクセルだけ異なる",
+			a:    base, b: onePixelOff,
+			want: ImageComparison{DimensionsMatch: true, MeanAbsoluteError: 0.25, MaxPixelDelta: 10, DifferentPixels: 1, TotalPixels: 20},
+		},
+		{
+			// R, G, B が255ずつ異なる: 255 * 3 / 4
+			name: "白と黒",
+			a:    solidImage(2, 2, color.White), b: solidImage(2, 2, color.Black),
+			want: ImageComparison{DimensionsMatch: true, MeanAbsoluteError: 191.25, MaxPixelDelta: 255, DifferentPixels: 4, TotalPixels: 4},
+		},
+		{
+			name: "アルファのみ異なる",
+			a:    solidImage(2, 1, color.NRGBA{0, 0, 0, 255}), b: solidImage(2, 1, color.NRGBA{0, 0, 0, 155}),
+			want: ImageComparison{DimensionsMatch: true, MeanAbsoluteError: 25, MaxPixelDelta: 100, DifferentPixels: 2, TotalPixels: 2},
+		},
+		{
+			name: "原点が異なる",
+			a:    base, b: offset,
+			want: ImageComparison{DimensionsMatch: true, TotalPixels: 20},
+		},
+		{
+			name: "空の画像",
+			a:    image.NewNRGBA(image.Rectangle{}), b: image.NewNRGBA(image.Rectangle{}),
+			want: ImageComparison{DimensionsMatch: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CompareImages(tt.a, tt.b)
+			if err != nil {
+				t.Fatalf("CompareImages に失敗しました: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("CompareImages = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestCompareImagesDimensionMismatch は寸法が異なる場合にリサンプリングせずエラーを返すことを確認します
+func TestCompareImagesDimensionMismatch(t *testing.T) {
+	got, err := CompareImages(gradientImage(8, 6), gradientImage(6, 8))
+	if err == nil {
+		t.Fatal("寸法が異なる画像でエラーになりませんでした")
+	}
+	if got.DimensionsMatch {
+		t.Errorf("DimensionsMatch = true, want false")
+	}
+}
@@ -0,0 +1,314 @@
+package imageutils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/jdeng/goheif"
+)
+
+// EXIFタグ名の定数（設定ファイルの strip_exif_tags で指定する名前）
+const (
+	EXIFTagGPS               = "GPS"               // GPS情報（GPS IFD全体）
+	EXIFTagDateTime          = "DateTime"          // ファイル更新日時
+	EXIFTagDateTimeOriginal  = "DateTimeOriginal"  // 撮影日時
+	EXIFTagDateTimeDigitized = "DateTimeDigitized" // デジタル化日時
+	EXIFTagMakerNote         = "MakerNote"         // メーカー独自情報
+	EXIFTagMake              = "Make"              // カメラメーカー
+	EXIFTagModel             = "Model"             // カメラ機種
+	EXIFTagSoftware          = "Software"          // 使用ソフトウェア
+	EXIFTagArtist            = "Artist"            // 撮影者
+	EXIFTagCopyright         = "Copyright"         // 著作権情報
+	EXIFTagOrientation       = "Orientation"       // 画像の向き
+	EXIFTagUserComment       = "UserComment"       // ユーザーコメント
+	EXIFTagSerialNumber      = "BodySerialNumber"  // カメラのシリアル番号
+)
+
+// exifTagIDs はタグ名とEXIFタグ番号の対応表です
+var exifTagIDs = map[string]uint16{
+	EXIFTagGPS:               0x8825,
+	EXIFTagDateTime:          0x0132,
+	EXIFTagDateTimeOriginal:  0x9003,
+	EXIFTagDateTimeDigitized: 0x9004,
+	EXIFTagMakerNote:         0x927C,
+	EXIFTagMake:              0x010F,
+	EXIFTagModel:             0x0110,
+	EXIFTagSoftware:          0x0131,
+	EXIFTagArtist:            0x013B,
+	EXIFTagCopyright:         0x8298,
+	EXIFTagOrientation:       0x0112,
+	EXIFTagUserComment:       0x9286,
+	EXIFTagSerialNumber:      0xA431,
+}
+
+const (
+	exifIFDPointerTag = 0x8769 // Exif IFDへのポインタ
+	gpsIFDPointerTag  = 0x8825 // GPS IFDへのポインタ
+	exifEntrySize     = 12     // IFDエントリ1件のバイト数
+)
+
+// exifHeader はJPEGのAPP1セグメントやHEIFのExifアイテムの先頭に付くヘッダーです
+var exifHeader = []byte("Exif\x00\x00")
+
+// exifTypeSizes はEXIFのデータ型ごとの1要素あたりのバイト数です
+var exifTypeSizes = map[uint16]uint32{
+	1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 6: 1, 7: 1, 8: 2, 9: 4, 10: 8, 11: 4, 12: 8,
+}
+
+// IsKnownEXIFTag はタグ名が削除対象として指定可能かどうかを返します
+func IsKnownEXIFTag(name string) bool {
+	_, ok := exifTagIDs[name]
+	return ok
+}
+
+// KnownEXIFTags は指定可能なタグ名の一覧を返します
+func KnownEXIFTags() []string {
+	names := make([]string, 0, len(exifTagIDs))
+	for name := range exifTagIDs {
+		names = append(names, name)
+	}
+	return names
+}
+
+// ExtractEXIF は画像ファイルからEXIFデータ（TIFF形式の本体）を取り出します
+// EXIFを含まない場合や未対応の形式の場合は nil を返します
+func ExtractEXIF(path string) ([]byte, error) {
+	switch GetFormatFromExt(filepath.Ext(path)) {
+	case "jpeg":
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("ファイルの読み込みに失敗しました: %v", err)
+		}
+		return extractJPEGEXIF(data)
+	case "heif":
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("ファイルを開けません: %v", err)
+		}
+		defer file.Close()
+
+		data, err := goheif.ExtractExif(file)
+		if err != nil {
+			// EXIFアイテムを持たないHEIFも多いため、エラーにはしない
+			return nil, nil
+		}
+		return bytes.TrimPrefix(data, exifHeader), nil
+	default:
+		return nil, nil
+	}
+}
+
+// extractJPEGEXIF はJPEGのマーカーを走査し、APP1セグメントのEXIFを取り出します
+func extractJPEGEXIF(data []byte) ([]byte, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, fmt.Errorf("JPEGの形式が不正です")
+	}
+
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			return nil, fmt.Errorf("JPEGマーカーが不正です（オフセット %d）", pos)
+		}
+		marker := data[pos+1]
+		// SOS以降は画像データのため走査を終了する
+		if marker == 0xDA || marker == 0xD9 {
+			return nil, nil
+		}
+		length := int(binary.BigEndian.Uint16(data[pos+2 : pos+4]))
+		end := pos + 2 + length
+		if length < 2 || end > len(data) {
+			return nil, fmt.Errorf("JPEGセグメント長が不正です（オフセット %d）", pos)
+		}
+		if marker == 0xE1 && bytes.HasPrefix(data[pos+4:end], exifHeader) {
+			exif := data[pos+4+len(exifHeader) : end]
+			return append([]byte(nil), exif...), nil
+		}
+		pos = end
+	}
+
+	return nil, nil
+}
+
+// exifReader はTIFF形式のEXIFデータを読み書きするための補助構造体です
+type exifReader struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+func (r *exifReader) uint16At(offset uint32) (uint16, error) {
+	if uint64(offset)+2 > uint64(len(r.data)) {
+		return 0, fmt.Errorf("EXIFデータが途中で途切れています（オフセット %d）", offset)
+	}
+	return r.order.Uint16(r.data[offset:]), nil
+}
+
+func (r *exifReader) uint32At(offset uint32) (uint32, error) {
+	if uint64(offset)+4 > uint64(len(r.data)) {
+		return 0, fmt.Errorf("EXIFデータが途中で途切れています（オフセット %d）", offset)
+	}
+	return r.order.Uint32(r.data[offset:]), nil
+}
+
+// zero は指定範囲のバイトを0で埋めます（範囲外は無視します）
+func (r *exifReader) zero(offset, length uint32) {
+	end := uint64(offset) + uint64(length)
+	if end > uint64(len(r.data)) {
+		return
+	}
+	for i := offset; i < uint32(end); i++ {
+		r.data[i] = 0
+	}
+}
+
+// StripEXIFTags はEXIFデータから指定したタグを取り除いたコピーを返します
+// 値の領域も0で埋めるため、削除したタグの内容はデータ中に残りません
+// tags が空の場合は何も削除せずにコピーを返します
+func StripEXIFTags(exif []byte, tags []string) ([]byte, error) {
+	data := append([]byte(nil), bytes.TrimPrefix(exif, exifHeader)...)
+	if len(tags) == 0 {
+		return data, nil
+	}
+
+	remove := make(map[uint16]bool, len(tags))
+	for _, name := range tags {
+		id, ok := exifTagIDs[name]
+		if !ok {
+			return nil, fmt.Errorf("未対応のEXIFタグ名です: %s", name)
+		}
+		remove[id] = true
+	}
+
+	if len(data) < 8 {
+		return nil, fmt.Errorf("EXIFデータが短すぎます")
+	}
+
+	r := &exifReader{data: data}
+	switch string(data[:2]) {
+	case "II":
+		r.order = binary.LittleEndian
+	case "MM":
+		r.order = binary.BigEndian
+	default:
+		return nil, fmt.Errorf("EXIFのバイトオーダーが不正です")
+	}
+
+	ifd0, err := r.uint32At(4)
+	if err != nil {
+		return nil, err
+	}
+
+	// IFD0とIFD1（サムネイル）を処理する
+	visited := make(map[uint32]bool)
+	next, err := r.stripIFD(ifd0, remove, visited)
+	if err != nil {
+		return nil, err
+	}
+	if next != 0 {
+		if _, err := r.stripIFD(next, remove, visited); err != nil {
+			return nil, err
+		}
+	}
+
+	return r.data, nil
+}
+
+// stripIFD はIFD内の削除対象エントリを取り除き、次のIFDのオフセットを返します
+// Exif IFDへのポインタは再帰的に処理し、GPS IFDは指定された場合に丸ごと消去します
+func (r *exifReader) stripIFD(offset uint32, remove map[uint16]bool, visited map[uint32]bool) (uint32, error) {
+	if offset == 0 || visited[offset] {
+		return 0, nil
+	}
+	visited[offset] = true
+
+	count, err := r.uint16At(offset)
+	if err != nil {
+		return 0, err
+	}
+	entriesStart := offset + 2
+	nextPos := entriesStart + uint32(count)*exifEntrySize
+	next, err := r.uint32At(nextPos)
+	if err != nil {
+		return 0, err
+	}
+
+	kept := 0
+	for i := 0; i < int(count); i++ {
+		entry := entriesStart + uint32(i)*exifEntrySize
+		tag := r.order.Uint16(r.data[entry:])
+
+		if tag == exifIFDPointerTag && !remove[tag] {
+			sub := r.order.Uint32(r.data[entry+8:])
+			if _, err := r.stripIFD(sub, remove, visited); err != nil {
+				return 0, err
+			}
+		}
+
+		if remove[tag] {
+			r.clearEntry(entry, visited)
+			continue
+		}
+
+		// 残すエントリを前方に詰める
+		dst := entriesStart + uint32(kept)*exifEntrySize
+		if dst != entry {
+			copy(r.data[dst:dst+exifEntrySize], r.data[entry:entry+exifEntrySize])
+		}
+		kept++
+	}
+
+	if kept != int(count) {
+		// エントリ数と次のIFDへのオフセットを書き直し、空いた領域を0で埋める
+		r.order.PutUint16(r.data[offset:], uint16(kept))
+		newNextPos := entriesStart + uint32(kept)*exifEntrySize
+		r.order.PutUint32(r.data[newNextPos:], next)
+		r.zero(newNextPos+4, nextPos-newNextPos)
+	}
+
+	return next, nil
+}
+
+// clearEntry は削除するエントリが参照する値の領域を0で埋めます
+func (r *exifReader) clearEntry(entry uint32, visited map[uint32]bool) {
+	tag := r.order.Uint16(r.data[entry:])
+	typ := r.order.Uint16(r.data[entry+2:])
+	count := r.order.Uint32(r.data[entry+4:])
+	valueOffset := r.order.Uint32(r.data[entry+8:])
+
+	if tag == gpsIFDPointerTag || tag == exifIFDPointerTag {
+		r.clearIFD(valueOffset, visited)
+		return
+	}
+
+	size, ok := exifTypeSizes[typ]
+	if !ok {
+		return
+	}
+	total := uint64(size) * uint64(count)
+	if total > 4 && total <= uint64(len(r.data)) {
+		r.zero(valueOffset, uint32(total))
+	}
+}
+
+// clearIFD はIFDとそのエントリが参照する値をすべて0で埋めます
+func (r *exifReader) clearIFD(offset uint32, visited map[uint32]bool) {
+	if offset == 0 || visited[offset] {
+		return
+	}
+	visited[offset] = true
+
+	count, err := r.uint16At(offset)
+	if err != nil {
+		return
+	}
+	end := offset + 2 + uint32(count)*exifEntrySize + 4
+	if uint64(end) > uint64(len(r.data)) {
+		return
+	}
+	for i := 0; i < int(count); i++ {
+		r.clearEntry(offset+2+uint32(i)*exifEntrySize, visited)
+	}
+	r.zero(offset, end-offset)
+}
@@ -0,0 +1,133 @@
+package imageutils
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/rwcarlsen/goexif/exif"
+	"github.com/rwcarlsen/goexif/tiff"
+)
+
+// EXIF情報のキー（ImageInfo.EXIF のキー）
+const (
+	EXIFKeyMake         = "make"          // カメラのメーカー
+	EXIFKeyModel        = "model"         // カメラの機種
+	EXIFKeyDateTaken    = "date_taken"    // 撮影日時（EXIFの記録形式のまま）
+	EXIFKeyGPSLatitude  = "gps_latitude"  // 緯度（10進数、南緯は負）
+	EXIFKeyGPSLongitude = "gps_longitude" // 経度（10進数、西経は負）
+	EXIFKeyISO          = "iso"           // ISO感度
+	EXIFKeyAperture     = "aperture"      // 絞り値（例: f/2.8）
+	EXIFKeyShutterSpeed = "shutter_speed" // シャッター速度（例: 1/125）
+)
+
+// readEXIFInfo はJPEG・HEICのEXIFから主要な撮影情報を取り出します
+// EXIFを含まない場合や解析できない場合は nil を返します。撮影日時を取得できない場合はゼロ値を返します
+func readEXIFInfo(path string) (map[string]string, time.Time) {
+	data, err := ExtractEXIF(path)
+	if err != nil || len(data) == 0 {
+		return nil, time.Time{}
+	}
+
+	x, err := exif.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, time.Time{}
+	}
+
+	values := make(map[string]string)
+	if s, ok := exifString(x, exif.Make); ok {
+		values[EXIFKeyMake] = s
+	}
+	if s, ok := exifString(x, exif.Model); ok {
+		values[EXIFKeyModel] = s
+	}
+
+	var dateTaken time.Time
+	if t, err := x.DateTime(); err == nil {
+		dateTaken = t
+		values[EXIFKeyDateTaken] = t.Format("2006-01-02 15:04:05")
+	}
+
+	if lat, long, err := x.LatLong(); err == nil {
+		values[EXIFKeyGPSLatitude] = strconv.FormatFloat(lat, 'f', 6, 64)
+		values[EXIFKeyGPSLongitude] = strconv.FormatFloat(long, 'f', 6, 64)
+	}
+
+	if tag, err := x.Get(exif.ISOSpeedRatings); err == nil {
+		if iso, err := tag.Int(0); err == nil {
+			values[EXIFKeyISO] = strconv.Itoa(iso)
+		}
+	}
+	if r, ok := exifRat(x, exif.FNumber); ok {
+		f, _ := r.Float64()
+		values[EXIFKeyAperture] = fmt.Sprintf("f/%s", strconv.FormatFloat(f, 'f', -1, 64))
+	}
+	if r, ok := exifRat(x, exif.ExposureTime); ok {
+		values[EXIFKeyShutterSpeed] = formatExposureTime(r)
+	}
+
+	if len(values) == 0 {
+		return nil, dateTaken
+	}
+	return values, dateTaken
+}
+
+// exifString は文字列のタグを取り出します
+func exifString(x *exif.Exif, name exif.FieldName) (string, bool) {
+	tag, err := x.Get(name)
+	if err != nil || tag.Format() != tiff.StringVal {
+		return "", false
+	}
+	s, err := tag.StringVal()
+	if err != nil || s == "" {
+		return "", false
+	}
+	return s, true
+}
+
+// exifRat は有理数のタグを取り出します
+func exifRat(x *exif.Exif, name exif.FieldName) (*big.Rat, bool) {
+	tag, err := x.Get(name)
+	if err != nil {
+		return nil, false
+	}
+	r, err := tag.Rat(0)
+	if err != nil || r.Sign() <= 0 {
+		return nil, false
+	}
+	return r, true
+}
+
+// formatExposureTime は露出時間を一般的な表記（1秒未満は 1/125、それ以上は 2.5）に整形します
+func formatExposureTime(r *big.Rat) string {
+	f, _ := r.Float64()
+	if f >= 1 {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return fmt.Sprintf("1/%d", int(1/f+0.5))
+}
+
+// ReadOrientation はJPEG・HEICのEXIFから画像の向き（Orientation、1〜8）を読み取ります
+// EXIFや Orientation タグがない場合、値が範囲外の場合は補正不要を表す1を返します
+func ReadOrientation(path string) int {
+	data, err := ExtractEXIF(path)
+	if err != nil || len(data) == 0 {
+		return 1
+	}
+
+	x, err := exif.Decode(bytes.NewReader(data))
+	if err != nil {
+		return 1
+	}
+	tag, err := x.Get(exif.Orientation)
+	if err != nil {
+		return 1
+	}
+	orientation, err := tag.Int(0)
+	if err != nil || orientation < 1 || orientation > 8 {
+		return 1
+	}
+	return orientation
+}
@@ -0,0 +1,290 @@
+package imageutils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"reflect"
+	"testing"
+)
+
+// exifShort はIFD0に記録するSHORT型（1値）のタグです
+type exifShort struct {
+	tag   uint16
+	value uint16
+}
+
+// tiffEXIF はIFD0にSHORT型のタグだけを持つ、リトルエンディアンのEXIF（TIFF形式の本体）を返します
+func tiffEXIF(entries ...exifShort) []byte {
+	var buf bytes.Buffer
+	le := binary.LittleEndian
+	buf.WriteString("II")
+	binary.Write(&buf, le, uint16(42))
+	binary.Write(&buf, le, uint32(8)) // IFD0のオフセット
+
+	binary.Write(&buf, le, uint16(len(entries)))
+	for _, e := range entries {
+		binary.Write(&buf, le, e.tag)
+		binary.Write(&buf, le, uint16(3)) // SHORT
+		binary.Write(&buf, le, uint32(1))
+		binary.Write(&buf, le, e.value)
+		binary.Write(&buf, le, uint16(0)) // 4バイトの値領域の残り
+	}
+	binary.Write(&buf, le, uint32(0)) // 次のIFDはない
+	return buf.Bytes()
+}
+
+// exifField はIFDに記録する任意の型のタグです（value はリトルエンディアンで符号化した値）
+type exifField struct {
+	tag   uint16
+	typ   uint16
+	count uint32
+	value []byte
+}
+
+// asciiField は文字列（NUL終端を含む）のタグを返します
+func asciiField(tag uint16, s string) exifField {
+	return exifField{tag: tag, typ: 2, count: uint32(len(s) + 1), value: append([]byte(s), 0)}
+}
+
+// shortField はSHORT型（1値）のタグを返します
+func shortField(tag, v uint16) exifField {
+	return exifField{tag: tag, typ: 3, count: 1, value: binary.LittleEndian.AppendUint16(nil, v)}
+}
+
+// longField はLONG型（1値）のタグを返します
+func longField(tag uint16, v uint32) exifField {
+	return exifField{tag: tag, typ: 4, count: 1, value: binary.LittleEndian.AppendUint32(nil, v)}
+}
+
+// rationalField は分子・分母の組を並べたRATIONAL型のタグを返します
+func rationalField(tag uint16, values ...[2]uint32) exifField {
+	f := exifField{tag: tag, typ: 5, count: uint32(len(values))}
+	for _, v := range values {
+		f.value = binary.LittleEndian.AppendUint32(f.value, v[0])
+		f.value = binary.LittleEndian.AppendUint32(f.value, v[1])
+	}
+	return f
+}
+
+// ifdSize はIFDとその値領域の合計のバイト数を返します
+func ifdSize(fields []exifField) uint32 {
+	size := uint32(2 + 12*len(fields) + 4)
+	for _, f := range fields {
+		if len(f.value) > 4 {
+			size += uint32(len(f.value)+1) &^ 1
+		}
+	}
+	return size
+}
+
+// writeIFD は offset の位置に置くIFDを、4バイトを超える値を直後に並べて書き込みます
+func writeIFD(buf *bytes.Buffer, fields []exifField, offset uint32) {
+	le := binary.LittleEndian
+	dataOffset := offset + uint32(2+12*len(fields)+4)
+	var data bytes.Buffer
+
+	binary.Write(buf, le, uint16(len(fields)))
+	for _, f := range fields {
+		binary.Write(buf, le, f.tag)
+		binary.Write(buf, le, f.typ)
+		binary.Write(buf, le, f.count)
+		if len(f.value) <= 4 {
+			buf.Write(append(f.value, make([]byte, 4-len(f.value))...))
+			continue
+		}
+		binary.Write(buf, le, dataOffset+uint32(data.Len()))
+		data.Write(f.value)
+		if data.Len()%2 == 1 {
+			data.WriteByte(0)
+		}
+	}
+	binary.Write(buf, le, uint32(0)) // 次のIFDはない
+	buf.Write(data.Bytes())
+}
+
+// tiffEXIFWithIFDs はIFD0に加えて、空でない場合はExif IFDとGPS IFDを持つリトルエンディアンのEXIFを返します
+// IFD0のタグは番号順に並べてください（各IFDへのポインタは末尾に追加します）
+func tiffEXIFWithIFDs(ifd0, exifIFD, gpsIFD []exifField) []byte {
+	ifd0 = append([]exifField{}, ifd0...)
+	if len(exifIFD) > 0 {
+		ifd0 = append(ifd0, longField(0x8769, 0))
+	}
+	if len(gpsIFD) > 0 {
+		ifd0 = append(ifd0, longField(0x8825, 0))
+	}
+
+	// 各IFDの位置を決めてからポインタを設定する
+	exifOffset := 8 + ifdSize(ifd0)
+	gpsOffset := exifOffset
+	if len(exifIFD) > 0 {
+		gpsOffset += ifdSize(exifIFD)
+	}
+	for i := range ifd0 {
+		switch ifd0[i].tag {
+		case 0x8769:
+			ifd0[i] = longField(0x8769, exifOffset)
+		case 0x8825:
+			ifd0[i] = longField(0x8825, gpsOffset)
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("II")
+	binary.Write(&buf, binary.LittleEndian, uint16(42))
+	binary.Write(&buf, binary.LittleEndian, uint32(8)) // IFD0のオフセット
+	writeIFD(&buf, ifd0, 8)
+	if len(exifIFD) > 0 {
+		writeIFD(&buf, exifIFD, exifOffset)
+	}
+	if len(gpsIFD) > 0 {
+		writeIFD(&buf, gpsIFD, gpsOffset)
+	}
+	return buf.Bytes()
+}
+
+// gpsFields は度・分・秒（1/100秒単位）で指定した緯度・経度のGPS IFDのタグを返します
+func gpsFields(latRef string, lat [3]uint32, longRef string, long [3]uint32) []exifField {
+	return []exifField{
+		asciiField(0x0001, latRef),
+		rationalField(0x0002, [2]uint32{lat[0], 1}, [2]uint32{lat[1], 1}, [2]uint32{lat[2], 100}),
+		asciiField(0x0003, longRef),
+		rationalField(0x0004, [2]uint32{long[0], 1}, [2]uint32{long[1], 1}, [2]uint32{long[2], 100}),
+	}
+}
+
+// jpegWithEXIF は width x height のJPEGのSOIの直後に、exif をAPP1セグメントとして挿入したデータを返します
+// exif が nil の場合はEXIFを持たないJPEGを返します
+func jpegWithEXIF(t *testing.T, width, height int, exif []byte) []byte {
+	t.Helper()
+
+	var encoded bytes.Buffer
+	if err := jpeg.Encode(&encoded, image.NewRGBA(image.Rect(0, 0, width, height)), nil); err != nil {
+		t.Fatalf("JPEGのエンコードに失敗しました: %v", err)
+	}
+	if exif == nil {
+		return encoded.Bytes()
+	}
+
+	payload := append([]byte("Exif\x00\x00"), exif...)
+	var out bytes.Buffer
+	out.Write([]byte{0xFF, 0xD8, 0xFF, 0xE1})
+	binary.Write(&out, binary.BigEndian, uint16(len(payload)+2))
+	out.Write(payload)
+	out.Write(encoded.Bytes()[2:])
+	return out.Bytes()
+}
+
+func TestReadOrientation(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		data []byte
+		want int
+	}{
+		{name: "90度回転", file: "rotated.jpg", data: jpegWithEXIF(t, 4, 2, tiffEXIF(exifShort{0x0112, 6})), want: 6},
+		{name: "左右反転", file: "mirrored.jpg", data: jpegWithEXIF(t, 4, 2, tiffEXIF(exifShort{0x0112, 2})), want: 2},
+		{name: "範囲外の値", file: "invalid.jpg", data: jpegWithEXIF(t, 4, 2, tiffEXIF(exifShort{0x0112, 9})), want: 1},
+		{name: "Orientationがない", file: "other.jpg", data: jpegWithEXIF(t, 4, 2, tiffEXIF(exifShort{0xA002, 4})), want: 1},
+		{name: "EXIFがない", file: "plain.jpg", data: jpegWithEXIF(t, 4, 2, nil), want: 1},
+		{name: "EXIFに対応しない形式", file: "image.png", data: encodedImage(t, "png"), want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeTempFile(t, tt.file, tt.data)
+			if got := ReadOrientation(path); got != tt.want {
+				t.Errorf("ReadOrientation = %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("ファイルがない", func(t *testing.T) {
+		if got := ReadOrientation("missing.jpg"); got != 1 {
+			t.Errorf("ReadOrientation = %d, want 1", got)
+		}
+	})
+}
+
+func TestGetImageInfoEXIF(t *testing.T) {
+	camera := []exifField{asciiField(0x010F, "Canon"), asciiField(0x0110, "EOS R5")}
+	shooting := []exifField{
+		rationalField(0x829A, [2]uint32{1, 125}),  // ExposureTime
+		rationalField(0x829D, [2]uint32{28, 10}),  // FNumber
+		shortField(0x8827, 400),                   // ISOSpeedRatings
+		asciiField(0x9003, "2024:05:06 07:08:09"), // DateTimeOriginal
+	}
+
+	tests := []struct {
+		name     string
+		exif     []byte
+		want     map[string]string
+		wantDate string
+	}{
+		{
+			name: "北緯・東経のGPS",
+			exif: tiffEXIFWithIFDs(camera, shooting, gpsFields("N", [3]uint32{35, 39, 2916}, "E", [3]uint32{139, 44, 5472})),
+			want: map[string]string{
+				EXIFKeyMake:         "Canon",
+				EXIFKeyModel:        "EOS R5",
+				EXIFKeyDateTaken:    "2024-05-06 07:08:09",
+				EXIFKeyGPSLatitude:  "35.658100",
+				EXIFKeyGPSLongitude: "139.748533",
+				EXIFKeyISO:          "400",
+				EXIFKeyAperture:     "f/2.8",
+				EXIFKeyShutterSpeed: "1/125",
+			},
+			wantDate: "2024-05-06 07:08:09",
+		},
+		{
+			name: "南緯・西経のGPSのみ",
+			exif: tiffEXIFWithIFDs(nil, nil, gpsFields("S", [3]uint32{33, 51, 2412}, "W", [3]uint32{70, 40, 1200})),
+			want: map[string]string{
+				EXIFKeyGPSLatitude:  "-33.856700",
+				EXIFKeyGPSLongitude: "-70.670000",
+			},
+		},
+		{
+			name: "撮影情報のみ",
+			exif: tiffEXIFWithIFDs(camera, shooting, nil),
+			want: map[string]string{
+				EXIFKeyMake:         "Canon",
+				EXIFKeyModel:        "EOS R5",
+				EXIFKeyDateTaken:    "2024-05-06 07:08:09",
+				EXIFKeyISO:          "400",
+				EXIFKeyAperture:     "f/2.8",
+				EXIFKeyShutterSpeed: "1/125",
+			},
+			wantDate: "2024-05-06 07:08:09",
+		},
+		{name: "撮影情報を含まないEXIF", exif: tiffEXIF(exifShort{0x0112, 1})},
+		{name: "EXIFがない"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeTempFile(t, "photo.jpg", jpegWithEXIF(t, 8, 6, tt.exif))
+			info, err := GetImageInfo(path)
+			if err != nil {
+				t.Fatalf("GetImageInfo に失敗しました: %v", err)
+			}
+			if info.Width != 8 || info.Height != 6 || !info.IsValid {
+				t.Errorf("基本情報 = %+v", info)
+			}
+
+			// EXIFがない場合は空のマップではなく nil になる
+			if !reflect.DeepEqual(info.EXIF, tt.want) {
+				t.Errorf("EXIF = %#v, want %#v", info.EXIF, tt.want)
+			}
+
+			if tt.wantDate == "" {
+				if !info.DateTaken.IsZero() {
+					t.Errorf("DateTaken = %v, want ゼロ値", info.DateTaken)
+				}
+			} else if got := info.DateTaken.Format("2006-01-02 15:04:05"); got != tt.wantDate {
+				t.Errorf("DateTaken = %s, want %s", got, tt.wantDate)
+			}
+		})
+	}
+}
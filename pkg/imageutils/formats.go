@@ -11,6 +11,20 @@ func SupportedImageFormats() []string {
 	}
 }
 
+// SupportedInputFormats は変換元として読み込める画像形式のリストを返します
+func SupportedInputFormats() []string {
+	return []string{
+		"jpg", "jpeg", "png", "gif", "heic", "heif", "svg",
+	}
+}
+
+// SupportedOutputFormats は変換先として出力できる画像形式のリストを返します
+func SupportedOutputFormats() []string {
+	return []string{
+		"webp", "avif", "jxl",
+	}
+}
+
 // GetSupportedImageExtensions は対応している画像拡張子のリストを返します
 func GetSupportedImageExtensions() []string {
 	formats := SupportedImageFormats()
@@ -59,6 +73,16 @@ func IsAVIFExt(ext string) bool {
 	return ext == ".avif"
 }
 
+// IsJXLExt は拡張子がJPEG XLファイルかどうかを判断します
+func IsJXLExt(ext string) bool {
+	ext = strings.ToLower(ext)
+	if !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+
+	return ext == ".jxl"
+}
+
 // IsHEICExt は拡張子がHEIC/HEIFファイルかどうかを判断します
 func IsHEICExt(ext string) bool {
 	ext = strings.ToLower(ext)
@@ -87,6 +111,8 @@ func GetFormatFromExt(ext string) string {
 		return "webp"
 	case ".avif":
 		return "avif"
+	case ".jxl":
+		return "jxl"
 	case ".heic", ".heif":
 		return "heif"
 	default:
@@ -101,7 +127,7 @@ func GetExtFromFormat(format string) string {
 	switch format {
 	case "jpeg":
 		return ".jpg"
-	case "png", "gif", "webp", "avif":
+	case "png", "gif", "webp", "avif", "jxl":
 		return "." + format
 	case "heif":
 		return ".heic"
@@ -0,0 +1,88 @@
+package imageutils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"math"
+)
+
+// pngSignature はPNGファイルの先頭8バイトです
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// SRGBGamma はsRGBに相当するPNGのガンマ値（gAMAチャンクの 45455 / 100000）です
+const SRGBGamma = 1 / 2.2
+
+// ReadPNGGamma はPNGのgAMAチャンクに記録されたガンマ値（例: 0.45455）を返します
+// sRGBチャンクまたはiCCPチャンクがある場合はそちらが優先されるため、gAMAがあっても0を返します
+// gAMAチャンクがない場合も0を返します。画像データ（IDAT）より後のチャンクは読み込みません
+func ReadPNGGamma(r io.Reader) (float64, error) {
+	signature := make([]byte, len(pngSignature))
+	if _, err := io.ReadFull(r, signature); err != nil {
+		return 0, fmt.Errorf("PNGの読み込みに失敗しました: %v", err)
+	}
+	if !bytes.Equal(signature, pngSignature) {
+		return 0, fmt.Errorf("PNGファイルではありません")
+	}
+
+	var gamma float64
+	header := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			return 0, fmt.Errorf("PNGのチャンクの読み込みに失敗しました: %v", err)
+		}
+		length := binary.BigEndian.Uint32(header[:4])
+		chunkType := string(header[4:8])
+
+		switch chunkType {
+		case "sRGB", "iCCP":
+			return 0, nil
+		case "IDAT", "IEND":
+			return gamma, nil
+		case "gAMA":
+			if length != 4 {
+				return 0, fmt.Errorf("gAMAチャンクの長さが不正です: %d", length)
+			}
+			value := make([]byte, 4)
+			if _, err := io.ReadFull(r, value); err != nil {
+				return 0, fmt.Errorf("gAMAチャンクの読み込みに失敗しました: %v", err)
+			}
+			gamma = float64(binary.BigEndian.Uint32(value)) / 100000
+			length = 0
+		}
+
+		// チャンクの残りとCRCを読み飛ばす
+		if _, err := io.CopyN(io.Discard, r, int64(length)+4); err != nil {
+			return 0, fmt.Errorf("PNGのチャンクの読み込みに失敗しました: %v", err)
+		}
+	}
+}
+
+// ApplyGamma はRGBの各チャンネルを 0〜1 に正規化した値 v に対して v^exponent を適用した画像を返します
+// アルファチャンネルは変更しません。半透明の画素は乗算済みでない色に対して適用します
+func ApplyGamma(img image.Image, exponent float64) *image.NRGBA64 {
+	// 16ビットの値ごとの変換結果をあらかじめ計算する
+	table := make([]uint16, 65536)
+	for i := range table {
+		table[i] = uint16(math.Round(math.Pow(float64(i)/65535, exponent) * 65535))
+	}
+
+	bounds := img.Bounds()
+	dst := image.NewNRGBA64(bounds)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBA64Model.Convert(img.At(x, y)).(color.NRGBA64)
+			dst.SetNRGBA64(x, y, color.NRGBA64{
+				R: table[c.R],
+				G: table[c.G],
+				B: table[c.B],
+				A: c.A,
+			})
+		}
+	}
+
+	return dst
+}
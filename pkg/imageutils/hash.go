@@ -0,0 +1,103 @@
+package imageutils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"io"
+	"math/bits"
+	"os"
+)
+
+// 差分ハッシュ（dHash）の縮小サイズ
+// 横に隣接するピクセルを比較するため、幅は高さより1つ大きくします
+const (
+	dHashWidth  = 9
+	dHashHeight = 8
+)
+
+// NearDuplicateThreshold は PHashSimilarity でほぼ同一の画像とみなす類似度の閾値です
+// この値を超える場合に同一画像の再圧縮やリサイズとみなします
+const NearDuplicateThreshold = 0.9
+
+// ComputeHash はファイル内容全体のSHA256を16進文字列で返します
+func ComputeHash(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("ファイルを開けません: %v", err)
+	}
+	defer file.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, file); err != nil {
+		return "", fmt.Errorf("ファイルの読み込みに失敗しました: %v", err)
+	}
+
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// ComputePerceptualHash は画像の差分ハッシュ（dHash）を計算します
+// 画像を9x8のグレースケールに縮小し、各行で隣接ピクセルの明るさを比較した結果を64ビットに詰めます
+// 再圧縮やリサイズでは値がほとんど変わらないため、PHashSimilarity で近似重複を判定できます
+func ComputePerceptualHash(img image.Image) (uint64, error) {
+	bounds := img.Bounds()
+	if bounds.Empty() {
+		return 0, fmt.Errorf("画像が空です")
+	}
+
+	gray := downsampleGray(img, dHashWidth, dHashHeight)
+
+	var hash uint64
+	for y := 0; y < dHashHeight; y++ {
+		for x := 0; x < dHashWidth-1; x++ {
+			hash <<= 1
+			if gray[y][x] < gray[y][x+1] {
+				hash |= 1
+			}
+		}
+	}
+
+	return hash, nil
+}
+
+// PHashSimilarity は2つの知覚ハッシュの類似度を0.0〜1.0で返します
+// 異なるビットの数（ハミング距離）を64で割った値を1.0から引いたもので、1.0は完全一致です
+func PHashSimilarity(a, b uint64) float64 {
+	return 1.0 - float64(bits.OnesCount64(a^b))/64.0
+}
+
+// downsampleGray は画像を width x height の輝度（0〜65535）に縮小します
+// 各セルに対応する領域のピクセルを平均するため、縮小時のエイリアシングを抑えられます
+func downsampleGray(img image.Image, width, height int) [][]float64 {
+	bounds := img.Bounds()
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+
+	gray := make([][]float64, height)
+	for cy := 0; cy < height; cy++ {
+		gray[cy] = make([]float64, width)
+
+		y0 := bounds.Min.Y + cy*srcH/height
+		y1 := max(bounds.Min.Y+(cy+1)*srcH/height, y0+1)
+		for cx := 0; cx < width; cx++ {
+			x0 := bounds.Min.X + cx*srcW/width
+			x1 := max(bounds.Min.X+(cx+1)*srcW/width, x0+1)
+
+			var sum float64
+			var count int
+			for y := y0; y < y1 && y < bounds.Max.Y; y++ {
+				for x := x0; x < x1 && x < bounds.Max.X; x++ {
+					r, g, b, _ := img.At(x, y).RGBA()
+					// ITU-R BT.601 の係数で輝度を求める
+					sum += 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)
+					count++
+				}
+			}
+			if count > 0 {
+				gray[cy][cx] = sum / float64(count)
+			}
+		}
+	}
+
+	return gray
+}
@@ -0,0 +1,123 @@
+package imageutils
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// horizontalGradient は左から右へ明るく（increasing が false の場合は暗く）なるグレースケール画像を返します
+func horizontalGradient(width, height int, increasing bool) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			v := uint8(x * 255 / (width - 1))
+			if !increasing {
+				v = 255 - v
+			}
+			img.Set(x, y, color.NRGBA{v, v, v, 255})
+		}
+	}
+	return img
+}
+
+// wavyImage は明るさがなめらかに波打つ画像を返します
+// 隣接するセルの明るさの差がはっきりしているため、再圧縮しても差分ハッシュがほとんど変わりません
+func wavyImage(width, height int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			v := uint8(128 + 100*math.Sin(float64(x)/9)*math.Cos(float64(y)/7))
+			img.Set(x, y, color.NRGBA{v, v / 2, 255 - v, 255})
+		}
+	}
+	return img
+}
+
+func TestComputeHash(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "hello.txt")
+	if err := os.WriteFile(path, []byte("hello\n"), 0644); err != nil {
+		t.Fatalf("ファイルの作成に失敗しました: %v", err)
+	}
+
+	// echo hello | sha256sum の結果
+	const want = "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03"
+	got, err := ComputeHash(path)
+	if err != nil {
+		t.Fatalf("ComputeHash に失敗しました: %v", err)
+	}
+	if got != want {
+		t.Errorf("ComputeHash = %s, want %s", got, want)
+	}
+
+	if _, err := ComputeHash(filepath.Join(dir, "missing.txt")); err == nil {
+		t.Error("存在しないファイルでエラーになりませんでした")
+	}
+}
+
+func TestComputePerceptualHash(t *testing.T) {
+	src := wavyImage(72, 64)
+
+	onePixelOff := wavyImage(72, 64)
+	onePixelOff.Set(10, 10, color.NRGBA{255, 0, 0, 255})
+
+	tests := []struct {
+		name    string
+		a, b    image.Image
+		wantMin float64
+		wantMax float64
+	}{
+		{name: "同一の画像", a: src, b: wavyImage(72, 64), wantMin: 1, wantMax: 1},
+		{name: "1ピクセルだけ異なる", a: src, b: onePixelOff, wantMin: NearDuplicateThreshold, wantMax: 1},
+		{name: "JPEGで再圧縮", a: src, b: compressJPEG(t, src, 50), wantMin: NearDuplicateThreshold, wantMax: 1},
+		{name: "縮小", a: horizontalGradient(90, 80, true), b: horizontalGradient(45, 40, true), wantMin: NearDuplicateThreshold, wantMax: 1},
+		{name: "まったく異なる画像", a: horizontalGradient(90, 80, true), b: horizontalGradient(90, 80, false), wantMin: 0, wantMax: 0.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hashA, err := ComputePerceptualHash(tt.a)
+			if err != nil {
+				t.Fatalf("ComputePerceptualHash に失敗しました: %v", err)
+			}
+			hashB, err := ComputePerceptualHash(tt.b)
+			if err != nil {
+				t.Fatalf("ComputePerceptualHash に失敗しました: %v", err)
+			}
+
+			if similarity := PHashSimilarity(hashA, hashB); similarity < tt.wantMin || similarity > tt.wantMax {
+				t.Errorf("PHashSimilarity = %f, want %.2f〜%.2f (%016x, %016x)", similarity, tt.wantMin, tt.wantMax, hashA, hashB)
+			}
+		})
+	}
+}
+
+func TestComputePerceptualHashEmpty(t *testing.T) {
+	if _, err := ComputePerceptualHash(image.NewNRGBA(image.Rectangle{})); err == nil {
+		t.Error("空の画像でエラーになりませんでした")
+	}
+}
+
+func TestPHashSimilarity(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b uint64
+		want float64
+	}{
+		{name: "一致", a: 0x0123456789abcdef, b: 0x0123456789abcdef, want: 1},
+		{name: "すべてのビットが異なる", a: 0, b: ^uint64(0), want: 0},
+		{name: "4ビットが異なる", a: 0, b: 0xf, want: 1 - 4.0/64},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PHashSimilarity(tt.a, tt.b); got != tt.want {
+				t.Errorf("PHashSimilarity(%016x, %016x) = %f, want %f", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,241 @@
+package imageutils
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"unicode/utf16"
+)
+
+// iccJPEGHeader はJPEGのAPP2セグメントでICCプロファイルを示す識別子です
+var iccJPEGHeader = []byte("ICC_PROFILE\x00")
+
+// iccHeaderSize はICCプロファイルのヘッダーのバイト数です（この後にタグテーブルが続きます）
+const iccHeaderSize = 128
+
+// GetColorProfile は画像ファイルに埋め込まれたICCプロファイルと、その説明（desc タグ）を返します
+// JPEGはAPP2セグメント、PNGは iCCP チャンク、HEIC/AVIFは colr ボックスから取り出します
+// ICCプロファイルを含まない場合や未対応の形式の場合は nil, "", nil を返します
+// プロファイルの説明を読み取れない場合は、名前を空文字列としてデータのみを返します
+func GetColorProfile(path string) ([]byte, string, error) {
+	format, err := DetectFormat(path)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var profile []byte
+	switch format {
+	case "jpeg", "png", "heif", "avif":
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, "", fmt.Errorf("ファイルの読み込みに失敗しました: %v", err)
+		}
+		switch format {
+		case "jpeg":
+			profile, err = extractJPEGICC(data)
+		case "png":
+			profile, err = extractPNGICC(data)
+		default:
+			profile = extractISOBMFFICC(data)
+		}
+		if err != nil {
+			return nil, "", err
+		}
+	default:
+		return nil, "", nil
+	}
+
+	if len(profile) == 0 {
+		return nil, "", nil
+	}
+	return profile, iccProfileDescription(profile), nil
+}
+
+// extractJPEGICC はJPEGのAPP2セグメントからICCプロファイルを取り出します
+// 64KBを超えるプロファイルは複数のセグメントに分割されるため、通し番号の順に連結します
+func extractJPEGICC(data []byte) ([]byte, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, fmt.Errorf("JPEGの形式が不正です")
+	}
+
+	// 通し番号（1から始まる）ごとのデータ
+	chunks := make(map[int][]byte)
+
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			return nil, fmt.Errorf("JPEGマーカーが不正です（オフセット %d）", pos)
+		}
+		marker := data[pos+1]
+		// SOS以降は画像データのため走査を終了する
+		if marker == 0xDA || marker == 0xD9 {
+			break
+		}
+		length := int(binary.BigEndian.Uint16(data[pos+2 : pos+4]))
+		end := pos + 2 + length
+		if length < 2 || end > len(data) {
+			return nil, fmt.Errorf("JPEGセグメント長が不正です（オフセット %d）", pos)
+		}
+		// APP2: "ICC_PROFILE\0" + 通し番号（1バイト）+ 総数（1バイト）+ データ
+		segment := data[pos+4 : end]
+		if marker == 0xE2 && bytes.HasPrefix(segment, iccJPEGHeader) && len(segment) >= len(iccJPEGHeader)+2 {
+			seq := int(segment[len(iccJPEGHeader)])
+			chunks[seq] = segment[len(iccJPEGHeader)+2:]
+		}
+		pos = end
+	}
+
+	if len(chunks) == 0 {
+		return nil, nil
+	}
+
+	seqs := make([]int, 0, len(chunks))
+	for seq := range chunks {
+		seqs = append(seqs, seq)
+	}
+	sort.Ints(seqs)
+
+	var profile []byte
+	for _, seq := range seqs {
+		profile = append(profile, chunks[seq]...)
+	}
+	return profile, nil
+}
+
+// extractPNGICC はPNGの iCCP チャンクから圧縮されたICCプロファイルを取り出して展開します
+func extractPNGICC(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, fmt.Errorf("PNGの形式が不正です")
+	}
+
+	pos := len(pngSignature)
+	for pos+8 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[pos : pos+4]))
+		chunkType := string(data[pos+4 : pos+8])
+		start := pos + 8
+		if length < 0 || start+length > len(data) {
+			return nil, fmt.Errorf("PNGチャンク %q の長さが不正です", chunkType)
+		}
+
+		switch chunkType {
+		case "iCCP":
+			// プロファイル名（NUL終端）+ 圧縮方式（1バイト）+ zlib圧縮されたプロファイル
+			payload := data[start : start+length]
+			nameEnd := bytes.IndexByte(payload, 0)
+			if nameEnd < 0 || nameEnd+2 > len(payload) {
+				return nil, fmt.Errorf("iCCPチャンクの形式が不正です")
+			}
+			reader, err := zlib.NewReader(bytes.NewReader(payload[nameEnd+2:]))
+			if err != nil {
+				return nil, fmt.Errorf("ICCプロファイルの展開に失敗しました: %v", err)
+			}
+			defer reader.Close()
+			profile, err := io.ReadAll(reader)
+			if err != nil {
+				return nil, fmt.Errorf("ICCプロファイルの展開に失敗しました: %v", err)
+			}
+			return profile, nil
+		case "IDAT", "IEND":
+			// iCCP は画像データより前にしか置けない
+			return nil, nil
+		}
+
+		// チャンクデータの後にCRC（4バイト）が続く
+		pos = start + length + 4
+	}
+
+	return nil, nil
+}
+
+// extractISOBMFFICC はHEIC/AVIFの colr ボックス（prof または rICC）からICCプロファイルを取り出します
+// XMPと同様に、ボックスの入れ子を辿らずにファイル内を検索し、ボックスのサイズが整合するものを採用します
+func extractISOBMFFICC(data []byte) []byte {
+	offset := 0
+	for {
+		i := bytes.Index(data[offset:], []byte("colr"))
+		if i < 0 {
+			return nil
+		}
+		typePos := offset + i
+		offset = typePos + 4
+
+		// ボックスのサイズ（4バイト）は種類の直前にある
+		if typePos < 4 || typePos+8 > len(data) {
+			continue
+		}
+		size := int(binary.BigEndian.Uint32(data[typePos-4 : typePos]))
+		boxEnd := typePos - 4 + size
+		if size < 12 || boxEnd > len(data) {
+			continue
+		}
+
+		switch string(data[typePos+4 : typePos+8]) {
+		case "prof", "rICC":
+			return append([]byte(nil), data[typePos+8:boxEnd]...)
+		}
+	}
+}
+
+// iccProfileDescription はICCプロファイルの desc タグからプロファイルの説明を読み取ります
+// ICC v2 の textDescriptionType と ICC v4 の multiLocalizedUnicodeType（先頭の言語）に対応します
+func iccProfileDescription(profile []byte) string {
+	if len(profile) < iccHeaderSize+4 {
+		return ""
+	}
+
+	count := int(binary.BigEndian.Uint32(profile[iccHeaderSize : iccHeaderSize+4]))
+	for i := 0; i < count; i++ {
+		entry := iccHeaderSize + 4 + i*12
+		if entry+12 > len(profile) {
+			return ""
+		}
+		if string(profile[entry:entry+4]) != "desc" {
+			continue
+		}
+
+		offset := int(binary.BigEndian.Uint32(profile[entry+4 : entry+8]))
+		size := int(binary.BigEndian.Uint32(profile[entry+8 : entry+12]))
+		if offset < 0 || size < 12 || offset+size > len(profile) {
+			return ""
+		}
+		return parseICCText(profile[offset : offset+size])
+	}
+
+	return ""
+}
+
+// parseICCText は desc タグのデータから文字列を取り出します
+func parseICCText(tag []byte) string {
+	switch string(tag[0:4]) {
+	case "desc":
+		// 種類（4バイト）+ 予約（4バイト）+ ASCII文字数（4バイト）+ NUL終端のASCII文字列
+		length := int(binary.BigEndian.Uint32(tag[8:12]))
+		if length <= 0 || 12+length > len(tag) {
+			return ""
+		}
+		return strings.TrimRight(string(tag[12:12+length]), "\x00 ")
+	case "mluc":
+		// 種類（4バイト）+ 予約（4バイト）+ レコード数（4バイト）+ レコードサイズ（4バイト）+ レコード
+		// レコード: 言語（2バイト）+ 国（2バイト）+ 長さ（4バイト）+ タグ先頭からのオフセット（4バイト）
+		if len(tag) < 28 || binary.BigEndian.Uint32(tag[8:12]) == 0 {
+			return ""
+		}
+		length := int(binary.BigEndian.Uint32(tag[20:24]))
+		offset := int(binary.BigEndian.Uint32(tag[24:28]))
+		if length <= 0 || offset+length > len(tag) {
+			return ""
+		}
+		units := make([]uint16, length/2)
+		for i := range units {
+			units[i] = binary.BigEndian.Uint16(tag[offset+i*2:])
+		}
+		return strings.TrimRight(string(utf16.Decode(units)), "\x00 ")
+	default:
+		return ""
+	}
+}
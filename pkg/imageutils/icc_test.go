@@ -0,0 +1,236 @@
+package imageutils
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"testing"
+	"unicode/utf16"
+)
+
+// テスト用のICCプロファイルの説明（desc タグ）
+const (
+	srgbProfileName = "sRGB IEC61966-2.1" // ICC v2（textDescriptionType）
+	p3ProfileName   = "Display P3"        // ICC v4（multiLocalizedUnicodeType）
+)
+
+// iccProfile はヘッダーと、desc タグ・白色点（wtpt タグ）を持つICCプロファイルを返します
+// version が4の場合は desc を multiLocalizedUnicodeType、それ以外は textDescriptionType で記録します
+// desc が空の場合は desc タグを含めません
+func iccProfile(version int, desc string) []byte {
+	be := binary.BigEndian
+
+	var descTag []byte
+	if desc != "" {
+		if version == 4 {
+			units := utf16.Encode([]rune(desc))
+			descTag = append([]byte("mluc"), make([]byte, 4)...)
+			descTag = be.AppendUint32(descTag, 1)  // レコード数
+			descTag = be.AppendUint32(descTag, 12) // レコードサイズ
+			descTag = append(descTag, "enUS"...)
+			descTag = be.AppendUint32(descTag, uint32(len(units)*2))
+			descTag = be.AppendUint32(descTag, 28) // 文字列のオフセット
+			for _, u := range units {
+				descTag = be.AppendUint16(descTag, u)
+			}
+		} else {
+			descTag = append([]byte("desc"), make([]byte, 4)...)
+			descTag = be.AppendUint32(descTag, uint32(len(desc)+1))
+			descTag = append(descTag, desc...)
+			descTag = append(descTag, 0)
+			// Unicode・ScriptCode の説明は空にする
+			descTag = append(descTag, make([]byte, 8+2+1+67)...)
+		}
+	}
+	// D50 の白色点（XYZType）
+	wtptTag := append([]byte("XYZ "), make([]byte, 4)...)
+	for _, v := range []uint32{0xF6D6, 0x10000, 0xD32D} {
+		wtptTag = be.AppendUint32(wtptTag, v)
+	}
+
+	type tag struct {
+		sig  string
+		data []byte
+	}
+	tags := []tag{{sig: "wtpt", data: wtptTag}}
+	if descTag != nil {
+		tags = append([]tag{{sig: "desc", data: descTag}}, tags...)
+	}
+
+	// タグのデータはタグテーブルの後に4バイト境界で並べる
+	offset := iccHeaderSize + 4 + 12*len(tags)
+	var table, data []byte
+	table = be.AppendUint32(table, uint32(len(tags)))
+	for _, t := range tags {
+		for len(data)%4 != 0 {
+			data = append(data, 0)
+		}
+		table = append(table, t.sig...)
+		table = be.AppendUint32(table, uint32(offset+len(data)))
+		table = be.AppendUint32(table, uint32(len(t.data)))
+		data = append(data, t.data...)
+	}
+
+	header := make([]byte, iccHeaderSize)
+	be.PutUint32(header[0:4], uint32(iccHeaderSize+len(table)+len(data)))
+	header[8] = byte(version)
+	copy(header[12:16], "mntr")
+	copy(header[16:20], "RGB ")
+	copy(header[20:24], "XYZ ")
+	copy(header[36:40], "acsp")
+
+	return append(append(header, table...), data...)
+}
+
+// jpegWithICC はプロファイルを segments 個のAPP2セグメントに分割して埋め込んだJPEGを返します
+// reverse が true の場合はセグメントを逆順に並べます（通し番号で連結されることの確認用）
+func jpegWithICC(t *testing.T, profile []byte, segments int, reverse bool) []byte {
+	t.Helper()
+
+	plain := jpegWithEXIF(t, 4, 2, nil)
+	chunkSize := (len(profile) + segments - 1) / segments
+
+	var apps [][]byte
+	for i := 0; i < segments; i++ {
+		chunk := profile[i*chunkSize : min((i+1)*chunkSize, len(profile))]
+		payload := append(append([]byte{}, iccJPEGHeader...), byte(i+1), byte(segments))
+		payload = append(payload, chunk...)
+
+		app := []byte{0xFF, 0xE2}
+		app = binary.BigEndian.AppendUint16(app, uint16(len(payload)+2))
+		apps = append(apps, append(app, payload...))
+	}
+	if reverse {
+		for i, j := 0, len(apps)-1; i < j; i, j = i+1, j-1 {
+			apps[i], apps[j] = apps[j], apps[i]
+		}
+	}
+
+	out := append([]byte{}, plain[:2]...)
+	for _, app := range apps {
+		out = append(out, app...)
+	}
+	return append(out, plain[2:]...)
+}
+
+// pngWithICC はIHDRチャンクの直後に、プロファイルを圧縮した iCCP チャンクを挿入したPNGを返します
+// profile が nil の場合は iCCP チャンクを持たないPNGを返します
+func pngWithICC(t *testing.T, name string, profile []byte) []byte {
+	t.Helper()
+
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, image.NewNRGBA(image.Rect(0, 0, 4, 2))); err != nil {
+		t.Fatalf("PNGのエンコードに失敗しました: %v", err)
+	}
+	data := encoded.Bytes()
+	if profile == nil {
+		return data
+	}
+
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	zw.Write(profile)
+	zw.Close()
+
+	payload := append(append([]byte(name), 0, 0), compressed.Bytes()...)
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(payload)))
+	chunk = append(append(chunk, "iCCP"...), payload...)
+	chunk = binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+
+	// シグネチャ（8バイト）とIHDRチャンク（長さ・種類・13バイトのデータ・CRC）の後に挿入する
+	const ihdrEnd = 8 + 4 + 4 + 13 + 4
+	return append(append(append([]byte{}, data[:ihdrEnd]...), chunk...), data[ihdrEnd:]...)
+}
+
+// avifWithICC は ftyp ボックスと、プロファイルを含む colr ボックス（prof）だけのAVIFを返します
+func avifWithICC(profile []byte) []byte {
+	box := func(boxType string, payload []byte) []byte {
+		b := binary.BigEndian.AppendUint32(nil, uint32(8+len(payload)))
+		return append(append(b, boxType...), payload...)
+	}
+	ftyp := box("ftyp", []byte("avif\x00\x00\x00\x00mif1"))
+	colr := box("colr", append([]byte("prof"), profile...))
+	return append(ftyp, box("meta", colr)...)
+}
+
+func TestGetColorProfile(t *testing.T) {
+	srgb := iccProfile(2, srgbProfileName)
+	p3 := iccProfile(4, p3ProfileName)
+	unnamed := iccProfile(4, "")
+
+	tests := []struct {
+		name        string
+		file        string
+		data        []byte
+		wantProfile []byte
+		wantName    string
+	}{
+		{name: "sRGBのJPEG", file: "srgb.jpg", data: jpegWithICC(t, srgb, 1, false), wantProfile: srgb, wantName: srgbProfileName},
+		{name: "Display P3のJPEG", file: "p3.jpg", data: jpegWithICC(t, p3, 1, false), wantProfile: p3, wantName: p3ProfileName},
+		{name: "複数のAPP2に分割されたJPEG", file: "split.jpg", data: jpegWithICC(t, p3, 3, true), wantProfile: p3, wantName: p3ProfileName},
+		{name: "sRGBのPNG", file: "srgb.png", data: pngWithICC(t, "sRGB", srgb), wantProfile: srgb, wantName: srgbProfileName},
+		{name: "Display P3のPNG", file: "p3.png", data: pngWithICC(t, "Display P3", p3), wantProfile: p3, wantName: p3ProfileName},
+		{name: "Display P3のAVIF", file: "p3.avif", data: avifWithICC(p3), wantProfile: p3, wantName: p3ProfileName},
+		{name: "説明のないプロファイル", file: "unnamed.png", data: pngWithICC(t, "", unnamed), wantProfile: unnamed, wantName: ""},
+		{name: "プロファイルのないJPEG", file: "plain.jpg", data: jpegWithEXIF(t, 4, 2, nil)},
+		{name: "プロファイルのないPNG", file: "plain.png", data: pngWithICC(t, "", nil)},
+		{name: "プロファイルに対応しない形式", file: "image.gif", data: encodedImage(t, "gif")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile, name, err := GetColorProfile(writeTempFile(t, tt.file, tt.data))
+			if err != nil {
+				t.Fatalf("GetColorProfile に失敗しました: %v", err)
+			}
+			if !bytes.Equal(profile, tt.wantProfile) {
+				t.Errorf("プロファイル = %d バイト, want %d バイト", len(profile), len(tt.wantProfile))
+			}
+			if tt.wantProfile == nil && profile != nil {
+				t.Errorf("プロファイル = %d バイト, want nil", len(profile))
+			}
+			if name != tt.wantName {
+				t.Errorf("プロファイル名 = %q, want %q", name, tt.wantName)
+			}
+		})
+	}
+
+	t.Run("壊れたiCCPチャンク", func(t *testing.T) {
+		data := pngWithICC(t, "broken", srgb)
+		// 圧縮データの先頭（zlibヘッダー）を壊す
+		at := bytes.Index(data, []byte("broken\x00\x00")) + len("broken\x00\x00")
+		data[at], data[at+1] = 0, 0
+		if _, _, err := GetColorProfile(writeTempFile(t, "broken.png", data)); err == nil {
+			t.Error("壊れたiCCPチャンクでエラーになりませんでした")
+		}
+	})
+}
+
+// TestGetImageInfoICCProfileName は GetImageInfo が埋め込まれたプロファイルの説明を返すことを確認します
+func TestGetImageInfoICCProfileName(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		data []byte
+		want string
+	}{
+		{name: "Display P3", file: "p3.jpg", data: jpegWithICC(t, iccProfile(4, p3ProfileName), 1, false), want: p3ProfileName},
+		{name: "sRGB", file: "srgb.png", data: pngWithICC(t, "sRGB", iccProfile(2, srgbProfileName)), want: srgbProfileName},
+		{name: "プロファイルなし", file: "plain.jpg", data: jpegWithEXIF(t, 4, 2, nil), want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := GetImageInfo(writeTempFile(t, tt.file, tt.data))
+			if err != nil {
+				t.Fatalf("GetImageInfo に失敗しました: %v", err)
+			}
+			if info.ICCProfileName != tt.want {
+				t.Errorf("ICCProfileName = %q, want %q", info.ICCProfileName, tt.want)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package imageutils
 import (
 	"fmt"
 	"image"
+	"image/draw"
 
 	// 画像フォーマットのデコーダを登録するためのブランクインポート
 	_ "image/gif"  // GIFデコーダを登録
@@ -14,16 +15,20 @@ import (
 
 // ImageInfo は画像に関する基本情報を保持する構造体です
 type ImageInfo struct {
-	Path      string    // ファイルパス
-	Format    string    // 画像形式
-	Width     int       // 幅（ピクセル）
-	Height    int       // 高さ（ピクセル）
-	Size      int64     // ファイルサイズ（バイト）
-	ModTime   time.Time // 最終更新日時
-	Channels  int       // カラーチャンネル数
-	BitDepth  int       // ビット深度
-	IsValid   bool      // 有効な画像かどうか
-	ErrorInfo string    // エラー情報（無効な場合）
+	Path           string            `json:"path"`                       // ファイルパス
+	Format         string            `json:"format"`                     // 画像形式
+	Width          int               `json:"width"`                      // 幅（ピクセル）
+	Height         int               `json:"height"`                     // 高さ（ピクセル）
+	Size           int64             `json:"size"`                       // ファイルサイズ（バイト）
+	ModTime        time.Time         `json:"mod_time"`                   // 最終更新日時
+	Channels       int               `json:"channels"`                   // カラーチャンネル数
+	BitDepth       int               `json:"bit_depth"`                  // ビット深度
+	IsValid        bool              `json:"is_valid"`                   // 有効な画像かどうか
+	ErrorInfo      string            `json:"error_info,omitempty"`       // エラー情報（無効な場合）
+	EXIF           map[string]string `json:"exif,omitempty"`             // 主要なEXIF情報（EXIFKey* をキーとする。EXIFがない場合はnil）
+	DateTaken      time.Time         `json:"date_taken"`                 // EXIFの撮影日時（取得できない場合はゼロ値）
+	IsAnimated     bool              `json:"is_animated"`                // 複数フレームを持つアニメーション画像かどうか
+	ICCProfileName string            `json:"icc_profile_name,omitempty"` // 埋め込まれたICCプロファイルの説明（ない場合は空）
 }
 
 // GetImageInfo は画像ファイルの基本情報を取得します
@@ -94,6 +99,15 @@ func GetImageInfo(path string) (*ImageInfo, error) {
 		info.BitDepth = 0
 	}
 
+	// JPEG・HEICはEXIFの撮影情報も取得する
+	info.EXIF, info.DateTaken = readEXIFInfo(path)
+
+	// GIF・WebP・AVIFはアニメーションかどうかも判定する（判定できない場合は静止画とみなす）
+	info.IsAnimated, _ = IsAnimated(path)
+
+	// 埋め込まれたICCプロファイルの説明（読み取れない場合は空のまま）
+	_, info.ICCProfileName, _ = GetColorProfile(path)
+
 	info.IsValid = true
 	return info, nil
 }
@@ -145,3 +159,13 @@ func (info *ImageInfo) GetSummary() string {
 		FormatImageSize(info.Size),
 		info.ModTime.Format("2006-01-02 15:04:05"))
 }
+
+// StripMetadata は画像のピクセルだけを新しい *image.NRGBA に複製して返します
+// image.NRGBA はEXIF・XMP・ICCなどのメタデータを持たないため、デコーダー固有の情報を引き継がない画像になります
+// 複製の範囲（Bounds）は元の画像と同じです
+func StripMetadata(img image.Image) *image.NRGBA {
+	bounds := img.Bounds()
+	dst := image.NewNRGBA(bounds)
+	draw.Draw(dst, bounds, img, bounds.Min, draw.Src)
+	return dst
+}
@@ -0,0 +1,49 @@
+package imageutils
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestStripMetadata(t *testing.T) {
+	src := image.NewYCbCr(image.Rect(10, 20, 18, 26), image.YCbCrSubsampleRatio420)
+	for i := range src.Y {
+		src.Y[i] = uint8(i * 5)
+	}
+
+	got := StripMetadata(src)
+	if got.Bounds() != src.Bounds() {
+		t.Fatalf("Bounds = %v, want %v", got.Bounds(), src.Bounds())
+	}
+	for y := src.Rect.Min.Y; y < src.Rect.Max.Y; y++ {
+		for x := src.Rect.Min.X; x < src.Rect.Max.X; x++ {
+			if want := color.NRGBAModel.Convert(src.At(x, y)); got.At(x, y) != want {
+				t.Fatalf("(%d, %d) = %v, want %v", x, y, got.At(x, y), want)
+			}
+		}
+	}
+}
+
+// BenchmarkStripMetadata は 4000x3000 の画像を複製する時間を、デコーダーが返す代表的な画像の型ごとに計測します
+func BenchmarkStripMetadata(b *testing.B) {
+	const width, height = 4000, 3000
+	rect := image.Rect(0, 0, width, height)
+
+	for _, bc := range []struct {
+		name string
+		img  image.Image
+	}{
+		{name: "ycbcr", img: image.NewYCbCr(rect, image.YCbCrSubsampleRatio420)}, // JPEG
+		{name: "rgba", img: image.NewRGBA(rect)},                                 // WebP・HEIC など
+		{name: "nrgba", img: gradientImage(width, height)},                       // PNG（透過あり）
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			b.SetBytes(width * height * 4)
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				StripMetadata(bc.img)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package imageutils
+
+import (
+	"image"
+)
+
+const (
+	// ssimBlockSize はSSIMを計算するブロックの1辺のピクセル数です
+	ssimBlockSize = 8
+	// ssimC1, ssimC2 はゼロ除算を避けるための安定化定数です（ダイナミックレンジ255）
+	ssimC1 = (0.01 * 255) * (0.01 * 255)
+	ssimC2 = (0.03 * 255) * (0.03 * 255)
+)
+
+// ComputeSSIM は2つの画像の構造的類似度（SSIM）を計算します
+// 輝度を8x8のブロックに分けて各ブロックのSSIMを求め、その平均を返します（1.0で完全一致）
+// 画像サイズが異なる場合は、左上を揃えた共通部分のみを比較します
+func ComputeSSIM(a, b image.Image) float64 {
+	ab, bb := a.Bounds(), b.Bounds()
+	width := min(ab.Dx(), bb.Dx())
+	height := min(ab.Dy(), bb.Dy())
+	if width <= 0 || height <= 0 {
+		return 0
+	}
+
+	lumaA := luminancePlane(a, width, height)
+	lumaB := luminancePlane(b, width, height)
+
+	// 8x8より小さい画像は全体を1ブロックとして扱う
+	blockW := min(ssimBlockSize, width)
+	blockH := min(ssimBlockSize, height)
+
+	var total float64
+	var blocks int
+	for y := 0; y+blockH <= height; y += blockH {
+		for x := 0; x+blockW <= width; x += blockW {
+			total += blockSSIM(lumaA, lumaB, width, x, y, blockW, blockH)
+			blocks++
+		}
+	}
+
+	if blocks == 0 {
+		return 0
+	}
+	return total / float64(blocks)
+}
+
+// blockSSIM は1ブロック分のSSIMを計算します
+func blockSSIM(lumaA, lumaB []float64, stride, x0, y0, blockW, blockH int) float64 {
+	n := float64(blockW * blockH)
+
+	var sumA, sumB float64
+	for y := y0; y < y0+blockH; y++ {
+		for x := x0; x < x0+blockW; x++ {
+			sumA += lumaA[y*stride+x]
+			sumB += lumaB[y*stride+x]
+		}
+	}
+	meanA, meanB := sumA/n, sumB/n
+
+	var varA, varB, covar float64
+	for y := y0; y < y0+blockH; y++ {
+		for x := x0; x < x0+blockW; x++ {
+			da := lumaA[y*stride+x] - meanA
+			db := lumaB[y*stride+x] - meanB
+			varA += da * da
+			varB += db * db
+			covar += da * db
+		}
+	}
+	varA /= n
+	varB /= n
+	covar /= n
+
+	return ((2*meanA*meanB + ssimC1) * (2*covar + ssimC2)) /
+		((meanA*meanA + meanB*meanB + ssimC1) * (varA + varB + ssimC2))
+}
+
+// luminancePlane は画像左上の width x height の範囲の輝度（0-255）を配列で返します
+func luminancePlane(img image.Image, width, height int) []float64 {
+	bounds := img.Bounds()
+	plane := make([]float64, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			r, g, b, _ := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			plane[y*width+x] = (0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)) / 257
+		}
+	}
+	return plane
+}
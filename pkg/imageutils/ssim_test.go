@@ -0,0 +1,80 @@
+package imageutils
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"math"
+	"testing"
+)
+
+// detailedImage はグラデーションに細かな格子模様を重ねた、圧縮で劣化しやすい画像を返します
+func detailedImage(width, height int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			v := uint8(x * 255 / width)
+			if (x+y)%2 == 0 {
+				v = 255 - v
+			}
+			img.Set(x, y, color.NRGBA{v, uint8(y * 255 / height), v / 2, 255})
+		}
+	}
+	return img
+}
+
+// compressJPEG は img を指定した画質のJPEGでエンコードし、デコードし直した画像を返します
+func compressJPEG(t *testing.T, img image.Image, quality int) image.Image {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		t.Fatalf("JPEGのエンコードに失敗しました: %v", err)
+	}
+	decoded, err := jpeg.Decode(&buf)
+	if err != nil {
+		t.Fatalf("JPEGのデコードに失敗しました: %v", err)
+	}
+	return decoded
+}
+
+func TestComputeSSIMIdentical(t *testing.T) {
+	tests := []struct {
+		name string
+		img  image.Image
+	}{
+		{name: "詳細な画像", img: detailedImage(64, 48)},
+		{name: "単色", img: solidImage(16, 16, color.NRGBA{40, 80, 120, 255})},
+		{name: "8x8未満", img: gradientImage(5, 3)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if ssim := ComputeSSIM(tt.img, tt.img); math.Abs(ssim-1) > 1e-9 {
+				t.Errorf("ComputeSSIM = %f, want 1.0", ssim)
+			}
+		})
+	}
+}
+
+func TestComputeSSIMCompressed(t *testing.T) {
+	src := detailedImage(64, 48)
+
+	high := ComputeSSIM(src, compressJPEG(t, src, 100))
+	low := ComputeSSIM(src, compressJPEG(t, src, 1))
+
+	if low >= 0.95 {
+		t.Errorf("強く圧縮した画像のSSIM = %f, 0.95 未満を期待しました", low)
+	}
+	if high <= low {
+		t.Errorf("高画質のSSIM (%f) が低画質のSSIM (%f) 以下です", high, low)
+	}
+}
+
+func TestComputeSSIMEmpty(t *testing.T) {
+	empty := image.NewNRGBA(image.Rectangle{})
+	if ssim := ComputeSSIM(empty, gradientImage(8, 8)); ssim != 0 {
+		t.Errorf("空の画像の ComputeSSIM = %f, want 0", ssim)
+	}
+}
@@ -0,0 +1,143 @@
+package imageutils
+
+import (
+	"image"
+
+	"golang.org/x/image/draw"
+)
+
+// ResizeMode はリサイズ時の縦横比の扱いです
+type ResizeMode int
+
+const (
+	// ResizeFit は縦横比を保ったまま、指定した幅と高さに収まるように縮小・拡大します
+	ResizeFit ResizeMode = iota
+	// ResizeFill は縦横比を保ったまま指定した幅と高さを覆うように拡大・縮小し、はみ出した部分を中央基準で切り取ります
+	ResizeFill
+	// ResizeStretch は縦横比を無視して指定した幅と高さに変形します
+	ResizeStretch
+)
+
+// ResizeImage は画像を指定した幅と高さを基準にリサイズした新しい画像を返します
+// 補間には Catmull-Rom を使用します。ResizeFit の場合、結果は指定した寸法より小さくなることがあります
+// 元の画像や指定した寸法が空の場合は空の画像を返します
+func ResizeImage(img image.Image, w, h int, mode ResizeMode) *image.NRGBA {
+	src := img.Bounds()
+	if src.Empty() || w <= 0 || h <= 0 {
+		return image.NewNRGBA(image.Rectangle{})
+	}
+
+	srcW, srcH := float64(src.Dx()), float64(src.Dy())
+	switch mode {
+	case ResizeFit:
+		// 幅と高さのうち、より縮小率の大きい方に合わせる
+		scale := min(float64(w)/srcW, float64(h)/srcH)
+		w = max(1, int(srcW*scale+0.5))
+		h = max(1, int(srcH*scale+0.5))
+	case ResizeFill:
+		// 出力と同じ縦横比になるよう、元画像の中央を切り取る
+		scale := max(float64(w)/srcW, float64(h)/srcH)
+		cropW := min(src.Dx(), max(1, int(float64(w)/scale+0.5)))
+		cropH := min(src.Dy(), max(1, int(float64(h)/scale+0.5)))
+		x0 := src.Min.X + (src.Dx()-cropW)/2
+		y0 := src.Min.Y + (src.Dy()-cropH)/2
+		src = image.Rect(x0, y0, x0+cropW, y0+cropH)
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, src, draw.Src, nil)
+	return dst
+}
+
+// RotateImage は画像を時計回りに degrees 度回転した新しい画像を返します
+// 対応する角度は90度単位（0, 90, 180, 270。負の値や360以上は正規化します）で、それ以外の角度の場合は回転せずに複製を返します
+func RotateImage(img image.Image, degrees int) *image.NRGBA {
+	src := toNRGBA(img)
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+
+	switch ((degrees % 360) + 360) % 360 {
+	case 90:
+		dst := image.NewNRGBA(image.Rect(0, 0, h, w))
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				copyNRGBAPixel(dst, h-1-y, x, src, x, y)
+			}
+		}
+		return dst
+	case 180:
+		dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				copyNRGBAPixel(dst, w-1-x, h-1-y, src, x, y)
+			}
+		}
+		return dst
+	case 270:
+		dst := image.NewNRGBA(image.Rect(0, 0, h, w))
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				copyNRGBAPixel(dst, y, w-1-x, src, x, y)
+			}
+		}
+		return dst
+	default:
+		return src
+	}
+}
+
+// FlipImage は画像を反転した新しい画像を返します
+// horizontal が true の場合は左右を、false の場合は上下を反転します
+func FlipImage(img image.Image, horizontal bool) *image.NRGBA {
+	src := toNRGBA(img)
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if horizontal {
+				copyNRGBAPixel(dst, w-1-x, y, src, x, y)
+			} else {
+				copyNRGBAPixel(dst, x, h-1-y, src, x, y)
+			}
+		}
+	}
+	return dst
+}
+
+// ApplyOrientation はEXIFの Orientation（1〜8）に従って画像を正しい向きに補正した新しい画像を返します
+// 範囲外の値の場合は補正せずに複製を返します
+func ApplyOrientation(img image.Image, orientation int) *image.NRGBA {
+	switch orientation {
+	case 2:
+		return FlipImage(img, true)
+	case 3:
+		return RotateImage(img, 180)
+	case 4:
+		return FlipImage(img, false)
+	case 5:
+		return FlipImage(RotateImage(img, 90), true)
+	case 6:
+		return RotateImage(img, 90)
+	case 7:
+		return FlipImage(RotateImage(img, 270), true)
+	case 8:
+		return RotateImage(img, 270)
+	default:
+		return toNRGBA(img)
+	}
+}
+
+// toNRGBA は画像を原点が (0, 0) の新しい *image.NRGBA に複製します
+func toNRGBA(img image.Image) *image.NRGBA {
+	bounds := img.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, bounds.Min, draw.Src)
+	return dst
+}
+
+// copyNRGBAPixel は src の (sx, sy) のピクセルを dst の (dx, dy) に複製します
+func copyNRGBAPixel(dst *image.NRGBA, dx, dy int, src *image.NRGBA, sx, sy int) {
+	di := dst.PixOffset(dx, dy)
+	si := src.PixOffset(sx, sy)
+	copy(dst.Pix[di:di+4], src.Pix[si:si+4])
+}
@@ -0,0 +1,183 @@
+package imageutils
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+var (
+	markerColor = color.NRGBA{255, 0, 0, 255}
+	centerColor = color.NRGBA{0, 255, 0, 255}
+)
+
+// markedImage は (mx, my) に markerColor のピクセルを置いた黒い画像を返します
+func markedImage(width, height, mx, my int) *image.NRGBA {
+	img := solidImage(width, height, color.NRGBA{0, 0, 0, 255})
+	img.Set(mx, my, markerColor)
+	return img
+}
+
+// findColor は c と同じ色の最初のピクセルの位置を返します
+func findColor(t *testing.T, img *image.NRGBA, c color.NRGBA) image.Point {
+	t.Helper()
+
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if img.NRGBAAt(x, y) == c {
+				return image.Pt(x, y)
+			}
+		}
+	}
+	t.Fatalf("色 %v のピクセルが見つかりません", c)
+	return image.Point{}
+}
+
+func TestResizeImageBounds(t *testing.T) {
+	src := gradientImage(200, 100)
+
+	tests := []struct {
+		name string
+		img  image.Image
+		w, h int
+		mode ResizeMode
+		want image.Rectangle
+	}{
+		{name: "Fitは幅に合わせる", img: src, w: 100, h: 100, mode: ResizeFit, want: image.Rect(0, 0, 100, 50)},
+		{name: "Fitは高さに合わせる", img: src, w: 400, h: 50, mode: ResizeFit, want: image.Rect(0, 0, 100, 50)},
+		{name: "Fitは拡大する", img: src, w: 400, h: 400, mode: ResizeFit, want: image.Rect(0, 0, 400, 200)},
+		{name: "Fillは指定した寸法になる", img: src, w: 50, h: 50, mode: ResizeFill, want: image.Rect(0, 0, 50, 50)},
+		{name: "Stretchは指定した寸法になる", img: src, w: 30, h: 70, mode: ResizeStretch, want: image.Rect(0, 0, 30, 70)},
+		{name: "寸法が0", img: src, w: 0, h: 50, mode: ResizeFit, want: image.Rectangle{}},
+		{name: "空の画像", img: image.NewNRGBA(image.Rectangle{}), w: 10, h: 10, mode: ResizeFit, want: image.Rectangle{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ResizeImage(tt.img, tt.w, tt.h, tt.mode).Bounds(); got != tt.want {
+				t.Errorf("ResizeImage の寸法 = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestResizeImageFillCropsCenter は ResizeFill が中央を切り取ることを確認します
+func TestResizeImageFillCropsCenter(t *testing.T) {
+	// 左右の1/4が赤、中央の1/2が緑の200x100の画像
+	src := solidImage(200, 100, markerColor)
+	for y := 0; y < 100; y++ {
+		for x := 50; x < 150; x++ {
+			src.Set(x, y, centerColor)
+		}
+	}
+
+	dst := ResizeImage(src, 50, 50, ResizeFill)
+	for _, p := range []image.Point{{0, 0}, {49, 0}, {0, 49}, {49, 49}, {25, 25}} {
+		if got := dst.NRGBAAt(p.X, p.Y); got != centerColor {
+			t.Errorf("(%d, %d) の色 = %v, want %v", p.X, p.Y, got, centerColor)
+		}
+	}
+}
+
+func TestRotateImage(t *testing.T) {
+	tests := []struct {
+		degrees    int
+		wantBounds image.Rectangle
+		wantMarker image.Point
+	}{
+		{degrees: 0, wantBounds: image.Rect(0, 0, 5, 3), wantMarker: image.Pt(1, 0)},
+		{degrees: 90, wantBounds: image.Rect(0, 0, 3, 5), wantMarker: image.Pt(2, 1)},
+		{degrees: 180, wantBounds: image.Rect(0, 0, 5, 3), wantMarker: image.Pt(3, 2)},
+		{degrees: 270, wantBounds: image.Rect(0, 0, 3, 5), wantMarker: image.Pt(0, 3)},
+		{degrees: -90, wantBounds: image.Rect(0, 0, 3, 5), wantMarker: image.Pt(0, 3)},
+		{degrees: 450, wantBounds: image.Rect(0, 0, 3, 5), wantMarker: image.Pt(2, 1)},
+		{degrees: 45, wantBounds: image.Rect(0, 0, 5, 3), wantMarker: image.Pt(1, 0)},
+	}
+
+	for _, tt := range tests {
+		// 5x3の画像の (1, 0) に目印、中央の (2, 1) に別の色を置く
+		src := markedImage(5, 3, 1, 0)
+		src.Set(2, 1, centerColor)
+
+		dst := RotateImage(src, tt.degrees)
+		if dst.Bounds() != tt.wantBounds {
+			t.Errorf("%d度: 寸法 = %v, want %v", tt.degrees, dst.Bounds(), tt.wantBounds)
+			continue
+		}
+		if got := findColor(t, dst, markerColor); got != tt.wantMarker {
+			t.Errorf("%d度: 目印の位置 = %v, want %v", tt.degrees, got, tt.wantMarker)
+		}
+
+		// 中央のピクセルは回転後も中央にある
+		wantCenter := image.Pt(tt.wantBounds.Dx()/2, tt.wantBounds.Dy()/2)
+		if got := findColor(t, dst, centerColor); got != wantCenter {
+			t.Errorf("%d度: 中央のピクセルの位置 = %v, want %v", tt.degrees, got, wantCenter)
+		}
+	}
+}
+
+func TestFlipImage(t *testing.T) {
+	tests := []struct {
+		name       string
+		horizontal bool
+		want       image.Point
+	}{
+		{name: "左右反転", horizontal: true, want: image.Pt(3, 0)},
+		{name: "上下反転", horizontal: false, want: image.Pt(0, 2)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst := FlipImage(markedImage(4, 3, 0, 0), tt.horizontal)
+			if dst.Bounds() != image.Rect(0, 0, 4, 3) {
+				t.Errorf("寸法 = %v, want %v", dst.Bounds(), image.Rect(0, 0, 4, 3))
+			}
+			if got := findColor(t, dst, markerColor); got != tt.want {
+				t.Errorf("左上の角のピクセルの位置 = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestApplyOrientation はEXIFの Orientation ごとに、3x2の画像の左上の角のピクセルが移動する位置を確認します
+func TestApplyOrientation(t *testing.T) {
+	tests := []struct {
+		orientation int
+		wantBounds  image.Rectangle
+		wantMarker  image.Point
+	}{
+		{orientation: 1, wantBounds: image.Rect(0, 0, 3, 2), wantMarker: image.Pt(0, 0)},
+		{orientation: 2, wantBounds: image.Rect(0, 0, 3, 2), wantMarker: image.Pt(2, 0)},
+		{orientation: 3, wantBounds: image.Rect(0, 0, 3, 2), wantMarker: image.Pt(2, 1)},
+		{orientation: 4, wantBounds: image.Rect(0, 0, 3, 2), wantMarker: image.Pt(0, 1)},
+		{orientation: 5, wantBounds: image.Rect(0, 0, 2, 3), wantMarker: image.Pt(0, 0)},
+		{orientation: 6, wantBounds: image.Rect(0, 0, 2, 3), wantMarker: image.Pt(1, 0)},
+		{orientation: 7, wantBounds: image.Rect(0, 0, 2, 3), wantMarker: image.Pt(1, 2)},
+		{orientation: 8, wantBounds: image.Rect(0, 0, 2, 3), wantMarker: image.Pt(0, 2)},
+		{orientation: 9, wantBounds: image.Rect(0, 0, 3, 2), wantMarker: image.Pt(0, 0)},
+	}
+
+	for _, tt := range tests {
+		dst := ApplyOrientation(markedImage(3, 2, 0, 0), tt.orientation)
+		if dst.Bounds() != tt.wantBounds {
+			t.Errorf("Orientation %d: 寸法 = %v, want %v", tt.orientation, dst.Bounds(), tt.wantBounds)
+			continue
+		}
+		if got := findColor(t, dst, markerColor); got != tt.wantMarker {
+			t.Errorf("Orientation %d: 目印の位置 = %v, want %v", tt.orientation, got, tt.wantMarker)
+		}
+	}
+}
+
+// TestTransformOffsetOrigin は原点が (0, 0) でない画像も正しく変換できることを確認します
+func TestTransformOffsetOrigin(t *testing.T) {
+	src := markedImage(6, 4, 2, 1).SubImage(image.Rect(2, 1, 6, 4)).(*image.NRGBA)
+
+	if got := findColor(t, RotateImage(src, 90), markerColor); got != image.Pt(2, 0) {
+		t.Errorf("RotateImage の目印の位置 = %v, want (2,0)", got)
+	}
+	if got := findColor(t, FlipImage(src, true), markerColor); got != image.Pt(3, 0) {
+		t.Errorf("FlipImage の目印の位置 = %v, want (3,0)", got)
+	}
+}
@@ -4,17 +4,26 @@ Package imageutils は画像処理に関連する再利用可能なユーティ
 package imageutils
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"image"
 	_ "image/gif"  // GIFデコーダを登録
 	_ "image/jpeg" // JPEGデコーダを登録
 	_ "image/png"  // PNGデコーダを登録
+	"io"
 	"log"
 	"os"
 	"path/filepath"
 	"strings"
 )
 
+// ErrMagicMismatch はファイル先頭のマジックバイトが拡張子と一致しない場合のエラーです
+var ErrMagicMismatch = errors.New("ファイルのマジックバイトが拡張子と一致しません")
+
+// magicHeaderSize はマジックバイト判定のために読み込む先頭バイト数です
+const magicHeaderSize = 16
+
 // IsValidImage は画像ファイルが有効かどうかを確認します
 func IsValidImage(path string) bool {
 	file, err := os.Open(path)
@@ -85,6 +94,22 @@ func IsValidFile(path string) (bool, int64) {
 
 	// 画像ファイルの場合は追加チェック
 	if IsImageExt(filepath.Ext(path)) {
+		// 拡張子チェックより先にマジックバイトを確認する
+		detected, err := DetectFormat(path)
+		if err != nil {
+			log.Printf("マジックバイトの検証に失敗しました: %s - %v", path, err)
+			return false, 0
+		}
+		expected := GetFormatFromExt(filepath.Ext(path))
+		switch {
+		case detected == FormatUnknown:
+			log.Printf("マジックバイトの検証に失敗しました: %s - %v: 拡張子=%s, 検出形式=%s", path, ErrMagicMismatch, expected, detected)
+			return false, 0
+		case !isMagicCompatible(expected, detected):
+			// 拡張子の付け間違いは内容の形式として扱い、処理を続ける
+			log.Printf("警告: 拡張子と内容の形式が一致しません。検出した形式として扱います: %s (拡張子=%s, 検出形式=%s)", path, expected, detected)
+		}
+
 		if !IsValidImage(path) {
 			return false, fileInfo.Size()
 		}
@@ -93,6 +118,125 @@ func IsValidFile(path string) (bool, int64) {
 	return true, fileInfo.Size()
 }
 
+// CheckMagicBytes はファイル先頭のマジックバイトと拡張子が一致するかを確認します
+// 一致しない場合は ErrMagicMismatch を返します
+func CheckMagicBytes(path string) error {
+	expected := GetFormatFromExt(filepath.Ext(path))
+	if expected == "" {
+		// 判定対象外の拡張子は検証しない
+		return nil
+	}
+
+	header, err := readMagicHeader(path)
+	if err != nil {
+		return err
+	}
+
+	detected := detectFormatFromMagic(header)
+	if !isMagicCompatible(expected, detected) {
+		return fmt.Errorf("%w: 拡張子=%s, 検出形式=%s", ErrMagicMismatch, expected, detected)
+	}
+
+	return nil
+}
+
+// FormatUnknown は DetectFormat が形式を判定できなかった場合の値です
+const FormatUnknown = "unknown"
+
+// DetectFormat はファイル先頭のマジックバイトから、拡張子によらず画像形式を判定します
+// 形式は GetFormatFromExt と同じ名前（jpeg, png, gif, webp, avif, heif, jxl）で返し、判定できない場合は FormatUnknown を返します
+func DetectFormat(path string) (string, error) {
+	header, err := readMagicHeader(path)
+	if err != nil {
+		return "", err
+	}
+
+	if format := detectFormatFromMagic(header); format != "" {
+		return format, nil
+	}
+	return FormatUnknown, nil
+}
+
+// readMagicHeader はマジックバイト判定のためにファイルの先頭を読み込みます
+// ファイルが magicHeaderSize より短い場合は読み込めた分だけを返します
+func readMagicHeader(path string) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("ファイルを開けません: %v", err)
+	}
+	defer file.Close()
+
+	header := make([]byte, magicHeaderSize)
+	n, err := io.ReadFull(file, header)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, fmt.Errorf("ファイル先頭の読み込みに失敗しました: %v", err)
+	}
+	return header[:n], nil
+}
+
+// detectFormatFromMagic はマジックバイトから画像形式を判定します
+// 判定できない場合は空文字列を返します
+func detectFormatFromMagic(header []byte) string {
+	switch {
+	case bytes.HasPrefix(header, []byte{0xFF, 0xD8, 0xFF}):
+		return "jpeg"
+	case bytes.HasPrefix(header, []byte{0x89, 0x50, 0x4E, 0x47}):
+		return "png"
+	case bytes.HasPrefix(header, []byte("GIF8")):
+		return "gif"
+	case len(header) >= 12 && bytes.Equal(header[0:4], []byte("RIFF")) && bytes.Equal(header[8:12], []byte("WEBP")):
+		return "webp"
+	case bytes.HasPrefix(header, []byte{0xFF, 0x0A}):
+		// JPEG XLのコードストリーム形式
+		return "jxl"
+	case bytes.HasPrefix(header, []byte{0x00, 0x00, 0x00, 0x0C, 'J', 'X', 'L', ' ', 0x0D, 0x0A, 0x87, 0x0A}):
+		// JPEG XLのコンテナ形式（ISOBMFFのftypより先に判定する）
+		return "jxl"
+	case len(header) >= 12 && bytes.Equal(header[4:8], []byte("ftyp")):
+		// AVIFとHEICはどちらもISOBMFFのftypボックスで始まるため、ブランドで区別する
+		switch string(header[8:12]) {
+		case "avif", "avis":
+			return "avif"
+		case "heic", "heix", "heim", "heis", "hevc", "hevx", "mif1", "msf1":
+			return "heif"
+		default:
+			// MP4などの画像以外のISOBMFF
+			return ""
+		}
+	default:
+		return ""
+	}
+}
+
+// isMagicCompatible は拡張子から期待される形式と検出された形式が一致するかを判断します
+func isMagicCompatible(expected, detected string) bool {
+	if expected == detected {
+		return true
+	}
+
+	// AVIFとHEIFは同じコンテナ形式のため、ブランドが異なっても許容する
+	isISOBMFF := func(format string) bool {
+		return format == "avif" || format == "heif"
+	}
+	return isISOBMFF(expected) && isISOBMFF(detected)
+}
+
+// VerifyComplete はファイルが期待されるサイズで完全に書き込まれているかを確認します
+// 転送途中で切断された場合などのサイズ不一致を検出するために使用します
+func VerifyComplete(path string, expectedSize int64) (bool, error) {
+	fileInfo, err := os.Stat(path)
+	if err != nil {
+		return false, fmt.Errorf("ファイル情報の取得に失敗しました: %v", err)
+	}
+
+	if fileInfo.Size() != expectedSize {
+		return false, fmt.Errorf("ファイルサイズが一致しません: %d バイト (期待値: %d バイト)",
+			fileInfo.Size(), expectedSize)
+	}
+
+	return true, nil
+}
+
 // IsValidImageSize はファイルサイズが指定された範囲内かどうかを確認します
 func IsValidImageSize(path string, minSize, maxSize int64) (bool, error) {
 	fileInfo, err := os.Stat(path)
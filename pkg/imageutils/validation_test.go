@@ -0,0 +1,281 @@
+package imageutils
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// ISOBMFF の ftyp ボックス（サイズ・"ftyp"・ブランド・マイナーバージョン）で始まるヘッダーを返します
+func ftypHeader(brand string) []byte {
+	return append([]byte{0x00, 0x00, 0x00, 0x1C, 'f', 't', 'y', 'p'}, append([]byte(brand), 0x00, 0x00, 0x00, 0x00)...)
+}
+
+var (
+	jpegHeader    = []byte{0xFF, 0xD8, 0xFF, 0xE0, 0x00, 0x10, 'J', 'F', 'I', 'F', 0x00}
+	pngHeader     = []byte{0x89, 'P', 'N', 'G', 0x0D, 0x0A, 0x1A, 0x0A, 0x00, 0x00, 0x00, 0x0D, 'I', 'H', 'D', 'R'}
+	gifHeader     = []byte("GIF89a\x01\x00\x01\x00")
+	webpHeader    = []byte("RIFF\x24\x00\x00\x00WEBPVP8 ")
+	jxlCodestream = []byte{0xFF, 0x0A, 0xFA, 0x7F}
+	jxlContainer  = []byte{0x00, 0x00, 0x00, 0x0C, 'J', 'X', 'L', ' ', 0x0D, 0x0A, 0x87, 0x0A}
+)
+
+func TestDetectFormatFromMagic(t *testing.T) {
+	tests := []struct {
+		name   string
+		header []byte
+		want   string
+	}{
+		{name: "JPEG", header: jpegHeader, want: "jpeg"},
+		{name: "PNG", header: pngHeader, want: "png"},
+		{name: "GIF87a", header: []byte("GIF87a\x01\x00"), want: "gif"},
+		{name: "GIF89a", header: gifHeader, want: "gif"},
+		{name: "WebP", header: webpHeader, want: "webp"},
+		{name: "AVIF (avif)", header: ftypHeader("avif"), want: "avif"},
+		{name: "AVIF (avis)", header: ftypHeader("avis"), want: "avif"},
+		{name: "HEIC (heic)", header: ftypHeader("heic"), want: "heif"},
+		{name: "HEIF (mif1)", header: ftypHeader("mif1"), want: "heif"},
+		{name: "JPEG XL コードストリーム", header: jxlCodestream, want: "jxl"},
+		{name: "JPEG XL コンテナ", header: jxlContainer, want: "jxl"},
+		{name: "MP4 (画像以外のISOBMFF)", header: ftypHeader("isom"), want: ""},
+		{name: "RIFF だが WebP でない (WAV)", header: []byte("RIFF\x24\x00\x00\x00WAVEfmt "), want: ""},
+		{name: "PDF", header: []byte("%PDF-1.7\n"), want: ""},
+		{name: "空", header: nil, want: ""},
+		{name: "途中で切れた JPEG", header: []byte{0xFF, 0xD8}, want: ""},
+		{name: "途中で切れた PNG", header: []byte{0x89, 'P', 'N'}, want: ""},
+		{name: "途中で切れた WebP", header: []byte("RIFF\x24\x00\x00\x00WEB"), want: ""},
+		{name: "途中で切れた ftyp", header: ftypHeader("avif")[:10], want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectFormatFromMagic(tt.header); got != tt.want {
+				t.Errorf("detectFormatFromMagic = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsMagicCompatible(t *testing.T) {
+	tests := []struct {
+		expected, detected string
+		want               bool
+	}{
+		{"jpeg", "jpeg", true},
+		{"png", "png", true},
+		{"avif", "avif", true},
+		{"avif", "heif", true},
+		{"heif", "avif", true},
+		{"jpeg", "png", false},
+		{"png", "gif", false},
+		{"webp", "jpeg", false},
+		{"jpeg", "", false},
+		{"avif", "jpeg", false},
+		{"heif", "jxl", false},
+	}
+
+	for _, tt := range tests {
+		if got := isMagicCompatible(tt.expected, tt.detected); got != tt.want {
+			t.Errorf("isMagicCompatible(%q, %q) = %v, want %v", tt.expected, tt.detected, got, tt.want)
+		}
+	}
+}
+
+// writeTempFile は一時ディレクトリに name のファイルを data の内容で作成し、パスを返します
+func writeTempFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("テストファイルの作成に失敗しました: %v", err)
+	}
+	return path
+}
+
+func TestCheckMagicBytes(t *testing.T) {
+	tests := []struct {
+		name         string
+		file         string
+		data         []byte
+		wantMismatch bool
+	}{
+		{name: "JPEG", file: "photo.jpg", data: jpegHeader},
+		{name: "JPEG (.jpeg)", file: "photo.jpeg", data: jpegHeader},
+		{name: "PNG", file: "image.png", data: pngHeader},
+		{name: "GIF", file: "anim.gif", data: gifHeader},
+		{name: "WebP", file: "image.webp", data: webpHeader},
+		{name: "AVIF", file: "image.avif", data: ftypHeader("avif")},
+		{name: "HEIC", file: "image.heic", data: ftypHeader("heic")},
+		{name: "HEIFブランドのAVIF", file: "image.avif", data: ftypHeader("mif1")},
+		{name: "JPEG XL", file: "image.jxl", data: jxlCodestream},
+		{name: "大文字の拡張子", file: "PHOTO.JPG", data: jpegHeader},
+		{name: "PDFの拡張子をJPEGに変更", file: "document.jpg", data: []byte("%PDF-1.7\n%\xe2\xe3\xcf\xd3"), wantMismatch: true},
+		{name: "PNGの拡張子をJPEGに変更", file: "image.jpg", data: pngHeader, wantMismatch: true},
+		{name: "JPEGの拡張子をPNGに変更", file: "photo.png", data: jpegHeader, wantMismatch: true},
+		{name: "GIFの拡張子をWebPに変更", file: "anim.webp", data: gifHeader, wantMismatch: true},
+		{name: "MP4の拡張子をAVIFに変更", file: "movie.avif", data: ftypHeader("isom"), wantMismatch: true},
+		{name: "途中で切れたJPEG", file: "short.jpg", data: []byte{0xFF, 0xD8}, wantMismatch: true},
+		{name: "途中で切れたWebP", file: "short.webp", data: []byte("RIFF"), wantMismatch: true},
+		{name: "空のPNG", file: "empty.png", data: nil, wantMismatch: true},
+		{name: "判定対象外の拡張子", file: "notes.txt", data: []byte("hello")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckMagicBytes(writeTempFile(t, tt.file, tt.data))
+			if tt.wantMismatch {
+				if !errors.Is(err, ErrMagicMismatch) {
+					t.Errorf("CheckMagicBytes = %v, ErrMagicMismatch を期待しました", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("CheckMagicBytes = %v, nil を期待しました", err)
+			}
+		})
+	}
+}
+
+func TestCheckMagicBytesMissingFile(t *testing.T) {
+	err := CheckMagicBytes(filepath.Join(t.TempDir(), "missing.jpg"))
+	if err == nil || errors.Is(err, ErrMagicMismatch) {
+		t.Errorf("存在しないファイルのエラー = %v, 読み込みのエラーを期待しました", err)
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		data []byte
+		want string
+	}{
+		{name: "JPEG", file: "photo.jpg", data: jpegHeader, want: "jpeg"},
+		{name: "PNG", file: "image.png", data: pngHeader, want: "png"},
+		{name: "GIF", file: "anim.gif", data: gifHeader, want: "gif"},
+		{name: "WebP", file: "image.webp", data: webpHeader, want: "webp"},
+		{name: "AVIF", file: "image.avif", data: ftypHeader("avif"), want: "avif"},
+		{name: "AVIFシーケンス", file: "anim.avif", data: ftypHeader("avis"), want: "avif"},
+		{name: "HEIC", file: "image.heic", data: ftypHeader("heic"), want: "heif"},
+		{name: "HEIF", file: "image.heif", data: ftypHeader("mif1"), want: "heif"},
+		{name: "JPEG XL コードストリーム", file: "image.jxl", data: jxlCodestream, want: "jxl"},
+		{name: "JPEG XL コンテナ", file: "image.jxl", data: jxlContainer, want: "jxl"},
+		{name: "拡張子がPNGのJPEG", file: "photo.png", data: jpegHeader, want: "jpeg"},
+		{name: "拡張子がJPEGのWebP", file: "image.jpg", data: webpHeader, want: "webp"},
+		{name: "拡張子のないGIF", file: "anim", data: gifHeader, want: "gif"},
+		{name: "PDF", file: "document.jpg", data: []byte("%PDF-1.7\n"), want: FormatUnknown},
+		{name: "MP4", file: "movie.avif", data: ftypHeader("isom"), want: FormatUnknown},
+		{name: "テキスト", file: "notes.txt", data: []byte("hello, world"), want: FormatUnknown},
+		{name: "空", file: "empty.png", data: nil, want: FormatUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DetectFormat(writeTempFile(t, tt.file, tt.data))
+			if err != nil {
+				t.Fatalf("DetectFormat に失敗しました: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("DetectFormat = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := DetectFormat(filepath.Join(t.TempDir(), "missing.jpg")); err == nil {
+		t.Error("存在しないファイルでエラーになりませんでした")
+	}
+}
+
+// encodedImage は小さな画像を format の形式でエンコードして返します
+func encodedImage(t *testing.T, format string) []byte {
+	t.Helper()
+
+	img := image.NewPaletted(image.Rect(0, 0, 4, 3), color.Palette{color.Black, color.White})
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case "jpeg":
+		err = jpeg.Encode(&buf, img, nil)
+	case "png":
+		err = png.Encode(&buf, img)
+	case "gif":
+		err = gif.Encode(&buf, img, nil)
+	}
+	if err != nil {
+		t.Fatalf("テスト画像のエンコードに失敗しました: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// captureLog はテスト中の標準ロガーの出力を返す関数を返します
+func captureLog(t *testing.T) func() string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+	return buf.String
+}
+
+func TestIsValidFile(t *testing.T) {
+	jpegData, pngData, gifData := encodedImage(t, "jpeg"), encodedImage(t, "png"), encodedImage(t, "gif")
+
+	tests := []struct {
+		name        string
+		file        string
+		data        []byte
+		wantValid   bool
+		wantSize    bool   // サイズが返されるかどうか
+		wantWarning string // ログに含まれるべき警告（空の場合は警告しない）
+	}{
+		{name: "JPEG", file: "photo.jpg", data: jpegData, wantValid: true, wantSize: true},
+		{name: "PNG", file: "image.png", data: pngData, wantValid: true, wantSize: true},
+		{name: "GIF", file: "anim.gif", data: gifData, wantValid: true, wantSize: true},
+		{name: "拡張子がJPEGのPNG", file: "image.jpg", data: pngData, wantValid: true, wantSize: true, wantWarning: "拡張子と内容の形式が一致しません"},
+		{name: "拡張子がPNGのJPEG", file: "photo.png", data: jpegData, wantValid: true, wantSize: true, wantWarning: "拡張子と内容の形式が一致しません"},
+		{name: "拡張子がGIFのPNG", file: "image.gif", data: pngData, wantValid: true, wantSize: true, wantWarning: "拡張子と内容の形式が一致しません"},
+		{name: "拡張子がJPEGのPDF", file: "document.jpg", data: []byte("%PDF-1.7\n%\xe2\xe3\xcf\xd3"), wantWarning: "マジックバイトの検証に失敗しました"},
+		{name: "拡張子がAVIFのMP4", file: "movie.avif", data: ftypHeader("isom"), wantWarning: "マジックバイトの検証に失敗しました"},
+		{name: "ヘッダーのみのPNG", file: "broken.png", data: pngHeader, wantSize: true},
+		{name: "デコーダーのないWebP", file: "image.webp", data: webpHeader, wantSize: true},
+		{name: "空のファイル", file: "empty.jpg", data: nil},
+		{name: "画像以外の拡張子", file: "notes.txt", data: []byte("hello"), wantValid: true, wantSize: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logged := captureLog(t)
+			valid, size := IsValidFile(writeTempFile(t, tt.file, tt.data))
+
+			if valid != tt.wantValid {
+				t.Errorf("有効 = %v, want %v", valid, tt.wantValid)
+			}
+			wantSize := int64(0)
+			if tt.wantSize {
+				wantSize = int64(len(tt.data))
+			}
+			if size != wantSize {
+				t.Errorf("サイズ = %d, want %d", size, wantSize)
+			}
+			if tt.wantWarning != "" && !strings.Contains(logged(), tt.wantWarning) {
+				t.Errorf("ログに %q が含まれていません: %s", tt.wantWarning, logged())
+			}
+			if tt.wantWarning == "" && strings.Contains(logged(), "一致しません") {
+				t.Errorf("不要な警告が出力されました: %s", logged())
+			}
+		})
+	}
+
+	if valid, size := IsValidFile(filepath.Join(t.TempDir(), "missing.jpg")); valid || size != 0 {
+		t.Errorf("存在しないファイル = (%v, %d), want (false, 0)", valid, size)
+	}
+}
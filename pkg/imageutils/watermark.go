@@ -0,0 +1,73 @@
+package imageutils
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"strings"
+)
+
+// 透かしの配置位置
+const (
+	WatermarkTopLeft     = "top-left"
+	WatermarkTopRight    = "top-right"
+	WatermarkBottomLeft  = "bottom-left"
+	WatermarkBottomRight = "bottom-right"
+	WatermarkCenter      = "center"
+)
+
+// IsValidWatermarkPosition は透かしの配置位置として有効な値かどうかを返します
+func IsValidWatermarkPosition(position string) bool {
+	switch strings.ToLower(position) {
+	case WatermarkTopLeft, WatermarkTopRight, WatermarkBottomLeft, WatermarkBottomRight, WatermarkCenter:
+		return true
+	default:
+		return false
+	}
+}
+
+// ApplyWatermark は画像に透かし画像を重ねた新しい画像を返します
+// position は配置位置（top-left, top-right, bottom-left, bottom-right, center）、
+// opacity は透かしの不透明度（0〜1）です。元の画像は変更しません
+func ApplyWatermark(src image.Image, watermark image.Image, position string, opacity float64) image.Image {
+	bounds := src.Bounds()
+	dst := image.NewRGBA(bounds)
+	draw.Draw(dst, bounds, src, bounds.Min, draw.Src)
+
+	if watermark == nil || opacity <= 0 {
+		return dst
+	}
+	if opacity > 1 {
+		opacity = 1
+	}
+
+	wmBounds := watermark.Bounds()
+	offset := watermarkOffset(bounds, wmBounds.Size(), position)
+	target := image.Rectangle{Min: offset, Max: offset.Add(wmBounds.Size())}
+
+	// 不透明度は一様なアルファマスクで適用する
+	mask := image.NewUniform(color.Alpha{A: uint8(opacity*255 + 0.5)})
+	draw.DrawMask(dst, target, watermark, wmBounds.Min, mask, image.Point{}, draw.Over)
+
+	return dst
+}
+
+// watermarkOffset は配置位置に応じた透かしの左上座標を返します
+func watermarkOffset(bounds image.Rectangle, size image.Point, position string) image.Point {
+	switch strings.ToLower(position) {
+	case WatermarkTopLeft:
+		return bounds.Min
+	case WatermarkTopRight:
+		return image.Pt(bounds.Max.X-size.X, bounds.Min.Y)
+	case WatermarkBottomLeft:
+		return image.Pt(bounds.Min.X, bounds.Max.Y-size.Y)
+	case WatermarkCenter:
+		return image.Pt(
+			bounds.Min.X+(bounds.Dx()-size.X)/2,
+			bounds.Min.Y+(bounds.Dy()-size.Y)/2,
+		)
+	default:
+		// 不明な値は右下とする
+		return image.Pt(bounds.Max.X-size.X, bounds.Max.Y-size.Y)
+	}
+}
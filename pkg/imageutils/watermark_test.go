@@ -0,0 +1,74 @@
+package imageutils
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestApplyWatermark(t *testing.T) {
+	white := color.NRGBA{255, 255, 255, 255}
+	red := color.NRGBA{255, 0, 0, 255}
+	src := solidImage(8, 6, white)
+	watermark := solidImage(2, 2, red)
+
+	tests := []struct {
+		position string
+		corner   image.Point // 透かしの左上に重なる画素
+	}{
+		{position: WatermarkTopLeft, corner: image.Pt(0, 0)},
+		{position: WatermarkTopRight, corner: image.Pt(6, 0)},
+		{position: WatermarkBottomLeft, corner: image.Pt(0, 4)},
+		{position: WatermarkBottomRight, corner: image.Pt(6, 4)},
+		{position: WatermarkCenter, corner: image.Pt(3, 2)},
+		{position: "unknown", corner: image.Pt(6, 4)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.position, func(t *testing.T) {
+			got := ApplyWatermark(src, watermark, tt.position, 1)
+
+			var reds int
+			b := got.Bounds()
+			for y := b.Min.Y; y < b.Max.Y; y++ {
+				for x := b.Min.X; x < b.Max.X; x++ {
+					if color.NRGBAModel.Convert(got.At(x, y)) == red {
+						reds++
+					}
+				}
+			}
+			if reds != 4 {
+				t.Errorf("赤い画素の数 = %d, want 4", reds)
+			}
+			for _, p := range []image.Point{tt.corner, tt.corner.Add(image.Pt(1, 1))} {
+				if c := color.NRGBAModel.Convert(got.At(p.X, p.Y)); c != red {
+					t.Errorf("画素 %v = %v, want %v", p, c, red)
+				}
+			}
+		})
+	}
+
+	t.Run("不透明度0.5", func(t *testing.T) {
+		got := ApplyWatermark(src, watermark, WatermarkBottomRight, 0.5)
+		c := color.NRGBAModel.Convert(got.At(7, 5)).(color.NRGBA)
+		if c.R != 255 || c.G < 126 || c.G > 128 || c.B != c.G {
+			t.Errorf("右下の画素 = %v, 白と赤の中間を期待しました", c)
+		}
+	})
+
+	t.Run("不透明度0と透かしなし", func(t *testing.T) {
+		for _, got := range []image.Image{
+			ApplyWatermark(src, watermark, WatermarkBottomRight, 0),
+			ApplyWatermark(src, nil, WatermarkBottomRight, 1),
+		} {
+			if c := color.NRGBAModel.Convert(got.At(7, 5)); c != white {
+				t.Errorf("右下の画素 = %v, want %v", c, white)
+			}
+		}
+	})
+
+	// 元の画像は変更しない
+	if c := src.NRGBAAt(7, 5); c != white {
+		t.Errorf("元の画像の画素 = %v, want %v", c, white)
+	}
+}
@@ -0,0 +1,82 @@
+package imageutils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// xmpHeader はJPEGのAPP1セグメントでXMPを示す名前空間です
+var xmpHeader = []byte("http://ns.adobe.com/xap/1.0/\x00")
+
+// XMPパケットの開始・終了を示す要素
+var (
+	xmpMetaStart = []byte("<x:xmpmeta")
+	xmpMetaEnd   = []byte("</x:xmpmeta>")
+)
+
+// ExtractXMP は画像ファイルからXMPパケットを取り出します
+// XMPを含まない場合や未対応の形式の場合は nil を返します
+func ExtractXMP(path string) ([]byte, error) {
+	format := GetFormatFromExt(filepath.Ext(path))
+	if format != "jpeg" && format != "heif" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("ファイルの読み込みに失敗しました: %v", err)
+	}
+
+	if format == "jpeg" {
+		return extractJPEGXMP(data)
+	}
+	return extractEmbeddedXMP(data), nil
+}
+
+// extractJPEGXMP はJPEGのマーカーを走査し、APP1セグメントのXMPを取り出します
+func extractJPEGXMP(data []byte) ([]byte, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, fmt.Errorf("JPEGの形式が不正です")
+	}
+
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			return nil, fmt.Errorf("JPEGマーカーが不正です（オフセット %d）", pos)
+		}
+		marker := data[pos+1]
+		// SOS以降は画像データのため走査を終了する
+		if marker == 0xDA || marker == 0xD9 {
+			return nil, nil
+		}
+		length := int(binary.BigEndian.Uint16(data[pos+2 : pos+4]))
+		end := pos + 2 + length
+		if length < 2 || end > len(data) {
+			return nil, fmt.Errorf("JPEGセグメント長が不正です（オフセット %d）", pos)
+		}
+		if marker == 0xE1 && bytes.HasPrefix(data[pos+4:end], xmpHeader) {
+			xmp := data[pos+4+len(xmpHeader) : end]
+			return append([]byte(nil), xmp...), nil
+		}
+		pos = end
+	}
+
+	return nil, nil
+}
+
+// extractEmbeddedXMP はファイル内に非圧縮で格納されたXMP（x:xmpmeta要素）を取り出します
+// HEIFではXMPがMIMEアイテムとしてそのまま格納されるため、要素の範囲を検索して取り出します
+func extractEmbeddedXMP(data []byte) []byte {
+	start := bytes.Index(data, xmpMetaStart)
+	if start < 0 {
+		return nil
+	}
+	end := bytes.Index(data[start:], xmpMetaEnd)
+	if end < 0 {
+		return nil
+	}
+	return append([]byte(nil), data[start:start+end+len(xmpMetaEnd)]...)
+}